@@ -1,15 +1,44 @@
 package action
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/assi010/gotransip/v6/rest"
+)
+
+// Status is one of the following strings
+// 'running', 'finished', 'failed'
+type Status string
+
+// Definition of all of the possible action statuses
+const (
+	// StatusRunning is the status field for an action that is still in progress
+	StatusRunning Status = "running"
+	// StatusFinished is the status field for an action that completed successfully
+	StatusFinished Status = "finished"
+	// StatusFailed is the status field for an action that failed
+	StatusFailed Status = "failed"
+)
+
+// Terminal returns true when the status is final, thus finished or failed
+func (s Status) Terminal() bool {
+	return s == StatusFinished || s == StatusFailed
+}
 
 // Action struct
 type Action struct {
-	UUID             string          `json:"uuid,omitempty"`
-	Name             string          `json:"name"`
-	ActionStartTime  string          `json:"actionStartTime"`
-	Status           string          `json:"status"`
-	Metadata         json.RawMessage `json:"metadata"`
-	ParentActionUUID string          `json:"parentActionUuid"`
+	// Unique identifier of the action
+	UUID string `json:"uuid,omitempty"`
+	// Name of the action, like 'snapshot revert', see the Name constants for the actions with typed metadata
+	Name string `json:"name"`
+	// Start time of the action in Europe/Amsterdam timezone
+	ActionStartTime rest.Time `json:"actionStartTime"`
+	// Status of the action
+	Status Status `json:"status"`
+	// Metadata of the action, use TypedMetadata or DecodeMetadata to decode it
+	Metadata json.RawMessage `json:"metadata"`
+	// Unique identifier of the parent action, empty for actions without a parent
+	ParentActionUUID string `json:"parentActionUuid"`
 }
 
 // actionWrapper struct contains a single Action in it,
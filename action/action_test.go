@@ -31,8 +31,8 @@ func TestActionRepository_GetActions(t *testing.T) {
 
 	assert.Equal(t, "6c7fa1c1-f509-4999-a513-bdf4e7a0cebb", actions[0].UUID)
 	assert.Equal(t, "snapshot revert", actions[0].Name)
-	assert.Equal(t, "2023-02-01 17:01:51", actions[0].ActionStartTime)
-	assert.Equal(t, "running", actions[0].Status)
+	assert.Equal(t, "2023-02-01 17:01:51 +0100 CET", actions[0].ActionStartTime.String())
+	assert.Equal(t, StatusRunning, actions[0].Status)
 	assert.Equal(t, 1337, metadata.Progress)
 	assert.Equal(t, "", actions[0].ParentActionUUID)
 }
@@ -60,8 +60,8 @@ func TestActionRepository_ParseActionFromResponse(t *testing.T) {
 
 	assert.Equal(t, "6c7fa1c1-f509-4999-a513-bdf4e7a0cebb", action.UUID)
 	assert.Equal(t, "snapshot revert", action.Name)
-	assert.Equal(t, "2023-02-01 17:01:51", action.ActionStartTime)
-	assert.Equal(t, "running", action.Status)
+	assert.Equal(t, "2023-02-01 17:01:51 +0100 CET", action.ActionStartTime.String())
+	assert.Equal(t, StatusRunning, action.Status)
 	assert.Equal(t, 1337, metadata.Progress)
 	assert.Equal(t, "", action.ParentActionUUID)
 }
@@ -0,0 +1,121 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Names of the actions of which the metadata can be decoded with TypedMetadata
+const (
+	// NameSnapshotRevert is the name of the action started by reverting a vps snapshot
+	NameSnapshotRevert = "snapshot revert"
+	// NameVpsClone is the name of the action started by cloning a vps
+	NameVpsClone = "vps clone"
+	// NameBackupRevert is the name of the action started by reverting a vps, big storage or block storage backup
+	NameBackupRevert = "backup revert"
+	// NameBigStorageOrder is the name of the action started by ordering a big storage
+	NameBigStorageOrder = "big storage order"
+	// NameBlockStorageOrder is the name of the action started by ordering a block storage
+	NameBlockStorageOrder = "block storage order"
+)
+
+var (
+	// ErrUnknownMetadata is returned by TypedMetadata for actions without a typed metadata struct,
+	// use DecodeMetadata with your own struct for those
+	ErrUnknownMetadata = errors.New("no typed metadata for this action")
+)
+
+// SnapshotRevertMetadata is the metadata of a NameSnapshotRevert action
+type SnapshotRevertMetadata struct {
+	// Progress of the revert in percent
+	Progress int `json:"progress"`
+	// Name of the vps the snapshot belongs to
+	VpsName string `json:"vpsName,omitempty"`
+	// Name of the snapshot that is reverted
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// Name of the vps the snapshot is reverted to, when it is not the vps the snapshot belongs to
+	DestinationVpsName string `json:"destinationVpsName,omitempty"`
+}
+
+// CloneMetadata is the metadata of a NameVpsClone action
+type CloneMetadata struct {
+	// Progress of the clone in percent
+	Progress int `json:"progress"`
+	// Name of the vps that is cloned
+	VpsName string `json:"vpsName,omitempty"`
+	// Name of the new vps
+	CloneVpsName string `json:"cloneVpsName,omitempty"`
+	// Availability zone the clone is created in
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+}
+
+// BackupRevertMetadata is the metadata of a NameBackupRevert action
+type BackupRevertMetadata struct {
+	// Progress of the revert in percent
+	Progress int `json:"progress"`
+	// Id of the backup that is reverted
+	BackupID int64 `json:"backupId,omitempty"`
+	// Name of the vps, big storage or block storage the backup is reverted to
+	DestinationName string `json:"destinationName,omitempty"`
+}
+
+// StorageOrderMetadata is the metadata of a NameBigStorageOrder or NameBlockStorageOrder action
+type StorageOrderMetadata struct {
+	// Progress of the order in percent
+	Progress int `json:"progress"`
+	// Name of the ordered storage
+	StorageName string `json:"storageName,omitempty"`
+	// Size of the ordered storage in kibibytes
+	Size int64 `json:"size,omitempty"`
+	// Name of the vps the storage is attached to
+	VpsName string `json:"vpsName,omitempty"`
+	// Availability zone the storage is created in
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+}
+
+// metadataDecoders decodes the metadata of the actions with typed metadata, keyed on the action name
+var metadataDecoders = map[string]func(action Action) (any, error){
+	NameSnapshotRevert:    decodeMetadataAny[SnapshotRevertMetadata],
+	NameVpsClone:          decodeMetadataAny[CloneMetadata],
+	NameBackupRevert:      decodeMetadataAny[BackupRevertMetadata],
+	NameBigStorageOrder:   decodeMetadataAny[StorageOrderMetadata],
+	NameBlockStorageOrder: decodeMetadataAny[StorageOrderMetadata],
+}
+
+// DecodeMetadata decodes the metadata of an action into T,
+// it can be used for actions the library does not have a typed metadata struct for.
+// An action without metadata results in the zero value of T.
+func DecodeMetadata[T any](action Action) (T, error) {
+	var metadata T
+	if len(action.Metadata) == 0 || string(action.Metadata) == "null" {
+		return metadata, nil
+	}
+	if err := json.Unmarshal(action.Metadata, &metadata); err != nil {
+		return metadata, fmt.Errorf("error decoding metadata of action '%s': %w", action.Name, err)
+	}
+
+	return metadata, nil
+}
+
+// TypedMetadata decodes the metadata of the action into the struct that belongs to its Name,
+// for example a SnapshotRevertMetadata for a NameSnapshotRevert action.
+// It returns ErrUnknownMetadata for other actions.
+func (a *Action) TypedMetadata() (any, error) {
+	decoder, ok := metadataDecoders[a.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownMetadata, a.Name)
+	}
+
+	return decoder(*a)
+}
+
+// decodeMetadataAny is DecodeMetadata returning the decoded metadata as any
+func decodeMetadataAny[T any](action Action) (any, error) {
+	metadata, err := DecodeMetadata[T](action)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
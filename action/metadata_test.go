@@ -0,0 +1,86 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_UnmarshalJSON(t *testing.T) {
+	const data = `{"uuid":"6c7fa1c1-f509-4999-a513-bdf4e7a0cebb","name":"snapshot revert","actionStartTime":"2023-07-01 09:30:00","status":"finished","metadata":{"progress":100}}`
+
+	var action Action
+	require.NoError(t, json.Unmarshal([]byte(data), &action))
+
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+	assert.True(t, action.ActionStartTime.Equal(time.Date(2023, 7, 1, 9, 30, 0, 0, amsterdam)))
+	assert.Equal(t, "2023-07-01T07:30:00Z", action.ActionStartTime.UTC().Format(time.RFC3339))
+	assert.Equal(t, StatusFinished, action.Status)
+	assert.True(t, action.Status.Terminal())
+	assert.False(t, StatusRunning.Terminal())
+}
+
+func TestAction_TypedMetadata(t *testing.T) {
+	tests := []struct {
+		action   Action
+		expected any
+	}{
+		{
+			action:   Action{Name: NameSnapshotRevert, Metadata: json.RawMessage(`{"progress":42,"vpsName":"example-vps","snapshotName":"1572607577"}`)},
+			expected: SnapshotRevertMetadata{Progress: 42, VpsName: "example-vps", SnapshotName: "1572607577"},
+		},
+		{
+			action:   Action{Name: NameVpsClone, Metadata: json.RawMessage(`{"progress":10,"vpsName":"example-vps","availabilityZone":"ams0"}`)},
+			expected: CloneMetadata{Progress: 10, VpsName: "example-vps", AvailabilityZone: "ams0"},
+		},
+		{
+			action:   Action{Name: NameBackupRevert, Metadata: json.RawMessage(`{"progress":99,"backupId":712332}`)},
+			expected: BackupRevertMetadata{Progress: 99, BackupID: 712332},
+		},
+		{
+			action:   Action{Name: NameBlockStorageOrder, Metadata: json.RawMessage(`{"progress":0,"size":2147483648}`)},
+			expected: StorageOrderMetadata{Size: 2147483648},
+		},
+		{
+			action:   Action{Name: NameBigStorageOrder},
+			expected: StorageOrderMetadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		metadata, err := tt.action.TypedMetadata()
+		require.NoError(t, err, tt.action.Name)
+		assert.Equal(t, tt.expected, metadata, tt.action.Name)
+	}
+
+	action := Action{Name: "vps install", Metadata: json.RawMessage(`{"progress":1}`)}
+	_, err := action.TypedMetadata()
+	assert.ErrorIs(t, err, ErrUnknownMetadata)
+	assert.EqualError(t, err, "no typed metadata for this action: 'vps install'")
+
+	action = Action{Name: NameVpsClone, Metadata: json.RawMessage(`{"progress":"ten"}`)}
+	metadata, err := action.TypedMetadata()
+	assert.Nil(t, metadata)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error decoding metadata of action 'vps clone'")
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	type installMetadata struct {
+		Progress        int    `json:"progress"`
+		OperatingSystem string `json:"operatingSystem"`
+	}
+
+	action := Action{Name: "vps install", Metadata: json.RawMessage(`{"progress":60,"operatingSystem":"ubuntu-22.04"}`)}
+	metadata, err := DecodeMetadata[installMetadata](action)
+	require.NoError(t, err)
+	assert.Equal(t, installMetadata{Progress: 60, OperatingSystem: "ubuntu-22.04"}, metadata)
+
+	metadata, err = DecodeMetadata[installMetadata](Action{Metadata: json.RawMessage("null")})
+	require.NoError(t, err)
+	assert.Equal(t, installMetadata{}, metadata)
+}
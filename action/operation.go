@@ -0,0 +1,212 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/rest"
+)
+
+var (
+	// ErrOperationNotFinished is returned by Operation.CreatedResource while the action is not finished yet
+	ErrOperationNotFinished = errors.New("operation is not finished")
+	// ErrNoCreatedResource is returned by Operation.CreatedResource when the operation does not create a resource,
+	// or when the name of the created resource could not be found
+	ErrNoCreatedResource = errors.New("no created resource found for this operation")
+	// ErrCancelNotSupported is returned by Operation.Cancel when the api can not cancel the operation
+	ErrCancelNotSupported = errors.New("cancel is not supported for this operation")
+)
+
+// OperationHooks adds resource specific behaviour to an Operation,
+// both hooks are optional
+type OperationHooks struct {
+	// CreatedResource returns the name of the resource created by the operation from its action
+	CreatedResource func(action Action) (string, error)
+	// Cancel cancels the resource created by the operation
+	Cancel func(resourceName string, endTime gotransip.CancellationTime) error
+}
+
+// Operation is a handle on the asynchronous action started by a request,
+// it is returned by the WithOperation methods of the other repositories.
+// The response of the request is embedded, so its StatusCode and ContentLocation remain available,
+// those methods return the operation together with the error of a failed request for the same reason.
+type Operation struct {
+	rest.Response
+
+	repository Repository
+	uuid       string
+	hooks      OperationHooks
+}
+
+// NewOperation returns an Operation for the action the response refers to in its Content-Location header.
+// An Operation for a response without an action returns ErrNoActionReturned from every method that needs the action.
+func NewOperation(client repository.Client, response rest.Response, hooks OperationHooks) *Operation {
+	// a response without a Content-Location results in an empty uuid, reported when the action is needed
+	uuid, _ := actionUUIDFromResponse(response)
+
+	return &Operation{
+		Response:   response,
+		repository: Repository{Client: client},
+		uuid:       uuid,
+		hooks:      hooks,
+	}
+}
+
+// UUID returns the uuid of the action, or an empty string when the response did not refer to an action
+func (o *Operation) UUID() string {
+	return o.uuid
+}
+
+// Action returns the current state of the action
+func (o *Operation) Action() (Action, error) {
+	if o.uuid == "" {
+		return Action{}, ErrNoActionReturned
+	}
+
+	return o.repository.GetByID(o.uuid)
+}
+
+// Status returns the current status of the action
+func (o *Operation) Status() (Status, error) {
+	action, err := o.Action()
+
+	return action.Status, err
+}
+
+// Wait waits until the action and its child actions are finished, see Repository.WaitForAction
+func (o *Operation) Wait(ctx context.Context, opts WaitOptions) (Action, error) {
+	if o.uuid == "" {
+		return Action{}, ErrNoActionReturned
+	}
+
+	return o.repository.WaitForAction(ctx, o.uuid, opts)
+}
+
+// CreatedResource returns the name of the resource created by a finished operation,
+// using the OperationHooks.CreatedResource hook the operation was created with.
+// It returns ErrOperationNotFinished when the action is not finished yet,
+// call Wait first to wait until it is.
+// An order does not return an action, so its hook returns ErrNoCreatedResource until the resource is listed.
+func (o *Operation) CreatedResource() (string, error) {
+	if o.hooks.CreatedResource == nil {
+		return "", ErrNoCreatedResource
+	}
+
+	action, err := o.actionForHooks()
+	if err != nil {
+		return "", err
+	}
+	if o.uuid != "" && action.Status != StatusFinished {
+		return "", fmt.Errorf("%w: '%s' (%s) is %s", ErrOperationNotFinished, action.Name, action.UUID, action.Status)
+	}
+
+	return o.hooks.CreatedResource(action)
+}
+
+// Cancel cancels the resource created by the operation, like a vps that was ordered or cloned.
+// This is only supported by operations that create a cancellable resource, others return ErrCancelNotSupported.
+func (o *Operation) Cancel(endTime gotransip.CancellationTime) error {
+	if o.hooks.Cancel == nil || o.hooks.CreatedResource == nil {
+		return ErrCancelNotSupported
+	}
+
+	action, err := o.actionForHooks()
+	if err != nil {
+		return err
+	}
+	resourceName, err := o.hooks.CreatedResource(action)
+	if err != nil {
+		return fmt.Errorf("error canceling operation %s: %w", o.uuid, err)
+	}
+
+	return o.hooks.Cancel(resourceName, endTime)
+}
+
+// actionForHooks returns the current state of the action, or an empty Action when the response did not refer
+// to one, like the response of an order
+func (o *Operation) actionForHooks() (Action, error) {
+	if o.uuid == "" {
+		return Action{}, nil
+	}
+
+	return o.Action()
+}
+
+// CreatedResourceFromList returns an OperationHooks.CreatedResource hook for requests that do not report the name
+// of the resource they create, like an order or a clone. The hook lists the resources again and returns the ones
+// that were not in before, the list from before the request, matched on the descriptions they were created with.
+// An empty description matches a new resource with any description, as long as there is only one new resource.
+// The names of several created resources are joined with ", ", see SplitResourceNames.
+// A resource someone else creates at the same time with the same description can be mistaken for the created one.
+func CreatedResourceFromList[T any](before []T, list func() ([]T, error), describe func(T) (name string, description string), descriptions ...string) func(action Action) (string, error) {
+	existing := make(map[string]bool, len(before))
+	for _, resource := range before {
+		name, _ := describe(resource)
+		existing[name] = true
+	}
+
+	return func(action Action) (string, error) {
+		resources, err := list()
+		if err != nil {
+			return "", fmt.Errorf("error listing resources: %w", err)
+		}
+
+		var newNames, newDescriptions []string
+		for _, resource := range resources {
+			if name, description := describe(resource); !existing[name] {
+				newNames = append(newNames, name)
+				newDescriptions = append(newDescriptions, description)
+			}
+		}
+
+		used := make([]bool, len(newNames))
+		names := make([]string, 0, len(descriptions))
+		for _, description := range descriptions {
+			if description == "" && len(newNames) > 1 {
+				return "", fmt.Errorf("%w: %d new resources without a description to tell them apart", ErrNoCreatedResource, len(newNames))
+			}
+			found := -1
+			for idx := range newNames {
+				if !used[idx] && (description == "" || newDescriptions[idx] == description) {
+					found = idx
+					break
+				}
+			}
+			if found < 0 {
+				return "", fmt.Errorf("%w: no new resource with description '%s' is listed", ErrNoCreatedResource, description)
+			}
+			used[found] = true
+			names = append(names, newNames[found])
+		}
+
+		return strings.Join(names, ", "), nil
+	}
+}
+
+// SplitResourceNames returns the names of the resources joined by a CreatedResourceFromList hook
+func SplitResourceNames(resourceNames string) []string {
+	return strings.Split(resourceNames, ", ")
+}
+
+// CreatedResourceFromMetadata returns an OperationHooks.CreatedResource hook
+// that reads the name of the created resource from the given key in the metadata of the action.
+// The api does not document the metadata of its actions, so the key has to be taken from a real response.
+func CreatedResourceFromMetadata(key string) func(action Action) (string, error) {
+	return func(action Action) (string, error) {
+		metadata, err := DecodeMetadata[map[string]any](action)
+		if err != nil {
+			return "", err
+		}
+
+		name, _ := metadata[key].(string)
+		if name == "" {
+			return "", fmt.Errorf("%w: action '%s' (%s) has no %s in its metadata", ErrNoCreatedResource, action.Name, action.UUID, key)
+		}
+
+		return name, nil
+	}
+}
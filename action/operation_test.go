@@ -0,0 +1,139 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneResponse is the response of a request that started the action with actionUUID
+var cloneResponse = rest.Response{StatusCode: 201, Method: rest.PostMethod, ContentLocation: "/v6/actions/" + actionUUID}
+
+func TestOperation(t *testing.T) {
+	server := scriptedServer{actions: []string{
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running","metadata":{"progress":10,"vpsName":"example-vps","cloneVpsName":"example-vps2"}}}`,
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running","metadata":{"progress":10,"vpsName":"example-vps","cloneVpsName":"example-vps2"}}}`,
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"finished","metadata":{"progress":100,"vpsName":"example-vps","cloneVpsName":"example-vps2"}}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	var canceled string
+	operation := NewOperation(repo.Client, cloneResponse, OperationHooks{
+		CreatedResource: CreatedResourceFromMetadata("cloneVpsName"),
+		Cancel: func(resourceName string, endTime gotransip.CancellationTime) error {
+			canceled = resourceName + " " + string(endTime)
+			return nil
+		},
+	})
+	assert.Equal(t, actionUUID, operation.UUID())
+	assert.Equal(t, 201, operation.StatusCode)
+
+	status, err := operation.Status()
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, status)
+
+	_, err = operation.CreatedResource()
+	assert.ErrorIs(t, err, ErrOperationNotFinished)
+	assert.EqualError(t, err, "operation is not finished: 'vps clone' ("+actionUUID+") is running")
+
+	action, err := operation.Wait(context.Background(), fastPolling)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFinished, action.Status)
+
+	name, err := operation.CreatedResource()
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps2", name)
+
+	require.NoError(t, operation.Cancel(gotransip.CancellationTimeImmediately))
+	assert.Equal(t, "example-vps2 immediately", canceled)
+}
+
+func TestOperation_WithoutHooks(t *testing.T) {
+	server := scriptedServer{actions: []string{
+		`{"action":{"uuid":"` + actionUUID + `","name":"snapshot revert","status":"finished","metadata":{"progress":100}}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	operation := NewOperation(repo.Client, cloneResponse, OperationHooks{})
+	_, err := operation.CreatedResource()
+	assert.ErrorIs(t, err, ErrNoCreatedResource)
+	assert.ErrorIs(t, operation.Cancel(gotransip.CancellationTimeEnd), ErrCancelNotSupported)
+
+	operation = NewOperation(repo.Client, cloneResponse, OperationHooks{CreatedResource: CreatedResourceFromMetadata("cloneVpsName")})
+	_, err = operation.CreatedResource()
+	assert.ErrorIs(t, err, ErrNoCreatedResource)
+	assert.EqualError(t, err, "no created resource found for this operation: action 'snapshot revert' ("+actionUUID+") has no cloneVpsName in its metadata")
+}
+
+func TestOperation_NoActionReturned(t *testing.T) {
+	operation := NewOperation(nil, rest.Response{StatusCode: 204}, OperationHooks{})
+	assert.Equal(t, "", operation.UUID())
+
+	_, err := operation.Status()
+	assert.ErrorIs(t, err, ErrNoActionReturned)
+	_, err = operation.Wait(context.Background(), fastPolling)
+	assert.ErrorIs(t, err, ErrNoActionReturned)
+}
+
+func TestOperation_CancelErrors(t *testing.T) {
+	server := scriptedServer{actions: []string{
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running","metadata":{"progress":0}}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	operation := NewOperation(repo.Client, cloneResponse, OperationHooks{
+		CreatedResource: CreatedResourceFromMetadata("cloneVpsName"),
+		Cancel: func(string, gotransip.CancellationTime) error {
+			t.Error("cancel should not be called without a created resource")
+			return nil
+		},
+	})
+
+	err := operation.Cancel(gotransip.CancellationTimeEnd)
+	assert.ErrorIs(t, err, ErrNoCreatedResource)
+	assert.Contains(t, err.Error(), "error canceling operation "+actionUUID)
+}
+
+func TestCreatedResourceFromList(t *testing.T) {
+	type resource struct{ name, description string }
+	describe := func(r resource) (string, string) { return r.name, r.description }
+	before := []resource{{"example-vps", "web-1"}}
+	var after []resource
+	var listErr error
+	list := func() ([]resource, error) { return after, listErr }
+
+	hook := CreatedResourceFromList(before, list, describe, "web-2", "web-2")
+	after = []resource{{"example-vps", "web-1"}, {"example-vps2", "web-2"}}
+	_, err := hook(Action{})
+	assert.ErrorIs(t, err, ErrNoCreatedResource)
+	assert.EqualError(t, err, "no created resource found for this operation: no new resource with description 'web-2' is listed")
+
+	after = append(after, resource{"example-vps3", "web-2"})
+	names, err := hook(Action{})
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps2, example-vps3", names)
+	assert.Equal(t, []string{"example-vps2", "example-vps3"}, SplitResourceNames(names))
+
+	// without a description only a single new resource can be told apart
+	hook = CreatedResourceFromList(before, list, describe, "")
+	_, err = hook(Action{})
+	assert.ErrorIs(t, err, ErrNoCreatedResource)
+	assert.Contains(t, err.Error(), "2 new resources without a description to tell them apart")
+
+	after = after[:2]
+	name, err := hook(Action{})
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps2", name)
+
+	listErr = errors.New("connection refused")
+	_, err = hook(Action{})
+	assert.EqualError(t, err, "error listing resources: connection refused")
+}
@@ -46,9 +46,18 @@ func (r *Repository) GetChildActionsByParentID(actionID string) ([]Action, error
 
 // ParseActionFromResponse parses the actionUuid from the content location header
 func (r *Repository) ParseActionFromResponse(response rest.Response) (Action, error) {
-	if response.ContentLocation == "" {
-		return Action{}, ErrNoActionReturned
+	actionUUID, err := actionUUIDFromResponse(response)
+	if err != nil {
+		return Action{}, err
 	}
-	actionUUID := strings.Replace(response.ContentLocation, "/v6/actions/", "", 1)
 	return r.GetByID(actionUUID)
 }
+
+// actionUUIDFromResponse returns the uuid of the action a response refers to in its Content-Location header
+func actionUUIDFromResponse(response rest.Response) (string, error) {
+	if response.ContentLocation == "" {
+		return "", ErrNoActionReturned
+	}
+
+	return strings.Replace(response.ContentLocation, "/v6/actions/", "", 1), nil
+}
@@ -0,0 +1,150 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/assi010/gotransip/v6/rest"
+	"github.com/assi010/gotransip/v6/wait"
+)
+
+var (
+	// ErrActionFailed is wrapped by every FailedError
+	ErrActionFailed = errors.New("action failed")
+)
+
+// FailedError is returned by WaitForAction when the action, or one of its child actions, failed
+type FailedError struct {
+	// Action is the action that failed, this is a child action when ParentUUID is set
+	Action Action
+	// ParentUUID is the uuid of the waited for action when one of its child actions failed
+	ParentUUID string
+}
+
+func (e *FailedError) Error() string {
+	if e.ParentUUID != "" {
+		return fmt.Sprintf("%s: child action '%s' (%s) of %s", ErrActionFailed, e.Action.Name, e.Action.UUID, e.ParentUUID)
+	}
+
+	return fmt.Sprintf("%s: '%s' (%s)", ErrActionFailed, e.Action.Name, e.Action.UUID)
+}
+
+// Is makes errors.Is(err, ErrActionFailed) work for a FailedError
+func (e *FailedError) Is(target error) bool {
+	return target == ErrActionFailed
+}
+
+// Progress is passed to the WaitOptions.Progress callback after every poll
+type Progress struct {
+	// Action is the current state of the waited for action
+	Action Action
+	// Children contains the current state of the child actions
+	Children []Action
+	// Percentage is the progress from the metadata of the action, only valid when HasPercentage is true
+	Percentage int
+	// HasPercentage is false when the metadata of the action does not contain progress
+	HasPercentage bool
+}
+
+// WaitOptions configures WaitForAction, the embedded wait.Options configure the polling
+type WaitOptions struct {
+	wait.Options
+	// Progress is called after every poll with the state of the action and its child actions
+	Progress func(progress Progress)
+	// SkipChildren disables polling the child actions,
+	// the action is then considered complete as soon as its own status is finished
+	SkipChildren bool
+}
+
+// metadataProgress is used to decode the progress from the metadata of an action
+type metadataProgress struct {
+	Progress *int `json:"progress"`
+}
+
+// WaitForAction polls the action with the given uuid until it and its child actions are finished.
+// It returns a FailedError when the action or one of its child actions failed,
+// and a wait.TimeoutError when the context deadline or the timeout of the WaitOptions is exceeded.
+func (r *Repository) WaitForAction(ctx context.Context, actionUUID string, opts WaitOptions) (Action, error) {
+	var children []Action
+	get := func() (Action, error) {
+		action, err := r.GetByID(actionUUID)
+		if err != nil {
+			return action, err
+		}
+		children = nil
+		if !opts.SkipChildren {
+			if children, err = r.GetChildActionsByParentID(actionUUID); err != nil {
+				return action, fmt.Errorf("error retrieving child actions: %w", err)
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(newProgress(action, children))
+		}
+
+		return action, nil
+	}
+
+	action, err := wait.Until(ctx, opts.Options, "action "+actionUUID, get, wait.Condition[Action]{
+		Description: "status finished",
+		Done: func(action Action) bool {
+			// a failed action is done as well, completed returns its FailedError below
+			done, err := completed(action, children)
+			return done || err != nil
+		},
+		State: func(action Action) string {
+			return fmt.Sprintf("'%s' with status %s", action.Name, action.Status)
+		},
+	})
+	if err != nil {
+		return action, err
+	}
+
+	_, err = completed(action, children)
+	return action, err
+}
+
+// WaitForActionFromResponse waits for the action started by a request, as returned by the WithResponse methods
+// of the other repositories. It returns ErrNoActionReturned when the response does not refer to an action.
+func (r *Repository) WaitForActionFromResponse(ctx context.Context, response rest.Response, opts WaitOptions) (Action, error) {
+	actionUUID, err := actionUUIDFromResponse(response)
+	if err != nil {
+		return Action{}, err
+	}
+
+	return r.WaitForAction(ctx, actionUUID, opts)
+}
+
+// completed returns true when the action and all child actions are finished,
+// and a FailedError when one of them failed
+func completed(action Action, children []Action) (bool, error) {
+	if action.Status == StatusFailed {
+		return true, &FailedError{Action: action}
+	}
+
+	done := action.Status == StatusFinished
+	for _, child := range children {
+		switch child.Status {
+		case StatusFailed:
+			return true, &FailedError{Action: child, ParentUUID: action.UUID}
+		case StatusFinished:
+		default:
+			done = false
+		}
+	}
+
+	return done, nil
+}
+
+// newProgress returns the Progress of an action, with the progress percentage decoded from its metadata
+func newProgress(action Action, children []Action) Progress {
+	progress := Progress{Action: action, Children: children}
+
+	metadata, err := DecodeMetadata[metadataProgress](action)
+	if err == nil && metadata.Progress != nil {
+		progress.Percentage = *metadata.Progress
+		progress.HasPercentage = true
+	}
+
+	return progress
+}
@@ -0,0 +1,197 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/rest"
+	"github.com/assi010/gotransip/v6/wait"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const actionUUID = "6c7fa1c1-f509-4999-a513-bdf4e7a0cebb"
+
+// scriptedServer responds to every poll with the next response in the script, repeating the last one
+type scriptedServer struct {
+	actions  []string
+	children []string
+	mutex    sync.Mutex
+	polls    int
+}
+
+func (s *scriptedServer) next(responses []string, poll int) string {
+	if poll >= len(responses) {
+		return responses[len(responses)-1]
+	}
+
+	return responses[poll]
+}
+
+func (s *scriptedServer) getRepository(t *testing.T) (Repository, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		var response string
+		switch req.URL.Path {
+		case "/actions/" + actionUUID:
+			response = s.next(s.actions, s.polls)
+			s.polls++
+		case "/actions/children/" + actionUUID:
+			if len(s.children) == 0 {
+				response = `{"actions":[]}`
+				break
+			}
+			response = s.next(s.children, s.polls-1)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			response = `{"error":"not found"}`
+		}
+		_, err := rw.Write([]byte(response))
+		require.NoError(t, err)
+	}))
+
+	config := gotransip.DemoClientConfiguration
+	config.URL = server.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(t, err)
+
+	return Repository{Client: client}, server.Close
+}
+
+// fastPolling polls every millisecond
+var fastPolling = WaitOptions{Options: wait.Options{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}}
+
+func TestRepository_WaitForAction(t *testing.T) {
+	server := scriptedServer{actions: []string{
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running","metadata":{"progress":10}}}`,
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running","metadata":{"progress":80}}}`,
+		`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"finished","metadata":{"progress":100}}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	var percentages []int
+	opts := fastPolling
+	opts.Progress = func(progress Progress) {
+		assert.True(t, progress.HasPercentage)
+		percentages = append(percentages, progress.Percentage)
+	}
+
+	action, err := repo.WaitForAction(context.Background(), actionUUID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFinished, action.Status)
+	assert.Equal(t, []int{10, 80, 100}, percentages)
+}
+
+func TestRepository_WaitForActionFollowsChildren(t *testing.T) {
+	server := scriptedServer{
+		actions: []string{
+			`{"action":{"uuid":"` + actionUUID + `","name":"big storage order","status":"running","metadata":{}}}`,
+			`{"action":{"uuid":"` + actionUUID + `","name":"big storage order","status":"finished","metadata":{}}}`,
+		},
+		children: []string{
+			`{"actions":[{"uuid":"child-1","name":"attach","status":"running","parentActionUuid":"` + actionUUID + `"}]}`,
+			`{"actions":[{"uuid":"child-1","name":"attach","status":"running","parentActionUuid":"` + actionUUID + `"}]}`,
+			`{"actions":[{"uuid":"child-1","name":"attach","status":"finished","parentActionUuid":"` + actionUUID + `"}]}`,
+		},
+	}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	var progresses []Progress
+	opts := fastPolling
+	opts.Progress = func(progress Progress) {
+		progresses = append(progresses, progress)
+	}
+
+	_, err := repo.WaitForAction(context.Background(), actionUUID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.polls, "the finished action should be polled until its child action finished")
+	require.Len(t, progresses, 3)
+	assert.False(t, progresses[0].HasPercentage)
+	assert.Equal(t, "child-1", progresses[2].Children[0].UUID)
+
+	// without following child actions the parent is enough
+	server.polls = 0
+	opts.SkipChildren = true
+	_, err = repo.WaitForAction(context.Background(), actionUUID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.polls)
+}
+
+func TestRepository_WaitForActionFailed(t *testing.T) {
+	server := scriptedServer{
+		actions: []string{`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running"}}`},
+		children: []string{
+			`{"actions":[{"uuid":"child-1","name":"copy disk","status":"failed","parentActionUuid":"` + actionUUID + `"}]}`,
+		},
+	}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	_, err := repo.WaitForAction(context.Background(), actionUUID, fastPolling)
+	assert.ErrorIs(t, err, ErrActionFailed)
+	assert.EqualError(t, err, "action failed: child action 'copy disk' (child-1) of "+actionUUID)
+
+	var failedError *FailedError
+	require.True(t, errors.As(err, &failedError))
+	assert.Equal(t, "child-1", failedError.Action.UUID)
+
+	server = scriptedServer{actions: []string{`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"failed"}}`}}
+	repo, tearDown = server.getRepository(t)
+	defer tearDown()
+
+	_, err = repo.WaitForAction(context.Background(), actionUUID, fastPolling)
+	assert.EqualError(t, err, "action failed: 'vps clone' ("+actionUUID+")")
+}
+
+func TestRepository_WaitForActionTimeout(t *testing.T) {
+	server := scriptedServer{actions: []string{`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"running"}}`}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	opts := fastPolling
+	opts.Timeout = 20 * time.Millisecond
+	action, err := repo.WaitForAction(context.Background(), actionUUID, opts)
+	assert.ErrorIs(t, err, wait.ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "timed out waiting for resource: action "+actionUUID+" did not reach status finished, last observed 'vps clone' with status running")
+	assert.Equal(t, StatusRunning, action.Status)
+
+	// a cancelled context is not a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.WaitForAction(ctx, actionUUID, fastPolling)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, wait.ErrTimeout)
+}
+
+func TestRepository_WaitForActionFromResponse(t *testing.T) {
+	server := scriptedServer{actions: []string{`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"finished"}}`}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	action, err := repo.WaitForActionFromResponse(context.Background(), rest.Response{ContentLocation: "/v6/actions/" + actionUUID}, fastPolling)
+	require.NoError(t, err)
+	assert.Equal(t, actionUUID, action.UUID)
+
+	_, err = repo.WaitForActionFromResponse(context.Background(), rest.Response{}, fastPolling)
+	assert.ErrorIs(t, err, ErrNoActionReturned)
+}
+
+func TestRepository_WaitForActionPollError(t *testing.T) {
+	server := scriptedServer{actions: []string{`{"action":{"uuid":"` + actionUUID + `","status":"running"}}`}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	_, err := repo.WaitForAction(context.Background(), "unknown", fastPolling)
+	assert.EqualError(t, err, "error retrieving action unknown: not found")
+}
@@ -0,0 +1,212 @@
+// Package audit records the mutating requests (POST, PUT, PATCH and DELETE) a client sends to the api,
+// so you can find out who changed what and when.
+//
+// Every request is written as one json line. Each line contains the hash of the previous line,
+// so removing or changing a line breaks the chain, which can be detected with Verify or VerifyFile.
+//
+//	auditLog, err := audit.OpenFile("/var/log/gotransip/audit.log", 10*1024*1024)
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer auditLog.Close()
+//	client, err := gotransip.NewClient(gotransip.ClientConfiguration{
+//		AccountName:    "accountName",
+//		PrivateKeyPath: "/path/to/api/private.key",
+//		AuditLog:       auditLog,
+//	})
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedValue replaces the value of sensitive fields in a logged request body
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the request body fields of which the value is never logged,
+// a field is redacted when its name contains one of these keys, ignoring case
+var DefaultRedactKeys = []string{"password", "secret", "token", "authcode", "privatekey", "installtext"}
+
+// Entry is one mutating request in the audit log
+type Entry struct {
+	// Time at which the request was sent
+	Time time.Time `json:"time"`
+	// Account is the account name of the client
+	Account string `json:"account,omitempty"`
+	// TokenID is the unique id (jti) of the token that was used for the request
+	TokenID string `json:"tokenId,omitempty"`
+	// Method is the http method of the request
+	Method string `json:"method"`
+	// Endpoint is the api endpoint of the request
+	Endpoint string `json:"endpoint"`
+	// Body is the request body, with the values of sensitive fields redacted
+	Body json.RawMessage `json:"body,omitempty"`
+	// StatusCode is the status code the api server responded with, zero when no response was received
+	StatusCode int `json:"status"`
+	// Error contains the error message when the request failed
+	Error string `json:"error,omitempty"`
+	// ActionUUID is the uuid of the action the api server started for this request, if any
+	ActionUUID string `json:"actionUuid,omitempty"`
+	// PreviousHash is the Hash of the previous entry in the log
+	PreviousHash string `json:"previousHash"`
+	// Hash is the sha256 hash of this entry, calculated with an empty Hash field
+	Hash string `json:"hash"`
+}
+
+// A Sink records audit entries, it is implemented by Log
+type Sink interface {
+	Record(entry Entry) error
+}
+
+// Log writes hash-chained entries as json lines to a writer
+type Log struct {
+	// RedactKeys overrides DefaultRedactKeys when set
+	RedactKeys []string
+	writer     io.Writer
+	lastHash   string
+	mutex      sync.Mutex
+	// rotation is set when the log writes to a file
+	rotation *fileRotation
+}
+
+// NewLog returns a Log that writes to the given writer.
+// The previousHash is the Hash of the last entry written before, it should be empty for a new log.
+func NewLog(writer io.Writer, previousHash string) *Log {
+	return &Log{writer: writer, lastHash: previousHash}
+}
+
+// LastHash returns the hash of the last entry written to the log
+func (l *Log) LastHash() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.lastHash
+}
+
+// Record redacts the body of the entry, chains it to the previous entry and writes it to the log
+func (l *Log) Record(entry Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	redactKeys := l.RedactKeys
+	if redactKeys == nil {
+		redactKeys = DefaultRedactKeys
+	}
+	body, err := redact(entry.Body, redactKeys)
+	if err != nil {
+		return err
+	}
+	entry.Body = body
+	entry.Time = entry.Time.UTC()
+	entry.PreviousHash = l.lastHash
+
+	entry.Hash, err = hashEntry(entry)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshalling audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if l.rotation != nil {
+		if err := l.rotation.rotateIfNeeded(l, len(line)); err != nil {
+			return err
+		}
+	}
+	if _, err := l.writer.Write(line); err != nil {
+		return fmt.Errorf("error writing audit entry: %w", err)
+	}
+	if l.rotation != nil {
+		l.rotation.size += int64(len(line))
+	}
+	l.lastHash = entry.Hash
+
+	return nil
+}
+
+// Close closes the underlying file of a log opened with OpenFile
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.rotation == nil {
+		return nil
+	}
+
+	return l.rotation.file.Close()
+}
+
+// hashEntry returns the hex encoded sha256 hash of the entry with an empty Hash field
+func hashEntry(entry Entry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling audit entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// redact replaces the values of sensitive fields in a json body
+func redact(body json.RawMessage, redactKeys []string) (json.RawMessage, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	// numbers are kept as json.Number, decoding them into a float64 would change ids above 2^53
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error decoding audit entry body: %w", err)
+	}
+	redacted, err := json.Marshal(redactValue(value, redactKeys))
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling audit entry body: %w", err)
+	}
+
+	return redacted, nil
+}
+
+// redactValue walks a decoded json value and replaces the values of sensitive fields
+func redactValue(value any, redactKeys []string) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, fieldValue := range value {
+			if isSensitive(key, redactKeys) {
+				value[key] = redactedValue
+				continue
+			}
+			value[key] = redactValue(fieldValue, redactKeys)
+		}
+	case []any:
+		for idx, item := range value {
+			value[idx] = redactValue(item, redactKeys)
+		}
+	}
+
+	return value
+}
+
+// isSensitive returns true when the field name contains one of the redact keys
+func isSensitive(field string, redactKeys []string) bool {
+	field = strings.ToLower(field)
+	for _, key := range redactKeys {
+		if strings.Contains(field, strings.ToLower(key)) {
+			return true
+		}
+	}
+
+	return false
+}
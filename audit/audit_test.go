@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry(endpoint string) Entry {
+	return Entry{
+		Time:       time.Date(2023, 2, 1, 17, 1, 51, 0, time.UTC),
+		Account:    "example-user",
+		TokenID:    "cw2!Rl56x3hRy#zS8bgN",
+		Method:     "PATCH",
+		Endpoint:   endpoint,
+		Body:       json.RawMessage(`{"action":"handover","targetCustomerName":"bb-b12345","authCode":"secret"}`),
+		StatusCode: 204,
+	}
+}
+
+func TestLog_Record(t *testing.T) {
+	var buffer bytes.Buffer
+	log := NewLog(&buffer, "")
+
+	require.NoError(t, log.Record(testEntry("/vps/example-vps")))
+	require.NoError(t, log.Record(testEntry("/vps/example-vps2")))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+
+	assert.Equal(t, "/vps/example-vps", first.Endpoint)
+	assert.JSONEq(t, `{"action":"handover","targetCustomerName":"bb-b12345","authCode":"[REDACTED]"}`, string(first.Body))
+	assert.Empty(t, first.PreviousHash)
+	assert.Len(t, first.Hash, 64)
+	assert.Equal(t, first.Hash, second.PreviousHash)
+	assert.Equal(t, second.Hash, log.LastHash())
+
+	lastHash, err := Verify(strings.NewReader(buffer.String()), "")
+	require.NoError(t, err)
+	assert.Equal(t, second.Hash, lastHash)
+}
+
+func TestLog_RedactNested(t *testing.T) {
+	var buffer bytes.Buffer
+	log := NewLog(&buffer, "")
+
+	entry := testEntry("/mail/example.com/mailboxes")
+	entry.Body = json.RawMessage(`{"mailboxes":[{"localPart":"info","password":"hunter2"}],"installFlavour":"cloudinit","base64InstallText":"I2Nsb3Vk"}`)
+	require.NoError(t, log.Record(entry))
+
+	var recorded Entry
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &recorded))
+	assert.JSONEq(t, `{"mailboxes":[{"localPart":"info","password":"[REDACTED]"}],"installFlavour":"cloudinit","base64InstallText":"[REDACTED]"}`, string(recorded.Body))
+}
+
+func TestLog_RedactKeepsLargeNumbers(t *testing.T) {
+	var buffer bytes.Buffer
+	log := NewLog(&buffer, "")
+
+	entry := testEntry("/vps/example-vps/backups/9007199254740993")
+	entry.Body = json.RawMessage(`{"id":9007199254740993,"ratio":0.1,"password":"hunter2"}`)
+	require.NoError(t, log.Record(entry))
+
+	var recorded Entry
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &recorded))
+	assert.JSONEq(t, `{"id":9007199254740993,"ratio":0.1,"password":"[REDACTED]"}`, string(recorded.Body))
+	assert.Contains(t, string(recorded.Body), "9007199254740993")
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	var buffer bytes.Buffer
+	log := NewLog(&buffer, "")
+	for _, endpoint := range []string{"/vps/a", "/vps/b", "/vps/c"} {
+		require.NoError(t, log.Record(testEntry(endpoint)))
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+
+	// changing an entry
+	changed := append([]string{}, lines...)
+	changed[1] = strings.Replace(changed[1], "/vps/b", "/vps/x", 1)
+	_, err := Verify(strings.NewReader(strings.Join(changed, "\n")), "")
+	assert.ErrorIs(t, err, ErrTampered)
+	assert.EqualError(t, err, "audit log has been tampered with: line 2: entry does not match its hash")
+
+	// removing an entry
+	removed := []string{lines[0], lines[2]}
+	_, err = Verify(strings.NewReader(strings.Join(removed, "\n")), "")
+	assert.EqualError(t, err, "audit log has been tampered with: line 2: entry is not chained to the previous entry")
+
+	// removing the first entry
+	_, err = Verify(strings.NewReader(strings.Join(lines[1:], "\n")), "")
+	assert.ErrorIs(t, err, ErrTampered)
+
+	// adding a field the hash does not cover
+	added := append([]string{}, lines...)
+	added[1] = strings.Replace(added[1], `{"time"`, `{"approvedBy":"someone","time"`, 1)
+	_, err = Verify(strings.NewReader(strings.Join(added, "\n")), "")
+	assert.ErrorIs(t, err, ErrTampered)
+	assert.Contains(t, err.Error(), "line 2: invalid entry")
+
+	// reformatting an entry
+	reformatted := append([]string{}, lines...)
+	reformatted[1] = strings.Replace(reformatted[1], `"status":204`, `"status": 204`, 1)
+	_, err = Verify(strings.NewReader(strings.Join(reformatted, "\n")), "")
+	assert.EqualError(t, err, "audit log has been tampered with: line 2: entry does not match its encoding")
+}
+
+func TestOpenFile_RotationAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	log, err := OpenFile(path, 600)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, log.Record(testEntry("/vps/example-vps")))
+	}
+	require.NoError(t, log.Close())
+
+	files, err := logFiles(path)
+	require.NoError(t, err)
+	assert.Greater(t, len(files), 1, "log file should have been rotated")
+	for _, file := range files {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(600))
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// reopening continues the chain
+	log, err = OpenFile(path, 600)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(testEntry("/vps/example-vps")))
+	require.NoError(t, log.Close())
+
+	lastHash, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, log.LastHash(), lastHash)
+
+	// removing a rotated file breaks the chain
+	require.NoError(t, os.Remove(files[0]))
+	_, err = VerifyFile(path)
+	assert.ErrorIs(t, err, ErrTampered)
+	assert.Contains(t, err.Error(), files[1])
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// logFileMode is used for audit log files, they may contain resource names and should not be world readable
+	logFileMode = 0600
+	// rotatedSuffixFormat is appended to the path of a rotated log file, it sorts in chronological order
+	rotatedSuffixFormat = "20060102T150405.000000000Z"
+)
+
+// fileRotation contains the state of a Log that writes to a file
+type fileRotation struct {
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+}
+
+// OpenFile returns a Log that appends to the file on the given path.
+// When maxSize is larger than zero, the file is rotated before it grows beyond maxSize bytes.
+// Rotated files are renamed to '<path>.<timestamp>' and never removed,
+// the chain continues from the last entry of the previous file.
+func OpenFile(path string, maxSize int64) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, logFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error opening audit log: %w", err)
+	}
+
+	// continue the chain from the last entry, which is in a rotated file when the current file is empty
+	files, err := logFiles(path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	var lastHash string
+	for idx := len(files) - 1; idx >= 0 && lastHash == ""; idx-- {
+		if lastHash, err = lastHashInFile(files[idx]); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	log := NewLog(file, lastHash)
+	log.rotation = &fileRotation{path: path, file: file, size: info.Size(), maxSize: maxSize}
+
+	return log, nil
+}
+
+// rotateIfNeeded renames the current file and opens a new one when writing the next line would exceed maxSize
+func (r *fileRotation) rotateIfNeeded(log *Log, lineLength int) error {
+	if r.maxSize <= 0 || r.size == 0 || r.size+int64(lineLength) <= r.maxSize {
+		return nil
+	}
+
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("error closing audit log: %w", err)
+	}
+	rotatedPath := r.path + "." + time.Now().UTC().Format(rotatedSuffixFormat)
+	if err := os.Rename(r.path, rotatedPath); err != nil {
+		return fmt.Errorf("error rotating audit log: %w", err)
+	}
+
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, logFileMode)
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	r.file = file
+	r.size = 0
+	log.writer = file
+
+	return nil
+}
+
+// logFiles returns the rotated files of the log on the given path in chronological order,
+// followed by the path itself
+func logFiles(path string) ([]string, error) {
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("error listing rotated audit logs: %w", err)
+	}
+
+	var files []string
+	for _, file := range rotated {
+		suffix := file[len(path)+1:]
+		if _, err := time.Parse(rotatedSuffixFormat, suffix); err == nil {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	return append(files, path), nil
+}
+
+// lastHashInFile returns the hash of the last entry in a log file
+func lastHashInFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading audit log: %w", err)
+	}
+	defer file.Close()
+
+	var lastLine []byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineLength)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lastLine = append(lastLine[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading audit log: %w", err)
+	}
+	if lastLine == nil {
+		return "", nil
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(lastLine, &entry); err != nil {
+		return "", fmt.Errorf("error decoding last audit log entry of '%s': %w", path, err)
+	}
+
+	return entry.Hash, nil
+}
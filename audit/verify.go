@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxLineLength is the longest audit log line we are able to read,
+// request bodies are limited so this should never be reached
+const maxLineLength = 4 * 1024 * 1024
+
+var (
+	// ErrTampered is wrapped by every VerificationError
+	ErrTampered = errors.New("audit log has been tampered with")
+)
+
+// VerificationError is returned when an audit log entry does not match its hash or the hash of the previous entry
+type VerificationError struct {
+	// File is the audit log file containing the entry, empty when verifying a reader
+	File string
+	// Line is the line number of the entry, starting at 1
+	Line int
+	// Reason describes what is wrong with the entry
+	Reason string
+}
+
+func (e *VerificationError) Error() string {
+	if len(e.File) > 0 {
+		return fmt.Sprintf("%s: %s:%d: %s", ErrTampered, e.File, e.Line, e.Reason)
+	}
+
+	return fmt.Sprintf("%s: line %d: %s", ErrTampered, e.Line, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrTampered) work for a VerificationError
+func (e *VerificationError) Is(target error) bool {
+	return target == ErrTampered
+}
+
+// Verify reads all entries from the reader and checks that each entry matches its hash
+// and is chained to the previous entry. The first entry should be chained to previousHash,
+// which is empty for the start of a log. It returns the hash of the last entry.
+func Verify(reader io.Reader, previousHash string) (string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxLineLength)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		// the hash covers the decoded entry, so the line has to be exactly the encoded entry:
+		// an added field or a reformatted value would otherwise go unnoticed
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return previousHash, &VerificationError{Line: line, Reason: fmt.Sprintf("invalid entry: %s", err)}
+		}
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return previousHash, fmt.Errorf("error marshalling audit entry: %w", err)
+		}
+		if !bytes.Equal(encoded, bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))) {
+			return previousHash, &VerificationError{Line: line, Reason: "entry does not match its encoding"}
+		}
+		if entry.PreviousHash != previousHash {
+			return previousHash, &VerificationError{Line: line, Reason: "entry is not chained to the previous entry"}
+		}
+		hash, err := hashEntry(entry)
+		if err != nil {
+			return previousHash, err
+		}
+		if hash != entry.Hash {
+			return previousHash, &VerificationError{Line: line, Reason: "entry does not match its hash"}
+		}
+		previousHash = entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return previousHash, fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	return previousHash, nil
+}
+
+// VerifyFile verifies the log file on the given path, including all of its rotated files.
+// It returns the hash of the last entry.
+func VerifyFile(path string) (string, error) {
+	files, err := logFiles(path)
+	if err != nil {
+		return "", err
+	}
+
+	var previousHash string
+	for _, file := range files {
+		previousHash, err = verifyFile(file, previousHash)
+		if err != nil {
+			return previousHash, err
+		}
+	}
+
+	return previousHash, nil
+}
+
+// verifyFile verifies a single log file, chained to the given previous hash
+func verifyFile(path string, previousHash string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return previousHash, fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	lastHash, err := Verify(file, previousHash)
+	var verificationError *VerificationError
+	if errors.As(err, &verificationError) {
+		verificationError.File = path
+	}
+
+	return lastHash, err
+}
@@ -71,6 +71,9 @@ type Authenticator struct {
 	TokenExpiration time.Duration
 	// A KeyManager is used to offload the signing of a new Token request to a third party (e.g. a key vault)
 	KeyManager KeyManager
+	// ClockSkew is used to check the Token expiry date against the api server clock instead of the local clock.
+	// The offset is measured from the responses on token requests. If not set, the local clock is used
+	ClockSkew *ClockSkew
 }
 
 // AuthRequest will be transformed and send in order to request a new Token
@@ -101,26 +104,61 @@ func (a *Authenticator) GetToken() (jwt.Token, error) {
 		}
 	}
 
-	if a.Token.Expired() && a.PrivateKeyBody == nil && a.KeyManager == nil {
+	if a.tokenExpired() && a.PrivateKeyBody == nil && a.KeyManager == nil {
 		return jwt.Token{}, ErrTokenExpired
 	}
-	if a.Token.Expired() {
-		var err error
-		a.Token, err = a.requestNewToken()
+	if a.tokenExpired() {
+		if err := a.refreshToken(); err != nil {
+			return jwt.Token{}, err
+		}
+	}
 
+	return a.Token, nil
+}
+
+// refreshToken requests a new Token and writes it to the token cache.
+// When the token cache is shared with other clients, it holds the cache lock while doing so
+// and uses the token from the cache if another client refreshed it in the meantime.
+func (a *Authenticator) refreshToken() error {
+	if locker, ok := a.TokenCache.(TokenCacheLocker); ok {
+		unlock, err := locker.Lock(a.getTokenCacheKey())
 		if err != nil {
-			return jwt.Token{}, err
+			return fmt.Errorf("error locking token cache: %w", err)
 		}
+		defer unlock()
 
-		// if a TokenCache is set we want to write acquired tokens to the cache
-		if a.TokenCache != nil {
-			if err = a.TokenCache.Set(a.getTokenCacheKey(), a.Token); err != nil {
-				return jwt.Token{}, fmt.Errorf("error writing token to cache: %w", err)
-			}
+		if err := a.retrieveTokenFromCache(); err != nil {
+			return err
+		}
+		if !a.tokenExpired() {
+			return nil
 		}
 	}
 
-	return a.Token, nil
+	var err error
+	a.Token, err = a.requestNewToken()
+
+	if err != nil {
+		return err
+	}
+
+	// if a TokenCache is set we want to write acquired tokens to the cache
+	if a.TokenCache != nil {
+		if err = a.TokenCache.Set(a.getTokenCacheKey(), a.Token); err != nil {
+			return fmt.Errorf("error writing token to cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// tokenExpired checks the expiry date of the Token against the api server clock when the clock skew is known
+func (a *Authenticator) tokenExpired() bool {
+	if a.ClockSkew != nil {
+		return a.Token.ExpiredAt(a.ClockSkew.Now())
+	}
+
+	return a.Token.Expired()
 }
 
 // retrieveTokenFromCache gets the token from the cache
@@ -131,6 +169,12 @@ func (a *Authenticator) retrieveTokenFromCache() error {
 		return fmt.Errorf("error getting token from cache: %w", err)
 	}
 
+	// older versions cached read-only tokens under the read/write cache key, so the cache could contain
+	// a token for the other mode. We ignore it, which results in a new token that replaces it in the cache
+	if claims, err := a.Token.Claims(); err == nil && claims.ReadOnly != a.ReadOnly {
+		a.Token = jwt.Token{}
+	}
+
 	return nil
 }
 
@@ -164,6 +208,7 @@ func (a *Authenticator) requestNewToken() (jwt.Token, error) {
 	}
 	httpRequest.Header.Add(signatureHeader, signature)
 
+	sentAt := time.Now()
 	httpResponse, err := a.HTTPClient.Do(httpRequest)
 	if err != nil {
 		return jwt.Token{}, fmt.Errorf("error requesting token: %w", err)
@@ -171,6 +216,10 @@ func (a *Authenticator) requestNewToken() (jwt.Token, error) {
 
 	defer httpResponse.Body.Close()
 
+	if a.ClockSkew != nil {
+		a.ClockSkew.Observe(httpResponse, sentAt, time.Now())
+	}
+
 	// read entire response body
 	b, err := io.ReadAll(httpResponse.Body)
 	if err != nil {
@@ -234,8 +283,12 @@ func (a *Authenticator) getAuthRequest() (rest.Request, error) {
 	}, nil
 }
 
-// getTokenCacheKey returns a name for the given Login and our authenticator name
+// getTokenCacheKey returns a name for the given Login and our authenticator name,
+// read-only tokens get their own key so they can be cached next to read/write tokens
 func (a *Authenticator) getTokenCacheKey() string {
+	if a.ReadOnly {
+		return fmt.Sprintf("%s-%s-readonly-token", labelPrefix, a.Login)
+	}
 	return fmt.Sprintf("%s-%s-token", labelPrefix, a.Login)
 }
 
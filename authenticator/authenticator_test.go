@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/assi010/gotransip/v6/internal/testutil/tokencache"
 	"github.com/assi010/gotransip/v6/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -191,6 +192,9 @@ func TestAuthenticator_getTokenCacheKey(t *testing.T) {
 	authenticator := Authenticator{Login: "test"}
 
 	assert.Equal(t, "gotransip-client-test-token", authenticator.getTokenCacheKey())
+
+	authenticator.ReadOnly = true
+	assert.Equal(t, "gotransip-client-test-readonly-token", authenticator.getTokenCacheKey())
 }
 
 func TestAuthenticator_getTokenExpirationString(t *testing.T) {
@@ -202,3 +206,34 @@ func TestAuthenticator_getTokenExpirationString(t *testing.T) {
 	authenticator.TokenExpiration = 60 * time.Minute
 	assert.Equal(t, "3600 seconds", authenticator.getTokenExpirationString())
 }
+
+func TestAuthenticator_IgnoresCachedTokenForOtherMode(t *testing.T) {
+	server := getMockServer(t)
+	defer server.Close()
+	key, err := os.ReadFile("../testdata/signature.key")
+	require.NoError(t, err)
+
+	readOnlyToken, err := jwt.New("eyJ0eXAiOiJKV1QifQ.eyJleHAiOjIxMTg3NDU1NTAsImp0aSI6InJlYWQtb25seS10b2tlbiIsInJvIjp0cnVlfQ.signature")
+	require.NoError(t, err)
+	cache := tokencache.Memory{"gotransip-client-test-user-token": readOnlyToken}
+
+	authenticator := Authenticator{
+		PrivateKeyBody: key,
+		BasePath:       server.URL,
+		Login:          "test-user",
+		HTTPClient:     http.DefaultClient,
+		TokenCache:     cache,
+	}
+
+	// the cached read-only token should not be used by a read/write authenticator
+	token, err := authenticator.GetToken()
+	require.NoError(t, err)
+	assert.Equal(t, DemoToken, token.RawToken)
+	assert.Equal(t, DemoToken, cache["gotransip-client-test-user-token"].RawToken)
+
+	// while a read-only authenticator can use it
+	authenticator = Authenticator{Login: "test-user", ReadOnly: true, TokenCache: tokencache.Memory{"gotransip-client-test-user-readonly-token": readOnlyToken}}
+	token, err = authenticator.GetToken()
+	require.NoError(t, err)
+	assert.Equal(t, readOnlyToken, token)
+}
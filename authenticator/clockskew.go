@@ -0,0 +1,119 @@
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// dateHeaderResolution is the resolution of the http Date header,
+// we assume the server time was halfway the second it reported
+const dateHeaderResolution = time.Second
+
+var (
+	// ErrClockSkew is wrapped by every ClockSkewError
+	ErrClockSkew = errors.New("clock skew with the api server is too large")
+)
+
+// ClockSkewError is returned when the measured clock skew exceeds ClockSkew.MaxOffset
+type ClockSkewError struct {
+	// Offset is the measured offset of the api server clock relative to the local clock
+	Offset time.Duration
+	// MaxOffset is the maximum allowed offset
+	MaxOffset time.Duration
+}
+
+func (e *ClockSkewError) Error() string {
+	return fmt.Sprintf("%s: offset %s exceeds %s, please synchronise the local clock", ErrClockSkew, e.Offset, e.MaxOffset)
+}
+
+// Is makes errors.Is(err, ErrClockSkew) work for a ClockSkewError
+func (e *ClockSkewError) Is(target error) bool {
+	return target == ErrClockSkew
+}
+
+// ClockSkew keeps track of the offset between the local clock and the clock of the api server.
+// The offset is measured from the Date header of responses and used to check token expiry dates
+// against the server clock, so a drifting local clock does not result in expired tokens being used.
+type ClockSkew struct {
+	// MaxOffset is the maximum offset we accept, when the measured offset is larger
+	// Check returns a ClockSkewError, unless Warn is set. If zero, any offset is accepted
+	MaxOffset time.Duration
+	// Warn is called instead of failing when the measured offset starts exceeding MaxOffset
+	Warn     func(offset time.Duration)
+	mutex    sync.RWMutex
+	offset   time.Duration
+	measured bool
+}
+
+// Observe measures the offset from the Date header of a response.
+// The sentAt and receivedAt times are the local times at which the request was sent and the response was received.
+func (c *ClockSkew) Observe(response *http.Response, sentAt time.Time, receivedAt time.Time) {
+	serverTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	// compare the server time with the local time halfway the round trip
+	localTime := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+	offset := serverTime.Add(dateHeaderResolution / 2).Sub(localTime)
+	// a measurement within the resolution of the Date header can not be distinguished from no skew at all
+	if abs(offset) <= dateHeaderResolution {
+		offset = 0
+	}
+
+	c.mutex.Lock()
+	wasExceeded := c.exceeded()
+	c.offset = offset
+	c.measured = true
+	warn := c.Warn != nil && !wasExceeded && c.exceeded()
+	c.mutex.Unlock()
+
+	if warn {
+		c.Warn(offset)
+	}
+}
+
+// Offset returns the last measured offset of the api server clock relative to the local clock,
+// the returned bool is false when no offset has been measured yet
+func (c *ClockSkew) Offset() (time.Duration, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.offset, c.measured
+}
+
+// Now returns the current time according to the api server clock
+func (c *ClockSkew) Now() time.Time {
+	offset, _ := c.Offset()
+
+	return time.Now().Add(offset)
+}
+
+// Check returns a ClockSkewError when the measured offset exceeds MaxOffset and Warn is not set
+func (c *ClockSkew) Check() error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.Warn == nil && c.exceeded() {
+		return &ClockSkewError{Offset: c.offset, MaxOffset: c.MaxOffset}
+	}
+
+	return nil
+}
+
+// exceeded returns true when the measured offset exceeds MaxOffset, the caller should hold the mutex
+func (c *ClockSkew) exceeded() bool {
+	return c.MaxOffset > 0 && abs(c.offset) > c.MaxOffset
+}
+
+// abs returns the absolute value of a duration
+func abs(duration time.Duration) time.Duration {
+	if duration < 0 {
+		return -duration
+	}
+
+	return duration
+}
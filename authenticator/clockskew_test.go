@@ -0,0 +1,88 @@
+package authenticator
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// responseWithDate returns a http response with the given server time in the Date header
+func responseWithDate(serverTime time.Time) *http.Response {
+	header := http.Header{}
+	header.Set("Date", serverTime.UTC().Format(http.TimeFormat))
+	return &http.Response{Header: header}
+}
+
+func TestClockSkew_Observe(t *testing.T) {
+	var clockSkew ClockSkew
+	_, measured := clockSkew.Offset()
+	assert.False(t, measured)
+
+	now := time.Now()
+	clockSkew.Observe(responseWithDate(now.Add(time.Hour)), now, now)
+	offset, measured := clockSkew.Offset()
+	assert.True(t, measured)
+	assert.InDelta(t, time.Hour, offset, float64(time.Second))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), clockSkew.Now(), time.Second)
+
+	// the resolution of the Date header is a second, so a small offset is no offset
+	clockSkew.Observe(responseWithDate(now), now, now)
+	offset, _ = clockSkew.Offset()
+	assert.Equal(t, time.Duration(0), offset)
+
+	// responses without a Date header are ignored
+	clockSkew.Observe(&http.Response{Header: http.Header{}}, now, now)
+	offset, measured = clockSkew.Offset()
+	assert.Equal(t, time.Duration(0), offset)
+	assert.True(t, measured)
+}
+
+func TestClockSkew_Check(t *testing.T) {
+	clockSkew := ClockSkew{MaxOffset: time.Minute}
+	require.NoError(t, clockSkew.Check())
+
+	now := time.Now()
+	clockSkew.Observe(responseWithDate(now.Add(-10*time.Minute)), now, now)
+	err := clockSkew.Check()
+	assert.ErrorIs(t, err, ErrClockSkew)
+	assert.Contains(t, err.Error(), "exceeds 1m0s")
+
+	clockSkew.Observe(responseWithDate(now), now, now)
+	assert.NoError(t, clockSkew.Check())
+}
+
+func TestClockSkew_Warn(t *testing.T) {
+	var warnings []time.Duration
+	clockSkew := ClockSkew{MaxOffset: time.Minute, Warn: func(offset time.Duration) {
+		warnings = append(warnings, offset)
+	}}
+
+	now := time.Now()
+	clockSkew.Observe(responseWithDate(now.Add(10*time.Minute)), now, now)
+	clockSkew.Observe(responseWithDate(now.Add(10*time.Minute)), now, now)
+	assert.NoError(t, clockSkew.Check(), "a warning should be given instead of an error")
+	require.Len(t, warnings, 1, "a warning should only be given once the offset starts exceeding the maximum")
+	assert.InDelta(t, 10*time.Minute, warnings[0], float64(time.Second))
+}
+
+func TestAuthenticator_UsesServerClockForExpiry(t *testing.T) {
+	// the local clock is an hour behind, so the token already expired according to the server
+	clockSkew := &ClockSkew{}
+	now := time.Now()
+	clockSkew.Observe(responseWithDate(now.Add(time.Hour)), now, now)
+
+	authenticator := Authenticator{
+		Token:     jwt.Token{ExpiryDate: now.Add(10 * time.Minute).Unix(), RawToken: "123"},
+		ClockSkew: clockSkew,
+	}
+	_, err := authenticator.GetToken()
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	authenticator.ClockSkew = nil
+	_, err = authenticator.GetToken()
+	assert.NoError(t, err)
+}
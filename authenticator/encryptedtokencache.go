@@ -0,0 +1,189 @@
+package authenticator
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/jwt"
+)
+
+var (
+	// ErrNoCacheKey will be thrown when the CacheKeyProvider of an EncryptedTokenCache did not provide a key
+	ErrNoCacheKey = errors.New("no token cache encryption key available")
+	// ErrInvalidCacheKey will be thrown when the token cache encryption key is not a 16, 24 or 32 byte AES key
+	ErrInvalidCacheKey = errors.New("token cache encryption key should be 16, 24 or 32 bytes long")
+)
+
+// A CacheKeyProvider provides the AES key that is used to encrypt cached tokens at rest,
+// it can be used to retrieve the key from a third party (e.g. a key vault)
+type CacheKeyProvider interface {
+	CacheKey() ([]byte, error)
+}
+
+// EnvCacheKey is a CacheKeyProvider that reads a base64 encoded key from the environment variable with this name
+type EnvCacheKey string
+
+// CacheKey returns the decoded key from the environment variable
+func (e EnvCacheKey) CacheKey() ([]byte, error) {
+	encodedKey, ok := os.LookupEnv(string(e))
+	if !ok || len(encodedKey) == 0 {
+		return nil, fmt.Errorf("%w: environment variable '%s' is not set", ErrNoCacheKey, string(e))
+	}
+
+	return decodeCacheKey(encodedKey)
+}
+
+// FileCacheKey is a CacheKeyProvider that reads a base64 encoded key from the file on this path
+type FileCacheKey string
+
+// CacheKey returns the decoded key from the key file
+func (f FileCacheKey) CacheKey() ([]byte, error) {
+	encodedKey, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("error reading token cache key file: %w", err)
+	}
+
+	return decodeCacheKey(string(encodedKey))
+}
+
+// decodeCacheKey decodes a base64 encoded key and checks if it can be used as an AES key
+func decodeCacheKey(encodedKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding token cache key: %w", err)
+	}
+
+	return key, checkCacheKey(key)
+}
+
+// checkCacheKey returns ErrInvalidCacheKey when the key can not be used as an AES key
+func checkCacheKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	case 0:
+		return ErrNoCacheKey
+	default:
+		return ErrInvalidCacheKey
+	}
+}
+
+// EncryptedTokenCache is a file based TokenCache that encrypts every token with AES-GCM before writing it.
+// It shares the locking and atomic write behaviour of the FileTokenCache.
+//
+// Every entry is bound to the AccountName and ReadOnly flag of the cache, an entry that was stored
+// by a cache for another account or mode can not be decrypted and is handled as a cache miss.
+// This way a read-only token is never served to a read-write client.
+type EncryptedTokenCache struct {
+	// AccountName is the account the cached tokens belong to
+	AccountName string
+	// ReadOnly should be set to true when the cache is used by a client in APIModeReadOnly
+	ReadOnly bool
+	// KeyProvider provides the key used to encrypt and decrypt tokens
+	KeyProvider CacheKeyProvider
+	// file stores the encrypted tokens
+	file *FileTokenCache
+}
+
+// NewEncryptedTokenCache opens or creates an encrypted cache File on the specified path.
+// The AccountName and readOnly flag should match the ClientConfiguration that will use the cache.
+func NewEncryptedTokenCache(path string, keyProvider CacheKeyProvider, accountName string, readOnly bool) (*EncryptedTokenCache, error) {
+	if keyProvider == nil {
+		return &EncryptedTokenCache{}, ErrNoCacheKey
+	}
+
+	// fail early when the key is not available, instead of on the first token request
+	if _, err := getCacheCipher(keyProvider); err != nil {
+		return &EncryptedTokenCache{}, err
+	}
+
+	file, err := NewFileTokenCache(path)
+	if err != nil {
+		return &EncryptedTokenCache{}, err
+	}
+
+	return &EncryptedTokenCache{
+		AccountName: accountName,
+		ReadOnly:    readOnly,
+		KeyProvider: keyProvider,
+		file:        file,
+	}, nil
+}
+
+// Set will encrypt the token and save it by name
+func (e *EncryptedTokenCache) Set(key string, token jwt.Token) error {
+	aead, err := getCacheCipher(e.KeyProvider)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error when getting random data for token cache nonce: %w", err)
+	}
+
+	data := aead.Seal(nonce, nonce, []byte(token.String()), e.additionalData(key))
+
+	return e.file.setItem(cacheItem{Key: key, Data: data, ExpiryDate: token.ExpiryDate})
+}
+
+// Get a previously acquired token by name, when the token can not be decrypted
+// with the current key, account name and mode an empty token is returned
+func (e *EncryptedTokenCache) Get(key string) (jwt.Token, error) {
+	aead, err := getCacheCipher(e.KeyProvider)
+	if err != nil {
+		return jwt.Token{}, err
+	}
+
+	item, found, err := e.file.getItem(key)
+	if err != nil || !found {
+		return jwt.Token{}, err
+	}
+
+	if len(item.Data) < aead.NonceSize() {
+		return jwt.Token{}, nil
+	}
+
+	nonce, ciphertext := item.Data[:aead.NonceSize()], item.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, e.additionalData(key))
+	if err != nil {
+		// the entry was written with another key or for another account or mode,
+		// handle it as a cache miss so a new token is requested and the entry is replaced
+		return jwt.Token{}, nil
+	}
+
+	return jwt.New(string(plaintext))
+}
+
+// additionalData returns the authenticated data that binds an entry to its key, account name and mode
+func (e *EncryptedTokenCache) additionalData(key string) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00read_only=%t", key, e.AccountName, e.ReadOnly))
+}
+
+// getCacheCipher returns an AES-GCM cipher using the key of the given provider
+func getCacheCipher(keyProvider CacheKeyProvider) (cipher.AEAD, error) {
+	if keyProvider == nil {
+		return nil, ErrNoCacheKey
+	}
+
+	key, err := keyProvider.CacheKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCacheKey(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating token cache cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,93 @@
+package authenticator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticCacheKey is a CacheKeyProvider that always returns the same key
+type staticCacheKey []byte
+
+func (s staticCacheKey) CacheKey() ([]byte, error) {
+	return s, nil
+}
+
+var testCacheKey = staticCacheKey(bytes.Repeat([]byte{0x42}, 32))
+
+func TestEncryptedTokenCache_SetGet(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_encrypted_cache")
+
+	cache, err := NewEncryptedTokenCache(cacheLocation, testCacheKey, "test-user", false)
+	require.NoError(t, err)
+
+	tokenToCache := jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}
+	require.NoError(t, cache.Set("testkey", tokenToCache))
+
+	// the token should not be readable from the cache file
+	content, err := os.ReadFile(cacheLocation)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), DemoToken)
+	assert.NotContains(t, string(content), base64.StdEncoding.EncodeToString([]byte(DemoToken)))
+
+	cache, err = NewEncryptedTokenCache(cacheLocation, testCacheKey, "test-user", false)
+	require.NoError(t, err)
+
+	dataFromCache, err := cache.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+}
+
+func TestEncryptedTokenCache_BoundToAccountAndMode(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_encrypted_cache")
+
+	readOnlyCache, err := NewEncryptedTokenCache(cacheLocation, testCacheKey, "test-user", true)
+	require.NoError(t, err)
+	require.NoError(t, readOnlyCache.Set("testkey", jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}))
+
+	readWriteCache, err := NewEncryptedTokenCache(cacheLocation, testCacheKey, "test-user", false)
+	require.NoError(t, err)
+	dataFromCache, err := readWriteCache.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache, "read-only token served to read-write cache")
+
+	otherAccountCache, err := NewEncryptedTokenCache(cacheLocation, testCacheKey, "other-user", true)
+	require.NoError(t, err)
+	dataFromCache, err = otherAccountCache.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache, "token served to another account")
+
+	otherKeyCache, err := NewEncryptedTokenCache(cacheLocation, staticCacheKey(bytes.Repeat([]byte{0x43}, 32)), "test-user", true)
+	require.NoError(t, err)
+	dataFromCache, err = otherKeyCache.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache, "token decrypted with another key")
+}
+
+func TestEncryptedTokenCache_KeyProviders(t *testing.T) {
+	encodedKey := base64.StdEncoding.EncodeToString(testCacheKey)
+
+	t.Setenv("GOTRANSIP_TEST_CACHE_KEY", encodedKey)
+	key, err := EnvCacheKey("GOTRANSIP_TEST_CACHE_KEY").CacheKey()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(testCacheKey), key)
+
+	_, err = EnvCacheKey("GOTRANSIP_TEST_CACHE_KEY_UNSET").CacheKey()
+	assert.ErrorIs(t, err, ErrNoCacheKey)
+
+	keyFile := filepath.Join(t.TempDir(), "cache.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(encodedKey+"\n"), 0600))
+	key, err = FileCacheKey(keyFile).CacheKey()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(testCacheKey), key)
+
+	t.Setenv("GOTRANSIP_TEST_CACHE_KEY", base64.StdEncoding.EncodeToString([]byte("too short")))
+	_, err = NewEncryptedTokenCache(filepath.Join(t.TempDir(), "cache"), EnvCacheKey("GOTRANSIP_TEST_CACHE_KEY"), "test-user", false)
+	assert.ErrorIs(t, err, ErrInvalidCacheKey)
+}
@@ -0,0 +1,65 @@
+//go:build !unix
+
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockRetryInterval is the time we wait before trying to acquire a taken lock again
+	lockRetryInterval = 10 * time.Millisecond
+	// lockTimeout is the maximum time we wait for a taken lock
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age after which we consider a lock file left behind by a crashed process
+	staleLockAge = 30 * time.Second
+)
+
+// lockFile takes a lock by exclusively creating the lock file on the given path.
+// Platforms without flock support can not share locks, so readers take an exclusive lock as well.
+// The returned function releases the lock again.
+func lockFile(path string, _ bool) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, cacheFileMode)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error creating cache lock File: %w", err)
+		}
+
+		// remove lock files of processes that crashed while holding the lock
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			breakStaleLock(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout while waiting for cache lock File '%s'", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// breakStaleLock moves a stale lock file out of the way. Between our check of its age and the move another waiter
+// could have replaced it with a fresh lock, so the moved file is checked again, a file that turns out to be fresh
+// is put back when the lock path is still free. Renaming is atomic, so only one waiter moves a given lock file.
+func breakStaleLock(path string) {
+	stalePath := path + ".stale-" + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := os.Rename(path, stalePath); err != nil {
+		return
+	}
+	defer os.Remove(stalePath)
+
+	if info, err := os.Stat(stalePath); err == nil && time.Since(info.ModTime()) <= staleLockAge {
+		// os.Link does not replace an existing file, so a lock taken in the meantime is kept
+		_ = os.Link(stalePath, path)
+	}
+}
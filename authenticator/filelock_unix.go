@@ -0,0 +1,44 @@
+//go:build unix
+
+package authenticator
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an advisory flock on the given path, creating the lock file if needed.
+// An exclusive lock is used for writers, a shared lock for readers.
+// The returned function releases the lock again.
+func lockFile(path string, exclusive bool) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, cacheFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("error opening cache lock File: %w", err)
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	if err := flock(file, how); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error locking cache File: %w", err)
+	}
+
+	return func() {
+		_ = flock(file, syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// flock retries the flock system call when it is interrupted by a signal
+func flock(file *os.File, how int) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
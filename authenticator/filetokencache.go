@@ -2,56 +2,89 @@ package authenticator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"sync"
 
+	"github.com/assi010/gotransip/v6/internal/atomicfile"
 	"github.com/assi010/gotransip/v6/jwt"
 )
 
+const (
+	// cacheFileMode is used for the cache file and its lock file,
+	// cached bearer tokens should only be readable by the owner
+	cacheFileMode = 0600
+	// lockFileSuffix is appended to the cache path to get the path of the advisory lock file.
+	// We can not lock the cache file itself, because it is replaced on every write
+	lockFileSuffix = ".lock"
+)
+
 // cacheItem is one named item inside the filesystem cache
 type cacheItem struct {
 	// Key of the cache item, containing
 	Key string `json:"key"`
 	// Data containing the content of the cache item
 	Data []byte `json:"data"`
+	// ExpiryDate is set when the token can not be read from Data, for example because Data is encrypted
+	ExpiryDate int64 `json:"expiryDate,omitempty"`
+}
+
+// cacheFileContent is the json structure that is written to the cache file
+type cacheFileContent struct {
+	// Items contains a list of cache items, all of them have a key
+	Items []cacheItem `json:"items"`
 }
 
 // FileTokenCache is a cache that takes a path and writes a json marshalled File to it,
 // it decodes it when created with the NewFileTokenCache method.
 // It has a Set method to save a token by name as jwt.Token
 // and a Get method one to get a previously acquired token by name returned as jwt.Token
+//
+// The cache file is created with 0600 permissions and guarded by an advisory lock on a sibling '.lock' file,
+// so multiple processes can share one cache file. Writes re-read the file first, prune expired tokens
+// and replace the file atomically, so a crash halfway a write never leaves a corrupt cache behind.
 type FileTokenCache struct {
-	// File contains the cache file
+	// Path is the location of the cache file on the filesystem
+	Path string
+	// File contains the cache file, its name is used when Path is empty.
+	//
+	// Deprecated: use NewFileTokenCache or set Path, the file is reopened for every read and write.
 	File *os.File
 	// CacheItems contains a list of cache items, all of them have a key
 	CacheItems []cacheItem `json:"items"`
-	// prevent simultaneous cache writes
-	writeLock sync.RWMutex
+	// prevent simultaneous cache access within this process
+	mutex sync.Mutex
 }
 
-// NewFileTokenCache opens or creates a filesystem cache File on the specified path
+// NewFileTokenCache opens or creates a filesystem cache File on the specified path.
+// A cache file that can not be decoded is treated as an empty cache and will be replaced on the next write.
 func NewFileTokenCache(path string) (*FileTokenCache, error) {
-	// open the File or create a new one on the given location
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	// create the File on the given location if it does not exist yet
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, cacheFileMode)
 	if err != nil {
 		return &FileTokenCache{}, fmt.Errorf("error opening cache File: %w", err)
 	}
+	if err := file.Close(); err != nil {
+		return &FileTokenCache{}, fmt.Errorf("error closing cache File: %w", err)
+	}
+
+	// tighten the permissions of cache files created by older versions of this library
+	if err := os.Chmod(path, cacheFileMode); err != nil {
+		return &FileTokenCache{}, fmt.Errorf("error setting cache File permissions: %w", err)
+	}
 
-	cache := FileTokenCache{File: file}
+	cache := FileTokenCache{Path: path}
 
-	// try to read the File
-	fileContent, err := io.ReadAll(file)
+	unlock, err := lockFile(cache.lockPath(), false)
 	if err != nil {
-		return &FileTokenCache{}, fmt.Errorf("error reading cache File: %w", err)
+		return &FileTokenCache{}, err
 	}
+	defer unlock()
 
-	if len(fileContent) > 0 {
-		// read the cached File data as json
-		if err := json.Unmarshal(fileContent, &cache); err != nil {
-			return &FileTokenCache{}, fmt.Errorf("error unmarshalling cache File: %w", err)
-		}
+	cache.CacheItems, err = cache.readItems()
+	if err != nil {
+		return &FileTokenCache{}, err
 	}
 
 	return &cache, nil
@@ -59,48 +92,145 @@ func NewFileTokenCache(path string) (*FileTokenCache, error) {
 
 // Set will save a token by name as jwt.Token
 func (f *FileTokenCache) Set(key string, token jwt.Token) error {
-	for idx, item := range f.CacheItems {
-		if item.Key == key {
-			f.CacheItems[idx].Data = []byte(token.String())
+	return f.setItem(cacheItem{Key: key, Data: []byte(token.String())})
+}
+
+// Get a previously acquired token by name returned as jwt.Token
+func (f *FileTokenCache) Get(key string) (jwt.Token, error) {
+	item, found, err := f.getItem(key)
+	if err != nil || !found {
+		return jwt.Token{}, err
+	}
+
+	return jwt.New(string(item.Data))
+}
+
+// setItem stores the given item, replacing an existing item with the same key
+// and pruning items that contain an expired token
+func (f *FileTokenCache) setItem(newItem cacheItem) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	unlock, err := lockFile(f.lockPath(), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// re-read the cache File, another process could have written to it since we last read it
+	items, err := f.readItems()
+	if err != nil {
+		return err
+	}
+
+	newItems := []cacheItem{newItem}
+	for _, item := range pruneExpiredItems(items) {
+		if item.Key != newItem.Key {
+			newItems = append(newItems, item)
+		}
+	}
+
+	if err := f.writeItems(newItems); err != nil {
+		return err
+	}
+	f.CacheItems = newItems
 
-			// persist this change to the cache File
-			return f.writeCacheToFile()
+	return nil
+}
+
+// getItem returns the item stored by the given key, the returned bool is false when there is no such item
+func (f *FileTokenCache) getItem(key string) (cacheItem, bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	unlock, err := lockFile(f.lockPath(), false)
+	if err != nil {
+		return cacheItem{}, false, err
+	}
+	defer unlock()
+
+	// pick up tokens written by other processes
+	f.CacheItems, err = f.readItems()
+	if err != nil {
+		return cacheItem{}, false, err
+	}
+
+	for _, item := range f.CacheItems {
+		if item.Key == key {
+			return item, true, nil
 		}
 	}
 
-	// if the key did not exist before, we append a new item to the cache item list
-	f.CacheItems = append(f.CacheItems, cacheItem{Key: key, Data: []byte(token.String())})
+	return cacheItem{}, false, nil
+}
 
-	return f.writeCacheToFile()
+// readItems reads the cache items from the cache File,
+// a missing or corrupt cache File results in an empty list of items
+func (f *FileTokenCache) readItems() ([]cacheItem, error) {
+	fileContent, err := os.ReadFile(f.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache File: %w", err)
+	}
+
+	var content cacheFileContent
+	if err := json.Unmarshal(fileContent, &content); err != nil {
+		// we can always request a new token, so we start over instead of failing forever
+		return nil, nil
+	}
+
+	return content.Items, nil
 }
 
-func (f *FileTokenCache) writeCacheToFile() error {
+// writeItems atomically replaces the cache File by writing to a temporary File and renaming it
+func (f *FileTokenCache) writeItems(items []cacheItem) error {
 	// try to convert the cache to json, so we can write it to File
-	cacheData, err := json.Marshal(f)
+	cacheData, err := json.Marshal(cacheFileContent{Items: items})
 	if err != nil {
 		return fmt.Errorf("error marshalling cache File: %w", err)
 	}
 
-	f.writeLock.Lock()
-	defer f.writeLock.Unlock()
-	// write the cache data to the File cache
-	if err := f.File.Truncate(0); err != nil {
-		return fmt.Errorf("error while truncating cache File: %w", err)
+	if err := atomicfile.WriteFile(f.path(), cacheData, cacheFileMode); err != nil {
+		return fmt.Errorf("error writing cache File: %w", err)
 	}
-	_, err = f.File.WriteAt(cacheData, 0)
 
-	return err
+	return nil
 }
 
-// Get a previously acquired token by name returned as jwt.Token
-func (f *FileTokenCache) Get(key string) (jwt.Token, error) {
-	for _, item := range f.CacheItems {
-		if item.Key == key {
-			dataAsString := string(item.Data)
+// path returns the location of the cache File, which is the name of the deprecated File field when Path is empty
+func (f *FileTokenCache) path() string {
+	if f.Path == "" && f.File != nil {
+		return f.File.Name()
+	}
+
+	return f.Path
+}
+
+// lockPath returns the location of the advisory lock File of this cache
+func (f *FileTokenCache) lockPath() string {
+	return f.path() + lockFileSuffix
+}
+
+// pruneExpiredItems returns the items that still contain a valid, not yet expired token
+func pruneExpiredItems(items []cacheItem) []cacheItem {
+	var validItems []cacheItem
+	for _, item := range items {
+		if item.ExpiryDate != 0 {
+			token := jwt.Token{ExpiryDate: item.ExpiryDate}
+			if !token.Expired() {
+				validItems = append(validItems, item)
+			}
+			continue
+		}
 
-			return jwt.New(dataAsString)
+		token, err := jwt.New(string(item.Data))
+		if err != nil || token.Expired() {
+			continue
 		}
+		validItems = append(validItems, item)
 	}
 
-	return jwt.Token{}, nil
+	return validItems
 }
@@ -1,12 +1,16 @@
 package authenticator
 
 import (
-	"github.com/assi010/gotransip/v6/jwt"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
+	"encoding/base64"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFileTokenCache_New(t *testing.T) {
@@ -33,6 +37,23 @@ func TestFileTokenCache_Set(t *testing.T) {
 	assert.Equal(t, tokenToCache, dataFromCache)
 }
 
+func TestFileTokenCache_DeprecatedFileField(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "cache"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	// caches built as a struct literal with the deprecated File field keep working
+	cache := FileTokenCache{File: file}
+	tokenToCache := jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}
+	require.NoError(t, cache.Set("testkey", tokenToCache))
+
+	reopened, err := NewFileTokenCache(file.Name())
+	require.NoError(t, err)
+	dataFromCache, err := reopened.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+}
+
 func TestFileTokenCache_SetGetFromFile(t *testing.T) {
 	cacheLocation := os.TempDir() + "/gotransip_cache_setgetfromfile"
 	defer os.Remove(cacheLocation)
@@ -49,14 +70,106 @@ func TestFileTokenCache_SetGetFromFile(t *testing.T) {
 	err = cache.Set("testkey", tokenToCache)
 	require.NoError(t, err)
 
-	// close the File so we know we will fetch it with a new File token cache
-	err = cache.File.Close()
+	// create a new File token cache so we know we will fetch it from the File
+	cache, err = NewFileTokenCache(cacheLocation)
+	require.NoError(t, err)
+
+	dataFromCache, err := cache.Get("testkey")
 	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+}
 
-	cache, err = NewFileTokenCache(cacheLocation)
+func TestFileTokenCache_Permissions(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_cache_permissions")
+	// simulate a world readable cache file created by an older version
+	require.NoError(t, os.WriteFile(cacheLocation, nil, 0644))
+
+	cache, err := NewFileTokenCache(cacheLocation)
+	require.NoError(t, err)
+
+	info, err := os.Stat(cacheLocation)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	err = cache.Set("testkey", jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken})
+	require.NoError(t, err)
+
+	info, err = os.Stat(cacheLocation)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestFileTokenCache_RecoversFromCorruptFile(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_cache_corrupt")
+	require.NoError(t, os.WriteFile(cacheLocation, []byte(`{"items":[{"key":"testkey","da`), 0600))
+
+	cache, err := NewFileTokenCache(cacheLocation)
 	require.NoError(t, err)
 
 	dataFromCache, err := cache.Get("testkey")
 	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache)
+
+	tokenToCache := jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}
+	require.NoError(t, cache.Set("testkey", tokenToCache))
+
+	dataFromCache, err = cache.Get("testkey")
+	require.NoError(t, err)
 	assert.Equal(t, tokenToCache, dataFromCache)
 }
+
+func TestFileTokenCache_SharedBetweenInstances(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_cache_shared")
+
+	first, err := NewFileTokenCache(cacheLocation)
+	require.NoError(t, err)
+	second, err := NewFileTokenCache(cacheLocation)
+	require.NoError(t, err)
+
+	tokenToCache := jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}
+	require.NoError(t, first.Set("first", tokenToCache))
+	// the second cache did not see the first write, but should not clobber it
+	require.NoError(t, second.Set("second", tokenToCache))
+
+	dataFromCache, err := first.Get("second")
+	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+
+	dataFromCache, err = second.Get("first")
+	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+}
+
+func TestFileTokenCache_PrunesExpiredTokens(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "gotransip_cache_prune")
+	expiredToken := createTestToken(t, time.Now().Add(-time.Hour).Unix())
+	content := `{"items":[{"key":"expired","data":"` + base64Encode(expiredToken) + `"}]}`
+	require.NoError(t, os.WriteFile(cacheLocation, []byte(content), 0600))
+
+	cache, err := NewFileTokenCache(cacheLocation)
+	require.NoError(t, err)
+	require.Len(t, cache.CacheItems, 1)
+
+	require.NoError(t, cache.Set("testkey", jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}))
+	require.Len(t, cache.CacheItems, 1)
+	assert.Equal(t, "testkey", cache.CacheItems[0].Key)
+
+	dataFromCache, err := cache.Get("expired")
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache)
+}
+
+// createTestToken returns an unsigned raw token that expires at the given unix timestamp
+func createTestToken(t *testing.T, expiryDate int64) string {
+	payload := fmt.Sprintf(`{"exp":%d}`, expiryDate)
+	token := "eyJ0eXAiOiJKV1QifQ." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+	_, err := jwt.New(token)
+	require.NoError(t, err)
+
+	return token
+}
+
+// base64Encode encodes a string the same way encoding/json encodes a byte slice
+func base64Encode(data string) string {
+	return base64.StdEncoding.EncodeToString([]byte(data))
+}
@@ -0,0 +1,140 @@
+package authenticator
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/assi010/gotransip/v6/jwt"
+)
+
+const (
+	// defaultRedisLockTTL is used when RedisTokenCache.LockTTL is not set,
+	// it should be well above the time it takes to request a new token
+	defaultRedisLockTTL = 30 * time.Second
+	// defaultRedisLockRetryInterval is used when RedisTokenCache.LockRetryInterval is not set
+	defaultRedisLockRetryInterval = 100 * time.Millisecond
+	// redisLockSuffix is appended to a cache key to get the key of the refresh lock
+	redisLockSuffix = ":lock"
+)
+
+// RedisDeleteIfEqualScript is a lua script that deletes KEYS[1] only when its value equals ARGV[1].
+// It can be used with EVAL to implement RedisClient.DeleteIfEqual,
+// so a client never releases a refresh lock that has been taken over by another client.
+const RedisDeleteIfEqualScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+var (
+	// ErrRedisLockTimeout will be thrown when the token refresh lock could not be acquired in time
+	ErrRedisLockTimeout = errors.New("timeout while waiting for token refresh lock")
+)
+
+// RedisClient contains the redis commands used by the RedisTokenCache.
+// It can be implemented with a thin wrapper around any client library
+// that talks to a Redis-compatible server, like redis, valkey or keydb.
+type RedisClient interface {
+	// Get returns the value stored by key, found is false when the key does not exist (GET)
+	Get(key string) (value []byte, found bool, err error)
+	// Set stores the value by key, the key expires after the given ttl (SET key value PX ttl)
+	Set(key string, value []byte, ttl time.Duration) error
+	// SetNX stores the value by key only if the key does not exist yet and returns whether it was stored,
+	// the key expires after the given ttl (SET key value NX PX ttl)
+	SetNX(key string, value []byte, ttl time.Duration) (bool, error)
+	// DeleteIfEqual deletes the key when its value equals the given value (EVAL RedisDeleteIfEqualScript)
+	DeleteIfEqual(key string, value []byte) error
+}
+
+// RedisTokenCache is a TokenCache backed by a Redis-compatible store.
+// It allows multiple replicas of a service to share one token,
+// instead of every replica requesting, and labeling, its own token.
+//
+// Tokens are stored with a ttl that matches their expiry date, measured against the api server clock
+// when a ClockSkew is set.
+// The RedisTokenCache implements the TokenCacheLocker interface,
+// so only one replica at a time requests a new token when the shared token expires.
+type RedisTokenCache struct {
+	// Client is used to send commands to the redis server
+	Client RedisClient
+	// KeyPrefix is prepended to every key, this allows sharing a redis server with other applications
+	KeyPrefix string
+	// LockTTL is the time after which a refresh lock is released when its holder crashed,
+	// it is also the maximum time we wait for a refresh lock. Defaults to 30 seconds
+	LockTTL time.Duration
+	// LockRetryInterval is the time we wait before trying to acquire a taken refresh lock again.
+	// Defaults to 100 milliseconds
+	LockRetryInterval time.Duration
+	// ClockSkew is used to compute the ttl of a token against the api server clock instead of the local clock,
+	// it is optional. A client created with gotransip.NewClient sets its own ClockSkew when this one is nil
+	ClockSkew *ClockSkew
+}
+
+// Set will save a token by name, the key expires when the token expires
+func (r *RedisTokenCache) Set(key string, token jwt.Token) error {
+	now := time.Now()
+	if r.ClockSkew != nil {
+		now = r.ClockSkew.Now()
+	}
+	ttl := time.Unix(token.ExpiryDate, 0).Sub(now)
+	if ttl <= 0 {
+		// an expired token is of no use to anyone
+		return nil
+	}
+
+	if err := r.Client.Set(r.KeyPrefix+key, []byte(token.String()), ttl); err != nil {
+		return fmt.Errorf("error writing token to redis: %w", err)
+	}
+
+	return nil
+}
+
+// Get a previously acquired token by name returned as jwt.Token
+func (r *RedisTokenCache) Get(key string) (jwt.Token, error) {
+	value, found, err := r.Client.Get(r.KeyPrefix + key)
+	if err != nil {
+		return jwt.Token{}, fmt.Errorf("error reading token from redis: %w", err)
+	}
+	if !found {
+		return jwt.Token{}, nil
+	}
+
+	return jwt.New(string(value))
+}
+
+// Lock acquires the refresh lock for the given key, waiting until the lock is released by another client.
+// The returned function releases the lock again.
+func (r *RedisTokenCache) Lock(key string) (func(), error) {
+	lockTTL := r.LockTTL
+	if lockTTL == 0 {
+		lockTTL = defaultRedisLockTTL
+	}
+	retryInterval := r.LockRetryInterval
+	if retryInterval == 0 {
+		retryInterval = defaultRedisLockRetryInterval
+	}
+
+	// a random value makes sure we only release our own lock
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, fmt.Errorf("error when getting random data for token refresh lock: %w", err)
+	}
+	ownerValue := []byte(fmt.Sprintf("%x", owner))
+	lockKey := r.KeyPrefix + key + redisLockSuffix
+	deadline := time.Now().Add(lockTTL)
+
+	for {
+		acquired, err := r.Client.SetNX(lockKey, ownerValue, lockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("error acquiring token refresh lock: %w", err)
+		}
+		if acquired {
+			return func() {
+				_ = r.Client.DeleteIfEqual(lockKey, ownerValue)
+			}, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrRedisLockTimeout
+		}
+		time.Sleep(retryInterval)
+	}
+}
@@ -0,0 +1,194 @@
+package authenticator
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRedis is an in-process stand-in for a redis server
+type memoryRedis struct {
+	mutex   sync.Mutex
+	values  map[string][]byte
+	expires map[string]time.Time
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{values: make(map[string][]byte), expires: make(map[string]time.Time)}
+}
+
+func (m *memoryRedis) Get(key string) ([]byte, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	value, found := m.get(key)
+	return value, found, nil
+}
+
+func (m *memoryRedis) Set(key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.values[key] = value
+	m.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (m *memoryRedis) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, found := m.get(key); found {
+		return false, nil
+	}
+	m.values[key] = value
+	m.expires[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (m *memoryRedis) DeleteIfEqual(key string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if current, found := m.get(key); found && bytes.Equal(current, value) {
+		delete(m.values, key)
+		delete(m.expires, key)
+	}
+	return nil
+}
+
+// get returns a value that is not expired yet, the caller should hold the mutex
+func (m *memoryRedis) get(key string) ([]byte, bool) {
+	value, found := m.values[key]
+	if found && time.Now().After(m.expires[key]) {
+		delete(m.values, key)
+		delete(m.expires, key)
+		return nil, false
+	}
+	return value, found
+}
+
+func TestRedisTokenCache_SetGet(t *testing.T) {
+	redis := newMemoryRedis()
+	cache := RedisTokenCache{Client: redis, KeyPrefix: "service-a:"}
+
+	tokenToCache := jwt.Token{ExpiryDate: 2118745550, RawToken: DemoToken}
+	require.NoError(t, cache.Set("testkey", tokenToCache))
+
+	// the ttl of the key should match the expiry date of the token
+	assert.WithinDuration(t, time.Unix(2118745550, 0), redis.expires["service-a:testkey"], time.Second)
+
+	dataFromCache, err := cache.Get("testkey")
+	require.NoError(t, err)
+	assert.Equal(t, tokenToCache, dataFromCache)
+
+	dataFromCache, err = cache.Get("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Token{}, dataFromCache)
+}
+
+func TestRedisTokenCache_SkipsExpiredToken(t *testing.T) {
+	redis := newMemoryRedis()
+	cache := RedisTokenCache{Client: redis}
+
+	require.NoError(t, cache.Set("testkey", jwt.Token{ExpiryDate: time.Now().Unix() - 10, RawToken: DemoToken}))
+	assert.Empty(t, redis.values)
+}
+
+func TestRedisTokenCache_ClockSkew(t *testing.T) {
+	// the api server clock is an hour ahead of the local clock
+	clockSkew := &ClockSkew{}
+	now := time.Now()
+	clockSkew.Observe(&http.Response{Header: http.Header{"Date": {now.Add(time.Hour).UTC().Format(http.TimeFormat)}}}, now, now)
+	redis := newMemoryRedis()
+	cache := RedisTokenCache{Client: redis, ClockSkew: clockSkew}
+
+	// the key expires when the token expires on the server clock, not an hour later
+	serverExpiry := clockSkew.Now().Add(10 * time.Minute)
+	require.NoError(t, cache.Set("testkey", jwt.Token{ExpiryDate: serverExpiry.Unix(), RawToken: DemoToken}))
+	assert.WithinDuration(t, now.Add(10*time.Minute), redis.expires["testkey"], 2*time.Second)
+
+	// a token that is only valid on the local clock is not stored
+	require.NoError(t, cache.Set("expired", jwt.Token{ExpiryDate: now.Add(30 * time.Minute).Unix(), RawToken: DemoToken}))
+	assert.NotContains(t, redis.values, "expired")
+}
+
+func TestRedisTokenCache_Lock(t *testing.T) {
+	redis := newMemoryRedis()
+	cache := RedisTokenCache{Client: redis, LockTTL: time.Second, LockRetryInterval: time.Millisecond}
+
+	unlock, err := cache.Lock("testkey")
+	require.NoError(t, err)
+
+	released := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		released <- time.Now()
+		unlock()
+	}()
+
+	// the second caller should wait until the first one releases the lock
+	unlock, err = cache.Lock("testkey")
+	require.NoError(t, err)
+	assert.False(t, time.Now().Before(<-released))
+	unlock()
+
+	// a lock held by another client should not be released by us
+	require.NoError(t, redis.Set("testkey:lock", []byte("other-client"), time.Minute))
+	cache.LockTTL = 20 * time.Millisecond
+	_, err = cache.Lock("testkey")
+	assert.ErrorIs(t, err, ErrRedisLockTimeout)
+	value, found, err := redis.Get("testkey:lock")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("other-client"), value)
+}
+
+func TestRedisTokenCache_SingleFlightRefresh(t *testing.T) {
+	var authRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&authRequests, 1)
+		// make the token request slow, so other replicas will wait for the lock
+		time.Sleep(50 * time.Millisecond)
+		_, err := rw.Write([]byte(fmt.Sprintf(`{"token":"%s"}`, DemoToken)))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	key, err := os.ReadFile("../testdata/signature.key")
+	require.NoError(t, err)
+
+	cache := &RedisTokenCache{Client: newMemoryRedis(), LockRetryInterval: time.Millisecond}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every replica has its own authenticator sharing the redis cache
+			replica := Authenticator{
+				PrivateKeyBody: key,
+				BasePath:       server.URL,
+				Login:          "test-user",
+				HTTPClient:     http.DefaultClient,
+				TokenCache:     cache,
+			}
+			token, err := replica.GetToken()
+			assert.NoError(t, err)
+			assert.Equal(t, DemoToken, token.RawToken)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&authRequests))
+}
@@ -11,3 +11,11 @@ type TokenCache interface {
 	// Get a previously acquired token by name returned as byte array
 	Get(key string) (jwt.Token, error)
 }
+
+// TokenCacheLocker can be implemented by a TokenCache that is shared between multiple clients.
+// When the cached token is expired, the authenticator holds the lock while requesting a new token,
+// so only one of the clients sharing the cache requests a new token.
+type TokenCacheLocker interface {
+	// Lock acquires the lock for the given key, the returned function releases it
+	Lock(key string) (func(), error)
+}
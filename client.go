@@ -6,13 +6,25 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/assi010/gotransip/v6/audit"
 	"github.com/assi010/gotransip/v6/authenticator"
 	"github.com/assi010/gotransip/v6/jwt"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 )
 
+var (
+	// ErrTokenModeMismatch will be returned when a read-only token is provided to a client in APIModeReadWrite
+	ErrTokenModeMismatch = errors.New("a read-only token can not be used in read/write mode")
+	// ErrReadOnlyMode will be returned when a client in APIModeReadOnly is used for a mutating request.
+	// The request is rejected before anything is sent to the api server.
+	ErrReadOnlyMode = errors.New("mutating requests are not allowed in read-only mode")
+)
+
 // client manages communication with the TransIP API
 // In most cases there should be only one, shared, client.
 type client struct {
@@ -27,6 +39,14 @@ type client struct {
 	// - creating an authentication request
 	// - requesting and setting a new token
 	authenticator *authenticator.Authenticator
+	// readWriteAuthenticator is only set in APIModeDualToken, it requests a short-lived
+	// read/write token for every mutating request, while the authenticator requests read-only tokens
+	readWriteAuthenticator *authenticator.Authenticator
+	// readWriteMutex makes sure a read/write token is only used by one request
+	readWriteMutex sync.Mutex
+	// clockSkew keeps track of the offset between the local clock and the api server clock,
+	// it is shared with the authenticators so they check token expiry dates against the server clock
+	clockSkew *authenticator.ClockSkew
 }
 
 // httpBodyLimit provides a maximum byte limit around the http body reader.
@@ -34,6 +54,12 @@ type client struct {
 // We do not expect to hit this extreme high number even when serving things like PDFs
 const httpBodyLimit = 1024 * 1024 * 4
 
+// actionsPath precedes the action uuid in the Content-Location header of responses that started an action
+const actionsPath = "/actions/"
+
+// defaultReadWriteTokenExpiration is used when ClientConfiguration.ReadWriteTokenExpiration is not set
+const defaultReadWriteTokenExpiration = 5 * time.Minute
+
 // NewClient creates a new API client.
 // optionally you could put a custom http.client in the configuration struct
 // to allow for advanced features such as caching.
@@ -88,6 +114,22 @@ func newClient(config ClientConfiguration) (*client, error) {
 		if err != nil {
 			return &client{}, err
 		}
+
+		claims, err := token.Claims()
+		if err != nil {
+			return &client{}, err
+		}
+
+		// a read-only token will only work in read-only mode,
+		// a read/write token is allowed in read-only mode as mutating calls are rejected by the client
+		if claims.ReadOnly {
+			if config.Mode == APIModeReadWrite {
+				return &client{}, ErrTokenModeMismatch
+			}
+			if len(config.Mode) == 0 {
+				config.Mode = APIModeReadOnly
+			}
+		}
 	}
 
 	// default to APIMode read/write
@@ -100,7 +142,15 @@ func newClient(config ClientConfiguration) (*client, error) {
 		config.URL = defaultBasePath
 	}
 
-	return &client{
+	clockSkew := &authenticator.ClockSkew{MaxOffset: config.MaxClockSkew, Warn: config.ClockSkewWarning}
+	// the ttl of a shared token has to match its expiry date on the server clock, like the expiry checks do
+	if redisCache, ok := config.TokenCache.(*authenticator.RedisTokenCache); ok && redisCache.ClockSkew == nil {
+		withClockSkew := *redisCache
+		withClockSkew.ClockSkew = clockSkew
+		config.TokenCache = &withClockSkew
+	}
+
+	c := &client{
 		authenticator: &authenticator.Authenticator{
 			Login:           config.AccountName,
 			PrivateKeyBody:  privateKeyBody,
@@ -108,24 +158,161 @@ func newClient(config ClientConfiguration) (*client, error) {
 			HTTPClient:      config.HTTPClient,
 			TokenCache:      config.TokenCache,
 			BasePath:        config.URL,
-			ReadOnly:        config.Mode == APIModeReadOnly,
+			ReadOnly:        config.Mode == APIModeReadOnly || config.Mode == APIModeDualToken,
 			TokenExpiration: config.TokenExpiration,
 			Whitelisted:     config.TokenWhitelisted,
 			KeyManager:      config.KeyManager,
+			ClockSkew:       clockSkew,
 		},
-		config: config,
-	}, nil
+		config:    config,
+		clockSkew: clockSkew,
+	}
+
+	if config.Mode == APIModeDualToken {
+		// read/write tokens are requested on demand, so we need a way to sign token requests
+		if privateKeyBody == nil && config.KeyManager == nil {
+			return &client{}, errors.New("PrivateKeyReader, PrivateKeyPath or KeyManager is required in dual token mode")
+		}
+
+		expiration := config.ReadWriteTokenExpiration
+		if expiration == 0 {
+			expiration = defaultReadWriteTokenExpiration
+		}
+
+		// read/write tokens are never cached, they are discarded after use
+		c.readWriteAuthenticator = &authenticator.Authenticator{
+			Login:           config.AccountName,
+			PrivateKeyBody:  privateKeyBody,
+			HTTPClient:      config.HTTPClient,
+			BasePath:        config.URL,
+			TokenExpiration: expiration,
+			Whitelisted:     config.TokenWhitelisted,
+			KeyManager:      config.KeyManager,
+			ClockSkew:       clockSkew,
+		}
+	}
+
+	return c, nil
 }
 
 // This method is used by all rest client methods, thus: 'get','post','put','delete'
 // It uses the authenticator to get a token, either statically provided by the user or requested from the authentication server
 // Then decodes the json response to a supplied interface
 func (c *client) call(method rest.Method, request rest.Request, result any) (rest.Response, error) {
-	token, err := c.authenticator.GetToken()
+	// a read-only token would be rejected by the api server anyway,
+	// so we don't even try to send mutating requests
+	if c.config.Mode == APIModeReadOnly && method.Mutating() {
+		return rest.Response{}, fmt.Errorf("%w: %s %s", ErrReadOnlyMode, method.Method, request.Endpoint)
+	}
+
+	if c.config.Policy != nil {
+		if err := c.config.Policy.Evaluate(method, request, c.lookup); err != nil {
+			return rest.Response{}, err
+		}
+	}
+
+	token, release, err := c.getToken(method)
 	if err != nil {
 		return rest.Response{}, fmt.Errorf("could not get token from authenticator: %w", err)
 	}
+	defer release()
 
+	// the token request could have measured the clock skew for the first time
+	if err := c.clockSkew.Check(); err != nil {
+		return rest.Response{}, err
+	}
+
+	if c.config.AuditLog == nil || !method.Mutating() {
+		return c.send(token, method, request, result)
+	}
+
+	sentAt := time.Now()
+	response, err := c.send(token, method, request, result)
+	if auditErr := c.recordAuditEntry(sentAt, token, method, request, response, err); auditErr != nil {
+		if err == nil {
+			return response, fmt.Errorf("request was sent, but writing the audit log failed: %w", auditErr)
+		}
+		return response, errors.Join(err, fmt.Errorf("writing the audit log failed: %w", auditErr))
+	}
+
+	return response, err
+}
+
+// recordAuditEntry writes a mutating request and its outcome to the audit log
+func (c *client) recordAuditEntry(sentAt time.Time, token jwt.Token, method rest.Method, request rest.Request, response rest.Response, requestErr error) error {
+	entry := audit.Entry{
+		Time:       sentAt,
+		Account:    c.config.AccountName,
+		TokenID:    tokenID(token),
+		Method:     method.Method,
+		Endpoint:   request.Endpoint,
+		StatusCode: response.StatusCode,
+	}
+	if request.Body != nil {
+		body, err := request.GetJSONBody()
+		if err != nil {
+			return err
+		}
+		entry.Body = body
+	}
+	if requestErr != nil {
+		entry.Error = requestErr.Error()
+	}
+	if idx := strings.LastIndex(response.ContentLocation, actionsPath); idx >= 0 {
+		entry.ActionUUID = response.ContentLocation[idx+len(actionsPath):]
+	}
+
+	return c.config.AuditLog.Record(entry)
+}
+
+// tokenID returns the unique id (jti) of a token, or an empty string when it can not be decoded
+func tokenID(token jwt.Token) string {
+	claims, err := token.Claims()
+	if err != nil {
+		return ""
+	}
+
+	return claims.ID
+}
+
+// lookup executes a GET request without evaluating the policy,
+// it is used by a RequestPolicy to retrieve information about the resource a request is about
+func (c *client) lookup(request rest.Request, result any) error {
+	token, err := c.authenticator.GetToken()
+	if err != nil {
+		return fmt.Errorf("could not get token from authenticator: %w", err)
+	}
+
+	_, err = c.send(token, rest.GetMethod, request, result)
+	return err
+}
+
+// getToken returns the token to use for a request with the given method.
+// In dual token mode mutating requests get a new read/write token, which is discarded by the returned function.
+// Otherwise the returned function does nothing.
+func (c *client) getToken(method rest.Method) (jwt.Token, func(), error) {
+	if c.readWriteAuthenticator == nil || !method.Mutating() {
+		token, err := c.authenticator.GetToken()
+		return token, func() {}, err
+	}
+
+	c.readWriteMutex.Lock()
+	release := func() {
+		c.readWriteAuthenticator.Token = jwt.Token{}
+		c.readWriteMutex.Unlock()
+	}
+
+	token, err := c.readWriteAuthenticator.GetToken()
+	if err != nil {
+		release()
+		return jwt.Token{}, nil, err
+	}
+
+	return token, release, nil
+}
+
+// send executes the request with the given token and decodes the json response to the supplied interface
+func (c *client) send(token jwt.Token, method rest.Method, request rest.Request, result any) (rest.Response, error) {
 	// if test mode is enabled we always want to change rest requests to add a HTTP test=1 query string
 	// to a HTTP request
 	if c.config.TestMode {
@@ -140,6 +327,7 @@ func (c *client) call(method rest.Method, request rest.Request, result any) (res
 	httpRequest.Header.Add("Authorization", token.GetAuthenticationHeaderValue())
 	httpRequest.Header.Set("User-Agent", userAgent)
 	client := c.config.HTTPClient
+	sentAt := time.Now()
 	httpResponse, err := client.Do(httpRequest)
 	if err != nil {
 		return rest.Response{}, fmt.Errorf("request error: %w", err)
@@ -147,6 +335,8 @@ func (c *client) call(method rest.Method, request rest.Request, result any) (res
 
 	defer httpResponse.Body.Close()
 
+	c.clockSkew.Observe(httpResponse, sentAt, time.Now())
+
 	bodyReader := io.LimitReader(httpResponse.Body, httpBodyLimit)
 
 	// read entire httpResponse body
@@ -169,6 +359,12 @@ func (c *client) call(method rest.Method, request rest.Request, result any) (res
 	return restResponse, err
 }
 
+// ClockOffset returns the measured offset of the api server clock relative to the local clock,
+// the returned bool is false when no offset has been measured yet
+func (c *client) ClockOffset() (time.Duration, bool) {
+	return c.clockSkew.Offset()
+}
+
 // ChangeBasePath changes base path to allow switching to mocks
 func (c *client) ChangeBasePath(path string) {
 	c.config.URL = path
@@ -2,18 +2,24 @@ package gotransip
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"testing/iotest"
 	"time"
 
+	"github.com/assi010/gotransip/v6/audit"
 	"github.com/assi010/gotransip/v6/authenticator"
+	"github.com/assi010/gotransip/v6/internal/testutil/tokencache"
+	"github.com/assi010/gotransip/v6/jwt"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 	"github.com/stretchr/testify/assert"
@@ -260,3 +266,323 @@ func (m *mockServer) getClient() (repository.Client, func()) {
 
 	return client, tearDown
 }
+
+func TestClient_ReadOnlyModeRejectsMutatingCalls(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method, "mutating request was sent to the api server")
+		_, err := rw.Write([]byte(`{"ping":"pong"}`))
+		require.NoError(t, err)
+	}))
+	defer httpServer.Close()
+
+	clientConfig := DemoClientConfiguration
+	clientConfig.URL = httpServer.URL
+	clientConfig.Mode = APIModeReadOnly
+	client, err := NewClient(clientConfig)
+	require.NoError(t, err)
+
+	restRequest := rest.Request{Endpoint: "/api-test"}
+	var response struct {
+		Ping string `json:"ping"`
+	}
+	require.NoError(t, client.Get(restRequest, &response))
+	assert.Equal(t, "pong", response.Ping)
+
+	restRequest = rest.Request{Endpoint: "/vps/example-vps"}
+	err = client.Post(restRequest)
+	assert.ErrorIs(t, err, ErrReadOnlyMode)
+	assert.EqualError(t, err, "mutating requests are not allowed in read-only mode: POST /vps/example-vps")
+	_, err = client.PostWithResponse(restRequest)
+	assert.ErrorIs(t, err, ErrReadOnlyMode)
+	assert.ErrorIs(t, client.Put(restRequest), ErrReadOnlyMode)
+	_, err = client.PutWithResponse(restRequest)
+	assert.ErrorIs(t, err, ErrReadOnlyMode)
+	assert.ErrorIs(t, client.Patch(restRequest), ErrReadOnlyMode)
+	_, err = client.PatchWithResponse(restRequest)
+	assert.ErrorIs(t, err, ErrReadOnlyMode)
+	assert.ErrorIs(t, client.Delete(restRequest), ErrReadOnlyMode)
+}
+
+// denyWritesPolicy denies every mutating request and checks that lookups work
+type denyWritesPolicy struct {
+	lookups int
+}
+
+func (d *denyWritesPolicy) Evaluate(method rest.Method, request rest.Request, lookup func(rest.Request, any) error) error {
+	var response struct {
+		Ping string `json:"ping"`
+	}
+	if err := lookup(rest.Request{Endpoint: "/api-test"}, &response); err != nil {
+		return err
+	}
+	d.lookups++
+	if method.Mutating() {
+		return errors.New("denied by test policy")
+	}
+	return nil
+}
+
+func TestClient_PolicyIsEvaluatedBeforeSending(t *testing.T) {
+	var requests []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		_, err := rw.Write([]byte(`{"ping":"pong"}`))
+		require.NoError(t, err)
+	}))
+	defer httpServer.Close()
+
+	policy := &denyWritesPolicy{}
+	clientConfig := DemoClientConfiguration
+	clientConfig.URL = httpServer.URL
+	clientConfig.Policy = policy
+	client, err := NewClient(clientConfig)
+	require.NoError(t, err)
+
+	var response any
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/vps"}, &response))
+	assert.EqualError(t, client.Delete(rest.Request{Endpoint: "/vps/example-vps"}), "denied by test policy")
+
+	// the lookups are not evaluated by the policy themselves
+	assert.Equal(t, 2, policy.lookups)
+	assert.Equal(t, []string{"GET /api-test", "GET /vps", "GET /api-test"}, requests)
+}
+
+func TestClient_AuditLogRecordsMutatingCalls(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "POST" {
+			rw.Header().Set("Content-Location", "/v6/actions/6c7fa1c1-f509-4999-a513-bdf4e7a0cebb")
+			rw.WriteHeader(201)
+			return
+		}
+		if req.Method == "DELETE" {
+			rw.WriteHeader(404)
+			_, err := rw.Write([]byte(`{"error":"VPS not found"}`))
+			require.NoError(t, err)
+			return
+		}
+		_, err := rw.Write([]byte(`{"ping":"pong"}`))
+		require.NoError(t, err)
+	}))
+	defer httpServer.Close()
+
+	var auditBuffer bytes.Buffer
+	clientConfig := DemoClientConfiguration
+	clientConfig.URL = httpServer.URL
+	clientConfig.AuditLog = audit.NewLog(&auditBuffer, "")
+	client, err := NewClient(clientConfig)
+	require.NoError(t, err)
+
+	var response any
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/vps"}, &response))
+	body := struct {
+		VpsName string `json:"vpsName"`
+	}{VpsName: "example-vps"}
+	_, err = client.PostWithResponse(rest.Request{Endpoint: "/vps", Body: &body})
+	require.NoError(t, err)
+	assert.Error(t, client.Delete(rest.Request{Endpoint: "/vps/unknown-vps"}))
+
+	lines := bytes.Split(bytes.TrimSpace(auditBuffer.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2, "only mutating calls should be recorded")
+
+	var post, del audit.Entry
+	require.NoError(t, json.Unmarshal(lines[0], &post))
+	require.NoError(t, json.Unmarshal(lines[1], &del))
+
+	assert.Equal(t, "POST", post.Method)
+	assert.Equal(t, "/vps", post.Endpoint)
+	assert.JSONEq(t, `{"vpsName":"example-vps"}`, string(post.Body))
+	assert.Equal(t, 201, post.StatusCode)
+	assert.Equal(t, "6c7fa1c1-f509-4999-a513-bdf4e7a0cebb", post.ActionUUID)
+	assert.Equal(t, "cw2!Rl56x3hRy#zS8bgN", post.TokenID)
+
+	assert.Equal(t, "DELETE", del.Method)
+	assert.Equal(t, 404, del.StatusCode)
+	assert.Equal(t, "VPS not found", del.Error)
+
+	_, err = audit.Verify(bytes.NewReader(auditBuffer.Bytes()), "")
+	assert.NoError(t, err)
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestClient_AuditLogWriteFailure(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "DELETE" {
+			rw.WriteHeader(404)
+			_, err := rw.Write([]byte(`{"error":"VPS not found"}`))
+			require.NoError(t, err)
+			return
+		}
+		rw.WriteHeader(204)
+	}))
+	defer httpServer.Close()
+
+	clientConfig := DemoClientConfiguration
+	clientConfig.URL = httpServer.URL
+	clientConfig.AuditLog = audit.NewLog(failingWriter{}, "")
+	client, err := NewClient(clientConfig)
+	require.NoError(t, err)
+
+	err = client.Put(rest.Request{Endpoint: "/vps/example-vps"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request was sent, but writing the audit log failed")
+
+	// both the request error and the audit error are returned
+	err = client.Delete(rest.Request{Endpoint: "/vps/unknown-vps"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VPS not found")
+	assert.Contains(t, err.Error(), "writing the audit log failed")
+	assert.Contains(t, err.Error(), "disk full")
+}
+
+// readOnlyToken is an unsigned token with the read-only claim set
+const readOnlyToken = "eyJ0eXAiOiJKV1QifQ.eyJleHAiOjIxMTg3NDU1NTAsImp0aSI6InJlYWQtb25seS10b2tlbiIsInJvIjp0cnVlfQ.signature"
+
+func TestNewClient_TokenModeMismatch(t *testing.T) {
+	// a read-only token without mode results in a read-only client
+	client, err := newClient(ClientConfiguration{Token: readOnlyToken})
+	require.NoError(t, err)
+	assert.Equal(t, APIModeReadOnly, client.GetConfig().Mode)
+	assert.True(t, client.GetAuthenticator().ReadOnly)
+
+	// a read-only token can not be used in read/write mode
+	_, err = newClient(ClientConfiguration{Token: readOnlyToken, Mode: APIModeReadWrite})
+	assert.ErrorIs(t, err, ErrTokenModeMismatch)
+
+	// a read/write token can be used in read-only mode, the client rejects mutating calls itself
+	client, err = newClient(ClientConfiguration{Token: authenticator.DemoToken, Mode: APIModeReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, APIModeReadOnly, client.GetConfig().Mode)
+}
+
+func TestClient_DualTokenMode(t *testing.T) {
+	var authRequests []authenticator.AuthRequest
+	var usedTokens []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth" {
+			var authRequest authenticator.AuthRequest
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&authRequest))
+			authRequests = append(authRequests, authRequest)
+
+			token := authenticator.DemoToken
+			if authRequest.ReadOnly {
+				token = readOnlyToken
+			}
+			_, err := fmt.Fprintf(rw, `{"token":"%s"}`, token)
+			require.NoError(t, err)
+			return
+		}
+
+		usedTokens = append(usedTokens, req.Method+" "+strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if req.Method == "GET" {
+			_, err := rw.Write([]byte(`{"ping":"pong"}`))
+			require.NoError(t, err)
+			return
+		}
+		if req.URL.Path == "/vps/unknown-vps" {
+			rw.WriteHeader(404)
+			_, err := rw.Write([]byte(`{"error":"Vps not found"}`))
+			require.NoError(t, err)
+			return
+		}
+		rw.WriteHeader(201)
+	}))
+	defer httpServer.Close()
+
+	cache := make(tokencache.Memory)
+	client, err := newClient(ClientConfiguration{
+		AccountName:              "example-user",
+		PrivateKeyPath:           "testdata/signature.key",
+		URL:                      httpServer.URL,
+		Mode:                     APIModeDualToken,
+		TokenCache:               cache,
+		ReadWriteTokenExpiration: time.Minute,
+	})
+	require.NoError(t, err)
+
+	var response any
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/vps"}, &response))
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/vps"}, &response))
+	require.NoError(t, client.Post(rest.Request{Endpoint: "/vps"}))
+	require.NoError(t, client.Post(rest.Request{Endpoint: "/vps"}))
+
+	assert.Equal(t, []string{
+		"GET " + readOnlyToken,
+		"GET " + readOnlyToken,
+		"POST " + authenticator.DemoToken,
+		"POST " + authenticator.DemoToken,
+	}, usedTokens)
+
+	// one read-only token, and a new read/write token for every mutating request
+	require.Len(t, authRequests, 3)
+	assert.True(t, authRequests[0].ReadOnly)
+	assert.False(t, authRequests[1].ReadOnly)
+	assert.Equal(t, "60 seconds", authRequests[1].ExpirationTime)
+	assert.False(t, authRequests[2].ReadOnly)
+
+	// only the read-only token is cached, the read/write token is discarded after use
+	assert.Len(t, cache, 1)
+	assert.Equal(t, readOnlyToken, cache["gotransip-client-example-user-readonly-token"].RawToken)
+	assert.Equal(t, jwt.Token{}, client.readWriteAuthenticator.Token)
+
+	// the read/write token is also discarded after a failed request
+	require.Error(t, client.Delete(rest.Request{Endpoint: "/vps/unknown-vps"}))
+	require.Len(t, authRequests, 4)
+	assert.Equal(t, jwt.Token{}, client.readWriteAuthenticator.Token)
+
+	// a static token can not be used to request read/write tokens
+	_, err = newClient(ClientConfiguration{Token: readOnlyToken, Mode: APIModeDualToken})
+	assert.EqualError(t, err, "PrivateKeyReader, PrivateKeyPath or KeyManager is required in dual token mode")
+}
+
+func TestClient_ClockSkew(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		// the api server clock is an hour ahead
+		rw.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		_, err := rw.Write([]byte(`{"ping":"pong"}`))
+		require.NoError(t, err)
+	}))
+	defer httpServer.Close()
+
+	clientConfig := DemoClientConfiguration
+	clientConfig.URL = httpServer.URL
+	clientConfig.MaxClockSkew = time.Minute
+	client, err := newClient(clientConfig)
+	require.NoError(t, err)
+
+	var response any
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/api-test"}, &response))
+	offset, measured := client.ClockOffset()
+	assert.True(t, measured)
+	assert.InDelta(t, time.Hour, offset, float64(2*time.Second))
+
+	// once the skew is known, requests fail
+	err = client.Get(rest.Request{Endpoint: "/api-test"}, &response)
+	assert.ErrorIs(t, err, authenticator.ErrClockSkew)
+
+	// a redis token cache computes the ttl of tokens against the same server clock
+	redisCache := &authenticator.RedisTokenCache{KeyPrefix: "service-a:"}
+	clientConfig.TokenCache = redisCache
+	client, err = newClient(clientConfig)
+	require.NoError(t, err)
+	usedCache, ok := client.authenticator.TokenCache.(*authenticator.RedisTokenCache)
+	require.True(t, ok)
+	assert.Same(t, client.clockSkew, usedCache.ClockSkew)
+	assert.Equal(t, "service-a:", usedCache.KeyPrefix)
+	assert.Nil(t, redisCache.ClockSkew)
+	clientConfig.TokenCache = nil
+
+	// unless a warning function is set
+	var warnings int
+	clientConfig.ClockSkewWarning = func(time.Duration) { warnings++ }
+	client, err = newClient(clientConfig)
+	require.NoError(t, err)
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/api-test"}, &response))
+	require.NoError(t, client.Get(rest.Request{Endpoint: "/api-test"}, &response))
+	assert.Equal(t, 1, warnings)
+}
@@ -0,0 +1,330 @@
+// Package cloudinit builds cloud-config user-data for vps installs with the cloudinit install flavour.
+//
+// Instead of concatenating yaml strings, describe the install with a Config. It is validated before it is
+// marshalled, and InstallOptions returns the options for vps.Repository.InstallOperatingSystemWithOptions:
+//
+//	config := cloudinit.Config{
+//		Hostname: "web-1",
+//		Users:    []cloudinit.User{{Name: "deploy", Sudo: cloudinit.SudoNoPassword, Shell: "/bin/bash"}},
+//		Packages: []string{"nginx"},
+//		RunCmd:   []string{"systemctl enable --now nginx"},
+//	}
+//	if err := config.AddAccountSSHKeys(client, "deploy"); err != nil {
+//		panic(err)
+//	}
+//	options, err := config.InstallOptions("ubuntu-24.04")
+//	if err != nil {
+//		panic(err)
+//	}
+//	err = vpsRepo.InstallOperatingSystemWithOptions("example-vps", options)
+package cloudinit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/sshkey"
+	"github.com/assi010/gotransip/v6/vps"
+	"gopkg.in/yaml.v3"
+)
+
+// header is the first line of every cloud-config document
+const header = "#cloud-config\n"
+
+// MaxSize is the maximum size in bytes of the marshalled user-data, before base64 encoding.
+// Many datasources, like the one of EC2, do not accept more than 16 KiB of user-data.
+const MaxSize = 16 * 1024
+
+// NetplanPath is the file the Network config is written to
+const NetplanPath = "/etc/netplan/60-gotransip.yaml"
+
+// SudoNoPassword is the sudo rule for a user that may run every command as root without a password
+const SudoNoPassword = "ALL=(ALL) NOPASSWD:ALL"
+
+var (
+	// ErrInvalidConfig is wrapped by every ValidationError
+	ErrInvalidConfig = errors.New("invalid cloud-config")
+	// ErrTooLarge is returned when the marshalled user-data is larger than MaxSize
+	ErrTooLarge = errors.New("cloud-config is too large")
+)
+
+// ValidationError is returned when a Config contains one or more invalid values
+type ValidationError struct {
+	// Problems describes every invalid value
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidConfig, strings.Join(e.Problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidConfig) work for a ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Config is a cloud-config document, see https://cloudinit.readthedocs.io/en/latest/reference/modules.html
+type Config struct {
+	// Hostname of the vps, also used as the hostname in the InstallOptions
+	Hostname string `yaml:"hostname,omitempty"`
+	// FQDN is the fully qualified domain name of the vps
+	FQDN string `yaml:"fqdn,omitempty"`
+	// Timezone like "Europe/Amsterdam"
+	Timezone string `yaml:"timezone,omitempty"`
+	// Users to create, use a User with name "default" to keep the default user of the image
+	Users []User `yaml:"users,omitempty"`
+	// SSHAuthorizedKeys are added to the default user
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+	// PackageUpdate updates the package database before installing Packages
+	PackageUpdate bool `yaml:"package_update,omitempty"`
+	// PackageUpgrade upgrades all installed packages
+	PackageUpgrade bool `yaml:"package_upgrade,omitempty"`
+	// Packages to install
+	Packages []string `yaml:"packages,omitempty"`
+	// WriteFiles are written before the packages are installed
+	WriteFiles []File `yaml:"write_files,omitempty"`
+	// RunCmd are shell commands executed on the first boot, after the packages are installed
+	RunCmd []string `yaml:"runcmd,omitempty"`
+	// Network is written to NetplanPath and applied before the RunCmd commands
+	Network *Network `yaml:"-"`
+	// Extra contains cloud-config modules that have no field in Config, they can not overwrite the fields
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// User is an account created on the first boot
+type User struct {
+	// Name of the account, or "default" for the default user of the image
+	Name string `yaml:"name"`
+	// Gecos is the full name of the user
+	Gecos string `yaml:"gecos,omitempty"`
+	// Groups the user is added to
+	Groups []string `yaml:"groups,omitempty"`
+	// Shell is the login shell, like "/bin/bash"
+	Shell string `yaml:"shell,omitempty"`
+	// Sudo is the sudo rule of the user, like SudoNoPassword
+	Sudo string `yaml:"sudo,omitempty"`
+	// LockPasswd disables password login, cloud-init locks the password when it is not set
+	LockPasswd *bool `yaml:"lock_passwd,omitempty"`
+	// HashedPasswd is the password hash, as generated by mkpasswd
+	HashedPasswd string `yaml:"hashed_passwd,omitempty"`
+	// SSHAuthorizedKeys are the public keys that can log in as the user
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+// File is written on the first boot
+type File struct {
+	// Path is the absolute path of the file
+	Path string `yaml:"path"`
+	// Content of the file, encoded according to Encoding
+	Content string `yaml:"content"`
+	// Owner of the file like "root:root"
+	Owner string `yaml:"owner,omitempty"`
+	// Permissions in octal, like "0644"
+	Permissions string `yaml:"permissions,omitempty"`
+	// Encoding of the Content, like "b64" or "gz+b64", empty for plain text
+	Encoding string `yaml:"encoding,omitempty"`
+	// Append adds the content to an existing file
+	Append bool `yaml:"append,omitempty"`
+	// Defer writes the file after the users and packages are installed, so Owner can be a created user
+	Defer bool `yaml:"defer,omitempty"`
+}
+
+// Network is the netplan configuration of the vps, for example to configure the interface of a private network
+type Network struct {
+	// Ethernets by interface name
+	Ethernets map[string]Ethernet `yaml:"ethernets"`
+}
+
+// Ethernet is the configuration of one network interface
+type Ethernet struct {
+	// MACAddress matches the interface on its mac address, the interface is then named after the key in Ethernets
+	MACAddress string `yaml:"-"`
+	// DHCP4 enables dhcp for ipv4
+	DHCP4 bool `yaml:"dhcp4,omitempty"`
+	// DHCP6 enables dhcp for ipv6
+	DHCP6 bool `yaml:"dhcp6,omitempty"`
+	// Addresses are static addresses with prefix length, like "192.168.0.2/24"
+	Addresses []string `yaml:"addresses,omitempty"`
+	// Routes are static routes over this interface
+	Routes []Route `yaml:"routes,omitempty"`
+	// Nameservers are the dns servers for this interface
+	Nameservers []string `yaml:"-"`
+	// SearchDomains are the dns search domains for this interface
+	SearchDomains []string `yaml:"-"`
+	// MTU of the interface, 0 for the default
+	MTU int `yaml:"mtu,omitempty"`
+}
+
+// Route is a static route
+type Route struct {
+	// To is the destination network, like "10.0.0.0/8" or "default"
+	To string `yaml:"to"`
+	// Via is the gateway
+	Via string `yaml:"via"`
+	// Metric of the route, 0 for the default
+	Metric int `yaml:"metric,omitempty"`
+}
+
+// netplanEthernet adds the netplan structure of the fields Ethernet flattens
+type netplanEthernet struct {
+	Ethernet    `yaml:",inline"`
+	Match       map[string]string `yaml:"match,omitempty"`
+	SetName     string            `yaml:"set-name,omitempty"`
+	Nameservers map[string]any    `yaml:"nameservers,omitempty"`
+}
+
+// netplan returns the netplan yaml of the network
+func (n *Network) netplan() ([]byte, error) {
+	ethernets := make(map[string]netplanEthernet, len(n.Ethernets))
+	for name, ethernet := range n.Ethernets {
+		netplanEth := netplanEthernet{Ethernet: ethernet}
+		if ethernet.MACAddress != "" {
+			netplanEth.Match = map[string]string{"macaddress": strings.ToLower(ethernet.MACAddress)}
+			netplanEth.SetName = name
+		}
+		if len(ethernet.Nameservers) > 0 || len(ethernet.SearchDomains) > 0 {
+			netplanEth.Nameservers = make(map[string]any)
+			if len(ethernet.Nameservers) > 0 {
+				netplanEth.Nameservers["addresses"] = ethernet.Nameservers
+			}
+			if len(ethernet.SearchDomains) > 0 {
+				netplanEth.Nameservers["search"] = ethernet.SearchDomains
+			}
+		}
+		ethernets[name] = netplanEth
+	}
+
+	return marshalYaml(map[string]any{"network": map[string]any{"version": 2, "ethernets": ethernets}})
+}
+
+// Marshal validates the config and returns it as a cloud-config document.
+// It returns a ValidationError for invalid values and an ErrTooLarge error when the document is larger than MaxSize.
+func (c *Config) Marshal() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	document := *c
+	if c.Network != nil {
+		netplan, err := c.Network.netplan()
+		if err != nil {
+			return nil, err
+		}
+		document.WriteFiles = append(append([]File{}, c.WriteFiles...), File{Path: NetplanPath, Content: string(netplan), Owner: "root:root", Permissions: "0600"})
+		document.RunCmd = append([]string{"netplan apply"}, c.RunCmd...)
+	}
+
+	data, err := marshalYaml(&document)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling cloud-config: %w", err)
+	}
+	data = append([]byte(header), data...)
+
+	// parse the document again, so values in Extra that do not survive a round trip are caught before the install
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("document is not valid yaml: %s", err)}}
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, the maximum is %d bytes", ErrTooLarge, len(data), MaxSize)
+	}
+
+	return data, nil
+}
+
+// Base64 returns the cloud-config document base64 encoded, as expected in InstallOptions.Base64InstallText
+func (c *Config) Base64() (string, error) {
+	data, err := c.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// InstallOptions returns the options to install the operating system with the cloudinit install flavour and this config
+func (c *Config) InstallOptions(operatingSystemName string) (vps.InstallOptions, error) {
+	installText, err := c.Base64()
+	if err != nil {
+		return vps.InstallOptions{}, err
+	}
+
+	return vps.InstallOptions{
+		OperatingSystemName: operatingSystemName,
+		InstallFlavour:      vps.InstallFlavourCloudInit,
+		Hostname:            c.Hostname,
+		Base64InstallText:   installText,
+	}, nil
+}
+
+// AddAccountSSHKeys adds the ssh keys of the account, from sshkey.Repository.GetAll, to the user with the given name.
+// An empty user name adds the keys to SSHAuthorizedKeys of the default user. Keys the user already has are skipped.
+func (c *Config) AddAccountSSHKeys(client repository.Client, userName string) error {
+	keys := &c.SSHAuthorizedKeys
+	if userName != "" {
+		keys = nil
+		for idx := range c.Users {
+			if c.Users[idx].Name == userName {
+				keys = &c.Users[idx].SSHAuthorizedKeys
+				break
+			}
+		}
+		if keys == nil {
+			return fmt.Errorf("%w: there is no user '%s'", ErrInvalidConfig, userName)
+		}
+	}
+
+	sshKeyRepo := sshkey.Repository{Client: client}
+	accountKeys, err := sshKeyRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("error listing ssh keys: %w", err)
+	}
+
+	for _, accountKey := range accountKeys {
+		key := strings.TrimSpace(accountKey.Key)
+		if !containsKey(*keys, key) {
+			*keys = append(*keys, key)
+		}
+	}
+
+	return nil
+}
+
+// containsKey returns true when the list contains the key, ignoring the comment of the keys
+func containsKey(keys []string, key string) bool {
+	fields := strings.Fields(key)
+	for _, existing := range keys {
+		existingFields := strings.Fields(existing)
+		if len(fields) >= 2 && len(existingFields) >= 2 && fields[0] == existingFields[0] && fields[1] == existingFields[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// marshalYaml marshals the value with an indent of two spaces, as used in the cloud-init documentation.
+// Values yaml can not represent, like functions in Config.Extra, result in an error instead of a panic.
+func marshalYaml(value interface{}) (data []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
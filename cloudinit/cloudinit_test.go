@@ -0,0 +1,229 @@
+package cloudinit
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey      = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f deploy@example"
+	testOtherKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAQAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8= admin@example"
+)
+
+func TestConfig_Marshal(t *testing.T) {
+	lockPasswd := true
+	config := Config{
+		Hostname: "web-1",
+		Timezone: "Europe/Amsterdam",
+		Users: []User{
+			{Name: "default"},
+			{Name: "deploy", Groups: []string{"sudo", "www-data"}, Shell: "/bin/bash", Sudo: SudoNoPassword, LockPasswd: &lockPasswd, SSHAuthorizedKeys: []string{testKey}},
+		},
+		PackageUpdate: true,
+		Packages:      []string{"nginx"},
+		WriteFiles:    []File{{Path: "/var/www/html/index.html", Content: "<h1>hello</h1>\n", Owner: "www-data:www-data", Permissions: "0644", Defer: true}},
+		RunCmd:        []string{"systemctl enable --now nginx"},
+		Extra:         map[string]interface{}{"ntp": map[string]interface{}{"enabled": true}},
+	}
+
+	data, err := config.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, `#cloud-config
+hostname: web-1
+timezone: Europe/Amsterdam
+users:
+  - name: default
+  - name: deploy
+    groups:
+      - sudo
+      - www-data
+    shell: /bin/bash
+    sudo: ALL=(ALL) NOPASSWD:ALL
+    lock_passwd: true
+    ssh_authorized_keys:
+      - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f deploy@example
+package_update: true
+packages:
+  - nginx
+write_files:
+  - path: /var/www/html/index.html
+    content: |
+      <h1>hello</h1>
+    owner: www-data:www-data
+    permissions: "0644"
+    defer: true
+runcmd:
+  - systemctl enable --now nginx
+ntp:
+  enabled: true
+`, string(data))
+
+	options, err := config.InstallOptions("ubuntu-24.04")
+	require.NoError(t, err)
+	assert.Equal(t, "ubuntu-24.04", options.OperatingSystemName)
+	assert.Equal(t, vps.InstallFlavourCloudInit, options.InstallFlavour)
+	assert.Equal(t, "web-1", options.Hostname)
+	decoded, err := base64.StdEncoding.DecodeString(options.Base64InstallText)
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+}
+
+func TestConfig_MarshalNetwork(t *testing.T) {
+	config := Config{
+		RunCmd: []string{"ping -c 1 192.168.0.1"},
+		Network: &Network{Ethernets: map[string]Ethernet{
+			"private": {
+				MACAddress:    "52:54:00:AB:CD:EF",
+				Addresses:     []string{"192.168.0.2/24"},
+				Routes:        []Route{{To: "10.0.0.0/8", Via: "192.168.0.1"}},
+				Nameservers:   []string{"192.168.0.1"},
+				SearchDomains: []string{"internal.example"},
+				MTU:           1450,
+			},
+		}},
+	}
+
+	data, err := config.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, `#cloud-config
+write_files:
+  - path: /etc/netplan/60-gotransip.yaml
+    content: |
+      network:
+        ethernets:
+          private:
+            addresses:
+              - 192.168.0.2/24
+            routes:
+              - to: 10.0.0.0/8
+                via: 192.168.0.1
+            mtu: 1450
+            match:
+              macaddress: 52:54:00:ab:cd:ef
+            set-name: private
+            nameservers:
+              addresses:
+                - 192.168.0.1
+              search:
+                - internal.example
+        version: 2
+    owner: root:root
+    permissions: "0600"
+runcmd:
+  - netplan apply
+  - ping -c 1 192.168.0.1
+`, string(data))
+	// the config itself is not changed
+	assert.Empty(t, config.WriteFiles)
+	assert.Len(t, config.RunCmd, 1)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate())
+
+	config := Config{
+		Hostname: "web_1",
+		FQDN:     "web-1",
+		Timezone: "Europe/New York",
+		Users: []User{
+			{Name: "Deploy"},
+			{Name: "deploy", SSHAuthorizedKeys: []string{"ssh-rsa AAAA"}},
+			{Name: "deploy"},
+			{Name: strings.Repeat("a", 33)},
+			{},
+		},
+		SSHAuthorizedKeys: []string{"not a key"},
+		Packages:          []string{"nginx php"},
+		WriteFiles: []File{
+			{Path: "etc/motd", Content: "hello"},
+			{Path: "/etc/motd", Content: "hello", Permissions: "644"},
+			{Path: "/etc/motd", Content: "hello", Permissions: "rw-r--r--"},
+			{Path: "/etc/issue", Content: "hello", Encoding: "b64"},
+			{Path: "/etc/hosts", Content: "hello", Encoding: "rot13"},
+			{Path: NetplanPath, Content: "network: {}"},
+		},
+		RunCmd: []string{" "},
+		Network: &Network{Ethernets: map[string]Ethernet{
+			"eth1": {MACAddress: "52:54:00", Addresses: []string{"192.168.0.2"}, Routes: []Route{{To: "everywhere", Via: "gateway"}}, Nameservers: []string{"dns"}, MTU: -1},
+			"eth0": {},
+		}},
+		Extra: map[string]interface{}{"packages": []string{"vim"}},
+	}
+
+	err := config.Validate()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	var validationError *ValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, []string{
+		"invalid hostname 'web_1'",
+		"invalid fqdn 'web-1'",
+		"invalid timezone 'Europe/New York'",
+		"invalid user name 'Deploy'",
+		"user 'deploy': invalid ssh public key: key is truncated",
+		"user 'deploy' is defined twice",
+		"user name 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa' is longer than 32 characters",
+		"user 5 has no name",
+		"ssh_authorized_keys: invalid ssh public key: unknown algorithm 'not'",
+		"invalid package name 'nginx php'",
+		"file 1: path 'etc/motd' is not absolute",
+		"file '/etc/motd' is written twice",
+		"file '/etc/motd': invalid permissions 'rw-r--r--', expected octal like 0644",
+		"file '/etc/issue': content is not base64 encoded",
+		"file '/etc/hosts': unknown encoding 'rot13'",
+		"file '/etc/netplan/60-gotransip.yaml' is used for the network config",
+		"runcmd 1 is empty",
+		"ethernet 'eth0' has no addresses and no dhcp",
+		"ethernet 'eth1': invalid mac address '52:54:00'",
+		"ethernet 'eth1': invalid address '192.168.0.2', expected an address with prefix length",
+		"ethernet 'eth1': invalid route destination 'everywhere'",
+		"ethernet 'eth1': invalid route gateway 'gateway'",
+		"ethernet 'eth1': invalid nameserver 'dns'",
+		"ethernet 'eth1': invalid mtu -1",
+		"extra module 'packages' has a field in Config, use the field instead",
+	}, validationError.Problems)
+
+	_, err = config.Marshal()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestConfig_MarshalTooLarge(t *testing.T) {
+	config := Config{WriteFiles: []File{{Path: "/etc/large", Content: strings.Repeat("a", MaxSize)}}}
+
+	_, err := config.Marshal()
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = config.InstallOptions("ubuntu-24.04")
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestConfig_MarshalInvalidExtra(t *testing.T) {
+	config := Config{Extra: map[string]interface{}{"bootcmd": func() {}}}
+
+	_, err := config.Marshal()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error marshalling cloud-config: cannot marshal type: func()")
+}
+
+func TestConfig_AddAccountSSHKeys(t *testing.T) {
+	apiResponse := `{"sshKeys":[{"id":1,"key":"` + testKey + `"},{"id":2,"key":"` + testOtherKey + `\n"}]}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/ssh-keys", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+
+	// the key of deploy is already there, with a different comment
+	config := Config{Users: []User{{Name: "deploy", SSHAuthorizedKeys: []string{strings.Replace(testKey, "deploy@example", "laptop", 1)}}}}
+	require.NoError(t, config.AddAccountSSHKeys(*client, "deploy"))
+	assert.Equal(t, []string{strings.Replace(testKey, "deploy@example", "laptop", 1), testOtherKey}, config.Users[0].SSHAuthorizedKeys)
+
+	require.NoError(t, config.AddAccountSSHKeys(*client, ""))
+	assert.Equal(t, []string{testKey, testOtherKey}, config.SSHAuthorizedKeys)
+
+	err := config.AddAccountSSHKeys(*client, "admin")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.EqualError(t, err, "invalid cloud-config: there is no user 'admin'")
+}
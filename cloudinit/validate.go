@@ -0,0 +1,200 @@
+package cloudinit
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/sshkey"
+)
+
+// maxUsernameLength is the maximum length of a user name the api accepts for cloudinit installs
+const maxUsernameLength = 32
+
+var (
+	// hostnameLabel matches one label of a hostname
+	hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	// username matches the user names accepted by useradd on most distributions
+	username = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+	// permissions matches octal file permissions
+	permissions = regexp.MustCompile(`^0?[0-7]{3}$`)
+)
+
+// encodings are the values of File.Encoding cloud-init understands, mapped to whether the content is base64 encoded
+var encodings = map[string]bool{
+	"":            false,
+	"text/plain":  false,
+	"b64":         true,
+	"base64":      true,
+	"gz":          false,
+	"gzip":        false,
+	"gz+b64":      true,
+	"gz+base64":   true,
+	"gzip+b64":    true,
+	"gzip+base64": true,
+}
+
+// fieldKeys are the cloud-config keys of the Config fields, Extra can not contain them
+var fieldKeys = []string{"hostname", "fqdn", "timezone", "users", "ssh_authorized_keys", "package_update", "package_upgrade", "packages", "write_files", "runcmd"}
+
+// Validate returns a ValidationError describing every invalid value in the config
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Hostname != "" && !validHostname(c.Hostname) {
+		add("invalid hostname '%s'", c.Hostname)
+	}
+	if c.FQDN != "" && (!validHostname(c.FQDN) || !strings.Contains(c.FQDN, ".")) {
+		add("invalid fqdn '%s'", c.FQDN)
+	}
+	if c.Timezone != "" && (strings.ContainsAny(c.Timezone, " \t") || strings.HasPrefix(c.Timezone, "/")) {
+		add("invalid timezone '%s'", c.Timezone)
+	}
+
+	userNames := make(map[string]bool, len(c.Users))
+	for idx, user := range c.Users {
+		switch {
+		case user.Name == "":
+			add("user %d has no name", idx+1)
+		case len(user.Name) > maxUsernameLength:
+			add("user name '%s' is longer than %d characters", user.Name, maxUsernameLength)
+		case !username.MatchString(user.Name):
+			add("invalid user name '%s'", user.Name)
+		case userNames[user.Name]:
+			add("user '%s' is defined twice", user.Name)
+		}
+		userNames[user.Name] = true
+		for _, key := range user.SSHAuthorizedKeys {
+			if err := sshkey.ValidateKey(key); err != nil {
+				add("user '%s': %s", user.Name, err)
+			}
+		}
+	}
+	for _, key := range c.SSHAuthorizedKeys {
+		if err := sshkey.ValidateKey(key); err != nil {
+			add("ssh_authorized_keys: %s", err)
+		}
+	}
+
+	for _, name := range c.Packages {
+		if name == "" || strings.ContainsAny(name, " \t\n") {
+			add("invalid package name '%s'", name)
+		}
+	}
+
+	filePaths := make(map[string]bool, len(c.WriteFiles))
+	for idx, file := range c.WriteFiles {
+		if !path.IsAbs(file.Path) {
+			add("file %d: path '%s' is not absolute", idx+1, file.Path)
+		} else if filePaths[file.Path] && !file.Append {
+			add("file '%s' is written twice", file.Path)
+		}
+		if c.Network != nil && file.Path == NetplanPath {
+			add("file '%s' is used for the network config", file.Path)
+		}
+		filePaths[file.Path] = true
+		if file.Permissions != "" && !permissions.MatchString(file.Permissions) {
+			add("file '%s': invalid permissions '%s', expected octal like 0644", file.Path, file.Permissions)
+		}
+		isBase64, known := encodings[file.Encoding]
+		if !known {
+			add("file '%s': unknown encoding '%s'", file.Path, file.Encoding)
+		} else if _, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(file.Content), "")); isBase64 && err != nil {
+			add("file '%s': content is not base64 encoded", file.Path)
+		}
+	}
+
+	for idx, command := range c.RunCmd {
+		if strings.TrimSpace(command) == "" {
+			add("runcmd %d is empty", idx+1)
+		}
+	}
+
+	if c.Network != nil {
+		problems = append(problems, c.Network.problems()...)
+	}
+
+	for _, key := range fieldKeys {
+		if _, exists := c.Extra[key]; exists {
+			add("extra module '%s' has a field in Config, use the field instead", key)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// problems returns the invalid values in the network config
+func (n *Network) problems() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(n.Ethernets) == 0 {
+		add("network has no ethernets")
+	}
+	names := make([]string, 0, len(n.Ethernets))
+	for name := range n.Ethernets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ethernet := n.Ethernets[name]
+		if !ethernet.DHCP4 && !ethernet.DHCP6 && len(ethernet.Addresses) == 0 {
+			add("ethernet '%s' has no addresses and no dhcp", name)
+		}
+		if ethernet.MACAddress != "" {
+			if _, err := net.ParseMAC(ethernet.MACAddress); err != nil {
+				add("ethernet '%s': invalid mac address '%s'", name, ethernet.MACAddress)
+			}
+		}
+		for _, address := range ethernet.Addresses {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				add("ethernet '%s': invalid address '%s', expected an address with prefix length", name, address)
+			}
+		}
+		for _, route := range ethernet.Routes {
+			if _, _, err := net.ParseCIDR(route.To); route.To != "default" && err != nil {
+				add("ethernet '%s': invalid route destination '%s'", name, route.To)
+			}
+			if net.ParseIP(route.Via) == nil {
+				add("ethernet '%s': invalid route gateway '%s'", name, route.Via)
+			}
+		}
+		for _, nameserver := range ethernet.Nameservers {
+			if net.ParseIP(nameserver) == nil {
+				add("ethernet '%s': invalid nameserver '%s'", name, nameserver)
+			}
+		}
+		if ethernet.MTU < 0 || ethernet.MTU > 65535 {
+			add("ethernet '%s': invalid mtu %d", name, ethernet.MTU)
+		}
+	}
+
+	return problems
+}
+
+// validHostname returns true for a hostname of labels separated by dots of at most 253 characters
+func validHostname(hostname string) bool {
+	if len(hostname) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
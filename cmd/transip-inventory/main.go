@@ -0,0 +1,68 @@
+// Command transip-inventory generates an inventory of the vpses and kubernetes nodes of an account.
+//
+// Without a -format flag it speaks the Ansible inventory script protocol, so it can be used as an inventory source.
+// Ansible does not pass flags, the credentials are read from $TRANSIP_ACCOUNT_NAME and $TRANSIP_PRIVATE_KEY
+// or $TRANSIP_TOKEN, and kubernetes nodes are included when $TRANSIP_INVENTORY_KUBERNETES is set:
+//
+//	ansible-inventory -i transip-inventory --list
+//	transip-inventory -format ssh_config -ssh-user root > ~/.ssh/config.d/transip
+//	transip-inventory -format hosts -domain example.com
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/internal/cli"
+	"github.com/assi010/gotransip/v6/inventory"
+)
+
+func main() {
+	var clientFlags cli.ClientFlags
+	clientFlags.Register(flag.CommandLine)
+	list := flag.Bool("list", false, "write the ansible inventory with all groups and hostvars")
+	host := flag.String("host", "", "write the ansible hostvars of a host")
+	format := flag.String("format", "ansible", "output format: ansible, ssh_config or hosts")
+	kubernetes := flag.Bool("kubernetes", os.Getenv("TRANSIP_INVENTORY_KUBERNETES") != "", "include the nodes of kubernetes clusters")
+	skipIPAddresses := flag.Bool("skip-ip-addresses", false, "only use the main ip address of every vps, saves a request per vps")
+	sshPrefix := flag.String("ssh-prefix", "", "prefix of the host names in the ssh_config format")
+	sshUser := flag.String("ssh-user", "", "user in the ssh_config format")
+	identityFile := flag.String("identity-file", "", "identity file in the ssh_config format")
+	domain := flag.String("domain", "", "domain appended to the host names in the hosts format")
+	ipv6 := flag.Bool("ipv6", false, "include ipv6 addresses in the hosts format")
+	flag.Parse()
+
+	config, err := clientFlags.Configuration()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := gotransip.NewClient(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inv, err := inventory.Collect(client, inventory.Options{Kubernetes: *kubernetes, SkipIPAddresses: *skipIPAddresses})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case *host != "":
+		err = inv.WriteAnsibleHost(os.Stdout, *host)
+	case *list || *format == "ansible":
+		err = inv.WriteAnsible(os.Stdout)
+	case *format == "ssh_config":
+		err = inv.WriteSSHConfig(os.Stdout, inventory.SSHConfigOptions{Prefix: *sshPrefix, User: *sshUser, IdentityFile: *identityFile})
+	case *format == "hosts":
+		err = inv.WriteHosts(os.Stdout, inventory.HostsOptions{Domain: *domain, IPv6: *ipv6})
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format '%s'\n", *format)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
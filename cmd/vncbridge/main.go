@@ -0,0 +1,68 @@
+// Command vncbridge exposes the VNC console of a vps on a local port, so any VNC client can connect to it:
+//
+//	vncbridge -account-name example -private-key transip.key -vps example-vps -regenerate-token
+//	vncviewer 127.0.0.1:5900
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/internal/cli"
+	"github.com/assi010/gotransip/v6/vncbridge"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+func main() {
+	var clientFlags cli.ClientFlags
+	clientFlags.Register(flag.CommandLine)
+	vpsName := flag.String("vps", "", "name of the vps")
+	listen := flag.String("listen", vncbridge.DefaultListenAddress, "address to listen on for vnc clients")
+	regenerateToken := flag.Bool("regenerate-token", false, "regenerate the vnc token and password after every session")
+	once := flag.Bool("once", false, "exit after the first session")
+	flag.Parse()
+
+	if *vpsName == "" {
+		fmt.Fprintln(os.Stderr, "the -vps flag is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := clientFlags.Configuration()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := gotransip.NewClient(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	bridge := vncbridge.Bridge{
+		Client:          client,
+		VpsName:         *vpsName,
+		RegenerateToken: *regenerateToken,
+		Once:            *once,
+		OnReady: func(address net.Addr, data vps.VncData) {
+			log.Printf("connect a vnc client to %s, the password is %s", address, data.Password)
+		},
+		OnSessionEnd: func(err error) {
+			if err != nil {
+				log.Printf("session ended: %s", err)
+				return
+			}
+			log.Print("session ended")
+		},
+	}
+	if err := bridge.ListenAndServe(ctx, *listen); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/assi010/gotransip/v6/audit"
 	"github.com/assi010/gotransip/v6/authenticator"
+	"github.com/assi010/gotransip/v6/rest"
 )
 
 const (
@@ -14,8 +16,8 @@ const (
 	userAgent       = "go-client-gotransip/" + libraryVersion
 )
 
-// APIMode specifies in which mode the API is used. Currently this is only
-// supports either readonly or readwrite
+// APIMode specifies in which mode the API is used. This is either readonly, readwrite
+// or dualtoken, which combines a read-only token with short-lived read/write tokens
 type APIMode string
 
 var (
@@ -24,12 +26,25 @@ var (
 	APIModeReadOnly APIMode = "readonly"
 	// APIModeReadWrite specifies that changes can be made from API calls
 	APIModeReadWrite APIMode = "readwrite"
+	// APIModeDualToken specifies that a read-only token is used for all requests that do not change data.
+	// For every request that does, a short-lived read/write token is requested, which is discarded after use.
+	// This mode requires a private key or KeyManager.
+	APIModeDualToken APIMode = "dualtoken"
 )
 
 // DemoClientConfiguration is the default configuration to use when testing the demo mode of the transip api.
 // Demo mode allows users to test without authenticating with their own credentials.
 var DemoClientConfiguration = ClientConfiguration{Token: authenticator.DemoToken}
 
+// A RequestPolicy decides whether the client may send a request, it is evaluated before anything is sent.
+// See the policy package for an implementation based on rules.
+type RequestPolicy interface {
+	// Evaluate returns an error when the request is not allowed.
+	// The lookup function can be used to retrieve information about the resource the request is about,
+	// like its tags. Requests done with lookup are not evaluated by the policy.
+	Evaluate(method rest.Method, request rest.Request, lookup func(request rest.Request, dest any) error) error
+}
+
 // ClientConfiguration stores the configuration of the API client
 type ClientConfiguration struct {
 	// AccountName is the name of the account of the user, this is used in combination with a private key.
@@ -52,8 +67,8 @@ type ClientConfiguration struct {
 	// optionally you can set your own HTTPClient
 	// to set extra non default settings
 	HTTPClient *http.Client
-	// APIMode specifies in which mode the API is used. Currently this is only
-	// supports either readonly or readwrite
+	// APIMode specifies in which mode the API is used, this is either readonly, readwrite or dualtoken.
+	// Defaults to readwrite, or readonly when a read-only Token is provided
 	Mode APIMode
 	// TokenCache is used to retrieve previously acquired tokens and saving new ones
 	// If not set we do not use a cache to store the new acquired tokens
@@ -62,10 +77,27 @@ type ClientConfiguration struct {
 	// If unspecified, the default is 1 day.
 	// This has no effect for tokens provided via the Token field.
 	TokenExpiration time.Duration
+	// ReadWriteTokenExpiration defines the lifetime of the read/write tokens requested in APIModeDualToken.
+	// If unspecified, the default is 5 minutes.
+	ReadWriteTokenExpiration time.Duration
 	// TokenWhitelisted is used to indicate only whitelisted IP's may use the new tokens requested by the authenticator.
 	// This has no effect for tokens provided via the Token field.
 	TokenWhitelisted bool
 	// A KeyManager is used to offload the signing of a new Token request to a third party (e.g. a key vault).
 	// This is meant as an alternative for providing a private key directly
 	KeyManager authenticator.KeyManager
+	// MaxClockSkew is the maximum offset between the local clock and the api server clock.
+	// The offset is measured from the Date header of every response and used to check token expiry dates.
+	// When it exceeds MaxClockSkew, requests fail with an authenticator.ClockSkewError unless ClockSkewWarning is set.
+	// If zero, any offset is accepted
+	MaxClockSkew time.Duration
+	// ClockSkewWarning is called instead of failing requests when the clock skew starts exceeding MaxClockSkew
+	ClockSkewWarning func(offset time.Duration)
+	// Policy is evaluated before every request, requests it denies are not sent.
+	// If not set all requests are allowed
+	Policy RequestPolicy
+	// AuditLog records every mutating request (POST, PUT, PATCH and DELETE) the client sends.
+	// See the audit package for a hash-chained log that writes to a file or writer.
+	// If not set, requests are not recorded
+	AuditLog audit.Sink
 }
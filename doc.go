@@ -71,6 +71,50 @@ not on a filesystem but on X datastore:
 		PrivateKeyReader: file,
 	})
 
+# Read-only mode
+
+A client created with Mode set to APIModeReadOnly requests read-only tokens and rejects
+mutating requests (POST, PUT, PATCH and DELETE) with ErrReadOnlyMode before anything is sent:
+
+	client, err := gotransip.NewClient(gotransip.ClientConfiguration{
+		AccountName:    "accountName",
+		PrivateKeyPath: "/path/to/api/private.key",
+		Mode:           gotransip.APIModeReadOnly,
+	})
+
+To find out which repository methods can be used in read-only mode, see repository.ReadOnlyOperations.
+
+Long-running services can use APIModeDualToken instead. The client then uses a (cached) read-only token for
+every request that does not change data, and requests a short-lived read/write token for every request that does.
+The read/write token is discarded after use, its lifetime can be set with ReadWriteTokenExpiration.
+
+# Clock skew
+
+The client measures the offset between the local clock and the api server clock from the Date header
+of every response, and uses the server clock to decide whether a token has expired.
+Use ClockOffset to retrieve the measured offset. When MaxClockSkew is set, requests fail with
+authenticator.ErrClockSkew once the offset exceeds it, unless a ClockSkewWarning function is set:
+
+	client, err := gotransip.NewClient(gotransip.ClientConfiguration{
+		AccountName:    "accountName",
+		PrivateKeyPath: "/path/to/api/private.key",
+		MaxClockSkew:   time.Minute,
+		ClockSkewWarning: func(offset time.Duration) {
+			log.Printf("local clock is off by %s", offset)
+		},
+	})
+
+# Diagnostics
+
+When a client does not work as expected, the doctor package runs a checklist for its configuration,
+from reachability of the api server to the permissions of the token, and gives a hint for every failed check:
+
+	report := doctor.Run(gotransip.ClientConfiguration{
+		AccountName:    "accountName",
+		PrivateKeyPath: "/path/to/api/private.key",
+	})
+	fmt.Print(report)
+
 # TokenCache
 
 If you would like to keep a token between multiple client instantiations,
@@ -98,6 +142,16 @@ your own token cacher: for example, one that caches to etcd
 		Get(key string) (jwt.Token, error)
 	}
 
+To keep cached tokens encrypted at rest, use an EncryptedTokenCache. The key is a base64 encoded
+AES key which can be read from an environment variable, a file or your own CacheKeyProvider:
+
+	cache, err := authenticator.NewEncryptedTokenCache(
+		"/tmp/path/to/gotransip_token_cache",
+		authenticator.EnvCacheKey("GOTRANSIP_CACHE_KEY"),
+		"accountName",
+		false,
+	)
+
 # Repositories
 
 All resource calls as can be seen on https://api.transip.nl/rest/docs.html
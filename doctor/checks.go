@@ -0,0 +1,455 @@
+package doctor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/authenticator"
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/assi010/gotransip/v6/rest"
+)
+
+// probeCacheKey is the key of the token written to the token cache to check whether it is readable and writable
+const probeCacheKey = "gotransip-doctor-probe"
+
+// expiryWarning is the remaining lifetime of a static token below which the token claims check warns
+const expiryWarning = time.Hour
+
+// configuredClient is implemented by the client returned by gotransip.NewClient,
+// it exposes the configuration after defaults have been applied and the authenticator built from it
+type configuredClient interface {
+	GetConfig() gotransip.ClientConfiguration
+	GetAuthenticator() *authenticator.Authenticator
+}
+
+// run holds the state shared between the checks of one Doctor.Run
+type run struct {
+	// input is the configuration as provided to the Doctor
+	input gotransip.ClientConfiguration
+	// config is the configuration with the defaults of the client applied
+	config gotransip.ClientConfiguration
+	// authenticator is the authenticator of the configured client
+	authenticator *authenticator.Authenticator
+	// clockSkew is measured from every response the checks receive
+	clockSkew *authenticator.ClockSkew
+	reachable bool
+	token     jwt.Token
+	claims    *jwt.Claims
+	report    Report
+}
+
+func newRun(config gotransip.ClientConfiguration) *run {
+	return &run{input: config, clockSkew: &authenticator.ClockSkew{}}
+}
+
+func (r *run) add(name string, status Status, message string, remediation string) {
+	if status == StatusPass || status == StatusSkip {
+		remediation = ""
+	}
+	r.report.Checks = append(r.report.Checks, Check{Name: name, Status: status, Message: message, Remediation: remediation})
+}
+
+// checkConfiguration creates a client from the configuration, all other checks depend on it
+func (r *run) checkConfiguration() bool {
+	client, err := gotransip.NewClient(r.input)
+	if err != nil {
+		remediation := "configure an AccountName with a PrivateKeyPath, PrivateKeyReader or KeyManager, or a Token"
+		if errors.Is(err, gotransip.ErrTokenModeMismatch) {
+			remediation = "set Mode to APIModeReadOnly, or create a read/write token in the control panel"
+		}
+		r.add(CheckConfiguration, StatusFail, err.Error(), remediation)
+		return false
+	}
+
+	configured, ok := client.(configuredClient)
+	if !ok {
+		r.add(CheckConfiguration, StatusFail, fmt.Sprintf("unsupported client type %T", client), "")
+		return false
+	}
+	r.config = configured.GetConfig()
+	r.authenticator = configured.GetAuthenticator()
+
+	account := r.config.AccountName
+	if account == "" {
+		account = "(static token)"
+	}
+	r.add(CheckConfiguration, StatusPass, fmt.Sprintf("account %s in %s mode using %s", account, r.config.Mode, r.config.URL), "")
+
+	return true
+}
+
+// checkConnection checks whether the api server answers and the TLS connection with it
+func (r *run) checkConnection() {
+	request, err := http.NewRequest(http.MethodGet, r.config.URL+"/api-test", nil)
+	if err != nil {
+		r.add(CheckReachability, StatusFail, fmt.Sprintf("invalid api url: %s", err), "set URL to the base url of the api, like https://api.transip.nl/v6")
+		r.add(CheckTLS, StatusSkip, "the api server is not reachable", "")
+		return
+	}
+
+	sentAt := time.Now()
+	response, err := r.config.HTTPClient.Do(request)
+	if err != nil {
+		if isTLSError(err) {
+			r.add(CheckReachability, StatusPass, fmt.Sprintf("the api server at %s answered", r.config.URL), "")
+			r.add(CheckTLS, StatusFail, err.Error(),
+				"make sure this host trusts the certificate authority of the api server, "+
+					"no proxy intercepts the TLS connection and the local clock is correct")
+			return
+		}
+		r.add(CheckReachability, StatusFail, err.Error(),
+			"check the URL, DNS resolution and the firewall and proxy settings (HTTPS_PROXY) between this host and the api server")
+		r.add(CheckTLS, StatusSkip, "the api server is not reachable", "")
+		return
+	}
+	defer response.Body.Close()
+
+	r.clockSkew.Observe(response, sentAt, time.Now())
+	r.reachable = true
+	r.add(CheckReachability, StatusPass, fmt.Sprintf("the api server at %s responded with status %d", r.config.URL, response.StatusCode), "")
+	r.checkTLS(response.TLS)
+}
+
+// checkTLS checks the state of the TLS connection with the api server
+func (r *run) checkTLS(state *tls.ConnectionState) {
+	if state == nil {
+		r.add(CheckTLS, StatusWarn, "the connection with the api server is not encrypted", "use an https URL")
+		return
+	}
+
+	message := tlsVersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		certificate := state.PeerCertificates[0]
+		message += fmt.Sprintf(", certificate for %s valid until %s", certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339))
+	}
+	if state.Version < tls.VersionTLS12 {
+		r.add(CheckTLS, StatusWarn, message, "TLS versions before 1.2 are insecure, update the TLS settings of the http client")
+		return
+	}
+
+	r.add(CheckTLS, StatusPass, message, "")
+}
+
+// checkAuthentication requests a new token, signed with the private key or KeyManager
+func (r *run) checkAuthentication() {
+	if len(r.config.Token) > 0 {
+		r.token = r.authenticator.Token
+		r.add(CheckAuthentication, StatusSkip, "a static token is configured, no token is requested", "")
+		return
+	}
+	if !r.reachable {
+		r.add(CheckAuthentication, StatusSkip, "the api server is not reachable", "")
+		return
+	}
+
+	token, err := r.requestToken(r.authenticator.ReadOnly)
+	if err != nil {
+		r.add(CheckAuthentication, StatusFail, fmt.Sprintf("requesting a token failed: %s", err), authenticationRemediation(err))
+		return
+	}
+	r.token = token
+
+	signer := "the private key"
+	if r.config.KeyManager != nil {
+		signer = "the KeyManager"
+	}
+	r.add(CheckAuthentication, StatusPass, fmt.Sprintf("requested a %s token signed with %s", tokenKind(r.authenticator.ReadOnly), signer), "")
+}
+
+// checkTokenClaims checks the claims of the token, like its expiry date
+func (r *run) checkTokenClaims() {
+	if len(r.token.RawToken) == 0 {
+		r.add(CheckTokenClaims, StatusSkip, "no token is available", "")
+		return
+	}
+
+	claims, err := r.token.Claims()
+	if err != nil {
+		r.add(CheckTokenClaims, StatusFail, fmt.Sprintf("the token can not be decoded: %s", err), "copy the complete token from the control panel")
+		return
+	}
+	r.claims = &claims
+
+	usable := "from any ip address"
+	if claims.Whitelisted() {
+		usable = "only from whitelisted ip addresses"
+	}
+	now := r.clockSkew.Now()
+	expiry := claims.ExpiryTime()
+	message := fmt.Sprintf("%s token usable %s, expires at %s", tokenKind(claims.ReadOnly), usable, expiry.Format(time.RFC3339))
+	remediation := "create a new token in the control panel, or configure a private key so tokens are requested automatically"
+
+	switch {
+	case r.token.ExpiredAt(now):
+		r.add(CheckTokenClaims, StatusFail, message+", the token has expired", remediation)
+	case len(r.config.Token) > 0 && expiry.Sub(now) < expiryWarning:
+		r.add(CheckTokenClaims, StatusWarn, message+fmt.Sprintf(", the token expires in %s", expiry.Sub(now).Round(time.Second)), remediation)
+	default:
+		r.add(CheckTokenClaims, StatusPass, message, "")
+	}
+}
+
+// checkClockSkew compares the local clock with the clock of the api server
+func (r *run) checkClockSkew() {
+	offset, measured := r.clockSkew.Offset()
+	if !measured {
+		r.add(CheckClockSkew, StatusSkip, "the api server clock is unknown", "")
+		return
+	}
+
+	maxOffset := r.config.MaxClockSkew
+	if maxOffset == 0 {
+		maxOffset = defaultMaxClockSkew
+	}
+
+	switch {
+	case offset == 0:
+		r.add(CheckClockSkew, StatusPass, "the local clock is in sync with the api server clock", "")
+	case offset > maxOffset || -offset > maxOffset:
+		r.add(CheckClockSkew, StatusFail, fmt.Sprintf("the local clock is %s, more than %s", describeOffset(offset), maxOffset),
+			"synchronise the local clock, for example with NTP, otherwise tokens are considered expired too early or too late")
+	default:
+		r.add(CheckClockSkew, StatusPass, fmt.Sprintf("the local clock is %s, within %s", describeOffset(offset), maxOffset), "")
+	}
+}
+
+// checkTokenCache writes a short-lived token to the token cache and reads it back
+func (r *run) checkTokenCache() {
+	if r.config.TokenCache == nil {
+		r.add(CheckTokenCache, StatusSkip, "no token cache is configured", "")
+		return
+	}
+
+	remediation := "check that the cache file and its directory are writable by this user, or that the cache backend is reachable"
+	probe, err := probeToken(time.Now().Add(time.Minute))
+	if err != nil {
+		r.add(CheckTokenCache, StatusFail, err.Error(), "")
+		return
+	}
+	if err := r.config.TokenCache.Set(probeCacheKey, probe); err != nil {
+		r.add(CheckTokenCache, StatusFail, fmt.Sprintf("the token cache is not writable: %s", err), remediation)
+		return
+	}
+	cached, err := r.config.TokenCache.Get(probeCacheKey)
+	if err != nil {
+		r.add(CheckTokenCache, StatusFail, fmt.Sprintf("the token cache is not readable: %s", err), remediation)
+		return
+	}
+	if cached.RawToken != probe.RawToken {
+		r.add(CheckTokenCache, StatusFail, "the token cache did not return the token written to it",
+			"when the token cache is encrypted, make sure the cache key is the same for every client using it")
+		return
+	}
+
+	r.add(CheckTokenCache, StatusPass, "a token was written to and read back from the token cache", "")
+}
+
+// checkReadAccess lists the first product of every product area with the token
+func (r *run) checkReadAccess(productAreas []ProductArea) {
+	skip := func(message string) {
+		for _, area := range productAreas {
+			r.add(readAccessCheckName(area), StatusSkip, message, "")
+		}
+	}
+	if len(r.token.RawToken) == 0 {
+		skip("no token is available")
+		return
+	}
+	if !r.reachable {
+		skip("the api server is not reachable")
+		return
+	}
+
+	// only read requests are sent, so a read-only client works for every token
+	client, err := gotransip.NewClient(gotransip.ClientConfiguration{
+		Token:      r.token.RawToken,
+		URL:        r.config.URL,
+		HTTPClient: r.config.HTTPClient,
+		TestMode:   r.config.TestMode,
+		Mode:       gotransip.APIModeReadOnly,
+	})
+	if err != nil {
+		skip(fmt.Sprintf("could not create a client with the token: %s", err))
+		return
+	}
+
+	for _, area := range productAreas {
+		var response any
+		request := rest.Request{Endpoint: area.Endpoint, Parameters: url.Values{"page": {"1"}, "pageSize": {"1"}}}
+		err := client.Get(request, &response)
+
+		var restErr *rest.Error
+		switch {
+		case err == nil:
+			r.add(readAccessCheckName(area), StatusPass, fmt.Sprintf("GET %s succeeded", area.Endpoint), "")
+		case errors.As(err, &restErr) && restErr.StatusCode == http.StatusNotFound:
+			r.add(readAccessCheckName(area), StatusSkip, fmt.Sprintf("GET %s is not available: %s", area.Endpoint, err), "")
+		case errors.As(err, &restErr) && (restErr.StatusCode == http.StatusUnauthorized || restErr.StatusCode == http.StatusForbidden):
+			r.add(readAccessCheckName(area), StatusFail, fmt.Sprintf("GET %s was denied: %s", area.Endpoint, err),
+				"check the ip whitelist and the access settings of the key pair or token in the control panel")
+		default:
+			r.add(readAccessCheckName(area), StatusFail, fmt.Sprintf("GET %s failed: %s", area.Endpoint, err),
+				"check the connection with the api server")
+		}
+	}
+}
+
+// checkPermissions checks whether the permissions of the token match the mode of the client
+func (r *run) checkPermissions() {
+	if r.claims == nil {
+		r.add(CheckPermissions, StatusSkip, "the token claims are unknown", "")
+		return
+	}
+
+	switch r.config.Mode {
+	case gotransip.APIModeReadWrite:
+		if r.claims.ReadOnly {
+			r.add(CheckPermissions, StatusFail, "the client is in read/write mode, but the token is read-only",
+				"set Mode to APIModeReadOnly, or create a read/write token in the control panel")
+			return
+		}
+		r.add(CheckPermissions, StatusPass, "the read/write token matches read/write mode", "")
+	case gotransip.APIModeReadOnly:
+		if !r.claims.ReadOnly {
+			r.add(CheckPermissions, StatusWarn, "the client is in read-only mode, but the token also allows changes",
+				"create a read-only token in the control panel, so a leaked token can not be used to make changes")
+			return
+		}
+		r.add(CheckPermissions, StatusPass, "the read-only token matches read-only mode", "")
+	case gotransip.APIModeDualToken:
+		r.checkDualTokenPermissions()
+	default:
+		r.add(CheckPermissions, StatusFail, fmt.Sprintf("unknown mode '%s'", r.config.Mode),
+			"set Mode to APIModeReadOnly, APIModeReadWrite or APIModeDualToken")
+	}
+}
+
+// checkDualTokenPermissions checks that a read/write token can be requested next to the read-only token
+func (r *run) checkDualTokenPermissions() {
+	if !r.claims.ReadOnly {
+		r.add(CheckPermissions, StatusFail, "the client is in dual token mode, but the token for reading allows changes",
+			"the api server did not honour the read-only token request, contact TransIP support")
+		return
+	}
+
+	token, err := r.requestToken(false)
+	if err != nil {
+		r.add(CheckPermissions, StatusFail, fmt.Sprintf("requesting a read/write token failed: %s", err), authenticationRemediation(err))
+		return
+	}
+	claims, err := token.Claims()
+	if err != nil {
+		r.add(CheckPermissions, StatusFail, fmt.Sprintf("the read/write token can not be decoded: %s", err), "")
+		return
+	}
+	if claims.ReadOnly {
+		r.add(CheckPermissions, StatusFail, "the api server returned a read-only token when a read/write token was requested",
+			"check that the key pair is allowed to make changes in the control panel")
+		return
+	}
+
+	r.add(CheckPermissions, StatusPass, "a read-only token is used for reading and read/write tokens can be requested for changes", "")
+}
+
+// requestToken requests a new short-lived token, bypassing the token cache
+func (r *run) requestToken(readOnly bool) (jwt.Token, error) {
+	tokenAuthenticator := authenticator.Authenticator{
+		Login:           r.authenticator.Login,
+		PrivateKeyBody:  r.authenticator.PrivateKeyBody,
+		KeyManager:      r.authenticator.KeyManager,
+		HTTPClient:      r.config.HTTPClient,
+		BasePath:        r.config.URL,
+		ReadOnly:        readOnly,
+		TokenExpiration: diagnosticTokenExpiration,
+		Whitelisted:     r.config.TokenWhitelisted,
+		ClockSkew:       r.clockSkew,
+	}
+
+	return tokenAuthenticator.GetToken()
+}
+
+// authenticationRemediation returns a hint for an error returned when requesting a token
+func authenticationRemediation(err error) string {
+	var restErr *rest.Error
+	switch {
+	case errors.Is(err, authenticator.ErrDecodingPrivateKey), strings.Contains(err.Error(), "private key"):
+		return "use the PEM encoded private key of the key pair generated in the control panel"
+	case errors.As(err, &restErr) && (restErr.StatusCode == http.StatusUnauthorized || restErr.StatusCode == http.StatusForbidden):
+		return "check that AccountName is the account the key pair was generated for, that the key pair still exists " +
+			"and, when it may only be used from whitelisted ip addresses, that the ip address of this host is whitelisted"
+	case errors.As(err, &restErr):
+		return "the api server rejected the token request, check the TokenExpiration setting"
+	default:
+		return "check the connection with the api server, or the KeyManager when one is configured"
+	}
+}
+
+// isTLSError returns true when a request failed because the TLS connection could not be set up
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &recordHeaderErr)
+}
+
+// tlsVersionName returns the name of a TLS version
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("TLS version 0x%04x", version)
+	}
+}
+
+// tokenKind describes the permissions of a token
+func tokenKind(readOnly bool) string {
+	if readOnly {
+		return "read-only"
+	}
+
+	return "read/write"
+}
+
+// describeOffset describes the offset of the api server clock relative to the local clock
+func describeOffset(offset time.Duration) string {
+	if offset > 0 {
+		return fmt.Sprintf("%s behind the api server clock", offset.Round(time.Second))
+	}
+
+	return fmt.Sprintf("%s ahead of the api server clock", (-offset).Round(time.Second))
+}
+
+// readAccessCheckName returns the name of the read access check of a product area
+func readAccessCheckName(area ProductArea) string {
+	return CheckReadAccess + ": " + area.Name
+}
+
+// probeToken returns an unsigned token that expires at the given time, it is only used to check the token cache
+func probeToken(expiry time.Time) (jwt.Token, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"jti":"%s","exp":%d}`, probeCacheKey, expiry.Unix())))
+
+	return jwt.New(header + "." + payload + ".")
+}
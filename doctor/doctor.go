@@ -0,0 +1,176 @@
+// Package doctor diagnoses connectivity and credential problems with the TransIP api.
+//
+// Where test.Repository.Test only checks that the api answers, Run goes through a full checklist:
+// reachability of the api server, TLS, signing token requests, the claims of the token, clock skew,
+// the token cache, read access per product area and whether the token permissions match the client mode.
+// Every failed check comes with a hint on how to fix it.
+//
+//	report := doctor.Run(gotransip.ClientConfiguration{
+//		AccountName:    "accountName",
+//		PrivateKeyPath: "/path/to/api/private.key",
+//	})
+//	fmt.Print(report)
+//	if !report.OK() {
+//		os.Exit(1)
+//	}
+package doctor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// StatusPass means the check succeeded
+	StatusPass Status = "pass"
+	// StatusWarn means the check succeeded, but something may cause problems
+	StatusWarn Status = "warn"
+	// StatusFail means the check failed
+	StatusFail Status = "fail"
+	// StatusSkip means the check was not executed, because it does not apply or a check it depends on failed
+	StatusSkip Status = "skip"
+)
+
+// The names of the checks in a Report, read access checks are named CheckReadAccess followed by the product area
+const (
+	CheckConfiguration  = "configuration"
+	CheckReachability   = "reachability"
+	CheckTLS            = "tls"
+	CheckAuthentication = "authentication"
+	CheckTokenClaims    = "token claims"
+	CheckClockSkew      = "clock skew"
+	CheckTokenCache     = "token cache"
+	CheckReadAccess     = "read access"
+	CheckPermissions    = "permissions"
+)
+
+// defaultMaxClockSkew is used for the clock skew check when ClientConfiguration.MaxClockSkew is not set
+const defaultMaxClockSkew = time.Minute
+
+// diagnosticTokenExpiration is the lifetime of the tokens requested while running the checks
+const diagnosticTokenExpiration = 5 * time.Minute
+
+// Check is the outcome of one item of the checklist
+type Check struct {
+	// Name of the check, see the Check constants
+	Name string `json:"name"`
+	// Status is the outcome of the check
+	Status Status `json:"status"`
+	// Message describes what was found
+	Message string `json:"message"`
+	// Remediation is a hint on how to fix a failed check or a warning
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Report contains the outcome of all checks, in the order they were executed
+type Report struct {
+	Checks []Check `json:"checks"`
+}
+
+// OK returns true when none of the checks failed
+func (r Report) OK() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the checks that failed
+func (r Report) Failed() []Check {
+	var failed []Check
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			failed = append(failed, check)
+		}
+	}
+
+	return failed
+}
+
+// Check returns the check with the given name, the returned bool is false when there is no such check
+func (r Report) Check(name string) (Check, bool) {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+
+	return Check{}, false
+}
+
+// String returns the report as human readable text, one line per check followed by its remediation hint
+func (r Report) String() string {
+	var builder strings.Builder
+	for _, check := range r.Checks {
+		fmt.Fprintf(&builder, "[%s] %s: %s\n", check.Status, check.Name, check.Message)
+		if check.Remediation != "" {
+			fmt.Fprintf(&builder, "       %s\n", check.Remediation)
+		}
+	}
+
+	return builder.String()
+}
+
+// ProductArea is a part of the api of which read access is checked
+type ProductArea struct {
+	// Name of the product area, used in the name of the check
+	Name string
+	// Endpoint that lists the products in this area
+	Endpoint string
+}
+
+// DefaultProductAreas are the product areas checked when Doctor.ProductAreas is not set
+var DefaultProductAreas = []ProductArea{
+	{Name: "domains", Endpoint: "/domains"},
+	{Name: "vps", Endpoint: "/vps"},
+	{Name: "haip", Endpoint: "/haips"},
+	{Name: "private networks", Endpoint: "/private-networks"},
+	{Name: "big storage", Endpoint: "/big-storages"},
+	{Name: "block storage", Endpoint: "/block-storages"},
+	{Name: "colocation", Endpoint: "/colocations"},
+	{Name: "kubernetes", Endpoint: "/kubernetes/clusters"},
+	{Name: "openstack", Endpoint: "/openstack/projects"},
+	{Name: "ssl certificates", Endpoint: "/ssl-certificates"},
+	{Name: "ssh keys", Endpoint: "/ssh-keys"},
+	{Name: "invoices", Endpoint: "/invoices"},
+}
+
+// Doctor runs the checklist for a client configuration
+type Doctor struct {
+	// Config is the configuration of the client to diagnose, the same configuration you pass to gotransip.NewClient
+	Config gotransip.ClientConfiguration
+	// ProductAreas overrides DefaultProductAreas when set
+	ProductAreas []ProductArea
+}
+
+// Run executes the checklist with the DefaultProductAreas for the given client configuration
+func Run(config gotransip.ClientConfiguration) Report {
+	doctor := Doctor{Config: config}
+
+	return doctor.Run()
+}
+
+// Run executes the checklist and returns the outcome of every check.
+// Checks that depend on a failed check are skipped.
+func (d *Doctor) Run() Report {
+	productAreas := d.ProductAreas
+	if productAreas == nil {
+		productAreas = DefaultProductAreas
+	}
+
+	r := newRun(d.Config)
+	if r.checkConfiguration() {
+		r.checkConnection()
+		r.checkAuthentication()
+		r.checkTokenClaims()
+		r.checkClockSkew()
+		r.checkTokenCache()
+		r.checkReadAccess(productAreas)
+		r.checkPermissions()
+	}
+
+	return r.report
+}
@@ -0,0 +1,272 @@
+package doctor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/authenticator"
+	"github.com/assi010/gotransip/v6/internal/testutil/tokencache"
+	"github.com/assi010/gotransip/v6/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createToken returns an unsigned token with the given read-only claim and expiry date
+func createToken(readOnly bool, expiry time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"jti": "doctor-test", "exp": expiry.Unix(), "ro": readOnly, "gk": true})
+
+	return "eyJ0eXAiOiJKV1QifQ." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+// apiServer is a fake api server, the statusCodes map overrides the status code of an endpoint
+type apiServer struct {
+	t           *testing.T
+	clockOffset time.Duration
+	statusCodes map[string]int
+	// readOnly is the read-only claim of the tokens handed out, nil means the requested mode is honoured
+	readOnly *bool
+}
+
+func (s *apiServer) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Date", time.Now().Add(s.clockOffset).UTC().Format(http.TimeFormat))
+
+		if statusCode, ok := s.statusCodes[req.URL.Path]; ok {
+			rw.WriteHeader(statusCode)
+			_, _ = fmt.Fprintf(rw, `{"error":"status %d"}`, statusCode)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/api-test":
+			if req.Header.Get("Authorization") == "" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				_, _ = rw.Write([]byte(`{"error":"No Authorization header found"}`))
+				return
+			}
+		case "/auth":
+			var authRequest authenticator.AuthRequest
+			require.NoError(s.t, json.NewDecoder(req.Body).Decode(&authRequest))
+			assert.Equal(s.t, "300 seconds", authRequest.ExpirationTime)
+			readOnly := authRequest.ReadOnly
+			if s.readOnly != nil {
+				readOnly = *s.readOnly
+			}
+			_, _ = fmt.Fprintf(rw, `{"token":"%s"}`, createToken(readOnly, time.Now().Add(5*time.Minute)))
+			return
+		}
+
+		assert.Equal(s.t, "page=1&pageSize=1", req.URL.RawQuery)
+		_, _ = rw.Write([]byte(`{}`))
+	}))
+}
+
+// failingTokenCache is a TokenCache that can not be written to
+type failingTokenCache struct{}
+
+func (failingTokenCache) Set(string, jwt.Token) error {
+	return errors.New("permission denied")
+}
+
+func (failingTokenCache) Get(string) (jwt.Token, error) {
+	return jwt.Token{}, nil
+}
+
+// statuses returns the status of every check by name
+func statuses(report Report) map[string]Status {
+	result := make(map[string]Status)
+	for _, check := range report.Checks {
+		result[check.Name] = check.Status
+	}
+
+	return result
+}
+
+func TestRun(t *testing.T) {
+	server := (&apiServer{t: t, statusCodes: map[string]int{"/vps": 403, "/openstack/projects": 404}}).start()
+	defer server.Close()
+
+	cache := make(tokencache.Memory)
+	report := Run(gotransip.ClientConfiguration{
+		AccountName:    "example-user",
+		PrivateKeyPath: "../testdata/signature.key",
+		URL:            server.URL,
+		TokenCache:     cache,
+	})
+
+	assert.Equal(t, map[string]Status{
+		CheckConfiguration:              StatusPass,
+		CheckReachability:               StatusPass,
+		CheckTLS:                        StatusWarn,
+		CheckAuthentication:             StatusPass,
+		CheckTokenClaims:                StatusPass,
+		CheckClockSkew:                  StatusPass,
+		CheckTokenCache:                 StatusPass,
+		"read access: domains":          StatusPass,
+		"read access: vps":              StatusFail,
+		"read access: haip":             StatusPass,
+		"read access: private networks": StatusPass,
+		"read access: big storage":      StatusPass,
+		"read access: block storage":    StatusPass,
+		"read access: colocation":       StatusPass,
+		"read access: kubernetes":       StatusPass,
+		"read access: openstack":        StatusSkip,
+		"read access: ssl certificates": StatusPass,
+		"read access: ssh keys":         StatusPass,
+		"read access: invoices":         StatusPass,
+		CheckPermissions:                StatusPass,
+	}, statuses(report))
+	assert.False(t, report.OK())
+
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "GET /vps was denied: status 403", failed[0].Message)
+	assert.NotEmpty(t, failed[0].Remediation)
+
+	check, ok := report.Check(CheckAuthentication)
+	require.True(t, ok)
+	assert.Equal(t, "requested a read/write token signed with the private key", check.Message)
+	assert.Empty(t, check.Remediation)
+
+	// the doctor does not touch the tokens of the client
+	assert.Equal(t, []string{probeCacheKey}, keys(cache))
+}
+
+func keys(cache tokencache.Memory) []string {
+	var result []string
+	for key := range cache {
+		result = append(result, key)
+	}
+
+	return result
+}
+
+func TestRun_DualTokenMode(t *testing.T) {
+	server := (&apiServer{t: t}).start()
+	defer server.Close()
+
+	doctor := Doctor{
+		Config: gotransip.ClientConfiguration{
+			AccountName:    "example-user",
+			PrivateKeyPath: "../testdata/signature.key",
+			URL:            server.URL,
+			Mode:           gotransip.APIModeDualToken,
+		},
+		ProductAreas: []ProductArea{{Name: "vps", Endpoint: "/vps"}},
+	}
+	report := doctor.Run()
+	assert.True(t, report.OK(), report.String())
+
+	check, _ := report.Check(CheckPermissions)
+	assert.Equal(t, StatusPass, check.Status)
+
+	// a key pair that can only request read-only tokens
+	readOnly := true
+	server = (&apiServer{t: t, readOnly: &readOnly}).start()
+	defer server.Close()
+	doctor.Config.URL = server.URL
+
+	report = doctor.Run()
+	check, _ = report.Check(CheckPermissions)
+	assert.Equal(t, StatusFail, check.Status)
+	assert.Equal(t, "the api server returned a read-only token when a read/write token was requested", check.Message)
+}
+
+func TestRun_StaticToken(t *testing.T) {
+	server := (&apiServer{t: t, clockOffset: time.Hour}).start()
+	defer server.Close()
+
+	report := (&Doctor{
+		Config: gotransip.ClientConfiguration{
+			Token: createToken(false, time.Now().Add(90*time.Minute)),
+			URL:   server.URL,
+			Mode:  gotransip.APIModeReadOnly,
+		},
+		ProductAreas: []ProductArea{},
+	}).Run()
+
+	assert.Equal(t, map[string]Status{
+		CheckConfiguration:  StatusPass,
+		CheckReachability:   StatusPass,
+		CheckTLS:            StatusWarn,
+		CheckAuthentication: StatusSkip,
+		// the token expires in 30 minutes according to the api server
+		CheckTokenClaims: StatusWarn,
+		CheckClockSkew:   StatusFail,
+		CheckTokenCache:  StatusSkip,
+		CheckPermissions: StatusWarn,
+	}, statuses(report))
+
+	check, _ := report.Check(CheckClockSkew)
+	assert.Equal(t, "the local clock is 1h0m0s behind the api server clock, more than 1m0s", check.Message)
+	assert.Contains(t, report.String(), "[fail] clock skew: the local clock is 1h0m0s behind the api server clock, more than 1m0s\n       synchronise the local clock")
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	server := (&apiServer{t: t}).start()
+	server.Close()
+
+	report := (&Doctor{
+		Config: gotransip.ClientConfiguration{
+			AccountName:    "example-user",
+			PrivateKeyPath: "../testdata/signature.key",
+			URL:            server.URL,
+			TokenCache:     failingTokenCache{},
+		},
+		ProductAreas: []ProductArea{{Name: "vps", Endpoint: "/vps"}},
+	}).Run()
+
+	assert.Equal(t, map[string]Status{
+		CheckConfiguration:  StatusPass,
+		CheckReachability:   StatusFail,
+		CheckTLS:            StatusSkip,
+		CheckAuthentication: StatusSkip,
+		CheckTokenClaims:    StatusSkip,
+		CheckClockSkew:      StatusSkip,
+		CheckTokenCache:     StatusFail,
+		"read access: vps":  StatusSkip,
+		CheckPermissions:    StatusSkip,
+	}, statuses(report))
+
+	check, _ := report.Check(CheckTokenCache)
+	assert.Equal(t, "the token cache is not writable: permission denied", check.Message)
+}
+
+func TestRun_InvalidConfiguration(t *testing.T) {
+	report := Run(gotransip.ClientConfiguration{AccountName: "example-user"})
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, StatusFail, report.Checks[0].Status)
+	assert.Equal(t, "PrivateKeyReader, PrivateKeyPath, token or KeyManager is required", report.Checks[0].Message)
+
+	report = Run(gotransip.ClientConfiguration{Token: createToken(true, time.Now().Add(time.Hour)), Mode: gotransip.APIModeReadWrite})
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "set Mode to APIModeReadOnly, or create a read/write token in the control panel", report.Checks[0].Remediation)
+}
+
+func TestRun_AuthenticationFailure(t *testing.T) {
+	server := (&apiServer{t: t, statusCodes: map[string]int{"/auth": 401}}).start()
+	defer server.Close()
+
+	report := (&Doctor{
+		Config: gotransip.ClientConfiguration{
+			AccountName:    "example-user",
+			PrivateKeyPath: "../testdata/signature.key",
+			URL:            server.URL,
+		},
+		ProductAreas: []ProductArea{},
+	}).Run()
+
+	check, _ := report.Check(CheckAuthentication)
+	assert.Equal(t, StatusFail, check.Status)
+	assert.Contains(t, check.Remediation, "AccountName")
+
+	check, _ = report.Check(CheckPermissions)
+	assert.Equal(t, StatusSkip, check.Status)
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/assi010/gotransip/v6"
 	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/vps"
+	"github.com/assi010/gotransip/v6/wait"
 )
 
 func main() {
@@ -26,10 +29,25 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	action, err := actionRepo.ParseActionFromResponse(response)
+	revertAction, err := actionRepo.ParseActionFromResponse(response)
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("%#v", action)
+	fmt.Printf("%#v\n", revertAction)
+
+	// Wait until the snapshot revert and its child actions are finished, printing the progress
+	revertAction, err = actionRepo.WaitForAction(context.Background(), revertAction.UUID, action.WaitOptions{
+		Options: wait.Options{Timeout: 30 * time.Minute},
+		Progress: func(progress action.Progress) {
+			if progress.HasPercentage {
+				log.Printf("%s: %s %d%%", progress.Action.Name, progress.Action.Status, progress.Percentage)
+			}
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%#v\n", revertAction)
 }
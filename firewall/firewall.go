@@ -0,0 +1,465 @@
+// Package firewall converts between the TransIP edge firewall of a vps and host firewall formats.
+//
+// The edge firewall, vps.Firewall, only has allow rules for incoming tcp and udp traffic on a port range,
+// optionally limited to a whitelist of source addresses. Host firewall rules that can not be represented,
+// like deny rules or rules matching on an interface, are reported as an Issue instead of being converted:
+//
+//	result, err := firewall.ParseNftables(file)
+//	if err != nil {
+//		panic(err)
+//	}
+//	for _, issue := range result.Issues {
+//		log.Println(issue)
+//	}
+//	_, err = firewallRepo.ApplyFirewall("example-vps", result.Firewall)
+//
+// A Linter checks the edge firewalls of all vpses for risky rules, like management ports open to every address:
+//
+//	linter := firewall.Linter{Suppressions: suppressions}
+//	report, err := linter.LintFleet(client)
+//	if err != nil {
+//		panic(err)
+//	}
+//	err = report.WriteSARIF(os.Stdout)
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// Family is an ip address family
+type Family string
+
+const (
+	// IPv4 is the ip version 4 address family
+	IPv4 Family = "ipv4"
+	// IPv6 is the ip version 6 address family
+	IPv6 Family = "ipv6"
+)
+
+// portRangeAll is the port range of a rule that does not match on a port
+var portRangeAll = portRange{start: 1, end: 65535}
+
+var (
+	// ErrSyntax is returned when the input can not be parsed at all, unsupported but valid rules are reported as an Issue
+	ErrSyntax = errors.New("syntax error")
+)
+
+// Issue describes a host firewall rule that can not be represented in the edge firewall, the rule is left out
+type Issue struct {
+	// Line is the line number of the rule in the input, starting at 1
+	Line int `json:"line"`
+	// Text is the rule as it appears in the input
+	Text string `json:"text"`
+	// Reason explains why the rule can not be represented
+	Reason string `json:"reason"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Text, i.Reason)
+}
+
+// Result is the outcome of parsing a host firewall
+type Result struct {
+	// Firewall contains the rules that could be converted
+	Firewall vps.Firewall
+	// Issues lists the rules that could not be converted
+	Issues []Issue
+}
+
+// portRange is an inclusive range of ports
+type portRange struct {
+	start, end int
+}
+
+// ruleKey identifies an edge firewall rule, like vps.Firewall does
+type ruleKey struct {
+	protocol string
+	ports    portRange
+}
+
+// ruleSources collects the allowed sources of a rule
+type ruleSources struct {
+	description string
+	anyIPv4     bool
+	anyIPv6     bool
+	ranges      []ipaddress.IPRange
+}
+
+// builder merges the allow rules of a host firewall into edge firewall rules,
+// host rules for the same protocol and ports with different sources become one rule with a whitelist
+type builder struct {
+	enabled bool
+	keys    []ruleKey
+	sources map[ruleKey]*ruleSources
+	issues  []Issue
+}
+
+func newBuilder() *builder {
+	return &builder{sources: make(map[ruleKey]*ruleSources)}
+}
+
+// allow adds an allow rule, a nil ipNet allows every address of the family,
+// or of both families when family is empty
+func (b *builder) allow(protocol string, ports portRange, family Family, ipNet *net.IPNet, description string) {
+	key := ruleKey{protocol: protocol, ports: ports}
+	sources, ok := b.sources[key]
+	if !ok {
+		sources = &ruleSources{}
+		b.sources[key] = sources
+		b.keys = append(b.keys, key)
+	}
+	if sources.description == "" {
+		sources.description = description
+	}
+
+	if ipNet != nil {
+		if ones, _ := ipNet.Mask.Size(); ones > 0 {
+			sources.ranges = append(sources.ranges, ipaddress.IPRange{IPNet: *ipNet})
+			return
+		}
+		family = familyOf(ipNet.IP)
+	}
+	switch family {
+	case IPv4:
+		sources.anyIPv4 = true
+	case IPv6:
+		sources.anyIPv6 = true
+	default:
+		sources.anyIPv4 = true
+		sources.anyIPv6 = true
+	}
+}
+
+// issue records a rule that can not be represented
+func (b *builder) issue(line int, text string, reason string) {
+	b.issues = append(b.issues, Issue{Line: line, Text: text, Reason: reason})
+}
+
+// result returns the firewall with the rules in the order they were first seen.
+// A tcp and udp rule for the same ports and sources become one tcp_udp rule.
+func (b *builder) result() Result {
+	firewall := vps.Firewall{IsEnabled: b.enabled, RuleSet: []vps.FirewallRule{}}
+	merged := make(map[ruleKey]bool)
+	for _, key := range b.keys {
+		if merged[key] {
+			continue
+		}
+		sources := b.sources[key]
+		rule := vps.FirewallRule{
+			Description: sources.description,
+			StartPort:   key.ports.start,
+			EndPort:     key.ports.end,
+			Protocol:    key.protocol,
+			Whitelist:   sources.whitelist(),
+		}
+
+		other, ok := b.sources[otherProtocolKey(key)]
+		if ok && key.protocol != vps.FirewallProtocolTCPUDP && sameSources(rule.Whitelist, other.whitelist()) {
+			merged[otherProtocolKey(key)] = true
+			rule.Protocol = vps.FirewallProtocolTCPUDP
+			if rule.Description == "" {
+				rule.Description = other.description
+			}
+		}
+		firewall.RuleSet = append(firewall.RuleSet, rule)
+	}
+
+	return Result{Firewall: firewall, Issues: b.issues}
+}
+
+// otherProtocolKey returns the key of the udp rule for a tcp rule and the other way around
+func otherProtocolKey(key ruleKey) ruleKey {
+	switch key.protocol {
+	case vps.FirewallProtocolTCP:
+		key.protocol = vps.FirewallProtocolUDP
+	case vps.FirewallProtocolUDP:
+		key.protocol = vps.FirewallProtocolTCP
+	}
+
+	return key
+}
+
+// sameSources returns true when both whitelists contain the same ranges
+func sameSources(a []ipaddress.IPRange, b []ipaddress.IPRange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ranges := make(map[string]bool, len(a))
+	for _, ipRange := range a {
+		ranges[ipRange.String()] = true
+	}
+	for _, ipRange := range b {
+		if !ranges[ipRange.String()] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// whitelist returns the whitelist of the sources, an empty whitelist allows every address.
+// Ranges that are covered by allowing every address of their family are left out.
+func (s *ruleSources) whitelist() []ipaddress.IPRange {
+	whitelist := []ipaddress.IPRange{}
+	if s.anyIPv4 && s.anyIPv6 {
+		return whitelist
+	}
+
+	seen := make(map[string]bool)
+	for _, ipRange := range s.ranges {
+		family := familyOf(ipRange.IP)
+		if (family == IPv4 && s.anyIPv4) || (family == IPv6 && s.anyIPv6) || seen[ipRange.String()] {
+			continue
+		}
+		seen[ipRange.String()] = true
+		whitelist = append(whitelist, ipRange)
+	}
+	if s.anyIPv4 {
+		whitelist = append(whitelist, anyAddress(IPv4))
+	}
+	if s.anyIPv6 {
+		whitelist = append(whitelist, anyAddress(IPv6))
+	}
+
+	return whitelist
+}
+
+// sources splits the whitelist of a rule per family,
+// anyIPv4 and anyIPv6 are true when every address of the family is allowed
+type sources struct {
+	anyIPv4, anyIPv6 bool
+	ipv4, ipv6       []string
+}
+
+// ruleSourcesOf returns the sources of an edge firewall rule
+func ruleSourcesOf(rule vps.FirewallRule) sources {
+	if len(rule.Whitelist) == 0 {
+		return sources{anyIPv4: true, anyIPv6: true}
+	}
+
+	var result sources
+	for _, ipRange := range rule.Whitelist {
+		ones, _ := ipRange.Mask.Size()
+		switch family := familyOf(ipRange.IP); {
+		case family == IPv4 && ones == 0:
+			result.anyIPv4 = true
+		case family == IPv6 && ones == 0:
+			result.anyIPv6 = true
+		case family == IPv4:
+			result.ipv4 = append(result.ipv4, ipRange.String())
+		default:
+			result.ipv6 = append(result.ipv6, ipRange.String())
+		}
+	}
+	if result.anyIPv4 {
+		result.ipv4 = nil
+	}
+	if result.anyIPv6 {
+		result.ipv6 = nil
+	}
+
+	return result
+}
+
+// of returns the ranges of a family, all is true when every address of the family is allowed
+func (s sources) of(family Family) (ranges []string, all bool) {
+	if family == IPv4 {
+		return s.ipv4, s.anyIPv4
+	}
+
+	return s.ipv6, s.anyIPv6
+}
+
+// protocols returns the protocols of an edge firewall rule
+func protocols(rule vps.FirewallRule) []string {
+	if rule.Protocol == vps.FirewallProtocolTCPUDP {
+		return []string{vps.FirewallProtocolTCP, vps.FirewallProtocolUDP}
+	}
+
+	return []string{rule.Protocol}
+}
+
+// anyAddress returns the range containing every address of the family
+func anyAddress(family Family) ipaddress.IPRange {
+	if family == IPv4 {
+		return ipaddress.IPRange{IPNet: net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}}
+	}
+
+	return ipaddress.IPRange{IPNet: net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}}
+}
+
+// familyOf returns the family of an ip address
+func familyOf(ip net.IP) Family {
+	if ip.To4() != nil {
+		return IPv4
+	}
+
+	return IPv6
+}
+
+// parseAddress parses an address with or without prefix length, an address without prefix is a single host
+func parseAddress(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid address '%s'", ErrSyntax, value)
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: invalid address '%s'", ErrSyntax, value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// parsePortRange parses a port or a range of ports separated by separator, like "22" or "8000:9000"
+func parsePortRange(value string, separator string) (portRange, error) {
+	start, end, isRange := strings.Cut(value, separator)
+	if !isRange {
+		end = start
+	}
+
+	startPort, err := strconv.Atoi(start)
+	if err != nil {
+		return portRange{}, fmt.Errorf("%w: invalid port '%s'", ErrSyntax, value)
+	}
+	endPort, err := strconv.Atoi(end)
+	if err != nil {
+		return portRange{}, fmt.Errorf("%w: invalid port '%s'", ErrSyntax, value)
+	}
+	if startPort < 1 || endPort > 65535 || startPort > endPort {
+		return portRange{}, fmt.Errorf("%w: invalid port range '%s'", ErrSyntax, value)
+	}
+
+	return portRange{start: startPort, end: endPort}, nil
+}
+
+// formatPortRange formats the ports of a rule as a single port or a range separated by separator
+func formatPortRange(rule vps.FirewallRule, separator string) string {
+	if rule.StartPort == rule.EndPort {
+		return strconv.Itoa(rule.StartPort)
+	}
+
+	return fmt.Sprintf("%d%s%d", rule.StartPort, separator, rule.EndPort)
+}
+
+// quote returns the value in double quotes, with quotes and backslashes escaped
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// splitFields splits a line into fields on whitespace. Double quoted strings become one field without the quotes,
+// and sets in braces, like "{ 22, 80 }", become one field including the braces.
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField := false
+	flush := func() {
+		if inField {
+			fields = append(fields, field.String())
+			field.Reset()
+			inField = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			inField = true
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				field.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("%w: unterminated quote in '%s'", ErrSyntax, line)
+			}
+		case c == '{':
+			flush()
+			end := strings.IndexByte(line[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated set in '%s'", ErrSyntax, line)
+			}
+			fields = append(fields, line[i:i+end+1])
+			i += end
+		case c == ' ' || c == '\t':
+			flush()
+		default:
+			inField = true
+			field.WriteByte(c)
+		}
+	}
+	flush()
+
+	return fields, nil
+}
+
+// setElements returns the elements of a set field like "{ 22, 80 }", or the field itself when it is not a set
+func setElements(field string) []string {
+	if !strings.HasPrefix(field, "{") {
+		return []string{field}
+	}
+
+	var elements []string
+	for _, element := range strings.Split(strings.Trim(field, "{}"), ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+
+	return elements
+}
+
+// hostRule is an allow rule of a host firewall that can be represented in the edge firewall
+type hostRule struct {
+	// protocol is tcp, udp or tcp_udp, empty when the rule does not match on a protocol
+	protocol string
+	// ports is empty when the rule does not match on a port
+	ports []portRange
+	// family limits the rule to one address family, empty for both
+	family Family
+	// sources is empty when the rule does not match on a source address
+	sources []*net.IPNet
+	// description is the comment of the rule
+	description string
+}
+
+// add adds the allow rule for every combination of port range and source
+func (b *builder) add(line int, text string, rule hostRule) {
+	if rule.protocol == "" {
+		if len(rule.ports) > 0 {
+			b.issue(line, text, "a port match without a tcp or udp protocol can not be represented")
+			return
+		}
+		rule.protocol = vps.FirewallProtocolTCPUDP
+	}
+	if len(rule.ports) == 0 {
+		rule.ports = []portRange{portRangeAll}
+	}
+
+	for _, ports := range rule.ports {
+		if len(rule.sources) == 0 {
+			b.allow(rule.protocol, ports, rule.family, nil, rule.description)
+			continue
+		}
+		for _, source := range rule.sources {
+			b.allow(rule.protocol, ports, rule.family, source, rule.description)
+		}
+	}
+}
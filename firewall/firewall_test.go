@@ -0,0 +1,77 @@
+package firewall
+
+import (
+	"net"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseRanges returns the IPRanges for the given cidrs
+func parseRanges(t *testing.T, cidrs ...string) []ipaddress.IPRange {
+	ranges := make([]ipaddress.IPRange, len(cidrs))
+	for idx, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ranges[idx] = ipaddress.IPRange{IPNet: *ipNet}
+	}
+
+	return ranges
+}
+
+// exampleFirewall returns a firewall using both protocols, port ranges and whitelists of both families
+func exampleFirewall(t *testing.T) vps.Firewall {
+	return vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{
+		{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "10.0.0.0/8", "2a01:7c8::/32")},
+		{Description: "HTTP", StartPort: 80, EndPort: 80, Protocol: vps.FirewallProtocolTCP},
+		{Description: "DNS", StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolTCPUDP},
+		{Description: "mosh", StartPort: 60000, EndPort: 61000, Protocol: vps.FirewallProtocolTCPUDP, Whitelist: parseRanges(t, "0.0.0.0/0")},
+	}}
+}
+
+// assertFirewall asserts that the firewalls have the same rules, regardless of their order
+func assertFirewall(t *testing.T, expected vps.Firewall, actual vps.Firewall) {
+	diff := vps.DiffFirewall(expected, actual)
+	assert.True(t, diff.IsEmpty(), "unexpected differences:\n%s", diff)
+}
+
+func TestSplitFields(t *testing.T) {
+	fields, err := splitFields(`tcp dport { 22, 80 } ip saddr 10.0.0.0/8 accept comment "say \"hi\""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tcp", "dport", "{ 22, 80 }", "ip", "saddr", "10.0.0.0/8", "accept", "comment", `say "hi"`}, fields)
+	assert.Equal(t, []string{"22", "80"}, setElements(fields[2]))
+	assert.Equal(t, []string{"accept"}, setElements(fields[6]))
+
+	_, err = splitFields(`comment "unterminated`)
+	assert.ErrorIs(t, err, ErrSyntax)
+	_, err = splitFields(`tcp dport { 22, 80`)
+	assert.ErrorIs(t, err, ErrSyntax)
+}
+
+func TestBuilder_Result(t *testing.T) {
+	builder := newBuilder()
+	_, tenNet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	_, anyIPv4, err := net.ParseCIDR("0.0.0.0/0")
+	require.NoError(t, err)
+
+	// every ipv4 address covers 10.0.0.0/8
+	builder.allow(vps.FirewallProtocolTCP, portRange{start: 22, end: 22}, IPv4, tenNet, "")
+	builder.allow(vps.FirewallProtocolTCP, portRange{start: 22, end: 22}, IPv4, anyIPv4, "SSH")
+	// both families allowed result in an empty whitelist
+	builder.allow(vps.FirewallProtocolTCP, portRange{start: 80, end: 80}, IPv4, nil, "")
+	builder.allow(vps.FirewallProtocolTCP, portRange{start: 80, end: 80}, IPv6, nil, "")
+	// tcp and udp for the same ports and sources are merged
+	builder.allow(vps.FirewallProtocolUDP, portRange{start: 80, end: 80}, "", nil, "QUIC")
+	builder.allow(vps.FirewallProtocolUDP, portRange{start: 22, end: 22}, IPv4, tenNet, "")
+
+	result := builder.result()
+	require.Len(t, result.Firewall.RuleSet, 3)
+	assert.Equal(t, "tcp 22 (SSH) from 0.0.0.0/0", result.Firewall.RuleSet[0].String())
+	assert.Equal(t, "tcp_udp 80 (QUIC) from any", result.Firewall.RuleSet[1].String())
+	assert.Equal(t, "udp 22 from 10.0.0.0/8", result.Firewall.RuleSet[2].String())
+	assert.Empty(t, result.Issues)
+}
@@ -0,0 +1,202 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// FormatIptablesSave returns the rules of the firewall for one address family in the format of iptables-save,
+// use IPv6 for input to ip6tables-restore. An enabled firewall drops all other incoming traffic.
+// Rules of which the whitelist only contains addresses of the other family are left out.
+func FormatIptablesSave(firewall vps.Firewall, family Family) string {
+	policy := "ACCEPT"
+	if firewall.IsEnabled {
+		policy = "DROP"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("*filter\n")
+	fmt.Fprintf(&builder, ":INPUT %s [0:0]\n", policy)
+	builder.WriteString(":FORWARD ACCEPT [0:0]\n")
+	builder.WriteString(":OUTPUT ACCEPT [0:0]\n")
+	for _, rule := range firewall.RuleSet {
+		ranges, all := ruleSourcesOf(rule).of(family)
+		if !all && len(ranges) == 0 {
+			continue
+		}
+		if all {
+			ranges = []string{""}
+		}
+
+		comment := ""
+		if rule.Description != "" {
+			comment = " -m comment --comment " + quote(rule.Description)
+		}
+		for _, source := range ranges {
+			if source != "" {
+				source = " -s " + source
+			}
+			for _, protocol := range protocols(rule) {
+				fmt.Fprintf(&builder, "-A INPUT%s -p %s -m %s --dport %s%s -j ACCEPT\n", source, protocol, protocol, formatPortRange(rule, ":"), comment)
+			}
+		}
+	}
+	builder.WriteString("COMMIT\n")
+
+	return builder.String()
+}
+
+// ParseIptablesSave converts the rules in the INPUT chain of the filter table of iptables-save output.
+// The output of ip6tables-save is recognised by its header comment, so the output of both can be concatenated.
+// The firewall is enabled when the policy of an INPUT chain, or a final rule, drops all other traffic.
+func ParseIptablesSave(reader io.Reader) (Result, error) {
+	builder := newBuilder()
+	family := IPv4
+	table := ""
+	reportedChains := make(map[string]bool)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == "COMMIT":
+		case strings.HasPrefix(line, "#"):
+			if strings.Contains(line, "ip6tables-save") {
+				family = IPv6
+			} else if strings.Contains(line, "iptables-save") {
+				family = IPv4
+			}
+		case strings.HasPrefix(line, "*"):
+			table = strings.TrimPrefix(line, "*")
+		case strings.HasPrefix(line, ":"):
+			fields := strings.Fields(strings.TrimPrefix(line, ":"))
+			if table == "filter" && len(fields) >= 2 && fields[0] == "INPUT" {
+				builder.enabled = builder.enabled || fields[1] == "DROP" || fields[1] == "REJECT"
+			}
+		case strings.HasPrefix(line, "-A "):
+			fields, err := splitFields(line)
+			if err != nil {
+				return Result{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if len(fields) < 2 {
+				return Result{}, fmt.Errorf("%w: line %d: missing chain in '%s'", ErrSyntax, lineNumber, line)
+			}
+			chain := table + " " + fields[1]
+			if table != "filter" || fields[1] != "INPUT" {
+				if !reportedChains[chain] {
+					reportedChains[chain] = true
+					builder.issue(lineNumber, line, fmt.Sprintf("rules in chain %s of table %s can not be represented, only the INPUT chain of the filter table is converted", fields[1], table))
+				}
+				continue
+			}
+			if err := parseIptablesRule(line, fields[2:], lineNumber, family, builder); err != nil {
+				return Result{}, err
+			}
+		default:
+			return Result{}, fmt.Errorf("%w: line %d: unexpected '%s'", ErrSyntax, lineNumber, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return builder.result(), nil
+}
+
+// parseIptablesRule parses the options of a rule in the INPUT chain
+func parseIptablesRule(line string, options []string, lineNumber int, family Family, builder *builder) error {
+	rule := hostRule{family: family}
+	target := ""
+	reason := ""
+
+	for idx := 0; idx < len(options) && reason == ""; idx++ {
+		option := options[idx]
+		value := ""
+		if idx+1 < len(options) {
+			value = options[idx+1]
+		}
+
+		switch option {
+		case "-p", "--protocol":
+			switch value {
+			case vps.FirewallProtocolTCP, vps.FirewallProtocolUDP:
+				rule.protocol = value
+			case "all":
+			default:
+				reason = fmt.Sprintf("the %s protocol can not be represented, only tcp and udp are supported", value)
+			}
+			idx++
+		case "-s", "--source":
+			for _, element := range strings.Split(value, ",") {
+				source, err := parseAddress(element)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				rule.sources = append(rule.sources, source)
+			}
+			idx++
+		case "--dport", "--destination-port", "--dports", "--destination-ports":
+			for _, element := range strings.Split(value, ",") {
+				ports, err := parsePortRange(element, ":")
+				if err != nil {
+					return fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				rule.ports = append(rule.ports, ports)
+			}
+			idx++
+		case "-m", "--match":
+			switch value {
+			case "tcp", "udp", "multiport", "comment":
+			case "state", "conntrack":
+				reason = "connection tracking can not be represented, the edge firewall always allows established connections"
+			case "limit", "hashlimit", "recent", "connlimit":
+				reason = "rate limiting can not be represented"
+			default:
+				reason = fmt.Sprintf("the %s match can not be represented", value)
+			}
+			idx++
+		case "--comment":
+			rule.description = value
+			idx++
+		case "-j", "--jump":
+			target = value
+			idx++
+		case "-i", "--in-interface", "-o", "--out-interface":
+			reason = "interface matching can not be represented"
+		case "-d", "--destination":
+			reason = "matching on the destination address can not be represented"
+		case "--sport", "--source-port", "--sports", "--source-ports":
+			reason = "matching on the source port can not be represented"
+		case "!":
+			reason = "negated matches can not be represented"
+		case "-g", "--goto":
+			reason = "the goto target can not be represented"
+		case "--reject-with":
+			idx++
+		default:
+			reason = fmt.Sprintf("the %s option can not be represented", option)
+		}
+	}
+
+	switch {
+	case reason != "":
+		builder.issue(lineNumber, line, reason)
+	case target == "ACCEPT":
+		builder.add(lineNumber, line, rule)
+	case (target == "DROP" || target == "REJECT") && isMatchAll(rule, family):
+		// a final rule dropping all traffic works like a DROP policy
+		builder.enabled = true
+	case target == "DROP" || target == "REJECT":
+		builder.issue(lineNumber, line, "deny rules can not be represented, the edge firewall only has allow rules")
+	case target == "LOG" || target == "":
+		// logging, or only counting, does not change what is allowed
+	default:
+		builder.issue(lineNumber, line, fmt.Sprintf("the %s target can not be represented", target))
+	}
+
+	return nil
+}
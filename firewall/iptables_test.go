@@ -0,0 +1,102 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatIptablesSave(t *testing.T) {
+	const expectedIPv4 = `*filter
+:INPUT DROP [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+-A INPUT -s 10.0.0.0/8 -p tcp -m tcp --dport 22 -m comment --comment "SSH" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 80 -m comment --comment "HTTP" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 53 -m comment --comment "DNS" -j ACCEPT
+-A INPUT -p udp -m udp --dport 53 -m comment --comment "DNS" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 60000:61000 -m comment --comment "mosh" -j ACCEPT
+-A INPUT -p udp -m udp --dport 60000:61000 -m comment --comment "mosh" -j ACCEPT
+COMMIT
+`
+	const expectedIPv6 = `*filter
+:INPUT DROP [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+-A INPUT -s 2a01:7c8::/32 -p tcp -m tcp --dport 22 -m comment --comment "SSH" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 80 -m comment --comment "HTTP" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 53 -m comment --comment "DNS" -j ACCEPT
+-A INPUT -p udp -m udp --dport 53 -m comment --comment "DNS" -j ACCEPT
+COMMIT
+`
+	firewall := exampleFirewall(t)
+	assert.Equal(t, expectedIPv4, FormatIptablesSave(firewall, IPv4))
+	assert.Equal(t, expectedIPv6, FormatIptablesSave(firewall, IPv6))
+
+	output := "# Generated by iptables-save v1.8.7\n" + FormatIptablesSave(firewall, IPv4) +
+		"# Generated by ip6tables-save v1.8.7\n" + FormatIptablesSave(firewall, IPv6)
+	result, err := ParseIptablesSave(strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	assert.True(t, result.Firewall.IsEnabled)
+	assertFirewall(t, firewall, result.Firewall)
+}
+
+func TestParseIptablesSave(t *testing.T) {
+	const output = `# Generated by iptables-save v1.8.7 on Sat Oct 17 12:00:00 2026
+*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -p tcp --dport 8080 -j REDIRECT --to-ports 80
+COMMIT
+*filter
+:INPUT ACCEPT [120:9600]
+:FORWARD DROP [0:0]
+-A INPUT -i lo -j ACCEPT
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -p tcp -m multiport --dports 80,443 -m comment --comment "web" -j ACCEPT
+-A INPUT -s 192.0.2.0/24,198.51.100.7/32 -p udp -m udp --dport 53 -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 25 -j REJECT --reject-with icmp-port-unreachable
+-A INPUT -p icmp -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 23 -j LOG
+-A INPUT -j DROP
+-A FORWARD -j ACCEPT
+-A FORWARD -i docker0 -j ACCEPT
+COMMIT
+`
+	result, err := ParseIptablesSave(strings.NewReader(output))
+	require.NoError(t, err)
+
+	assertFirewall(t, vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{
+		{Description: "web", StartPort: 80, EndPort: 80, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "0.0.0.0/0")},
+		{Description: "web", StartPort: 443, EndPort: 443, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "0.0.0.0/0")},
+		{StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolUDP, Whitelist: parseRanges(t, "192.0.2.0/24", "198.51.100.7/32")},
+	}}, result.Firewall)
+
+	var issues []string
+	for _, issue := range result.Issues {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"line 4: -A PREROUTING -p tcp --dport 8080 -j REDIRECT --to-ports 80: rules in chain PREROUTING of table nat can not be represented, only the INPUT chain of the filter table is converted",
+		"line 9: -A INPUT -i lo -j ACCEPT: interface matching can not be represented",
+		"line 10: -A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT: connection tracking can not be represented, the edge firewall always allows established connections",
+		"line 13: -A INPUT -p tcp -m tcp --dport 25 -j REJECT --reject-with icmp-port-unreachable: deny rules can not be represented, the edge firewall only has allow rules",
+		"line 14: -A INPUT -p icmp -j ACCEPT: the icmp protocol can not be represented, only tcp and udp are supported",
+		"line 17: -A FORWARD -j ACCEPT: rules in chain FORWARD of table filter can not be represented, only the INPUT chain of the filter table is converted",
+	}, issues)
+}
+
+func TestParseIptablesSave_Errors(t *testing.T) {
+	_, err := ParseIptablesSave(strings.NewReader("*filter\n-A INPUT -s 10.0.0.0/33 -j ACCEPT\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+
+	_, err = ParseIptablesSave(strings.NewReader("*filter\n-A INPUT -p tcp --dport 70000 -j ACCEPT\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+
+	_, err = ParseIptablesSave(strings.NewReader("*filter\niptables -A INPUT -j ACCEPT\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.EqualError(t, err, "syntax error: line 2: unexpected 'iptables -A INPUT -j ACCEPT'")
+}
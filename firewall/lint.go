@@ -0,0 +1,431 @@
+package firewall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity of a lint finding
+type Severity string
+
+const (
+	// SeverityLow is used for findings that do not open anything up, like redundant rules
+	SeverityLow Severity = "low"
+	// SeverityMedium is used for findings that probably allow more than intended
+	SeverityMedium Severity = "medium"
+	// SeverityHigh is used for findings that expose services to the internet
+	SeverityHigh Severity = "high"
+	// SeverityCritical is used for findings that expose management services to the internet
+	SeverityCritical Severity = "critical"
+)
+
+// severityRanks orders the severities from low to critical
+var severityRanks = map[Severity]int{SeverityLow: 1, SeverityMedium: 2, SeverityHigh: 3, SeverityCritical: 4}
+
+// AtLeast returns true when the severity is equal to or more severe than the given minimum
+func (s Severity) AtLeast(minimum Severity) bool {
+	return severityRanks[s] >= severityRanks[minimum]
+}
+
+// The ids of the lint checks, used in a Finding, a Suppression and Linter.Severities
+const (
+	// CheckManagementPortOpen reports management ports, see DefaultManagementPorts, open to every address
+	CheckManagementPortOpen = "management-port-open"
+	// CheckFirewallDisabled reports firewalls that are not enabled, which allows all incoming traffic
+	CheckFirewallDisabled = "firewall-disabled"
+	// CheckWidePortRange reports rules with a port range larger than Linter.MaxPortRange
+	CheckWidePortRange = "wide-port-range"
+	// CheckDuplicateRule reports rules that allow exactly the same traffic as another rule
+	CheckDuplicateRule = "duplicate-rule"
+	// CheckShadowedRule reports rules that only allow traffic another rule already allows
+	CheckShadowedRule = "shadowed-rule"
+)
+
+// checks describes every check, in the order they are executed
+var checks = []struct {
+	id          string
+	severity    Severity
+	description string
+}{
+	{CheckFirewallDisabled, SeverityHigh, "The firewall is disabled, all incoming traffic is allowed"},
+	{CheckManagementPortOpen, SeverityCritical, "A management port is open to every address"},
+	{CheckWidePortRange, SeverityMedium, "A rule opens a very wide port range"},
+	{CheckDuplicateRule, SeverityLow, "A rule allows exactly the same traffic as another rule"},
+	{CheckShadowedRule, SeverityLow, "A rule only allows traffic that another rule already allows"},
+}
+
+// DefaultManagementPorts are the ports checked when Linter.ManagementPorts is not set, with the service they are known for
+var DefaultManagementPorts = map[int]string{
+	22:   "SSH",
+	3306: "MySQL",
+	3389: "RDP",
+	5432: "PostgreSQL",
+	6379: "Redis",
+}
+
+// DefaultMaxPortRange is used when Linter.MaxPortRange is not set
+const DefaultMaxPortRange = 1000
+
+var (
+	// ErrInvalidSuppression is returned when a suppression does not have a known check or a valid vps name pattern
+	ErrInvalidSuppression = errors.New("invalid suppression")
+)
+
+// Finding is a risky part of the firewall of a vps
+type Finding struct {
+	// Check is the id of the check that reported the finding, see the Check constants
+	Check string `json:"check"`
+	// Severity of the finding
+	Severity Severity `json:"severity"`
+	// VpsName is the name of the vps of which the firewall was checked
+	VpsName string `json:"vpsName"`
+	// Rule is the rule the finding is about, nil for findings about the firewall as a whole
+	Rule *vps.FirewallRule `json:"rule,omitempty"`
+	// RelatedRule is the rule that duplicates or shadows Rule
+	RelatedRule *vps.FirewallRule `json:"relatedRule,omitempty"`
+	// Message describes the finding
+	Message string `json:"message"`
+	// Suppressed is true when a suppression matched the finding
+	Suppressed bool `json:"suppressed,omitempty"`
+	// Justification is the reason of the suppression that matched the finding
+	Justification string `json:"justification,omitempty"`
+}
+
+func (f Finding) String() string {
+	text := fmt.Sprintf("[%s] %s: %s: %s", f.Severity, f.VpsName, f.Check, f.Message)
+	if f.Suppressed {
+		text += fmt.Sprintf(" (suppressed: %s)", f.Justification)
+	}
+
+	return text
+}
+
+// Suppression hides findings that are accepted risks, for example:
+//
+//   - check: management-port-open
+//     vpsName: bastion-*
+//     port: 22
+//     reason: bastion hosts accept ssh from everywhere, access is key-only
+type Suppression struct {
+	// Check is the id of the suppressed check, empty to suppress every check
+	Check string `json:"check,omitempty" yaml:"check"`
+	// VpsName is the name of the vps, or a pattern as accepted by path.Match, empty to match every vps
+	VpsName string `json:"vpsName,omitempty" yaml:"vpsName"`
+	// Port limits the suppression to findings about rules of which the port range contains the port, 0 for every port
+	Port int `json:"port,omitempty" yaml:"port"`
+	// Reason why the finding is an accepted risk, it is required and used as justification of the suppressed finding
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// Validate returns an ErrInvalidSuppression error when the suppression has an unknown check,
+// an invalid vps name pattern, an invalid port or no reason
+func (s Suppression) Validate() error {
+	if s.Check != "" && checkSeverity(s.Check) == "" {
+		return fmt.Errorf("%w: unknown check '%s'", ErrInvalidSuppression, s.Check)
+	}
+	if _, err := path.Match(s.VpsName, ""); err != nil {
+		return fmt.Errorf("%w: invalid vps name pattern '%s'", ErrInvalidSuppression, s.VpsName)
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("%w: invalid port %d", ErrInvalidSuppression, s.Port)
+	}
+	if strings.TrimSpace(s.Reason) == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidSuppression)
+	}
+
+	return nil
+}
+
+// matches returns true when the suppression applies to the finding
+func (s Suppression) matches(finding Finding) bool {
+	if s.Check != "" && s.Check != finding.Check {
+		return false
+	}
+	if s.VpsName != "" {
+		if matched, _ := path.Match(s.VpsName, finding.VpsName); !matched {
+			return false
+		}
+	}
+	if s.Port != 0 && (finding.Rule == nil || s.Port < finding.Rule.StartPort || s.Port > finding.Rule.EndPort) {
+		return false
+	}
+
+	return true
+}
+
+// LoadSuppressions reads a yaml, or json, list of suppressions from the reader and validates them,
+// see Suppression for an example
+func LoadSuppressions(reader io.Reader) ([]Suppression, error) {
+	var suppressions []Suppression
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&suppressions); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error decoding suppressions: %w", err)
+	}
+	for idx, suppression := range suppressions {
+		if err := suppression.Validate(); err != nil {
+			return nil, fmt.Errorf("suppression %d: %w", idx+1, err)
+		}
+	}
+
+	return suppressions, nil
+}
+
+// LintError is a vps of which the firewall could not be loaded
+type LintError struct {
+	// VpsName is the name of the vps
+	VpsName string `json:"vpsName"`
+	// Message is the error returned by the api
+	Message string `json:"message"`
+}
+
+// Report contains the findings for all firewalls that were checked
+type Report struct {
+	// Findings ordered by vps name, suppressed findings included
+	Findings []Finding `json:"findings"`
+	// Errors for vpses of which the firewall could not be loaded
+	Errors []LintError `json:"errors,omitempty"`
+}
+
+// Active returns the findings that are not suppressed
+func (r Report) Active() []Finding {
+	var active []Finding
+	for _, finding := range r.Findings {
+		if !finding.Suppressed {
+			active = append(active, finding)
+		}
+	}
+
+	return active
+}
+
+// AtLeast returns the findings that are not suppressed and have at least the given severity
+func (r Report) AtLeast(minimum Severity) []Finding {
+	var findings []Finding
+	for _, finding := range r.Active() {
+		if finding.Severity.AtLeast(minimum) {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// String returns the report as human readable text, one line per finding
+func (r Report) String() string {
+	var builder strings.Builder
+	for _, finding := range r.Findings {
+		builder.WriteString(finding.String() + "\n")
+	}
+	for _, lintError := range r.Errors {
+		fmt.Fprintf(&builder, "[error] %s: %s\n", lintError.VpsName, lintError.Message)
+	}
+
+	return builder.String()
+}
+
+// WriteJSON writes the report as indented json
+func (r Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// Linter checks firewalls for risky rules
+type Linter struct {
+	// ManagementPorts overrides DefaultManagementPorts when set
+	ManagementPorts map[int]string
+	// MaxPortRange is the number of ports a rule may open before it is reported, DefaultMaxPortRange when 0
+	MaxPortRange int
+	// Severities overrides the default severity of checks, by check id
+	Severities map[string]Severity
+	// Suppressions mark matching findings as suppressed
+	Suppressions []Suppression
+}
+
+// LintFleet loads the firewall of every vps through the FirewallRepository and checks it.
+// A firewall that can not be loaded is recorded in Report.Errors, an error is only returned when the vpses can not be listed.
+func (l *Linter) LintFleet(client repository.Client) (Report, error) {
+	vpsRepo := vps.Repository{Client: client}
+	firewallRepo := vps.FirewallRepository{Client: client}
+
+	vpss, err := vpsRepo.GetAll()
+	if err != nil {
+		return Report{}, fmt.Errorf("error listing vpses: %w", err)
+	}
+	sort.Slice(vpss, func(i, j int) bool { return vpss[i].Name < vpss[j].Name })
+
+	report := Report{Findings: []Finding{}}
+	for _, vpsItem := range vpss {
+		firewall, err := firewallRepo.GetFirewall(vpsItem.Name)
+		if err != nil {
+			report.Errors = append(report.Errors, LintError{VpsName: vpsItem.Name, Message: err.Error()})
+			continue
+		}
+		report.Findings = append(report.Findings, l.Lint(vpsItem.Name, firewall)...)
+	}
+
+	return report, nil
+}
+
+// Lint checks the firewall of one vps and returns its findings, suppressed findings included
+func (l *Linter) Lint(vpsName string, firewall vps.Firewall) []Finding {
+	var findings []Finding
+	report := func(check string, rule *vps.FirewallRule, related *vps.FirewallRule, format string, args ...interface{}) {
+		findings = append(findings, l.finding(Finding{
+			Check:       check,
+			VpsName:     vpsName,
+			Rule:        rule,
+			RelatedRule: related,
+			Message:     fmt.Sprintf(format, args...),
+		}))
+	}
+
+	if !firewall.IsEnabled {
+		report(CheckFirewallDisabled, nil, nil, "the firewall is disabled, all incoming traffic is allowed")
+	}
+
+	managementPorts := l.ManagementPorts
+	if managementPorts == nil {
+		managementPorts = DefaultManagementPorts
+	}
+	ports := make([]int, 0, len(managementPorts))
+	for port := range managementPorts {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	maxPortRange := l.MaxPortRange
+	if maxPortRange == 0 {
+		maxPortRange = DefaultMaxPortRange
+	}
+
+	for idx := range firewall.RuleSet {
+		rule := &firewall.RuleSet[idx]
+		if openTo := openToEveryone(*rule); openTo != "" {
+			for _, port := range ports {
+				if port >= rule.StartPort && port <= rule.EndPort {
+					report(CheckManagementPortOpen, rule, nil, "%s port %d is open to %s by rule %s", managementPorts[port], port, openTo, rule)
+				}
+			}
+		}
+		if size := rule.EndPort - rule.StartPort + 1; size > maxPortRange {
+			report(CheckWidePortRange, rule, nil, "rule %s opens %d ports, more than %d", rule, size, maxPortRange)
+		}
+	}
+
+	for idx := range firewall.RuleSet {
+		rule := &firewall.RuleSet[idx]
+		for otherIdx := range firewall.RuleSet {
+			other := &firewall.RuleSet[otherIdx]
+			if idx == otherIdx || !ruleCovers(*other, *rule) {
+				continue
+			}
+			if ruleCovers(*rule, *other) {
+				// only the later one of duplicate rules is reported
+				if otherIdx < idx {
+					report(CheckDuplicateRule, rule, other, "rule %s duplicates rule %s", rule, other)
+					break
+				}
+				continue
+			}
+			report(CheckShadowedRule, rule, other, "rule %s is shadowed by rule %s", rule, other)
+			break
+		}
+	}
+
+	return findings
+}
+
+// finding sets the severity of the finding and applies the first matching suppression
+func (l *Linter) finding(finding Finding) Finding {
+	finding.Severity = checkSeverity(finding.Check)
+	if severity, ok := l.Severities[finding.Check]; ok {
+		finding.Severity = severity
+	}
+	for _, suppression := range l.Suppressions {
+		if suppression.matches(finding) {
+			finding.Suppressed = true
+			finding.Justification = suppression.Reason
+			break
+		}
+	}
+
+	return finding
+}
+
+// checkSeverity returns the default severity of a check, empty for an unknown check
+func checkSeverity(check string) Severity {
+	for _, c := range checks {
+		if c.id == check {
+			return c.severity
+		}
+	}
+
+	return ""
+}
+
+// openToEveryone returns the whole address ranges a rule allows, like "0.0.0.0/0", or empty when it is limited
+func openToEveryone(rule vps.FirewallRule) string {
+	if len(rule.Whitelist) == 0 {
+		return "0.0.0.0/0 and ::/0"
+	}
+
+	var open []string
+	for _, ipRange := range rule.Whitelist {
+		if ones, _ := ipRange.Mask.Size(); ones == 0 {
+			open = append(open, ipRange.String())
+		}
+	}
+
+	return strings.Join(open, " and ")
+}
+
+// ruleCovers returns true when rule a allows all traffic rule b allows
+func ruleCovers(a vps.FirewallRule, b vps.FirewallRule) bool {
+	if a.Protocol != b.Protocol && a.Protocol != vps.FirewallProtocolTCPUDP {
+		return false
+	}
+	if a.StartPort > b.StartPort || a.EndPort < b.EndPort {
+		return false
+	}
+	if len(a.Whitelist) == 0 {
+		return true
+	}
+	if len(b.Whitelist) == 0 {
+		return false
+	}
+	for _, bRange := range b.Whitelist {
+		covered := false
+		for _, aRange := range a.Whitelist {
+			if networkCovers(aRange.IPNet, bRange.IPNet) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+
+	return true
+}
+
+// networkCovers returns true when network a contains every address of network b
+func networkCovers(a net.IPNet, b net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+
+	return aBits == bBits && aOnes <= bOnes && a.Contains(b.IP)
+}
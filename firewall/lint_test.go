@@ -0,0 +1,183 @@
+package firewall
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// riskyFirewall returns a disabled firewall that triggers every check
+func riskyFirewall(t *testing.T) vps.Firewall {
+	return vps.Firewall{IsEnabled: false, RuleSet: []vps.FirewallRule{
+		{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: vps.FirewallProtocolTCP},
+		{Description: "databases", StartPort: 3306, EndPort: 5432, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "10.0.0.0/8", "::/0")},
+		{Description: "office", StartPort: 3306, EndPort: 3306, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "10.1.0.0/16")},
+		{Description: "SSH again", StartPort: 22, EndPort: 22, Protocol: vps.FirewallProtocolTCP},
+		{Description: "DNS", StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolUDP, Whitelist: parseRanges(t, "192.0.2.0/24")},
+	}}
+}
+
+func TestLinter_Lint(t *testing.T) {
+	linter := Linter{}
+	findings := linter.Lint("example-vps", riskyFirewall(t))
+
+	var lines []string
+	for _, finding := range findings {
+		lines = append(lines, finding.String())
+	}
+	assert.Equal(t, []string{
+		"[high] example-vps: firewall-disabled: the firewall is disabled, all incoming traffic is allowed",
+		"[critical] example-vps: management-port-open: SSH port 22 is open to 0.0.0.0/0 and ::/0 by rule tcp 22 (SSH) from any",
+		"[critical] example-vps: management-port-open: MySQL port 3306 is open to ::/0 by rule tcp 3306-5432 (databases) from 10.0.0.0/8, ::/0",
+		"[critical] example-vps: management-port-open: RDP port 3389 is open to ::/0 by rule tcp 3306-5432 (databases) from 10.0.0.0/8, ::/0",
+		"[critical] example-vps: management-port-open: PostgreSQL port 5432 is open to ::/0 by rule tcp 3306-5432 (databases) from 10.0.0.0/8, ::/0",
+		"[medium] example-vps: wide-port-range: rule tcp 3306-5432 (databases) from 10.0.0.0/8, ::/0 opens 2127 ports, more than 1000",
+		"[critical] example-vps: management-port-open: SSH port 22 is open to 0.0.0.0/0 and ::/0 by rule tcp 22 (SSH again) from any",
+		"[low] example-vps: shadowed-rule: rule tcp 3306 (office) from 10.1.0.0/16 is shadowed by rule tcp 3306-5432 (databases) from 10.0.0.0/8, ::/0",
+		"[low] example-vps: duplicate-rule: rule tcp 22 (SSH again) from any duplicates rule tcp 22 (SSH) from any",
+	}, lines)
+
+	require.Len(t, findings, 9)
+	assert.Nil(t, findings[0].Rule)
+	assert.Equal(t, "SSH again", findings[8].Rule.Description)
+	assert.Equal(t, "SSH", findings[8].RelatedRule.Description)
+}
+
+func TestLinter_LintOptions(t *testing.T) {
+	linter := Linter{
+		ManagementPorts: map[int]string{53: "DNS"},
+		MaxPortRange:    5000,
+		Severities:      map[string]Severity{CheckFirewallDisabled: SeverityCritical},
+		Suppressions: []Suppression{
+			{Check: CheckManagementPortOpen, VpsName: "bastion-*", Port: 22, Reason: "ssh is key-only"},
+			{VpsName: "legacy", Reason: "to be removed"},
+		},
+	}
+	firewall := vps.Firewall{RuleSet: []vps.FirewallRule{
+		{StartPort: 22, EndPort: 22, Protocol: vps.FirewallProtocolTCP},
+		{StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolTCPUDP, Whitelist: parseRanges(t, "0.0.0.0/0")},
+		{StartPort: 1, EndPort: 4000, Protocol: vps.FirewallProtocolUDP, Whitelist: parseRanges(t, "10.0.0.0/8")},
+	}}
+
+	findings := linter.Lint("bastion-1", firewall)
+	require.Len(t, findings, 2)
+	assert.Equal(t, CheckFirewallDisabled, findings[0].Check)
+	assert.Equal(t, SeverityCritical, findings[0].Severity)
+	assert.False(t, findings[0].Suppressed)
+	assert.Equal(t, "DNS port 53 is open to 0.0.0.0/0 by rule tcp_udp 53 from 0.0.0.0/0", findings[1].Message)
+	assert.False(t, findings[1].Suppressed)
+
+	linter.ManagementPorts = nil
+	findings = linter.Lint("bastion-1", firewall)
+	require.Len(t, findings, 2)
+	assert.Equal(t, CheckManagementPortOpen, findings[1].Check)
+	assert.True(t, findings[1].Suppressed)
+	assert.Equal(t, "ssh is key-only", findings[1].Justification)
+
+	for _, finding := range linter.Lint("legacy", firewall) {
+		assert.True(t, finding.Suppressed)
+		assert.Equal(t, "to be removed", finding.Justification)
+	}
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	assert.True(t, SeverityCritical.AtLeast(SeverityHigh))
+	assert.True(t, SeverityMedium.AtLeast(SeverityMedium))
+	assert.False(t, SeverityLow.AtLeast(SeverityMedium))
+}
+
+func TestLoadSuppressions(t *testing.T) {
+	suppressions, err := LoadSuppressions(strings.NewReader(`
+- check: management-port-open
+  vpsName: bastion-*
+  port: 22
+  reason: bastion hosts accept ssh from everywhere
+- vpsName: legacy
+  reason: to be removed
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Suppression{
+		{Check: CheckManagementPortOpen, VpsName: "bastion-*", Port: 22, Reason: "bastion hosts accept ssh from everywhere"},
+		{VpsName: "legacy", Reason: "to be removed"},
+	}, suppressions)
+
+	suppressions, err = LoadSuppressions(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, suppressions)
+
+	_, err = LoadSuppressions(strings.NewReader("- check: open-ports\n  reason: test\n"))
+	assert.ErrorIs(t, err, ErrInvalidSuppression)
+	assert.EqualError(t, err, "suppression 1: invalid suppression: unknown check 'open-ports'")
+
+	_, err = LoadSuppressions(strings.NewReader("- vpsName: '['\n  reason: test\n"))
+	assert.ErrorIs(t, err, ErrInvalidSuppression)
+
+	_, err = LoadSuppressions(strings.NewReader("- check: duplicate-rule\n"))
+	assert.ErrorIs(t, err, ErrInvalidSuppression)
+
+	_, err = LoadSuppressions(strings.NewReader("- vps: legacy\n  reason: test\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error decoding suppressions")
+}
+
+func TestLinter_LintFleet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodGet, req.Method)
+		switch req.URL.Path {
+		case "/vps":
+			_, _ = rw.Write([]byte(`{"vpss":[{"name":"web-2"},{"name":"web-1"},{"name":"locked"}]}`))
+		case "/vps/web-1/firewall":
+			_, _ = rw.Write([]byte(`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"SSH","startPort":22,"endPort":22,"protocol":"tcp","whitelist":[]}]}}`))
+		case "/vps/web-2/firewall":
+			_, _ = rw.Write([]byte(`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"SSH","startPort":22,"endPort":22,"protocol":"tcp","whitelist":["10.0.0.0/8"]}]}}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error":"Vps not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	config := gotransip.DemoClientConfiguration
+	config.URL = server.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(t, err)
+
+	linter := Linter{}
+	report, err := linter.LintFleet(client)
+	require.NoError(t, err)
+
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "web-1", report.Findings[0].VpsName)
+	assert.Equal(t, CheckManagementPortOpen, report.Findings[0].Check)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "locked", report.Errors[0].VpsName)
+	assert.Equal(t, "Vps not found", report.Errors[0].Message)
+
+	assert.Len(t, report.AtLeast(SeverityCritical), 1)
+	assert.Equal(t, "[critical] web-1: management-port-open: SSH port 22 is open to 0.0.0.0/0 and ::/0 by rule tcp 22 (SSH) from any\n[error] locked: Vps not found\n", report.String())
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	linter := Linter{Suppressions: []Suppression{{Check: CheckDuplicateRule, Reason: "kept for clarity"}}}
+	report := Report{Findings: linter.Lint("example-vps", riskyFirewall(t))}
+	assert.Len(t, report.Active(), 8)
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buffer))
+
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+	assert.Contains(t, buffer.String(), `"whitelist": [
+          "10.0.0.0/8",
+          "::/0"
+        ]`)
+}
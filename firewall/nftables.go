@@ -0,0 +1,400 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// nftServices maps the service names nft prints for well known ports, unless it is run with -nn
+var nftServices = map[string]string{
+	"ftp": "21", "ssh": "22", "telnet": "23", "smtp": "25", "domain": "53", "http": "80", "pop3": "110",
+	"ntp": "123", "imap": "143", "snmp": "161", "ldap": "389", "https": "443", "submission": "587",
+	"ldaps": "636", "imaps": "993", "pop3s": "995", "mysql": "3306", "rdp": "3389", "postgresql": "5432",
+}
+
+// nftBlock is a table, chain or other block, like a set, of an nftables ruleset
+type nftBlock struct {
+	kind string
+	name string
+	// family of a table, empty for inet tables
+	family Family
+	// supported is false for tables of families other than ip, ip6 and inet
+	supported bool
+	// hook of a chain
+	hook string
+	// reported is set when the issue that rules in this chain are not supported was reported
+	reported bool
+	// depth counts the open braces of other blocks
+	depth int
+}
+
+// FormatNftables returns the firewall as an nftables ruleset with an inet table named transip.
+// An enabled firewall drops all other incoming traffic, a disabled firewall accepts it.
+func FormatNftables(firewall vps.Firewall) string {
+	policy := "accept"
+	if firewall.IsEnabled {
+		policy = "drop"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("table inet transip {\n")
+	builder.WriteString("\tchain input {\n")
+	fmt.Fprintf(&builder, "\t\ttype filter hook input priority filter; policy %s;\n", policy)
+	for _, rule := range firewall.RuleSet {
+		match := nftMatch(rule)
+		comment := ""
+		if rule.Description != "" {
+			comment = " comment " + quote(rule.Description)
+		}
+
+		sources := ruleSourcesOf(rule)
+		if sources.anyIPv4 && sources.anyIPv6 {
+			fmt.Fprintf(&builder, "\t\t%s accept%s\n", match, comment)
+			continue
+		}
+		for _, family := range []Family{IPv4, IPv6} {
+			ranges, all := sources.of(family)
+			switch {
+			case all:
+				fmt.Fprintf(&builder, "\t\tmeta nfproto %s %s accept%s\n", family, match, comment)
+			case len(ranges) > 0:
+				fmt.Fprintf(&builder, "\t\t%s saddr %s %s accept%s\n", nftAddressKeyword(family), nftSet(ranges), match, comment)
+			}
+		}
+	}
+	builder.WriteString("\t}\n")
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// ParseNftables converts the rules in the input chains of an nftables ruleset, like the output of nft list ruleset.
+// The firewall is enabled when the policy of an input chain, or a final rule, drops all other traffic.
+func ParseNftables(reader io.Reader) (Result, error) {
+	builder := newBuilder()
+	var stack []*nftBlock
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var current *nftBlock
+		if len(stack) > 0 {
+			current = stack[len(stack)-1]
+		}
+
+		if current != nil && current.kind == "other" {
+			current.depth += strings.Count(line, "{") - strings.Count(line, "}")
+			if current.depth <= 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		if line == "}" {
+			if current == nil {
+				return Result{}, fmt.Errorf("%w: line %d: unexpected '}'", ErrSyntax, lineNumber)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if strings.HasSuffix(line, "{") {
+			block, err := openNftBlock(line, current, lineNumber, builder)
+			if err != nil {
+				return Result{}, err
+			}
+			stack = append(stack, block)
+			continue
+		}
+		if current == nil || current.kind != "chain" {
+			continue
+		}
+
+		if err := parseNftChainLine(line, lineNumber, current, stack[0], builder); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Result{}, err
+	}
+	if len(stack) > 0 {
+		return Result{}, fmt.Errorf("%w: missing '}' for %s %s", ErrSyntax, stack[len(stack)-1].kind, stack[len(stack)-1].name)
+	}
+
+	return builder.result(), nil
+}
+
+// openNftBlock returns the block opened by a line ending with '{'
+func openNftBlock(line string, parent *nftBlock, lineNumber int, builder *builder) (*nftBlock, error) {
+	fields := strings.Fields(strings.TrimSuffix(line, "{"))
+	switch {
+	case parent == nil && len(fields) >= 2 && fields[0] == "table":
+		table := &nftBlock{kind: "table", name: fields[len(fields)-1], supported: true, family: IPv4}
+		if len(fields) == 3 {
+			switch fields[1] {
+			case "ip":
+			case "ip6":
+				table.family = IPv6
+			case "inet":
+				table.family = ""
+			default:
+				table.supported = false
+				builder.issue(lineNumber, line, fmt.Sprintf("tables of the %s family can not be represented, only ip, ip6 and inet tables are converted", fields[1]))
+			}
+		}
+		if !table.supported {
+			return &nftBlock{kind: "other", name: table.name, depth: 1}, nil
+		}
+		return table, nil
+	case parent != nil && parent.kind == "table" && len(fields) == 2 && fields[0] == "chain":
+		return &nftBlock{kind: "chain", name: fields[1]}, nil
+	case parent != nil:
+		return &nftBlock{kind: "other", name: strings.Join(fields, " "), depth: 1}, nil
+	}
+
+	return nil, fmt.Errorf("%w: line %d: unexpected '%s'", ErrSyntax, lineNumber, line)
+}
+
+// parseNftChainLine parses a statement or rule in a chain
+func parseNftChainLine(line string, lineNumber int, chain *nftBlock, table *nftBlock, builder *builder) error {
+	if strings.HasPrefix(line, "type ") || strings.HasPrefix(line, "policy ") {
+		for _, statement := range strings.Split(line, ";") {
+			fields := strings.Fields(statement)
+			for idx := 0; idx+1 < len(fields); idx++ {
+				switch fields[idx] {
+				case "hook":
+					chain.hook = fields[idx+1]
+				case "policy":
+					if chain.hook == "input" {
+						builder.enabled = builder.enabled || fields[idx+1] == "drop"
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if chain.hook != "input" {
+		if !chain.reported {
+			chain.reported = true
+			builder.issue(lineNumber, line, fmt.Sprintf("rules in chain %s can not be represented, only chains with hook input are converted", chain.name))
+		}
+		return nil
+	}
+
+	rule, verdict, reason, err := parseNftRule(line, table.family)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", lineNumber, err)
+	}
+	switch {
+	case reason != "":
+		builder.issue(lineNumber, line, reason)
+	case verdict == "accept":
+		builder.add(lineNumber, line, rule)
+	case (verdict == "drop" || verdict == "reject") && isMatchAll(rule, table.family):
+		// a final rule dropping all traffic works like a drop policy
+		builder.enabled = true
+	case verdict == "drop" || verdict == "reject":
+		builder.issue(lineNumber, line, "deny rules can not be represented, the edge firewall only has allow rules")
+	case verdict == "":
+		builder.issue(lineNumber, line, "rules without a verdict can not be represented")
+	}
+
+	return nil
+}
+
+// parseNftRule parses an nftables rule. It returns the reason when the rule can not be represented,
+// and an error for invalid addresses or ports.
+func parseNftRule(line string, family Family) (rule hostRule, verdict string, reason string, err error) {
+	fields, err := splitFields(line)
+	if err != nil {
+		return rule, "", "", err
+	}
+	rule.family = family
+
+	var ports []string
+	for idx := 0; idx < len(fields); idx++ {
+		field := fields[idx]
+		next := ""
+		if idx+1 < len(fields) {
+			next = fields[idx+1]
+		}
+		value := ""
+		if idx+2 < len(fields) {
+			value = fields[idx+2]
+		}
+
+		switch field {
+		case "ip", "ip6":
+			if next != "saddr" {
+				return rule, "", fmt.Sprintf("matching on %s %s can not be represented", field, next), nil
+			}
+			if rule.family, reason = nftFamily(rule.family, field); reason != "" {
+				return rule, "", reason, nil
+			}
+			for _, element := range setElements(value) {
+				if strings.HasPrefix(element, "@") {
+					return rule, "", "named sets can not be represented", nil
+				}
+				source, err := parseAddress(element)
+				if err != nil {
+					return rule, "", "", err
+				}
+				if familyOf(source.IP) != rule.family {
+					return rule, "", "", fmt.Errorf("%w: %s address '%s' in an %s match", ErrSyntax, familyOf(source.IP), element, field)
+				}
+				rule.sources = append(rule.sources, source)
+			}
+			idx += 2
+		case "tcp", "udp", "th":
+			if next != "dport" {
+				return rule, "", fmt.Sprintf("matching on %s %s can not be represented", field, next), nil
+			}
+			if field != "th" {
+				if rule.protocol != "" && rule.protocol != field {
+					return rule, "", "matching on multiple protocols can not be represented", nil
+				}
+				rule.protocol = field
+			}
+			ports = setElements(value)
+			idx += 2
+		case "meta":
+			switch next {
+			case "l4proto":
+				if rule.protocol, reason = nftProtocol(setElements(value)); reason != "" {
+					return rule, "", reason, nil
+				}
+			case "nfproto":
+				if rule.family, reason = nftFamily(rule.family, value); reason != "" {
+					return rule, "", reason, nil
+				}
+			case "iif", "iifname", "oif", "oifname":
+				return rule, "", "interface matching can not be represented", nil
+			default:
+				return rule, "", fmt.Sprintf("matching on meta %s can not be represented", next), nil
+			}
+			idx += 2
+		case "iif", "iifname", "oif", "oifname":
+			return rule, "", "interface matching can not be represented", nil
+		case "ct":
+			return rule, "", "connection tracking can not be represented, the edge firewall always allows established connections", nil
+		case "limit":
+			return rule, "", "rate limiting can not be represented", nil
+		case "counter":
+			// counter, optionally followed by its packets and bytes
+			for idx+2 < len(fields) && (fields[idx+1] == "packets" || fields[idx+1] == "bytes") {
+				idx += 2
+			}
+		case "log":
+			// logging does not change what is allowed, skip its options
+			for idx+2 < len(fields) && (fields[idx+1] == "prefix" || fields[idx+1] == "level" || fields[idx+1] == "group") {
+				idx += 2
+			}
+		case "comment":
+			rule.description = next
+			idx++
+		case "accept", "drop", "reject":
+			verdict = field
+			if field == "reject" {
+				// reject with its optional icmp type ends the rule
+				idx = len(fields)
+			}
+		case "jump", "goto", "return", "queue", "continue":
+			return rule, "", fmt.Sprintf("the %s verdict can not be represented", field), nil
+		default:
+			return rule, "", fmt.Sprintf("the '%s' expression can not be represented", field), nil
+		}
+	}
+
+	for _, port := range ports {
+		if service, ok := nftServices[port]; ok {
+			port = service
+		}
+		parsed, err := parsePortRange(port, "-")
+		if err != nil {
+			return rule, "", "", err
+		}
+		rule.ports = append(rule.ports, parsed)
+	}
+	if len(rule.ports) > 0 && rule.protocol == "" {
+		return rule, "", "a port match without a tcp or udp protocol can not be represented", nil
+	}
+
+	return rule, verdict, "", nil
+}
+
+// nftFamily returns the family of an ip, ip6, ipv4 or ipv6 keyword,
+// and a reason when it conflicts with the family the rule already has
+func nftFamily(current Family, keyword string) (Family, string) {
+	family := IPv4
+	if keyword == "ip6" || keyword == "ipv6" {
+		family = IPv6
+	}
+	if current != "" && current != family {
+		return current, fmt.Sprintf("matching %s traffic in an %s rule can never match", family, current)
+	}
+
+	return family, ""
+}
+
+// nftProtocol returns the protocol of the elements of a meta l4proto match
+func nftProtocol(elements []string) (string, string) {
+	hasTCP, hasUDP := false, false
+	for _, element := range elements {
+		switch element {
+		case vps.FirewallProtocolTCP:
+			hasTCP = true
+		case vps.FirewallProtocolUDP:
+			hasUDP = true
+		default:
+			return "", fmt.Sprintf("the %s protocol can not be represented, only tcp and udp are supported", element)
+		}
+	}
+	if hasTCP && hasUDP {
+		return vps.FirewallProtocolTCPUDP, ""
+	}
+	if hasTCP {
+		return vps.FirewallProtocolTCP, ""
+	}
+
+	return vps.FirewallProtocolUDP, ""
+}
+
+// isMatchAll returns true when the rule does not match on anything besides the family of its table
+func isMatchAll(rule hostRule, family Family) bool {
+	return rule.protocol == "" && len(rule.ports) == 0 && len(rule.sources) == 0 && rule.family == family
+}
+
+// nftMatch returns the protocol and port match of a rule
+func nftMatch(rule vps.FirewallRule) string {
+	ports := formatPortRange(rule, "-")
+	if rule.Protocol == vps.FirewallProtocolTCPUDP {
+		return fmt.Sprintf("meta l4proto { tcp, udp } th dport %s", ports)
+	}
+
+	return fmt.Sprintf("%s dport %s", rule.Protocol, ports)
+}
+
+// nftAddressKeyword returns the keyword used to match addresses of a family
+func nftAddressKeyword(family Family) string {
+	if family == IPv4 {
+		return "ip"
+	}
+
+	return "ip6"
+}
+
+// nftSet returns a single value, or an anonymous set for multiple values
+func nftSet(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	return "{ " + strings.Join(values, ", ") + " }"
+}
@@ -0,0 +1,115 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatNftables(t *testing.T) {
+	const expected = `table inet transip {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ip saddr 10.0.0.0/8 tcp dport 22 accept comment "SSH"
+		ip6 saddr 2a01:7c8::/32 tcp dport 22 accept comment "SSH"
+		tcp dport 80 accept comment "HTTP"
+		meta l4proto { tcp, udp } th dport 53 accept comment "DNS"
+		meta nfproto ipv4 meta l4proto { tcp, udp } th dport 60000-61000 accept comment "mosh"
+	}
+}
+`
+	firewall := exampleFirewall(t)
+	ruleset := FormatNftables(firewall)
+	assert.Equal(t, expected, ruleset)
+
+	result, err := ParseNftables(strings.NewReader(ruleset))
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	assert.True(t, result.Firewall.IsEnabled)
+	assertFirewall(t, firewall, result.Firewall)
+}
+
+func TestParseNftables(t *testing.T) {
+	const ruleset = `#!/usr/sbin/nft -f
+flush ruleset
+
+table inet filter {
+	set admins {
+		type ipv4_addr
+		elements = { 192.0.2.1,
+			     192.0.2.2 }
+	}
+
+	chain input {
+		type filter hook input priority 0; policy accept;
+		ct state established,related accept
+		iif "lo" accept
+		tcp dport ssh ip saddr @admins accept
+		tcp dport { 80, https } counter packets 10 bytes 600 accept comment "web"
+		ip saddr 192.0.2.0/24 udp dport 53 accept
+		ip6 saddr 2001:db8::/32 udp dport 53 log prefix "dns " accept
+		tcp dport 25 drop
+		icmp type echo-request accept
+		counter drop
+	}
+
+	chain forward {
+		type filter hook forward priority 0; policy drop;
+		iifname "docker0" accept
+	}
+}
+
+table ip legacy {
+	chain input {
+		type filter hook input priority 10;
+		tcp dport 8080 accept
+	}
+}
+
+table bridge filter {
+	chain input {
+		type filter hook input priority 0;
+	}
+}
+`
+	result, err := ParseNftables(strings.NewReader(ruleset))
+	require.NoError(t, err)
+	assert.True(t, result.Firewall.IsEnabled)
+
+	assertFirewall(t, vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{
+		{Description: "web", StartPort: 80, EndPort: 80, Protocol: vps.FirewallProtocolTCP},
+		{Description: "web", StartPort: 443, EndPort: 443, Protocol: vps.FirewallProtocolTCP},
+		{StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolUDP, Whitelist: parseRanges(t, "192.0.2.0/24", "2001:db8::/32")},
+		{StartPort: 8080, EndPort: 8080, Protocol: vps.FirewallProtocolTCP, Whitelist: parseRanges(t, "0.0.0.0/0")},
+	}}, result.Firewall)
+
+	var issues []string
+	for _, issue := range result.Issues {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"line 13: ct state established,related accept: connection tracking can not be represented, the edge firewall always allows established connections",
+		`line 14: iif "lo" accept: interface matching can not be represented`,
+		"line 15: tcp dport ssh ip saddr @admins accept: named sets can not be represented",
+		"line 19: tcp dport 25 drop: deny rules can not be represented, the edge firewall only has allow rules",
+		"line 20: icmp type echo-request accept: the 'icmp' expression can not be represented",
+		`line 26: iifname "docker0" accept: rules in chain forward can not be represented, only chains with hook input are converted`,
+		"line 37: table bridge filter {: tables of the bridge family can not be represented, only ip, ip6 and inet tables are converted",
+	}, issues)
+}
+
+func TestParseNftables_Errors(t *testing.T) {
+	_, err := ParseNftables(strings.NewReader("table inet filter {\n\tchain input {\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.EqualError(t, err, "syntax error: missing '}' for chain input")
+
+	_, err = ParseNftables(strings.NewReader("table inet filter {\n\tchain input {\n\t\ttype filter hook input priority 0;\n\t\tip saddr 300.0.0.1 accept\n\t}\n}\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.EqualError(t, err, "line 4: syntax error: invalid address '300.0.0.1'")
+
+	_, err = ParseNftables(strings.NewReader("}\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+}
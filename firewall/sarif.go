@@ -0,0 +1,170 @@
+package firewall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// sarifSchema and sarifVersion identify the version of the Static Analysis Results Interchange Format written by WriteSARIF
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifToolName and sarifToolURI identify the linter as the tool of a sarif run
+const (
+	sarifToolName = "gotransip-firewall-lint"
+	sarifToolURI  = "https://github.com/assi010/gotransip"
+)
+
+// sarifLevels maps severities to sarif result levels
+var sarifLevels = map[Severity]string{
+	SeverityLow:      "note",
+	SeverityMedium:   "warning",
+	SeverityHigh:     "error",
+	SeverityCritical: "error",
+}
+
+// sarifSecurityScores maps severities to the security-severity property security dashboards use to rank results,
+// a score from 0.0 to 10.0 like CVSS
+var sarifSecurityScores = map[Severity]string{
+	SeverityLow:      "2.0",
+	SeverityMedium:   "5.0",
+	SeverityHigh:     "8.0",
+	SeverityCritical: "9.5",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           sarifPropertyBag       `json:"properties"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifPropertyBag struct {
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string             `json:"ruleId"`
+	RuleIndex           int                `json:"ruleIndex"`
+	Level               string             `json:"level"`
+	Message             sarifMessage       `json:"message"`
+	Locations           []sarifLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+	Properties          sarifPropertyBag   `json:"properties"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log, suppressed findings are included with their justification.
+// Findings refer to the firewall of a vps with a logical location, like "example-vps/firewall/tcp 22 (SSH) from any",
+// and the security-severity property is set so security dashboards can rank them.
+func (r Report) WriteSARIF(writer io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			InformationURI: sarifToolURI,
+			Rules:          make([]sarifRule, len(checks)),
+		}},
+		Results: make([]sarifResult, 0, len(r.Findings)),
+	}
+
+	ruleIndexes := make(map[string]int, len(checks))
+	for idx, check := range checks {
+		ruleIndexes[check.id] = idx
+		run.Tool.Driver.Rules[idx] = sarifRule{
+			ID:                   check.id,
+			ShortDescription:     sarifMessage{Text: check.description},
+			DefaultConfiguration: sarifRuleConfiguration{Level: sarifLevels[check.severity]},
+			Properties:           sarifPropertyBag{SecuritySeverity: sarifSecurityScores[check.severity], Tags: []string{"security", "firewall"}},
+		}
+	}
+
+	for _, finding := range r.Findings {
+		location := finding.VpsName + "/firewall"
+		if finding.Rule != nil {
+			location += "/" + finding.Rule.String()
+		}
+
+		result := sarifResult{
+			RuleID:    finding.Check,
+			RuleIndex: ruleIndexes[finding.Check],
+			Level:     sarifLevels[finding.Severity],
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               finding.VpsName,
+				FullyQualifiedName: location,
+				Kind:               "resource",
+			}}}},
+			PartialFingerprints: map[string]string{"findingHash/v1": fingerprint(finding.Check, location)},
+			Properties:          sarifPropertyBag{SecuritySeverity: sarifSecurityScores[finding.Severity]},
+		}
+		if finding.Suppressed {
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: finding.Justification}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// fingerprint returns a stable hash of the parts, so dashboards can track a finding across runs
+func fingerprint(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
@@ -0,0 +1,59 @@
+package firewall
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport_WriteSARIF(t *testing.T) {
+	linter := Linter{Suppressions: []Suppression{{Check: CheckDuplicateRule, Reason: "kept for clarity"}}}
+	report := Report{Findings: linter.Lint("example-vps", riskyFirewall(t))}
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&buffer))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "gotransip-firewall-lint", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 5)
+	assert.Equal(t, CheckManagementPortOpen, run.Tool.Driver.Rules[1].ID)
+	assert.Equal(t, "error", run.Tool.Driver.Rules[1].DefaultConfiguration.Level)
+	assert.Equal(t, "9.5", run.Tool.Driver.Rules[1].Properties.SecuritySeverity)
+
+	require.Len(t, run.Results, 9)
+	disabled := run.Results[0]
+	assert.Equal(t, CheckFirewallDisabled, disabled.RuleID)
+	assert.Equal(t, 0, disabled.RuleIndex)
+	assert.Equal(t, "error", disabled.Level)
+	assert.Equal(t, "example-vps/firewall", disabled.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Empty(t, disabled.Suppressions)
+
+	duplicate := run.Results[8]
+	assert.Equal(t, CheckDuplicateRule, duplicate.RuleID)
+	assert.Equal(t, 3, duplicate.RuleIndex)
+	assert.Equal(t, "note", duplicate.Level)
+	assert.Equal(t, "example-vps", duplicate.Locations[0].LogicalLocations[0].Name)
+	assert.Equal(t, "example-vps/firewall/tcp 22 (SSH again) from any", duplicate.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, []sarifSuppression{{Kind: "external", Justification: "kept for clarity"}}, duplicate.Suppressions)
+
+	// the same finding results in the same fingerprint, different findings do not
+	assert.Len(t, duplicate.PartialFingerprints["findingHash/v1"], 32)
+	assert.NotEqual(t, run.Results[1].PartialFingerprints, run.Results[6].PartialFingerprints)
+	var again bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&again))
+	assert.Equal(t, buffer.String(), again.String())
+}
+
+func TestReport_WriteSARIFEmpty(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Report{}.WriteSARIF(&buffer))
+	assert.Contains(t, buffer.String(), `"results": []`)
+}
@@ -0,0 +1,216 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// ufwNumberPrefix matches the rule number printed by ufw status numbered
+var ufwNumberPrefix = regexp.MustCompile(`^\[\s*\d+\]\s*`)
+
+// ufwV6Suffix marks rules and addresses for ipv6 in ufw status output
+const ufwV6Suffix = " (v6)"
+
+// FormatUfwStatus returns the firewall in the format of ufw status, with the ipv6 rules after the ipv4 rules
+func FormatUfwStatus(firewall vps.Firewall) string {
+	status := "inactive"
+	if firewall.IsEnabled {
+		status = "active"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Status: %s\n\n", status)
+	builder.WriteString(ufwRow("To", "Action", "From", ""))
+	builder.WriteString(ufwRow("--", "------", "----", ""))
+	for _, family := range []Family{IPv4, IPv6} {
+		for _, rule := range firewall.RuleSet {
+			ranges, all := ruleSourcesOf(rule).of(family)
+			if all {
+				ranges = []string{"Anywhere"}
+			}
+			for _, to := range ufwTo(rule) {
+				for _, from := range ranges {
+					row := to
+					if family == IPv6 {
+						row += ufwV6Suffix
+						if all {
+							from += ufwV6Suffix
+						}
+					}
+					builder.WriteString(ufwRow(row, "ALLOW", from, rule.Description))
+				}
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// ParseUfwStatus converts the rules in the output of ufw status, ufw status verbose or ufw status numbered.
+// The firewall is enabled when ufw is active and does not allow incoming traffic by default.
+func ParseUfwStatus(reader io.Reader) (Result, error) {
+	builder := newBuilder()
+	defaultAllow := false
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "Logging:") || strings.HasPrefix(line, "New profiles:"):
+		case strings.HasPrefix(line, "Status:"):
+			builder.enabled = strings.TrimSpace(strings.TrimPrefix(line, "Status:")) == "active"
+		case strings.HasPrefix(line, "Default:"):
+			defaultAllow = strings.Contains(line, "allow (incoming)")
+		case strings.HasPrefix(line, "To ") && strings.Contains(line, "Action"):
+		default:
+			if err := parseUfwRule(line, lineNumber, builder); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Result{}, err
+	}
+
+	builder.enabled = builder.enabled && !defaultAllow
+
+	return builder.result(), nil
+}
+
+// parseUfwRule parses a rule line of ufw status
+func parseUfwRule(line string, lineNumber int, builder *builder) error {
+	text := line
+	line = ufwNumberPrefix.ReplaceAllString(line, "")
+	description := ""
+	if idx := strings.Index(line, " # "); idx >= 0 {
+		description = strings.TrimSpace(line[idx+3:])
+		line = line[:idx]
+	}
+
+	fields := strings.Fields(line)
+	actionIdx := -1
+	for idx, field := range fields {
+		if field == "ALLOW" || field == "DENY" || field == "REJECT" || field == "LIMIT" {
+			actionIdx = idx
+			break
+		}
+	}
+	if actionIdx < 0 {
+		return fmt.Errorf("%w: line %d: no action in '%s'", ErrSyntax, lineNumber, text)
+	}
+	action := fields[actionIdx]
+	fromIdx := actionIdx + 1
+	direction := "IN"
+	if fromIdx < len(fields) && (fields[fromIdx] == "IN" || fields[fromIdx] == "OUT" || fields[fromIdx] == "FWD") {
+		direction = fields[fromIdx]
+		fromIdx++
+	}
+	to := strings.Join(fields[:actionIdx], " ")
+	from := strings.Join(fields[fromIdx:], " ")
+
+	switch {
+	case action == "DENY" || action == "REJECT":
+		builder.issue(lineNumber, text, "deny rules can not be represented, the edge firewall only has allow rules")
+		return nil
+	case action == "LIMIT":
+		builder.issue(lineNumber, text, "rate limiting can not be represented")
+		return nil
+	case direction != "IN":
+		builder.issue(lineNumber, text, "only incoming rules can be represented")
+		return nil
+	case strings.Contains(to, " on ") || strings.Contains(from, " on "):
+		builder.issue(lineNumber, text, "interface matching can not be represented")
+		return nil
+	}
+
+	rule := hostRule{family: IPv4, description: description}
+	if strings.HasSuffix(to, ufwV6Suffix) || strings.HasSuffix(from, ufwV6Suffix) {
+		rule.family = IPv6
+		to = strings.TrimSuffix(to, ufwV6Suffix)
+		from = strings.TrimSuffix(from, ufwV6Suffix)
+	}
+
+	if reason, err := parseUfwTo(to, &rule); err != nil || reason != "" {
+		if reason != "" {
+			builder.issue(lineNumber, text, reason)
+		}
+		return err
+	}
+
+	if from != "Anywhere" {
+		if strings.Contains(from, " ") {
+			builder.issue(lineNumber, text, "matching on the source port can not be represented")
+			return nil
+		}
+		source, err := parseAddress(from)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		rule.sources = append(rule.sources, source)
+	}
+
+	builder.add(lineNumber, text, rule)
+
+	return nil
+}
+
+// parseUfwTo parses the To column of a rule, like "Anywhere", "22/tcp" or "80,443,8000:9000/tcp".
+// It returns a reason when the column can not be represented.
+func parseUfwTo(to string, rule *hostRule) (string, error) {
+	if to == "Anywhere" {
+		return "", nil
+	}
+	if strings.Contains(to, " ") || net.ParseIP(strings.Split(to, "/")[0]) != nil {
+		return "matching on the destination address can not be represented", nil
+	}
+	if to[0] < '0' || to[0] > '9' {
+		return fmt.Sprintf("application profile %s can not be represented, use the ports of the profile instead", to), nil
+	}
+
+	ports, protocol, hasProtocol := strings.Cut(to, "/")
+	switch {
+	case !hasProtocol:
+		rule.protocol = vps.FirewallProtocolTCPUDP
+	case protocol == vps.FirewallProtocolTCP || protocol == vps.FirewallProtocolUDP:
+		rule.protocol = protocol
+	default:
+		return fmt.Sprintf("the %s protocol can not be represented, only tcp and udp are supported", protocol), nil
+	}
+	for _, element := range strings.Split(ports, ",") {
+		parsed, err := parsePortRange(element, ":")
+		if err != nil {
+			return "", err
+		}
+		rule.ports = append(rule.ports, parsed)
+	}
+
+	return "", nil
+}
+
+// ufwTo returns the To column of a rule, a port range of a rule for both protocols needs a row per protocol
+func ufwTo(rule vps.FirewallRule) []string {
+	ports := formatPortRange(rule, ":")
+	if rule.Protocol != vps.FirewallProtocolTCPUDP {
+		return []string{ports + "/" + rule.Protocol}
+	}
+	if rule.StartPort == rule.EndPort {
+		return []string{ports}
+	}
+
+	return []string{ports + "/tcp", ports + "/udp"}
+}
+
+// ufwRow formats a row of ufw status output
+func ufwRow(to string, action string, from string, comment string) string {
+	if comment == "" {
+		return strings.TrimRight(fmt.Sprintf("%-26s %-11s %s", to, action, from), " ") + "\n"
+	}
+
+	return fmt.Sprintf("%-26s %-11s %-26s # %s\n", to, action, from, comment)
+}
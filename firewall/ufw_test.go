@@ -0,0 +1,105 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatUfwStatus(t *testing.T) {
+	const expected = `Status: active
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW       10.0.0.0/8                 # SSH
+80/tcp                     ALLOW       Anywhere                   # HTTP
+53                         ALLOW       Anywhere                   # DNS
+60000:61000/tcp            ALLOW       Anywhere                   # mosh
+60000:61000/udp            ALLOW       Anywhere                   # mosh
+22/tcp (v6)                ALLOW       2a01:7c8::/32              # SSH
+80/tcp (v6)                ALLOW       Anywhere (v6)              # HTTP
+53 (v6)                    ALLOW       Anywhere (v6)              # DNS
+`
+	firewall := exampleFirewall(t)
+	status := FormatUfwStatus(firewall)
+	assert.Equal(t, expected, status)
+
+	result, err := ParseUfwStatus(strings.NewReader(status))
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	assert.True(t, result.Firewall.IsEnabled)
+	assertFirewall(t, firewall, result.Firewall)
+}
+
+func TestParseUfwStatus(t *testing.T) {
+	const status = `Status: active
+
+     To                         Action      From
+     --                         ------      ----
+[ 1] OpenSSH                    ALLOW IN    Anywhere
+[ 2] 80,443/tcp                 ALLOW IN    Anywhere                   # web
+[ 3] 53/udp                     ALLOW IN    192.0.2.0/24
+[ 4] 25/tcp                     DENY IN     Anywhere
+[ 5] 22/tcp                     LIMIT IN    Anywhere
+[ 6] 8080 on eth1               ALLOW IN    Anywhere
+[ 7] 53/udp (v6)                ALLOW IN    2001:db8::/32
+[ 8] 80,443/tcp (v6)            ALLOW IN    Anywhere (v6)              # web
+[ 9] Anywhere                   ALLOW OUT   10.0.0.1
+[10] 10.0.0.5 3306/tcp          ALLOW IN    10.0.0.0/8
+`
+	result, err := ParseUfwStatus(strings.NewReader(status))
+	require.NoError(t, err)
+	assert.True(t, result.Firewall.IsEnabled)
+
+	assertFirewall(t, vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{
+		{Description: "web", StartPort: 80, EndPort: 80, Protocol: vps.FirewallProtocolTCP},
+		{Description: "web", StartPort: 443, EndPort: 443, Protocol: vps.FirewallProtocolTCP},
+		{StartPort: 53, EndPort: 53, Protocol: vps.FirewallProtocolUDP, Whitelist: parseRanges(t, "192.0.2.0/24", "2001:db8::/32")},
+	}}, result.Firewall)
+
+	var issues []string
+	for _, issue := range result.Issues {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"line 5: [ 1] OpenSSH                    ALLOW IN    Anywhere: application profile OpenSSH can not be represented, use the ports of the profile instead",
+		"line 8: [ 4] 25/tcp                     DENY IN     Anywhere: deny rules can not be represented, the edge firewall only has allow rules",
+		"line 9: [ 5] 22/tcp                     LIMIT IN    Anywhere: rate limiting can not be represented",
+		"line 10: [ 6] 8080 on eth1               ALLOW IN    Anywhere: interface matching can not be represented",
+		"line 13: [ 9] Anywhere                   ALLOW OUT   10.0.0.1: only incoming rules can be represented",
+		"line 14: [10] 10.0.0.5 3306/tcp          ALLOW IN    10.0.0.0/8: matching on the destination address can not be represented",
+	}, issues)
+}
+
+func TestParseUfwStatus_Disabled(t *testing.T) {
+	result, err := ParseUfwStatus(strings.NewReader("Status: inactive\n"))
+	require.NoError(t, err)
+	assert.False(t, result.Firewall.IsEnabled)
+	assert.Empty(t, result.Firewall.RuleSet)
+
+	const verbose = `Status: active
+Logging: on (low)
+Default: allow (incoming), allow (outgoing), disabled (routed)
+New profiles: skip
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW IN    Anywhere
+`
+	result, err = ParseUfwStatus(strings.NewReader(verbose))
+	require.NoError(t, err)
+	assert.False(t, result.Firewall.IsEnabled)
+	assert.Len(t, result.Firewall.RuleSet, 1)
+}
+
+func TestParseUfwStatus_Errors(t *testing.T) {
+	_, err := ParseUfwStatus(strings.NewReader("Status: active\n22/tcp OPEN Anywhere\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.EqualError(t, err, "syntax error: line 2: no action in '22/tcp OPEN Anywhere'")
+
+	_, err = ParseUfwStatus(strings.NewReader("Status: active\n22/tcp ALLOW 10.0.0.300\n"))
+	assert.ErrorIs(t, err, ErrSyntax)
+}
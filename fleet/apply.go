@@ -0,0 +1,272 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6/internal/atomicfile"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/assi010/gotransip/v6/wait"
+)
+
+var (
+	// ErrApprovalRequired is wrapped by every ApprovalError
+	ErrApprovalRequired = errors.New("destructive steps need to be approved")
+)
+
+// ApprovalError is returned by Apply when the plan contains destructive steps that are not approved
+type ApprovalError struct {
+	// StepIDs are the ids of the steps that are not approved
+	StepIDs []string
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrApprovalRequired, strings.Join(e.StepIDs, ", "))
+}
+
+// Is makes errors.Is(err, ErrApprovalRequired) work for an ApprovalError
+func (e *ApprovalError) Is(target error) bool {
+	return target == ErrApprovalRequired
+}
+
+// ApplyOptions configure Apply
+type ApplyOptions struct {
+	// Approve contains the ids of the destructive steps that may be executed
+	Approve []string
+	// ApproveDestructive approves every destructive step of the plan
+	ApproveDestructive bool
+	// StatePath is the json file in which the completed steps are recorded, steps in it are skipped.
+	// When empty nothing is recorded and a failed apply can only be resumed by planning again.
+	StatePath string
+	// Wait configures waiting for ordered vpses and for upgraded vpses to be unlocked
+	Wait wait.Options
+	// OnStep is called before a step is executed, it is optional
+	OnStep func(step Step)
+}
+
+// State records the steps of a plan that are completed, so an apply that failed halfway can be resumed
+type State struct {
+	// Completed maps the Step.ID of the completed steps to the time they were completed
+	Completed map[string]time.Time `json:"completed"`
+}
+
+// IsCompleted returns true when the step is completed
+func (s *State) IsCompleted(step Step) bool {
+	_, ok := s.Completed[step.ID()]
+	return ok
+}
+
+// SetCompleted records that the step is completed
+func (s *State) SetCompleted(step Step, completed time.Time) {
+	if s.Completed == nil {
+		s.Completed = make(map[string]time.Time)
+	}
+	s.Completed[step.ID()] = completed
+}
+
+// LoadState reads the state from a json file, it returns an empty state when the file does not exist
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{Completed: map[string]time.Time{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading fleet state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing fleet state %s: %w", path, err)
+	}
+	if state.Completed == nil {
+		state.Completed = map[string]time.Time{}
+	}
+
+	return &state, nil
+}
+
+// Save writes the state to a json file, see atomicfile.WriteFile
+func (s *State) Save(path string) error {
+	if err := atomicfile.WriteJSON(path, s, 0600); err != nil {
+		return fmt.Errorf("error saving fleet state: %w", err)
+	}
+
+	return nil
+}
+
+// Apply executes the steps of the plan in order and stops at the first step that fails.
+// It returns an ApprovalError before executing anything when a destructive step is not approved.
+//
+// A step is recorded as completed in the state at ApplyOptions.StatePath as soon as the api accepted it,
+// before waiting for the ordered or upgraded vpses, so resuming after a failure never orders or upgrades twice.
+// Running Apply again with the same plan and state skips the completed steps.
+func Apply(ctx context.Context, client repository.Client, plan Plan, options ApplyOptions) error {
+	state := &State{Completed: map[string]time.Time{}}
+	if options.StatePath != "" {
+		var err error
+		if state, err = LoadState(options.StatePath); err != nil {
+			return err
+		}
+	}
+
+	approved := make(map[string]bool, len(options.Approve))
+	for _, id := range options.Approve {
+		approved[id] = true
+	}
+	var unapproved []string
+	for _, step := range plan.Steps {
+		if step.Destructive() && !options.ApproveDestructive && !approved[step.ID()] && !state.IsCompleted(step) {
+			unapproved = append(unapproved, step.ID())
+		}
+	}
+	if len(unapproved) > 0 {
+		return &ApprovalError{StepIDs: unapproved}
+	}
+
+	for _, step := range plan.Steps {
+		if state.IsCompleted(step) {
+			continue
+		}
+		if options.OnStep != nil {
+			options.OnStep(step)
+		}
+
+		completed := func() error {
+			state.SetCompleted(step, time.Now())
+			if options.StatePath == "" {
+				return nil
+			}
+			return state.Save(options.StatePath)
+		}
+		if err := applyStep(ctx, client, step, options.Wait, completed); err != nil {
+			return fmt.Errorf("step %s: %w", step.ID(), err)
+		}
+	}
+
+	return nil
+}
+
+// applyStep executes a single step and calls completed as soon as the api accepted the change
+func applyStep(ctx context.Context, client repository.Client, step Step, waitOptions wait.Options, completed func() error) error {
+	vpsRepo := vps.Repository{Client: client}
+
+	switch step.Kind {
+	case StepOrder:
+		if err := vpsRepo.OrderMultiple(step.Orders); err != nil {
+			return fmt.Errorf("error ordering vpses: %w", err)
+		}
+		if err := completed(); err != nil {
+			return err
+		}
+		return waitForOrders(ctx, vpsRepo, step.Orders, waitOptions)
+	case StepUpgrade:
+		if err := vpsRepo.Upgrade(step.VpsName, step.ProductName); err != nil {
+			return fmt.Errorf("error upgrading vps: %w", err)
+		}
+		if err := completed(); err != nil {
+			return err
+		}
+		_, err := vpsRepo.WaitUntilUnlocked(ctx, step.VpsName, waitOptions)
+		return err
+	case StepOrderAddons:
+		if err := vpsRepo.OrderAddons(step.VpsName, step.Addons); err != nil {
+			return fmt.Errorf("error ordering addons: %w", err)
+		}
+		if err := completed(); err != nil {
+			return err
+		}
+		_, err := vpsRepo.WaitUntilUnlocked(ctx, step.VpsName, waitOptions)
+		return err
+	case StepCancelAddon:
+		for _, addon := range step.Addons {
+			if err := vpsRepo.CancelAddon(step.VpsName, addon); err != nil {
+				return fmt.Errorf("error cancelling addon %s: %w", addon, err)
+			}
+		}
+	case StepUpdate:
+		vpsItem, err := vpsRepo.GetByName(step.VpsName)
+		if err != nil {
+			return fmt.Errorf("error getting vps: %w", err)
+		}
+		vpsItem.Description = step.Description
+		vpsItem.Tags = step.Tags
+		if err := vpsRepo.Update(vpsItem); err != nil {
+			return fmt.Errorf("error updating vps: %w", err)
+		}
+	case StepFirewall:
+		if step.Firewall == nil {
+			return errors.New("firewall step without a firewall")
+		}
+		firewallRepo := vps.FirewallRepository{Client: client}
+		if _, err := firewallRepo.ApplyFirewall(step.VpsName, *step.Firewall); err != nil {
+			return fmt.Errorf("error applying firewall: %w", err)
+		}
+	case StepSettings:
+		settingRepo := vps.SettingRepository{Client: client}
+		for _, setting := range step.Settings {
+			if err := settingRepo.Update(step.VpsName, setting); err != nil {
+				return fmt.Errorf("error updating setting %s: %w", setting.Name, err)
+			}
+		}
+	case StepCancel:
+		if err := vpsRepo.Cancel(step.VpsName, step.CancellationTime); err != nil {
+			return fmt.Errorf("error cancelling vps: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown step kind '%s'", step.Kind)
+	}
+
+	return completed()
+}
+
+// waitForOrders waits until a vps with the description of every order is listed and unlocked.
+// The api does not return an action or the names of ordered vpses, so they are recognised by their description.
+func waitForOrders(ctx context.Context, vpsRepo vps.Repository, orders []vps.Order, waitOptions wait.Options) error {
+	descriptions := make([]string, 0, len(orders))
+	for _, order := range orders {
+		descriptions = append(descriptions, order.Description)
+	}
+
+	_, err := wait.Until(ctx, waitOptions, "ordered vpses "+strings.Join(descriptions, ", "), vpsRepo.GetAll,
+		wait.Condition[[]vps.Vps]{
+			Description: "listed and unlocked",
+			Done:        func(vpses []vps.Vps) bool { return len(pendingOrders(vpses, descriptions)) == 0 },
+			State: func(vpses []vps.Vps) string {
+				return "pending " + strings.Join(pendingOrders(vpses, descriptions), ", ")
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error waiting for the ordered vpses: %w", err)
+	}
+
+	return nil
+}
+
+// pendingOrders returns the descriptions without an unlocked vps, followed by whether the vps is missing or locked
+func pendingOrders(vpses []vps.Vps, descriptions []string) []string {
+	unlocked := make(map[string]bool, len(vpses))
+	for _, vpsItem := range vpses {
+		unlocked[vpsItem.Description] = unlocked[vpsItem.Description] || !vpsItem.IsLocked
+	}
+
+	var pending []string
+	for _, description := range descriptions {
+		isUnlocked, listed := unlocked[description]
+		switch {
+		case !listed:
+			pending = append(pending, fmt.Sprintf("'%s' (not listed)", description))
+		case !isUnlocked:
+			pending = append(pending, fmt.Sprintf("'%s' (locked)", description))
+		}
+	}
+
+	return pending
+}
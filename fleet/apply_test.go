@@ -0,0 +1,184 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/assi010/gotransip/v6/wait"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOrderedVpses returns a copy of the responses in which the members ordered for the testSpec group are listed
+func withOrderedVpses(responses map[string]string) map[string]string {
+	ordered := make(map[string]string, len(responses))
+	for path, response := range responses {
+		ordered[path] = response
+	}
+	ordered["/vps"] = strings.Replace(responses["/vps"], `{"vpss":[`, `{"vpss":[
+		{"name":"example-vps7","description":"web-4 frontend","productName":"vps-bladevps-x4","availabilityZone":"ams0"},
+		{"name":"example-vps8","description":"web-5 frontend","productName":"vps-bladevps-x4","availabilityZone":"ams0"},`, 1)
+
+	return ordered
+}
+
+func TestApply(t *testing.T) {
+	spec, err := LoadSpec(strings.NewReader(testSpec))
+	require.NoError(t, err)
+	server := fleetServer(t, fleetResponses)
+	server.Failures = map[string]bool{"DELETE /vps/example-vps4": true}
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan, err := Planner{}.Plan(client, spec)
+	require.NoError(t, err)
+	server.Responses = withOrderedVpses(fleetResponses)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	options := ApplyOptions{StatePath: statePath, Wait: wait.Options{InitialInterval: time.Millisecond}}
+
+	// nothing is executed before the destructive steps are approved
+	err = Apply(context.Background(), client, plan, options)
+	require.Error(t, err)
+	var approvalError *ApprovalError
+	require.True(t, errors.As(err, &approvalError))
+	assert.True(t, errors.Is(err, ErrApprovalRequired))
+	assert.Equal(t, []string{plan.Steps[1].ID(), plan.Steps[4].ID(), plan.Steps[6].ID(), plan.Steps[7].ID(), plan.Steps[8].ID()}, approvalError.StepIDs)
+	assert.Empty(t, server.Changes)
+
+	options.Approve = approvalError.StepIDs[:1]
+	err = Apply(context.Background(), client, plan, options)
+	require.True(t, errors.As(err, &approvalError))
+	assert.Len(t, approvalError.StepIDs, 4)
+	assert.Empty(t, server.Changes)
+
+	// the cancellation of example-vps4 fails, the steps before it are recorded in the state
+	options.Approve = nil
+	options.ApproveDestructive = true
+	var executed []StepKind
+	options.OnStep = func(step Step) { executed = append(executed, step.Kind) }
+	err = Apply(context.Background(), client, plan, options)
+	require.Error(t, err)
+	assert.Equal(t, "step "+plan.Steps[7].ID()+": error cancelling vps: Vps not found", err.Error())
+	assert.Equal(t, []StepKind{StepOrder, StepUpgrade, StepOrderAddons, StepUpdate, StepFirewall, StepSettings, StepCancelAddon, StepCancel}, executed)
+	assert.Equal(t, []string{
+		`POST /vps {"vpss":[{"productName":"vps-bladevps-x4","operatingSystem":"debian-12","availabilityZone":"ams0","description":"web-4 frontend","addons":["vps-addon-1-extra-ip-address"]},{"productName":"vps-bladevps-x4","operatingSystem":"debian-12","availabilityZone":"ams0","description":"web-5 frontend","addons":["vps-addon-1-extra-ip-address"]}]}`,
+		`POST /vps/example-vps/upgrades {"productName":"vps-bladevps-x4"}`,
+		`POST /vps/example-vps/addons {"addons":["vps-addon-1-extra-ip-address"]}`,
+		`PUT /vps/example-vps2 {"vps":{"name":"example-vps2","uuid":"","description":"web-3 frontend","productName":"vps-bladevps-x4","isCustomerLocked":false,"tags":["web"]}}`,
+		`PUT /vps/example-vps/firewall {"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"https","startPort":443,"endPort":443,"protocol":"tcp","whitelist":[]},{"description":"ssh","startPort":22,"endPort":22,"protocol":"tcp","whitelist":["192.0.2.0/24"]}]}}`,
+		`PUT /vps/example-vps/settings/blockVpsMailPorts {"setting":{"name":"blockVpsMailPorts","dataType":"boolean","readOnly":false,"value":{"valueBoolean":true,"valueString":""}}}`,
+		`DELETE /vps/example-vps/addons/vps-addon-backups`,
+	}, server.Changes)
+
+	state, err := LoadState(statePath)
+	require.NoError(t, err)
+	assert.Len(t, state.Completed, 7)
+	assert.True(t, state.IsCompleted(plan.Steps[6]))
+	assert.False(t, state.IsCompleted(plan.Steps[7]))
+
+	// resuming only executes the remaining steps
+	server.Failures = nil
+	server.Changes = nil
+	executed = nil
+	require.NoError(t, Apply(context.Background(), client, plan, options))
+	assert.Equal(t, []StepKind{StepCancel, StepCancel}, executed)
+	assert.Equal(t, []string{
+		`DELETE /vps/example-vps4 {"endTime":"end"}`,
+		`DELETE /vps/example-vps3 {"endTime":"end"}`,
+	}, server.Changes)
+
+	// completed destructive steps do not need to be approved again
+	server.Changes = nil
+	options.ApproveDestructive = false
+	require.NoError(t, Apply(context.Background(), client, plan, options))
+	assert.Empty(t, server.Changes)
+}
+
+func TestApply_WithoutState(t *testing.T) {
+	server := fleetServer(t, fleetResponses)
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan := Plan{Steps: []Step{
+		{Kind: StepUpgrade, Group: "web", VpsName: "example-vps2", ProductName: "vps-bladevps-x8"},
+		{Kind: "reinstall", Group: "web", VpsName: "example-vps2"},
+	}}
+	err := Apply(context.Background(), client, plan, ApplyOptions{ApproveDestructive: true, Wait: wait.Options{InitialInterval: time.Millisecond}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown step kind 'reinstall'")
+	assert.Equal(t, []string{`POST /vps/example-vps2/upgrades {"productName":"vps-bladevps-x8"}`}, server.Changes)
+}
+
+func TestState_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.Completed)
+
+	step := Step{Kind: StepCancel, Group: "web", VpsName: "example-vps"}
+	completed := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	state.SetCompleted(step, completed)
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsCompleted(step))
+	assert.True(t, completed.Equal(loaded.Completed[step.ID()]))
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = LoadState(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error parsing fleet state")
+}
+
+func TestApply_WaitForOrders(t *testing.T) {
+	// the ordered vpses are listed from the third poll on, and unlocked from the fourth
+	lists := []string{
+		`{"vpss":[]}`,
+		`{"vpss":[{"name":"example-vps","description":"web-1"}]}`,
+		`{"vpss":[{"name":"example-vps","description":"web-1"},{"name":"example-vps2","description":"web-2","isLocked":true}]}`,
+		`{"vpss":[{"name":"example-vps","description":"web-1"},{"name":"example-vps2","description":"web-2"}]}`,
+	}
+	var polls int
+	server := fleetServer(t, map[string]string{})
+	server.Fallback = func(rw http.ResponseWriter, req *http.Request) bool {
+		if req.URL.Path != "/vps" {
+			return false
+		}
+		list := lists[len(lists)-1]
+		if polls < len(lists) {
+			list = lists[polls]
+		}
+		_, _ = rw.Write([]byte(list))
+		polls++
+		return true
+	}
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan := Plan{Steps: []Step{{Kind: StepOrder, Group: "web", Orders: []vps.Order{
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", Description: "web-1"},
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", Description: "web-2"},
+	}}}}
+	options := ApplyOptions{Wait: wait.Options{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}}
+	require.NoError(t, Apply(context.Background(), client, plan, options))
+	assert.Equal(t, 4, polls)
+	assert.Len(t, server.Changes, 1)
+
+	// the order is not listed before the timeout
+	polls = 0
+	lists = lists[:2]
+	options.Wait.Timeout = 20 * time.Millisecond
+	err := Apply(context.Background(), client, plan, options)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, wait.ErrTimeout))
+	assert.Contains(t, err.Error(), "error waiting for the ordered vpses: ")
+	assert.Contains(t, err.Error(), "last observed pending 'web-2' (not listed)")
+}
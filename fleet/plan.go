@@ -0,0 +1,601 @@
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/product"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// StepKind is the kind of change a Step makes
+type StepKind string
+
+const (
+	// StepOrder orders the missing members of a group with vps.Repository.OrderMultiple
+	StepOrder StepKind = "order"
+	// StepUpgrade upgrades a member to the product of its group, it is destructive
+	StepUpgrade StepKind = "upgrade"
+	// StepOrderAddons orders the missing addons of a member
+	StepOrderAddons StepKind = "order-addons"
+	// StepCancelAddon cancels an addon the group does not have, it is destructive
+	StepCancelAddon StepKind = "cancel-addon"
+	// StepUpdate updates the description and tags of a member
+	StepUpdate StepKind = "update"
+	// StepFirewall makes the firewall of a member match the firewall of its group,
+	// it is destructive when it restricts access to the member
+	StepFirewall StepKind = "firewall"
+	// StepSettings updates the settings of a member
+	StepSettings StepKind = "settings"
+	// StepCancel cancels a vps that is no longer a member of its group, it is destructive
+	StepCancel StepKind = "cancel"
+)
+
+// Step is a single change of a Plan
+type Step struct {
+	// Kind of change
+	Kind StepKind `json:"kind"`
+	// Group is the name prefix of the group the step belongs to
+	Group string `json:"group"`
+	// VpsName is the vps that is changed, it is empty for StepOrder
+	VpsName string `json:"vpsName,omitempty"`
+	// Orders are the vpses ordered by StepOrder
+	Orders []vps.Order `json:"orders,omitempty"`
+	// ProductName is the product StepUpgrade upgrades to
+	ProductName string `json:"productName,omitempty"`
+	// Addons are ordered by StepOrderAddons, or cancelled by StepCancelAddon which has exactly one
+	Addons []string `json:"addons,omitempty"`
+	// Description is the description StepUpdate sets
+	Description string `json:"description,omitempty"`
+	// Tags are the tags StepUpdate sets
+	Tags []string `json:"tags,omitempty"`
+	// Firewall is the firewall StepFirewall applies
+	Firewall *vps.Firewall `json:"firewall,omitempty"`
+	// FirewallDiff describes the changes of StepFirewall with a line per rule
+	FirewallDiff string `json:"firewallDiff,omitempty"`
+	// RestrictsAccess is true when StepFirewall enables the firewall, removes rules or narrows a whitelist
+	RestrictsAccess bool `json:"restrictsAccess,omitempty"`
+	// Settings are the settings StepSettings updates
+	Settings []vps.Setting `json:"settings,omitempty"`
+	// CancellationTime is when StepCancel cancels the vps
+	CancellationTime gotransip.CancellationTime `json:"cancellationTime,omitempty"`
+	// Reason describes the change for humans
+	Reason string `json:"reason"`
+}
+
+// Destructive returns true for steps that can not be undone or that can cut off access to a vps,
+// they need to be approved before Apply executes them
+func (s Step) Destructive() bool {
+	switch s.Kind {
+	case StepCancel, StepCancelAddon, StepUpgrade:
+		return true
+	case StepFirewall:
+		return s.RestrictsAccess
+	}
+
+	return false
+}
+
+// ID identifies the step, it is the kind, the vps or group and a hash of the change.
+// A step with a different change gets a different id, so an approval or a completed step in the state
+// never applies to a changed step.
+func (s Step) ID() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	target := s.VpsName
+	if target == "" {
+		target = s.Group
+	}
+
+	return fmt.Sprintf("%s:%s:%s", s.Kind, target, hex.EncodeToString(sum[:])[:12])
+}
+
+// Plan contains the steps that make the fleet match the spec, in the order Apply executes them
+type Plan struct {
+	// Steps are the changes
+	Steps []Step `json:"steps"`
+	// Notes describe differences the planner can not change, like a product that is not an upgrade
+	Notes []string `json:"notes,omitempty"`
+}
+
+// IsEmpty returns true when the plan has no steps
+func (p Plan) IsEmpty() bool {
+	return len(p.Steps) == 0
+}
+
+// Destructive returns the destructive steps of the plan
+func (p Plan) Destructive() []Step {
+	var steps []Step
+	for _, step := range p.Steps {
+		if step.Destructive() {
+			steps = append(steps, step)
+		}
+	}
+
+	return steps
+}
+
+// String returns the plan with a line per step, destructive steps are marked with an exclamation mark,
+// followed by the notes
+func (p Plan) String() string {
+	if p.IsEmpty() && len(p.Notes) == 0 {
+		return "no changes\n"
+	}
+
+	var builder strings.Builder
+	for _, step := range p.Steps {
+		marker := " "
+		if step.Destructive() {
+			marker = "!"
+		}
+		target := step.VpsName
+		if target == "" {
+			target = step.Group
+		}
+		fmt.Fprintf(&builder, "%s %-12s %s: %s  [%s]\n", marker, step.Kind, target, step.Reason, step.ID())
+		if step.FirewallDiff != "" {
+			for _, line := range strings.Split(strings.TrimRight(step.FirewallDiff, "\n"), "\n") {
+				fmt.Fprintf(&builder, "      %s\n", line)
+			}
+		}
+	}
+	for _, note := range p.Notes {
+		fmt.Fprintf(&builder, "  %-12s %s\n", "note", note)
+	}
+
+	return builder.String()
+}
+
+// Planner compares a Spec with the vpses of the account
+type Planner struct {
+	// CancellationTime is when vpses that are no longer a member are cancelled, gotransip.CancellationTimeEnd when empty
+	CancellationTime gotransip.CancellationTime
+}
+
+// member is a vps that belongs to a group
+type member struct {
+	index int
+	vps   vps.Vps
+}
+
+// Plan returns the steps that make the fleet match the spec. For every group it orders the missing members,
+// cancels members beyond the count or with a duplicate index, and plans the changes of every other member.
+// Locked members are skipped with a note. It returns an error when the vpses or the state of a member
+// can not be retrieved, a plan based on partial information would be misleading.
+func (p Planner) Plan(client repository.Client, spec Spec) (Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return Plan{}, err
+	}
+	vpsRepo := vps.Repository{Client: client}
+	vpses, err := vpsRepo.GetAll()
+	if err != nil {
+		return Plan{}, fmt.Errorf("error listing vpses: %w", err)
+	}
+	sort.Slice(vpses, func(i, j int) bool { return vpses[i].Name < vpses[j].Name })
+
+	cancellationTime := p.CancellationTime
+	if cancellationTime == "" {
+		cancellationTime = gotransip.CancellationTimeEnd
+	}
+
+	var plan Plan
+	for _, group := range spec.Groups {
+		var members []member
+		for _, vpsItem := range vpses {
+			if index, ok := group.memberIndex(vpsItem.Description); ok {
+				members = append(members, member{index: index, vps: vpsItem})
+			}
+		}
+		sort.SliceStable(members, func(i, j int) bool { return members[i].index < members[j].index })
+
+		present := make(map[int]string, len(members))
+		var keep []member
+		for _, current := range members {
+			switch existing, duplicate := present[current.index]; {
+			case current.index > group.Count:
+				plan.Steps = append(plan.Steps, cancelStep(group, current, cancellationTime,
+					fmt.Sprintf("%s is beyond the count of %d", group.memberName(current.index), group.Count)))
+			case duplicate:
+				plan.Steps = append(plan.Steps, cancelStep(group, current, cancellationTime,
+					fmt.Sprintf("%s is a duplicate of %s", group.memberName(current.index), existing)))
+			default:
+				present[current.index] = current.vps.Name
+				keep = append(keep, current)
+			}
+		}
+
+		if order := orderStep(group, present); order != nil {
+			plan.Steps = append(plan.Steps, *order)
+			if configured := group.configured(); len(configured) > 0 {
+				plan.Notes = append(plan.Notes, fmt.Sprintf("%s: the %s of the new members are set by the next plan and apply",
+					group.NamePrefix, joinAnd(configured)))
+			}
+		}
+		for _, current := range keep {
+			if err := planMember(client, &plan, group, current); err != nil {
+				return Plan{}, fmt.Errorf("vps %s: %w", current.vps.Name, err)
+			}
+		}
+	}
+
+	sortSteps(plan.Steps)
+
+	return plan, nil
+}
+
+// stepOrder is the order in which the kinds of steps are executed, the new members are ordered first
+// and vpses are cancelled last
+var stepOrder = map[StepKind]int{
+	StepOrder:       0,
+	StepUpgrade:     1,
+	StepOrderAddons: 2,
+	StepUpdate:      3,
+	StepFirewall:    4,
+	StepSettings:    5,
+	StepCancelAddon: 6,
+	StepCancel:      7,
+}
+
+// sortSteps orders the steps by kind, keeping the order of the groups and members within a kind
+func sortSteps(steps []Step) {
+	sort.SliceStable(steps, func(i, j int) bool { return stepOrder[steps[i].Kind] < stepOrder[steps[j].Kind] })
+}
+
+// configured returns what the group configures on its members after they are ordered
+func (g Group) configured() []string {
+	var configured []string
+	if g.Tags != nil {
+		configured = append(configured, "tags")
+	}
+	if g.Firewall != nil {
+		configured = append(configured, "firewall")
+	}
+	if len(g.Settings) > 0 {
+		configured = append(configured, "settings")
+	}
+
+	return configured
+}
+
+// joinAnd joins the values with commas and the last one with "and"
+func joinAnd(values []string) string {
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+
+	return strings.Join(values[:len(values)-1], ", ") + " and " + values[len(values)-1]
+}
+
+// cancelStep returns the step that cancels a vps that is no longer a member of the group
+func cancelStep(group Group, current member, cancellationTime gotransip.CancellationTime, reason string) Step {
+	return Step{
+		Kind:             StepCancel,
+		Group:            group.NamePrefix,
+		VpsName:          current.vps.Name,
+		CancellationTime: cancellationTime,
+		Reason:           fmt.Sprintf("%s, cancel at %s", reason, cancellationTime),
+	}
+}
+
+// orderStep returns the step that orders the members that are not present, or nil when all are
+func orderStep(group Group, present map[int]string) *Step {
+	var orders []vps.Order
+	var names []string
+	for index := 1; index <= group.Count; index++ {
+		if _, ok := present[index]; ok {
+			continue
+		}
+		orders = append(orders, vps.Order{
+			ProductName:      group.ProductName,
+			OperatingSystem:  group.OperatingSystem,
+			AvailabilityZone: group.AvailabilityZone,
+			Description:      group.memberDescription(index),
+			Addons:           group.Addons,
+		})
+		names = append(names, group.memberName(index))
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("%s with %s and %s", strings.Join(names, ", "), group.ProductName, group.OperatingSystem)
+	if group.AvailabilityZone != "" {
+		reason += " in " + group.AvailabilityZone
+	}
+	if len(group.Addons) > 0 {
+		reason += ", addons " + strings.Join(group.Addons, ", ")
+	}
+
+	return &Step{Kind: StepOrder, Group: group.NamePrefix, Orders: orders, Reason: reason}
+}
+
+// planMember adds the steps and notes that make a member match its group
+func planMember(client repository.Client, plan *Plan, group Group, current member) error {
+	vpsItem := current.vps
+	name := group.memberName(current.index)
+	note := func(format string, args ...interface{}) {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%s (%s): %s", vpsItem.Name, name, fmt.Sprintf(format, args...)))
+	}
+	newStep := func(kind StepKind, reason string) Step {
+		return Step{Kind: kind, Group: group.NamePrefix, VpsName: vpsItem.Name, Reason: reason}
+	}
+
+	if vpsItem.IsLocked {
+		note("skipped, the vps is locked")
+		return nil
+	}
+	if group.AvailabilityZone != "" && vpsItem.AvailabilityZone != group.AvailabilityZone {
+		note("is in %s instead of %s, a vps can not move to another availability zone", vpsItem.AvailabilityZone, group.AvailabilityZone)
+	}
+
+	vpsRepo := vps.Repository{Client: client}
+	if vpsItem.ProductName != group.ProductName {
+		upgrades, err := vpsRepo.GetUpgrades(vpsItem.Name)
+		if err != nil {
+			return fmt.Errorf("error getting upgrades: %w", err)
+		}
+		if containsProduct(upgrades, group.ProductName) {
+			step := newStep(StepUpgrade, fmt.Sprintf("%s -> %s", vpsItem.ProductName, group.ProductName))
+			step.ProductName = group.ProductName
+			plan.Steps = append(plan.Steps, step)
+		} else {
+			note("%s is not an upgrade of %s, a vps can not be downgraded", group.ProductName, vpsItem.ProductName)
+		}
+	}
+
+	if group.Addons != nil {
+		if err := planAddons(vpsRepo, plan, group, vpsItem, newStep, note); err != nil {
+			return err
+		}
+	}
+
+	description := group.memberDescription(current.index)
+	tags := vpsItem.Tags
+	if group.Tags != nil {
+		tags = sortedCopy(group.Tags)
+	}
+	var changes []string
+	if vpsItem.Description != description {
+		changes = append(changes, fmt.Sprintf("description %q -> %q", vpsItem.Description, description))
+	}
+	if !equalStrings(sortedCopy(vpsItem.Tags), sortedCopy(tags)) {
+		changes = append(changes, fmt.Sprintf("tags [%s] -> [%s]", strings.Join(vpsItem.Tags, ", "), strings.Join(tags, ", ")))
+	}
+	if len(changes) > 0 {
+		step := newStep(StepUpdate, strings.Join(changes, ", "))
+		step.Description = description
+		step.Tags = tags
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	if group.Firewall != nil {
+		desired, err := group.Firewall.firewall()
+		if err != nil {
+			return err
+		}
+		firewallRepo := vps.FirewallRepository{Client: client}
+		firewall, err := firewallRepo.GetFirewall(vpsItem.Name)
+		if err != nil {
+			return fmt.Errorf("error getting firewall: %w", err)
+		}
+		if diff := vps.DiffFirewall(firewall, desired); !diff.IsEmpty() {
+			step := newStep(StepFirewall, firewallSummary(diff))
+			step.Firewall = &desired
+			step.FirewallDiff = diff.String()
+			step.RestrictsAccess = restrictsAccess(diff)
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+
+	if len(group.Settings) > 0 {
+		if err := planSettings(client, plan, group, vpsItem, newStep, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planAddons adds the steps that order the missing addons and cancel the addons the group does not have
+func planAddons(vpsRepo vps.Repository, plan *Plan, group Group, vpsItem vps.Vps, newStep func(StepKind, string) Step, note func(string, ...interface{})) error {
+	addons, err := vpsRepo.GetAddons(vpsItem.Name)
+	if err != nil {
+		return fmt.Errorf("error getting addons: %w", err)
+	}
+
+	var missing []string
+	for _, addon := range group.Addons {
+		switch {
+		case containsProduct(addons.Active, addon):
+		case containsProduct(addons.Available, addon):
+			missing = append(missing, addon)
+		default:
+			note("addon %s is not available", addon)
+		}
+	}
+	if len(missing) > 0 {
+		step := newStep(StepOrderAddons, strings.Join(missing, ", "))
+		step.Addons = missing
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	for _, active := range addons.Active {
+		if containsString(group.Addons, active.Name) {
+			continue
+		}
+		if !containsProduct(addons.Cancellable, active.Name) {
+			note("addon %s is not in the spec but can not be cancelled", active.Name)
+			continue
+		}
+		step := newStep(StepCancelAddon, active.Name)
+		step.Addons = []string{active.Name}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	return nil
+}
+
+// planSettings adds the step that updates the settings with a different value than the group
+func planSettings(client repository.Client, plan *Plan, group Group, vpsItem vps.Vps, newStep func(StepKind, string) Step, note func(string, ...interface{})) error {
+	settingRepo := vps.SettingRepository{Client: client}
+	settings, err := settingRepo.GetAll(vpsItem.Name)
+	if err != nil {
+		return fmt.Errorf("error getting settings: %w", err)
+	}
+	byName := make(map[string]vps.Setting, len(settings))
+	for _, setting := range settings {
+		byName[setting.Name] = setting
+	}
+
+	var changed []vps.Setting
+	var changes []string
+	for _, name := range group.settingNames() {
+		value := group.Settings[name]
+		setting, ok := byName[name]
+		if !ok {
+			note("setting %s does not exist", name)
+			continue
+		}
+
+		current := setting.Value.ValueString
+		if setting.DataType == vps.SettingDataTypeBoolean {
+			desired, err := strconv.ParseBool(value)
+			if err != nil {
+				note("setting %s is a boolean, not '%s'", name, value)
+				continue
+			}
+			current = strconv.FormatBool(setting.Value.ValueBoolean)
+			value = strconv.FormatBool(desired)
+			setting.Value = vps.SettingValue{ValueBoolean: desired}
+		} else {
+			setting.Value = vps.SettingValue{ValueString: value}
+		}
+		if current == value {
+			continue
+		}
+		if setting.ReadOnly {
+			note("setting %s is read only", name)
+			continue
+		}
+		changed = append(changed, setting)
+		changes = append(changes, fmt.Sprintf("%s %s -> %s", name, current, value))
+	}
+	if len(changed) > 0 {
+		step := newStep(StepSettings, strings.Join(changes, ", "))
+		step.Settings = changed
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	return nil
+}
+
+// restrictsAccess returns true when a firewall diff can cut off access to a vps: it enables the firewall,
+// which drops all traffic no rule allows, removes a rule or narrows the whitelist of a rule
+func restrictsAccess(diff vps.FirewallDiff) bool {
+	if (diff.EnabledChanged && diff.IsEnabled) || len(diff.Removed) > 0 {
+		return true
+	}
+	for _, change := range diff.Changed {
+		if narrowsWhitelist(change.Old.Whitelist, change.New.Whitelist) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// narrowsWhitelist returns true when the new whitelist does not contain every range of the old one,
+// an empty whitelist allows every address
+func narrowsWhitelist(old []ipaddress.IPRange, new []ipaddress.IPRange) bool {
+	if len(new) == 0 {
+		return false
+	}
+	if len(old) == 0 {
+		return true
+	}
+	allowed := make(map[string]bool, len(new))
+	for _, ipRange := range new {
+		allowed[ipRange.String()] = true
+	}
+	for _, ipRange := range old {
+		if !allowed[ipRange.String()] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firewallSummary returns a one line summary of a firewall diff
+func firewallSummary(diff vps.FirewallDiff) string {
+	var parts []string
+	if diff.EnabledChanged {
+		if diff.IsEnabled {
+			parts = append(parts, "enable")
+		} else {
+			parts = append(parts, "disable")
+		}
+	}
+	for _, count := range []struct {
+		number int
+		what   string
+	}{{len(diff.Added), "added"}, {len(diff.Removed), "removed"}, {len(diff.Changed), "changed"}} {
+		if count.number > 0 {
+			parts = append(parts, fmt.Sprintf("%d rules %s", count.number, count.what))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// containsProduct returns true when one of the products has the name
+func containsProduct(products []product.Product, name string) bool {
+	for _, item := range products {
+		if item.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsString returns true when the values contain the value
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedCopy returns a sorted copy of the values
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// equalStrings returns true when both slices contain the same values in the same order
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,249 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fleetResponses are the responses of fleetServer for the vpses of the testSpec group
+var fleetResponses = map[string]string{
+	"/vps": `{"vpss":[
+		{"name":"example-vps","description":"web-1 frontend","productName":"vps-bladevps-x2","availabilityZone":"ams0","tags":["web"]},
+		{"name":"example-vps2","description":"web-3","productName":"vps-bladevps-x4","availabilityZone":"ams0"},
+		{"name":"example-vps3","description":"web-7 frontend","productName":"vps-bladevps-x4","availabilityZone":"ams0","tags":["web"]},
+		{"name":"example-vps4","description":"web-3 frontend","productName":"vps-bladevps-x4","availabilityZone":"ams0","tags":["web"]},
+		{"name":"example-vps5","description":"db-1","productName":"vps-bladevps-x8","availabilityZone":"rtm0"},
+		{"name":"example-vps6","description":"web-2 frontend","productName":"vps-bladevps-x4","availabilityZone":"ams0","isLocked":true}]}`,
+	"/vps/example-vps":           `{"vps":{"name":"example-vps","description":"web-1 frontend","productName":"vps-bladevps-x4","tags":["web"]}}`,
+	"/vps/example-vps2":          `{"vps":{"name":"example-vps2","description":"web-3","productName":"vps-bladevps-x4"}}`,
+	"/vps/example-vps/upgrades":  `{"upgrades":[{"name":"vps-bladevps-x4"},{"name":"vps-bladevps-x8"}]}`,
+	"/vps/example-vps/addons":    `{"addons":{"active":[{"name":"vps-addon-backups"}],"available":[{"name":"vps-addon-1-extra-ip-address"}],"cancellable":[{"name":"vps-addon-backups"}]}}`,
+	"/vps/example-vps2/addons":   `{"addons":{"active":[{"name":"vps-addon-1-extra-ip-address"}],"cancellable":[{"name":"vps-addon-1-extra-ip-address"}]}}`,
+	"/vps/example-vps/firewall":  `{"vpsFirewall":{"isEnabled":false,"ruleSet":[]}}`,
+	"/vps/example-vps2/firewall": `{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"https","startPort":443,"endPort":443,"protocol":"tcp","whitelist":[]},{"description":"ssh","startPort":22,"endPort":22,"protocol":"tcp","whitelist":["192.0.2.0/24"]}]}}`,
+	"/vps/example-vps/settings":  `{"settings":[{"name":"blockVpsMailPorts","dataType":"boolean","readOnly":false,"value":{"valueBoolean":false}}]}`,
+	"/vps/example-vps2/settings": `{"settings":[{"name":"blockVpsMailPorts","dataType":"boolean","readOnly":false,"value":{"valueBoolean":true}}]}`,
+}
+
+// fleetServer serves the responses for GET requests and records the changes,
+// the body of a PUT is served for later GET requests of the same path
+func fleetServer(t *testing.T, responses map[string]string) *testutil.RoutedMockServer {
+	return &testutil.RoutedMockServer{T: t, Responses: responses, StorePuts: true, Error: "Vps not found"}
+}
+
+// stepLines returns the steps of a plan as "kind target: reason" lines
+func stepLines(plan Plan) []string {
+	var lines []string
+	for _, step := range plan.Steps {
+		target := step.VpsName
+		if target == "" {
+			target = step.Group
+		}
+		lines = append(lines, string(step.Kind)+" "+target+": "+step.Reason)
+	}
+
+	return lines
+}
+
+func TestPlanner_Plan(t *testing.T) {
+	spec, err := LoadSpec(strings.NewReader(testSpec))
+	require.NoError(t, err)
+	server := fleetServer(t, fleetResponses)
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan, err := Planner{}.Plan(client, spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"order web: web-4, web-5 with vps-bladevps-x4 and debian-12 in ams0, addons vps-addon-1-extra-ip-address",
+		"upgrade example-vps: vps-bladevps-x2 -> vps-bladevps-x4",
+		"order-addons example-vps: vps-addon-1-extra-ip-address",
+		`update example-vps2: description "web-3" -> "web-3 frontend", tags [] -> [web]`,
+		"firewall example-vps: enable, 2 rules added",
+		"settings example-vps: blockVpsMailPorts false -> true",
+		"cancel-addon example-vps: vps-addon-backups",
+		"cancel example-vps4: web-3 is a duplicate of example-vps2, cancel at end",
+		"cancel example-vps3: web-7 is beyond the count of 5, cancel at end",
+	}, stepLines(plan))
+	assert.Equal(t, []string{
+		"web: the tags, firewall and settings of the new members are set by the next plan and apply",
+		"example-vps6 (web-2): skipped, the vps is locked",
+	}, plan.Notes)
+	assert.Empty(t, server.Changes)
+
+	order := plan.Steps[0]
+	assert.Equal(t, []vps.Order{
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", AvailabilityZone: "ams0", Description: "web-4 frontend", Addons: []string{"vps-addon-1-extra-ip-address"}},
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", AvailabilityZone: "ams0", Description: "web-5 frontend", Addons: []string{"vps-addon-1-extra-ip-address"}},
+	}, order.Orders)
+	assert.Equal(t, []string{"web"}, plan.Steps[3].Tags)
+	require.NotNil(t, plan.Steps[4].Firewall)
+	assert.Len(t, plan.Steps[4].Firewall.RuleSet, 2)
+	assert.Equal(t, []vps.Setting{{Name: "blockVpsMailPorts", DataType: "boolean", Value: vps.SettingValue{ValueBoolean: true}}}, plan.Steps[5].Settings)
+
+	destructive := plan.Destructive()
+	require.Len(t, destructive, 5)
+	assert.Equal(t, StepUpgrade, destructive[0].Kind)
+	assert.Equal(t, StepFirewall, destructive[1].Kind)
+	assert.Equal(t, StepCancelAddon, destructive[2].Kind)
+	assert.True(t, plan.Steps[4].RestrictsAccess)
+
+	output := plan.String()
+	assert.Contains(t, output, "  order        web: web-4, web-5")
+	assert.Contains(t, output, "! upgrade      example-vps: vps-bladevps-x2 -> vps-bladevps-x4")
+	assert.Contains(t, output, "! firewall     example-vps: enable, 2 rules added")
+	assert.Contains(t, output, "! cancel       example-vps3: web-7 is beyond the count of 5, cancel at end  ["+plan.Steps[8].ID()+"]\n")
+	assert.Contains(t, output, "      + ")
+	assert.Contains(t, output, "  note         example-vps6 (web-2): skipped, the vps is locked\n")
+}
+
+func TestPlanner_PlanNotes(t *testing.T) {
+	responses := map[string]string{
+		"/vps":                      `{"vpss":[{"name":"example-vps","description":"web-1","productName":"vps-bladevps-x8","availabilityZone":"rtm0"}]}`,
+		"/vps/example-vps/upgrades": `{"upgrades":[]}`,
+		"/vps/example-vps/addons":   `{"addons":{"active":[{"name":"vps-addon-storage"}]}}`,
+		"/vps/example-vps/settings": `{"settings":[{"name":"tcpMonitoringAvailable","dataType":"boolean","readOnly":true,"value":{"valueBoolean":false}}]}`,
+	}
+	server := fleetServer(t, responses)
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	spec := Spec{Groups: []Group{{
+		NamePrefix:       "web",
+		Count:            1,
+		ProductName:      "vps-bladevps-x4",
+		AvailabilityZone: "ams0",
+		OperatingSystem:  "debian-12",
+		Addons:           []string{"vps-addon-1-extra-ip-address"},
+		Settings:         map[string]string{"tcpMonitoringAvailable": "true", "unknown": "x"},
+	}}}
+	plan, err := Planner{CancellationTime: gotransip.CancellationTimeImmediately}.Plan(client, spec)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Steps)
+	assert.Equal(t, []string{
+		"example-vps (web-1): is in rtm0 instead of ams0, a vps can not move to another availability zone",
+		"example-vps (web-1): vps-bladevps-x4 is not an upgrade of vps-bladevps-x8, a vps can not be downgraded",
+		"example-vps (web-1): addon vps-addon-1-extra-ip-address is not available",
+		"example-vps (web-1): addon vps-addon-storage is not in the spec but can not be cancelled",
+		"example-vps (web-1): setting tcpMonitoringAvailable is read only",
+		"example-vps (web-1): setting unknown does not exist",
+	}, plan.Notes)
+
+	spec.Groups[0].Count = 0
+	plan, err = Planner{CancellationTime: gotransip.CancellationTimeImmediately}.Plan(client, spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cancel example-vps: web-1 is beyond the count of 0, cancel at immediately"}, stepLines(plan))
+	assert.Equal(t, gotransip.CancellationTimeImmediately, plan.Steps[0].CancellationTime)
+}
+
+func TestPlanner_PlanErrors(t *testing.T) {
+	spec, err := LoadSpec(strings.NewReader(testSpec))
+	require.NoError(t, err)
+
+	server := fleetServer(t, map[string]string{})
+	client, closeServer := server.GetClient()
+	defer closeServer()
+	_, err = Planner{}.Plan(client, spec)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error listing vpses")
+
+	server.Responses = map[string]string{"/vps": fleetResponses["/vps"]}
+	_, err = Planner{}.Plan(client, spec)
+	require.Error(t, err)
+	assert.Equal(t, "vps example-vps: error getting upgrades: Vps not found", err.Error())
+
+	_, err = Planner{}.Plan(client, Spec{Groups: []Group{{NamePrefix: "web"}}})
+	assert.ErrorIs(t, err, ErrInvalidSpec)
+}
+
+func TestPlan_String(t *testing.T) {
+	assert.Equal(t, "no changes\n", Plan{}.String())
+
+	step := Step{Kind: StepCancel, Group: "web", VpsName: "example-vps"}
+	other := step
+	other.CancellationTime = gotransip.CancellationTimeEnd
+	assert.NotEqual(t, step.ID(), other.ID())
+	assert.True(t, strings.HasPrefix(step.ID(), "cancel:example-vps:"))
+	assert.Equal(t, "order:web:", (Step{Kind: StepOrder, Group: "web"}).ID()[:10])
+}
+
+func TestGroup_Configured(t *testing.T) {
+	assert.Empty(t, Group{NamePrefix: "web", Addons: []string{"vps-addon-backups"}}.configured())
+
+	group := Group{NamePrefix: "web", Tags: []string{}, Settings: map[string]string{"blockVpsMailPorts": "true"}}
+	assert.Equal(t, "tags and settings", joinAnd(group.configured()))
+	assert.Equal(t, "firewall", joinAnd(Group{Firewall: &FirewallSpec{}}.configured()))
+}
+
+func TestStep_Destructive(t *testing.T) {
+	for _, kind := range []StepKind{StepCancel, StepCancelAddon, StepUpgrade} {
+		assert.True(t, Step{Kind: kind}.Destructive(), kind)
+	}
+	for _, kind := range []StepKind{StepOrder, StepOrderAddons, StepUpdate, StepFirewall, StepSettings} {
+		assert.False(t, Step{Kind: kind}.Destructive(), kind)
+	}
+	assert.True(t, Step{Kind: StepFirewall, RestrictsAccess: true}.Destructive())
+}
+
+func TestPlanner_PlanFirewallRestrictsAccess(t *testing.T) {
+	server := fleetServer(t, map[string]string{
+		"/vps": `{"vpss":[{"name":"example-vps","description":"web-1","productName":"vps-bladevps-x4"}]}`,
+		"/vps/example-vps/firewall": `{"vpsFirewall":{"isEnabled":true,"ruleSet":[
+			{"description":"ssh","startPort":22,"endPort":22,"protocol":"tcp","whitelist":[]},
+			{"description":"https","startPort":443,"endPort":443,"protocol":"tcp","whitelist":[]}]}}`,
+	})
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	spec := Spec{Groups: []Group{{
+		NamePrefix:      "web",
+		Count:           1,
+		ProductName:     "vps-bladevps-x4",
+		OperatingSystem: "debian-12",
+		Firewall:        &FirewallSpec{Enabled: true, Rules: []FirewallRuleSpec{{Description: "https", Ports: "443", Protocol: "tcp"}}},
+	}}}
+	plan, err := Planner{}.Plan(client, spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"firewall example-vps: 1 rules removed"}, stepLines(plan))
+	assert.True(t, plan.Steps[0].RestrictsAccess)
+	assert.Len(t, plan.Destructive(), 1)
+}
+
+func TestRestrictsAccess(t *testing.T) {
+	ipRange := func(cidr string) ipaddress.IPRange {
+		var ipRange ipaddress.IPRange
+		require.NoError(t, ipRange.UnmarshalText([]byte(cidr)))
+		return ipRange
+	}
+	ssh := vps.FirewallRule{Description: "ssh", StartPort: 22, EndPort: 22, Protocol: vps.FirewallProtocolTCP}
+	office := ssh
+	office.Whitelist = []ipaddress.IPRange{ipRange("192.0.2.0/24")}
+	offices := ssh
+	offices.Whitelist = []ipaddress.IPRange{ipRange("192.0.2.0/24"), ipRange("198.51.100.0/24")}
+	renamed := ssh
+	renamed.Description = "SSH"
+
+	for name, test := range map[string]struct {
+		current, desired vps.Firewall
+		restricts        bool
+	}{
+		"enable":           {vps.Firewall{RuleSet: []vps.FirewallRule{ssh}}, vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{ssh}}, true},
+		"disable":          {vps.Firewall{IsEnabled: true}, vps.Firewall{}, false},
+		"add":              {vps.Firewall{IsEnabled: true}, vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{ssh}}, false},
+		"remove":           {vps.Firewall{IsEnabled: true, RuleSet: []vps.FirewallRule{ssh}}, vps.Firewall{IsEnabled: true}, true},
+		"restrict any":     {vps.Firewall{RuleSet: []vps.FirewallRule{ssh}}, vps.Firewall{RuleSet: []vps.FirewallRule{office}}, true},
+		"drop a range":     {vps.Firewall{RuleSet: []vps.FirewallRule{offices}}, vps.Firewall{RuleSet: []vps.FirewallRule{office}}, true},
+		"add a range":      {vps.Firewall{RuleSet: []vps.FirewallRule{office}}, vps.Firewall{RuleSet: []vps.FirewallRule{offices}}, false},
+		"allow any":        {vps.Firewall{RuleSet: []vps.FirewallRule{office}}, vps.Firewall{RuleSet: []vps.FirewallRule{ssh}}, false},
+		"description only": {vps.Firewall{RuleSet: []vps.FirewallRule{ssh}}, vps.Firewall{RuleSet: []vps.FirewallRule{renamed}}, false},
+	} {
+		assert.Equal(t, test.restricts, restrictsAccess(vps.DiffFirewall(test.current, test.desired)), name)
+	}
+}
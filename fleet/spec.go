@@ -0,0 +1,281 @@
+// Package fleet manages groups of vpses declaratively.
+//
+// A Spec describes groups of identical vpses in YAML: the product, availability zone, operating system, tags, addons,
+// firewall and settings of every member. The Planner compares the spec with the vpses of the account and returns a Plan
+// of steps, which can be reviewed before Apply executes it:
+//
+//	spec, err := fleet.LoadSpec(file)
+//	if err != nil {
+//		panic(err)
+//	}
+//	plan, err := fleet.Planner{}.Plan(client, spec)
+//	if err != nil {
+//		panic(err)
+//	}
+//	fmt.Print(plan)
+//	err = fleet.Apply(ctx, client, plan, fleet.ApplyOptions{StatePath: "fleet-state.json"})
+//
+// TransIP generates the names of new vpses, so the members of a group are recognised by their description,
+// which starts with the name prefix of the group followed by the index of the member, like "web-1".
+// Vpses that are not a member of any group are never changed.
+//
+// The names of new members are only known once they are ordered, so a plan can not contain their tags, firewall
+// and settings. Apply waits until the ordered vpses are listed and unlocked, after which a second plan and apply
+// configures them. The plan contains a note for every group that needs this.
+//
+// Cancelling a vps or an addon can not be undone, and neither can an upgrade because a vps can not be downgraded.
+// A firewall step that enables the firewall, removes a rule or narrows the whitelist of a rule can cut off access
+// to a vps. Apply refuses to start when the plan contains such a destructive step that is not approved
+// in the ApplyOptions.
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/vps"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrInvalidSpec is wrapped by every ValidationError
+	ErrInvalidSpec = errors.New("invalid fleet spec")
+)
+
+// namePrefix matches a name prefix, which is followed by a dash and the index of the member in its description
+var namePrefix = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// ValidationError is returned when a Spec contains one or more invalid values
+type ValidationError struct {
+	// Problems describes every invalid value
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidSpec, strings.Join(e.Problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidSpec) work for a ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidSpec
+}
+
+// Spec is the desired state of the fleet
+type Spec struct {
+	// Groups of identical vpses
+	Groups []Group `yaml:"groups"`
+}
+
+// Group describes a number of identical vpses
+type Group struct {
+	// NamePrefix identifies the group, the description of its members starts with it followed by the member index
+	NamePrefix string `yaml:"namePrefix"`
+	// Count is the number of members
+	Count int `yaml:"count"`
+	// ProductName of the members, like vps-bladevps-x4
+	ProductName string `yaml:"productName"`
+	// AvailabilityZone of the members, the api chooses one when empty
+	AvailabilityZone string `yaml:"availabilityZone"`
+	// OperatingSystem that is installed on new members
+	OperatingSystem string `yaml:"operatingSystem"`
+	// Tags of the members, the tags are not changed when nil
+	Tags []string `yaml:"tags"`
+	// Addons of the members, the addons are not changed when nil
+	Addons []string `yaml:"addons"`
+	// Description is added to the description of the members after the name prefix and index
+	Description string `yaml:"description"`
+	// Firewall of the members, the firewall is not changed when nil
+	Firewall *FirewallSpec `yaml:"firewall"`
+	// Settings of the members by name, like blockVpsMailPorts: "true", other settings are not changed
+	Settings map[string]string `yaml:"settings"`
+}
+
+// FirewallSpec is the desired firewall of the members of a group
+type FirewallSpec struct {
+	// Enabled enables the firewall
+	Enabled bool `yaml:"enabled"`
+	// Rules are the rules of the firewall, other rules are removed
+	Rules []FirewallRuleSpec `yaml:"rules"`
+}
+
+// FirewallRuleSpec is a firewall rule
+type FirewallRuleSpec struct {
+	// Description of the rule
+	Description string `yaml:"description"`
+	// Ports is a single port like "22" or a range like "8000-8100"
+	Ports string `yaml:"ports"`
+	// Protocol is tcp, udp or tcp_udp
+	Protocol string `yaml:"protocol"`
+	// Whitelist contains the ranges that are allowed to connect, empty to allow all
+	Whitelist []ipaddress.IPRange `yaml:"whitelist"`
+}
+
+// LoadSpec reads a spec from YAML and validates it
+func LoadSpec(reader io.Reader) (Spec, error) {
+	var spec Spec
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && err != io.EOF {
+		return Spec{}, fmt.Errorf("error decoding fleet spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return Spec{}, err
+	}
+
+	return spec, nil
+}
+
+// Validate returns a ValidationError describing every invalid value in the spec
+func (s Spec) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	prefixes := make(map[string]bool, len(s.Groups))
+	for idx, group := range s.Groups {
+		switch {
+		case group.NamePrefix == "":
+			add("group %d has no name prefix", idx+1)
+		case !namePrefix.MatchString(group.NamePrefix):
+			add("invalid name prefix '%s'", group.NamePrefix)
+		case prefixes[group.NamePrefix]:
+			add("name prefix '%s' is used twice", group.NamePrefix)
+		}
+		prefixes[group.NamePrefix] = true
+
+		if group.Count < 0 {
+			add("group '%s' has a negative count", group.NamePrefix)
+		}
+		if group.ProductName == "" {
+			add("group '%s' has no product name", group.NamePrefix)
+		}
+		if group.OperatingSystem == "" {
+			add("group '%s' has no operating system", group.NamePrefix)
+		}
+		if strings.ContainsAny(group.Description, "\n\t") {
+			add("group '%s' has a description with tabs or newlines", group.NamePrefix)
+		}
+		for _, tag := range group.Tags {
+			if tag == "" || strings.ContainsAny(tag, " \t\n") {
+				add("group '%s' has invalid tag '%s'", group.NamePrefix, tag)
+			}
+		}
+		addons := make(map[string]bool, len(group.Addons))
+		for _, addon := range group.Addons {
+			if addon == "" || addons[addon] {
+				add("group '%s' has an empty or duplicate addon '%s'", group.NamePrefix, addon)
+			}
+			addons[addon] = true
+		}
+		for _, name := range group.settingNames() {
+			if value := group.Settings[name]; name == "" {
+				add("group '%s' has a setting without a name", group.NamePrefix)
+			} else if value == "" {
+				add("group '%s' has setting '%s' without a value", group.NamePrefix, name)
+			}
+		}
+		if group.Firewall != nil {
+			if _, err := group.Firewall.firewall(); err != nil {
+				add("group '%s': %s", group.NamePrefix, err)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// settingNames returns the names of the settings in alphabetical order
+func (g Group) settingNames() []string {
+	names := make([]string, 0, len(g.Settings))
+	for name := range g.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// memberName returns the name of the member with the given index, which starts the description of the vps
+func (g Group) memberName(index int) string {
+	return fmt.Sprintf("%s-%d", g.NamePrefix, index)
+}
+
+// memberDescription returns the description of the member with the given index
+func (g Group) memberDescription(index int) string {
+	if g.Description == "" {
+		return g.memberName(index)
+	}
+
+	return g.memberName(index) + " " + g.Description
+}
+
+// memberIndex returns the member index of a vps description, and false when the vps is not a member of the group
+func (g Group) memberIndex(description string) (int, bool) {
+	rest, ok := strings.CutPrefix(description, g.NamePrefix+"-")
+	if !ok {
+		return 0, false
+	}
+	digits, _, _ := strings.Cut(rest, " ")
+	index, err := strconv.Atoi(digits)
+	if err != nil || index < 1 || strconv.Itoa(index) != digits {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// firewall returns the vps firewall of the spec, it returns a vps.ErrInvalidFirewall error for invalid rules
+func (f FirewallSpec) firewall() (vps.Firewall, error) {
+	firewall := vps.Firewall{IsEnabled: f.Enabled, RuleSet: []vps.FirewallRule{}}
+	for _, rule := range f.Rules {
+		start, end, err := parsePorts(rule.Ports)
+		if err != nil {
+			return vps.Firewall{}, err
+		}
+		whitelist := rule.Whitelist
+		if whitelist == nil {
+			whitelist = []ipaddress.IPRange{}
+		}
+		firewall.RuleSet = append(firewall.RuleSet, vps.FirewallRule{
+			Description: rule.Description,
+			StartPort:   start,
+			EndPort:     end,
+			Protocol:    rule.Protocol,
+			Whitelist:   whitelist,
+		})
+	}
+	if err := firewall.Validate(); err != nil {
+		return vps.Firewall{}, err
+	}
+
+	return firewall, nil
+}
+
+// parsePorts parses a single port like "22" or a range like "8000-8100"
+func parsePorts(ports string) (int, int, error) {
+	first, last, isRange := strings.Cut(ports, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid ports '%s'", vps.ErrInvalidFirewall, ports)
+	}
+	if !isRange {
+		return start, start, nil
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(last))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid ports '%s'", vps.ErrInvalidFirewall, ports)
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,147 @@
+package fleet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `
+groups:
+  - namePrefix: web
+    count: 5
+    productName: vps-bladevps-x4
+    availabilityZone: ams0
+    operatingSystem: debian-12
+    tags: [web]
+    addons: [vps-addon-1-extra-ip-address]
+    description: frontend
+    firewall:
+      enabled: true
+      rules:
+        - description: https
+          ports: "443"
+          protocol: tcp
+        - description: ssh
+          ports: "22"
+          protocol: tcp
+          whitelist: [192.0.2.0/24]
+    settings:
+      blockVpsMailPorts: "true"
+`
+
+func TestLoadSpec(t *testing.T) {
+	spec, err := LoadSpec(strings.NewReader(testSpec))
+	require.NoError(t, err)
+	require.Len(t, spec.Groups, 1)
+
+	group := spec.Groups[0]
+	assert.Equal(t, "web", group.NamePrefix)
+	assert.Equal(t, 5, group.Count)
+	assert.Equal(t, "vps-bladevps-x4", group.ProductName)
+	assert.Equal(t, "ams0", group.AvailabilityZone)
+	assert.Equal(t, "debian-12", group.OperatingSystem)
+	assert.Equal(t, []string{"web"}, group.Tags)
+	assert.Equal(t, []string{"vps-addon-1-extra-ip-address"}, group.Addons)
+	assert.Equal(t, map[string]string{"blockVpsMailPorts": "true"}, group.Settings)
+	require.NotNil(t, group.Firewall)
+
+	firewall, err := group.Firewall.firewall()
+	require.NoError(t, err)
+	assert.True(t, firewall.IsEnabled)
+	require.Len(t, firewall.RuleSet, 2)
+	assert.Equal(t, 443, firewall.RuleSet[0].StartPort)
+	assert.Equal(t, 443, firewall.RuleSet[0].EndPort)
+	assert.Empty(t, firewall.RuleSet[0].Whitelist)
+	require.Len(t, firewall.RuleSet[1].Whitelist, 1)
+	assert.Equal(t, "192.0.2.0/24", firewall.RuleSet[1].Whitelist[0].String())
+
+	spec, err = LoadSpec(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, spec.Groups)
+}
+
+func TestLoadSpec_UnknownField(t *testing.T) {
+	_, err := LoadSpec(strings.NewReader("groups:\n  - namePrefix: web\n    size: 3\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error decoding fleet spec")
+}
+
+func TestSpec_Validate(t *testing.T) {
+	spec := Spec{Groups: []Group{
+		{NamePrefix: "web", Count: 1, ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12"},
+		{NamePrefix: "web", Count: -1, OperatingSystem: "debian-12"},
+		{NamePrefix: "-db", ProductName: "vps-bladevps-x4"},
+		{Count: 1, ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12",
+			Tags: []string{"two words"}, Addons: []string{"a", "a"}, Settings: map[string]string{"blockVpsMailPorts": ""}},
+		{NamePrefix: "app", ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12",
+			Firewall: &FirewallSpec{Rules: []FirewallRuleSpec{{Ports: "https", Protocol: "tcp"}}}},
+		{NamePrefix: "lb", ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12",
+			Firewall: &FirewallSpec{Rules: []FirewallRuleSpec{{Ports: "80", Protocol: "sctp"}}}},
+	}}
+
+	err := spec.Validate()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidSpec))
+
+	var validationError *ValidationError
+	require.True(t, errors.As(err, &validationError))
+	assert.Equal(t, []string{
+		"name prefix 'web' is used twice",
+		"group 'web' has a negative count",
+		"group 'web' has no product name",
+		"invalid name prefix '-db'",
+		"group '-db' has no operating system",
+		"group 4 has no name prefix",
+		"group '' has invalid tag 'two words'",
+		"group '' has an empty or duplicate addon 'a'",
+		"group '' has setting 'blockVpsMailPorts' without a value",
+		"group 'app': invalid firewall: invalid ports 'https'",
+	}, validationError.Problems[:10])
+	require.Len(t, validationError.Problems, 11)
+	assert.Contains(t, validationError.Problems[10], "group 'lb': invalid firewall")
+}
+
+func TestGroup_MemberIndex(t *testing.T) {
+	group := Group{NamePrefix: "web", Description: "frontend"}
+	assert.Equal(t, "web-2", group.memberName(2))
+	assert.Equal(t, "web-2 frontend", group.memberDescription(2))
+	assert.Equal(t, "web-2", Group{NamePrefix: "web"}.memberDescription(2))
+
+	for description, expected := range map[string]int{
+		"web-1":             1,
+		"web-12 frontend":   12,
+		"web-3 old name":    3,
+		"web-0":             0,
+		"web-01":            0,
+		"web-":              0,
+		"web-eu-1":          0,
+		"webserver-1":       0,
+		"web-1x":            0,
+		"db-1":              0,
+		"my web-1 frontend": 0,
+	} {
+		index, ok := group.memberIndex(description)
+		assert.Equal(t, expected, index, description)
+		assert.Equal(t, expected > 0, ok, description)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	start, end, err := parsePorts("22")
+	require.NoError(t, err)
+	assert.Equal(t, []int{22, 22}, []int{start, end})
+
+	start, end, err = parsePorts("8000-8100")
+	require.NoError(t, err)
+	assert.Equal(t, []int{8000, 8100}, []int{start, end})
+
+	for _, ports := range []string{"", "ssh", "80-", "-80"} {
+		_, _, err = parsePorts(ports)
+		assert.True(t, errors.Is(err, vps.ErrInvalidFirewall), ports)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 )
@@ -61,6 +62,23 @@ func (r *Repository) OrderWithResponse(productName string, description string) (
 	return r.Client.PostWithResponse(rest.Request{Endpoint: "/haips", Body: requestBody})
 }
 
+// OrderWithOperation allows you to order a new Haip and returns an operation,
+// which finds the new HA-IP by its description and can cancel it
+func (r *Repository) OrderWithOperation(productName string, description string) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing haips before the order: %w", err)
+	}
+	response, err := r.OrderWithResponse(productName, description)
+	hooks := action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before, r.GetAll,
+			func(haip Haip) (string, string) { return haip.Name, haip.Description }, description),
+		Cancel: r.Cancel,
+	}
+
+	return action.NewOperation(r.Client, response, hooks), err
+}
+
 // Update allows you to alter your Haip in several ways outlined below:
 //   - Set the description of a HA-IP;
 //   - Set the PTR record;
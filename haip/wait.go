@@ -0,0 +1,22 @@
+package haip
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/assi010/gotransip/v6/wait"
+)
+
+// WaitUntilActive waits until the Haip is active and ready to use, for example after ordering it.
+// An inactive Haip will not become active by itself, in that case a wait.UnexpectedStateError is returned.
+func (r *Repository) WaitUntilActive(ctx context.Context, haipName string, opts wait.Options) (Haip, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("haip %s", haipName),
+		func() (Haip, error) { return r.GetByName(haipName) },
+		wait.Condition[Haip]{
+			Description: fmt.Sprintf("status %s", HaipStatusActive),
+			Done:        func(haip Haip) bool { return haip.Status == HaipStatusActive },
+			Failed:      func(haip Haip) bool { return haip.Status == HaipStatusInactive },
+			State:       func(haip Haip) string { return fmt.Sprintf("status %s", haip.Status) },
+		},
+	)
+}
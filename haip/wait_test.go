@@ -0,0 +1,34 @@
+package haip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/wait"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_WaitUntilActive(t *testing.T) {
+	opts := wait.Options{InitialInterval: time.Millisecond, Timeout: time.Second}
+
+	server := testutil.MockServer{T: t, ExpectedURL: "/haips/example-haip", ExpectedMethod: "GET", StatusCode: 200, Response: `{"haip":{"name":"example-haip","status":"active"}}`}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := Repository{Client: *client}
+
+	haip, err := repo.WaitUntilActive(context.Background(), "example-haip", opts)
+	require.NoError(t, err)
+	assert.Equal(t, HaipStatusActive, haip.Status)
+
+	server = testutil.MockServer{T: t, ExpectedURL: "/haips/example-haip", ExpectedMethod: "GET", StatusCode: 200, Response: `{"haip":{"name":"example-haip","status":"inactive"}}`}
+	client, tearDown = server.GetClient()
+	defer tearDown()
+	repo = Repository{Client: *client}
+
+	_, err = repo.WaitUntilActive(context.Background(), "example-haip", opts)
+	assert.ErrorIs(t, err, wait.ErrUnexpectedState)
+	assert.EqualError(t, err, "resource reached an unexpected state: haip example-haip can not reach status active, it is status inactive")
+}
@@ -0,0 +1,254 @@
+// Package installtemplate renders preseed and kickstart files for unattended vps installs with the installer flavour.
+//
+// A Library contains templates written with text/template, like the built-in Debian preseed and RHEL kickstart
+// templates of DefaultLibrary. Every template lists the operating systems it supports, and InstallOptionsForVps
+// checks the operating system against the template and the install flavours from GetOperatingSystems,
+// so an incompatible template fails before the reinstall is submitted:
+//
+//	library := installtemplate.DefaultLibrary()
+//	options, err := library.InstallOptionsForVps(client, "example-vps", "debian-preseed", "debian-12", installtemplate.Params{
+//		Hostname:    "web-1",
+//		Domain:      "example.com",
+//		Timezone:    "Europe/Amsterdam",
+//		RootSSHKeys: []string{"ssh-ed25519 AAAA... admin@example.com"},
+//		Disk:        installtemplate.DiskLayout{LVM: true},
+//	})
+//	if err != nil {
+//		panic(err)
+//	}
+//	err = vpsRepo.InstallOperatingSystemWithOptions("example-vps", options)
+package installtemplate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// Format is the kind of install text a template renders
+type Format string
+
+const (
+	// FormatPreseed is a debian-installer preseed file
+	FormatPreseed Format = "preseed"
+	// FormatKickstart is an anaconda kickstart file
+	FormatKickstart Format = "kickstart"
+)
+
+var (
+	// ErrUnknownTemplate is returned when the library has no template with the given name
+	ErrUnknownTemplate = errors.New("unknown install template")
+	// ErrInvalidTemplate is returned when a template can not be parsed or does not list any operating system
+	ErrInvalidTemplate = errors.New("invalid install template")
+	// ErrUnknownOperatingSystem is returned when the operating system is not available for the vps
+	ErrUnknownOperatingSystem = errors.New("unknown operating system")
+	// ErrIncompatible is returned when a template can not be used to install the operating system
+	ErrIncompatible = errors.New("install template is incompatible with the operating system")
+)
+
+// Template is an install text template
+type Template struct {
+	// Name identifies the template in a Library
+	Name string
+	// Format of the rendered text
+	Format Format
+	// OperatingSystems are patterns, as accepted by path.Match, of the operating system names the template supports
+	OperatingSystems []string
+	// Text is the text/template source, it is executed with the Params after the defaults are filled in
+	Text string
+
+	parsed *template.Template
+}
+
+// Supports returns true when one of the OperatingSystems patterns matches the operating system name
+func (t *Template) Supports(operatingSystemName string) bool {
+	for _, pattern := range t.OperatingSystems {
+		if matched, _ := path.Match(pattern, operatingSystemName); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Library is a set of templates by name
+type Library struct {
+	templates map[string]*Template
+}
+
+// NewLibrary returns a library with the given templates, it returns an ErrInvalidTemplate error
+// when a template can not be parsed
+func NewLibrary(templates ...Template) (*Library, error) {
+	library := &Library{templates: make(map[string]*Template, len(templates))}
+	for _, tmpl := range templates {
+		if err := library.Add(tmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	return library, nil
+}
+
+// DefaultLibrary returns a library with the built-in templates:
+// debian-preseed for Debian and rhel-kickstart for AlmaLinux, Rocky Linux, CentOS Stream and Fedora
+func DefaultLibrary() *Library {
+	library, err := NewLibrary(builtinTemplates...)
+	if err != nil {
+		panic(err)
+	}
+
+	return library
+}
+
+// Add parses the template and adds it to the library, replacing a template with the same name
+func (l *Library) Add(tmpl Template) error {
+	if tmpl.Name == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidTemplate)
+	}
+	if tmpl.Format != FormatPreseed && tmpl.Format != FormatKickstart {
+		return fmt.Errorf("%w: template '%s' has unknown format '%s'", ErrInvalidTemplate, tmpl.Name, tmpl.Format)
+	}
+	if len(tmpl.OperatingSystems) == 0 {
+		return fmt.Errorf("%w: template '%s' does not list any operating system", ErrInvalidTemplate, tmpl.Name)
+	}
+	for _, pattern := range tmpl.OperatingSystems {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: template '%s' has invalid operating system pattern '%s'", ErrInvalidTemplate, tmpl.Name, pattern)
+		}
+	}
+
+	parsed, err := template.New(tmpl.Name).Option("missingkey=error").Parse(tmpl.Text)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	tmpl.parsed = parsed
+	l.templates[tmpl.Name] = &tmpl
+
+	return nil
+}
+
+// Template returns the template with the given name
+func (l *Library) Template(name string) (*Template, error) {
+	tmpl, ok := l.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownTemplate, name)
+	}
+
+	return tmpl, nil
+}
+
+// Templates returns all templates ordered by name
+func (l *Library) Templates() []*Template {
+	templates := make([]*Template, 0, len(l.templates))
+	for _, tmpl := range l.templates {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
+	return templates
+}
+
+// Compatible returns the templates that can install the operating system, ordered by name.
+// It is empty when the operating system does not support the installer install flavour.
+func (l *Library) Compatible(operatingSystem vps.OperatingSystem) []*Template {
+	var compatible []*Template
+	for _, tmpl := range l.Templates() {
+		if checkCompatible(tmpl, operatingSystem) == nil {
+			compatible = append(compatible, tmpl)
+		}
+	}
+
+	return compatible
+}
+
+// Render validates the params and renders the template with the given name
+func (l *Library) Render(name string, params Params) ([]byte, error) {
+	tmpl, err := l.Template(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.parsed.Execute(&buffer, params.withDefaults()); err != nil {
+		return nil, fmt.Errorf("error rendering install template '%s': %w", name, err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// InstallOptions renders the template for the operating system and returns the options to install it with the
+// installer flavour. It returns an ErrIncompatible error when the operating system does not support the installer
+// flavour or the template does not support the operating system.
+func (l *Library) InstallOptions(name string, operatingSystem vps.OperatingSystem, params Params) (vps.InstallOptions, error) {
+	tmpl, err := l.Template(name)
+	if err != nil {
+		return vps.InstallOptions{}, err
+	}
+	if err := checkCompatible(tmpl, operatingSystem); err != nil {
+		return vps.InstallOptions{}, err
+	}
+
+	text, err := l.Render(name, params)
+	if err != nil {
+		return vps.InstallOptions{}, err
+	}
+
+	return vps.InstallOptions{
+		OperatingSystemName: operatingSystem.Name,
+		InstallFlavour:      vps.InstallFlavourInstaller,
+		Hostname:            params.Hostname,
+		Base64InstallText:   base64.StdEncoding.EncodeToString(text),
+	}, nil
+}
+
+// InstallOptionsForVps looks up the operating system in the operating systems available for the vps,
+// using vps.Repository.GetOperatingSystems, and returns the InstallOptions for it
+func (l *Library) InstallOptionsForVps(client repository.Client, vpsName string, name string, operatingSystemName string, params Params) (vps.InstallOptions, error) {
+	vpsRepo := vps.Repository{Client: client}
+	operatingSystems, err := vpsRepo.GetOperatingSystems(vpsName)
+	if err != nil {
+		return vps.InstallOptions{}, fmt.Errorf("error listing operating systems: %w", err)
+	}
+
+	for _, operatingSystem := range operatingSystems {
+		if operatingSystem.Name == operatingSystemName {
+			return l.InstallOptions(name, operatingSystem, params)
+		}
+	}
+
+	return vps.InstallOptions{}, fmt.Errorf("%w: '%s' is not available for vps %s", ErrUnknownOperatingSystem, operatingSystemName, vpsName)
+}
+
+// checkCompatible returns an ErrIncompatible error when the template can not install the operating system
+func checkCompatible(tmpl *Template, operatingSystem vps.OperatingSystem) error {
+	supportsInstaller := false
+	for _, flavour := range operatingSystem.InstallFlavours {
+		if flavour == vps.InstallFlavourInstaller {
+			supportsInstaller = true
+			break
+		}
+	}
+	if !supportsInstaller {
+		flavours := make([]string, len(operatingSystem.InstallFlavours))
+		for idx, flavour := range operatingSystem.InstallFlavours {
+			flavours[idx] = string(flavour)
+		}
+		return fmt.Errorf("%w: %s does not support the installer flavour, only '%s'", ErrIncompatible, operatingSystem.Name, strings.Join(flavours, "', '"))
+	}
+	if !tmpl.Supports(operatingSystem.Name) {
+		return fmt.Errorf("%w: template '%s' supports %s, not %s", ErrIncompatible, tmpl.Name, strings.Join(tmpl.OperatingSystems, ", "), operatingSystem.Name)
+	}
+
+	return nil
+}
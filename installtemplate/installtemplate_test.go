@@ -0,0 +1,179 @@
+package installtemplate
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	debian = vps.OperatingSystem{Name: "debian-12", InstallFlavours: []vps.InstallFlavour{vps.InstallFlavourInstaller, vps.InstallFlavourCloudInit}}
+	alma   = vps.OperatingSystem{Name: "almalinux-9", InstallFlavours: []vps.InstallFlavour{vps.InstallFlavourInstaller}}
+	ubuntu = vps.OperatingSystem{Name: "ubuntu-24.04", InstallFlavours: []vps.InstallFlavour{vps.InstallFlavourCloudInit, vps.InstallFlavourPreinstallable}}
+)
+
+func TestLibrary_RenderPreseed(t *testing.T) {
+	text, err := DefaultLibrary().Render("debian-preseed", Params{
+		Hostname:    "web-1",
+		Domain:      "example.com",
+		Timezone:    "Europe/Amsterdam",
+		RootSSHKeys: []string{testKey},
+		Disk:        DiskLayout{LVM: true},
+		Packages:    []string{"nginx", "curl"},
+	})
+	require.NoError(t, err)
+
+	rendered := string(text)
+	for _, line := range []string{
+		"d-i netcfg/get_hostname string web-1\n",
+		"d-i netcfg/get_domain string example.com\n",
+		"d-i passwd/root-password-crypted password !\n",
+		"d-i time/zone string Europe/Amsterdam\n",
+		"d-i partman-auto/disk string /dev/vda\n",
+		"d-i partman-auto/method string lvm\n",
+		"d-i partman-auto/choose_recipe select atomic\n",
+		"d-i pkgsel/include string openssh-server nginx curl\n",
+		"d-i grub-installer/bootdev string /dev/vda\n",
+		"    echo '" + testKey + "' >> /target/root/.ssh/authorized_keys; \\\n",
+		"    in-target passwd -l root; \\\n",
+	} {
+		assert.Contains(t, rendered, line)
+	}
+	assert.NotContains(t, rendered, "partman-auto/method string regular")
+}
+
+func TestLibrary_RenderKickstart(t *testing.T) {
+	text, err := DefaultLibrary().Render("rhel-kickstart", Params{
+		Hostname:         "db-1",
+		Domain:           "example.com",
+		Locale:           "nl_NL.UTF-8",
+		RootPasswordHash: "$6$salt$hash",
+		Disk:             DiskLayout{Device: "sda", SeparateHome: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `# anaconda kickstart rendered by gotransip installtemplate
+text
+lang nl_NL.UTF-8
+keyboard --vckeymap=us
+timezone UTC --utc
+network --bootproto=dhcp --hostname=db-1.example.com --activate
+rootpw --iscrypted $6$salt$hash
+
+ignoredisk --only-use=sda
+zerombr
+clearpart --all --initlabel --drives=sda
+bootloader --location=mbr --boot-drive=sda
+autopart --type=plain
+
+firewall --enabled --ssh
+selinux --enforcing
+services --enabled=sshd
+reboot
+
+%packages
+@^minimal-environment
+openssh-server
+%end
+`, string(text))
+}
+
+func TestLibrary_RenderErrors(t *testing.T) {
+	library := DefaultLibrary()
+
+	_, err := library.Render("windows-unattend", Params{Hostname: "web-1", RootSSHKeys: []string{testKey}})
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+	assert.EqualError(t, err, "unknown install template: 'windows-unattend'")
+
+	_, err = library.Render("debian-preseed", Params{Hostname: "web_1", RootSSHKeys: []string{testKey}})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+}
+
+func TestLibrary_Add(t *testing.T) {
+	library, err := NewLibrary(Template{Name: "custom", Format: FormatKickstart, OperatingSystems: []string{"rockylinux-9"}, Text: "network --hostname={{.FQDN}}\n{{.Missing}}\n"})
+	require.NoError(t, err)
+	_, err = library.Render("custom", Params{Hostname: "web-1", RootSSHKeys: []string{testKey}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error rendering install template 'custom'")
+
+	tests := []struct {
+		template Template
+		expected string
+	}{
+		{Template{Format: FormatPreseed, OperatingSystems: []string{"debian-*"}}, "invalid install template: a name is required"},
+		{Template{Name: "custom", Format: "autoyast", OperatingSystems: []string{"opensuse-*"}}, "invalid install template: template 'custom' has unknown format 'autoyast'"},
+		{Template{Name: "custom", Format: FormatPreseed}, "invalid install template: template 'custom' does not list any operating system"},
+		{Template{Name: "custom", Format: FormatPreseed, OperatingSystems: []string{"debian-["}}, "invalid install template: template 'custom' has invalid operating system pattern 'debian-['"},
+		{Template{Name: "custom", Format: FormatPreseed, OperatingSystems: []string{"debian-*"}, Text: "{{.Hostname"}, "invalid install template: template: custom:1: unclosed action"},
+	}
+	for _, tt := range tests {
+		err := library.Add(tt.template)
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+		assert.EqualError(t, err, tt.expected)
+	}
+}
+
+func TestLibrary_Compatible(t *testing.T) {
+	library := DefaultLibrary()
+
+	names := func(templates []*Template) []string {
+		var result []string
+		for _, tmpl := range templates {
+			result = append(result, tmpl.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"debian-preseed", "rhel-kickstart"}, names(library.Templates()))
+	assert.Equal(t, []string{"debian-preseed"}, names(library.Compatible(debian)))
+	assert.Equal(t, []string{"rhel-kickstart"}, names(library.Compatible(alma)))
+	assert.Empty(t, library.Compatible(ubuntu))
+}
+
+func TestLibrary_InstallOptions(t *testing.T) {
+	library := DefaultLibrary()
+	params := Params{Hostname: "web-1", RootSSHKeys: []string{testKey}}
+
+	options, err := library.InstallOptions("debian-preseed", debian, params)
+	require.NoError(t, err)
+	assert.Equal(t, "debian-12", options.OperatingSystemName)
+	assert.Equal(t, vps.InstallFlavourInstaller, options.InstallFlavour)
+	assert.Equal(t, "web-1", options.Hostname)
+	text, err := base64.StdEncoding.DecodeString(options.Base64InstallText)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(text), "# debian-installer preseed"))
+
+	_, err = library.InstallOptions("rhel-kickstart", debian, params)
+	assert.ErrorIs(t, err, ErrIncompatible)
+	assert.EqualError(t, err, "install template is incompatible with the operating system: template 'rhel-kickstart' supports almalinux-*, rockylinux-*, centos-stream-*, fedora-*, not debian-12")
+
+	_, err = library.InstallOptions("debian-preseed", ubuntu, params)
+	assert.ErrorIs(t, err, ErrIncompatible)
+	assert.EqualError(t, err, "install template is incompatible with the operating system: ubuntu-24.04 does not support the installer flavour, only 'cloudinit', 'preinstallable'")
+}
+
+func TestLibrary_InstallOptionsForVps(t *testing.T) {
+	const apiResponse = `{"operatingSystems":[
+		{"name":"debian-12","description":"Debian 12","installFlavours":["installer","cloudinit"]},
+		{"name":"ubuntu-24.04","description":"Ubuntu 24.04","installFlavours":["cloudinit"]}]}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/vps/example-vps/operating-systems", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+
+	library := DefaultLibrary()
+	params := Params{Hostname: "web-1", RootSSHKeys: []string{testKey}}
+
+	options, err := library.InstallOptionsForVps(*client, "example-vps", "debian-preseed", "debian-12", params)
+	require.NoError(t, err)
+	assert.Equal(t, "debian-12", options.OperatingSystemName)
+
+	_, err = library.InstallOptionsForVps(*client, "example-vps", "debian-preseed", "ubuntu-24.04", params)
+	assert.ErrorIs(t, err, ErrIncompatible)
+
+	_, err = library.InstallOptionsForVps(*client, "example-vps", "rhel-kickstart", "almalinux-9", params)
+	assert.ErrorIs(t, err, ErrUnknownOperatingSystem)
+	assert.EqualError(t, err, "unknown operating system: 'almalinux-9' is not available for vps example-vps")
+}
@@ -0,0 +1,163 @@
+package installtemplate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/sshkey"
+)
+
+// The values used for the Params that are not set
+const (
+	defaultDomain   = "localdomain"
+	defaultTimezone = "UTC"
+	defaultLocale   = "en_US.UTF-8"
+	defaultKeymap   = "us"
+	defaultDevice   = "vda"
+)
+
+var (
+	// ErrInvalidParams is wrapped by every ValidationError
+	ErrInvalidParams = errors.New("invalid install parameters")
+)
+
+var (
+	// hostnameLabel matches one label of a hostname
+	hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	// device matches the name of a disk, like vda or nvme0n1
+	device = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	// setting matches values like a timezone, locale, keymap or package name, which are inserted without quoting
+	setting = regexp.MustCompile(`^[A-Za-z0-9_.+@:/-]+$`)
+	// passwordHash matches a crypt(3) hash as generated by mkpasswd or openssl passwd
+	passwordHash = regexp.MustCompile(`^\$[0-9a-z]+\$[./A-Za-z0-9$=,]+$`)
+)
+
+// ValidationError is returned when Params contain one or more invalid values
+type ValidationError struct {
+	// Problems describes every invalid value
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidParams, strings.Join(e.Problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidParams) work for a ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidParams
+}
+
+// DiskLayout describes how the installer partitions the disk, the whole disk is erased
+type DiskLayout struct {
+	// Device is the name of the disk, vda when empty
+	Device string
+	// LVM puts the partitions in an LVM volume group instead of plain partitions
+	LVM bool
+	// SeparateHome creates a separate /home partition instead of one root partition
+	SeparateHome bool
+}
+
+// Params are the values an install template is rendered with
+type Params struct {
+	// Hostname of the vps, without the domain
+	Hostname string
+	// Domain of the vps, localdomain when empty
+	Domain string
+	// Timezone like "Europe/Amsterdam", UTC when empty
+	Timezone string
+	// Locale like "nl_NL.UTF-8", en_US.UTF-8 when empty
+	Locale string
+	// Keymap of the console, us when empty
+	Keymap string
+	// RootSSHKeys are the public keys that can log in as root
+	RootSSHKeys []string
+	// RootPasswordHash is a crypt(3) hash of the root password, when empty the root password is locked
+	RootPasswordHash string
+	// Disk is the partitioning of the disk
+	Disk DiskLayout
+	// Packages are installed in addition to the minimal system and the ssh server
+	Packages []string
+}
+
+// FQDN returns the hostname followed by the domain
+func (p Params) FQDN() string {
+	return p.Hostname + "." + p.Domain
+}
+
+// withDefaults returns the params with the defaults filled in for values that are not set
+func (p Params) withDefaults() Params {
+	if p.Domain == "" {
+		p.Domain = defaultDomain
+	}
+	if p.Timezone == "" {
+		p.Timezone = defaultTimezone
+	}
+	if p.Locale == "" {
+		p.Locale = defaultLocale
+	}
+	if p.Keymap == "" {
+		p.Keymap = defaultKeymap
+	}
+	if p.Disk.Device == "" {
+		p.Disk.Device = defaultDevice
+	}
+
+	return p
+}
+
+// Validate returns a ValidationError describing every invalid value.
+// A hostname and a way to log in, a root ssh key or password hash, are required.
+func (p Params) Validate() error {
+	p = p.withDefaults()
+
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !hostnameLabel.MatchString(p.Hostname) {
+		add("invalid hostname '%s', expected a single label without the domain", p.Hostname)
+	}
+	for _, label := range strings.Split(p.Domain, ".") {
+		if !hostnameLabel.MatchString(label) {
+			add("invalid domain '%s'", p.Domain)
+			break
+		}
+	}
+	for _, value := range []struct{ name, value string }{{"timezone", p.Timezone}, {"locale", p.Locale}, {"keymap", p.Keymap}} {
+		if !setting.MatchString(value.value) {
+			add("invalid %s '%s'", value.name, value.value)
+		}
+	}
+
+	if len(p.RootSSHKeys) == 0 && p.RootPasswordHash == "" {
+		add("a root ssh key or a root password hash is required to log in")
+	}
+	for _, key := range p.RootSSHKeys {
+		if err := sshkey.ValidateKey(key); err != nil {
+			add("%s", err)
+		} else if strings.ContainsAny(key, "'\"\\\n") {
+			add("ssh key comment contains quotes or backslashes")
+		}
+	}
+	if p.RootPasswordHash != "" && !passwordHash.MatchString(p.RootPasswordHash) {
+		add("root password hash is not a crypt(3) hash like $6$salt$hash, never use a plain text password")
+	}
+
+	if !device.MatchString(p.Disk.Device) {
+		add("invalid disk device '%s', expected a name like vda", p.Disk.Device)
+	}
+	for _, name := range p.Packages {
+		if !setting.MatchString(name) {
+			add("invalid package name '%s'", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package installtemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f admin@example"
+
+func TestParams_Validate(t *testing.T) {
+	assert.NoError(t, Params{Hostname: "web-1", RootSSHKeys: []string{testKey}}.Validate())
+	assert.NoError(t, Params{Hostname: "web-1", RootPasswordHash: "$6$rounds=4096$salt$hash./"}.Validate())
+
+	params := Params{
+		Hostname:         "web-1.example.com",
+		Domain:           "example..com",
+		Timezone:         "Europe/New York",
+		Locale:           "en_US.UTF-8; reboot",
+		RootSSHKeys:      []string{"ssh-rsa AAAA", testKey + " it's me"},
+		RootPasswordHash: "hunter2",
+		Disk:             DiskLayout{Device: "/dev/vda"},
+		Packages:         []string{"vim curl"},
+	}
+	err := params.Validate()
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	var validationError *ValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, []string{
+		"invalid hostname 'web-1.example.com', expected a single label without the domain",
+		"invalid domain 'example..com'",
+		"invalid timezone 'Europe/New York'",
+		"invalid locale 'en_US.UTF-8; reboot'",
+		"invalid ssh public key: key is truncated",
+		"ssh key comment contains quotes or backslashes",
+		"root password hash is not a crypt(3) hash like $6$salt$hash, never use a plain text password",
+		"invalid disk device '/dev/vda', expected a name like vda",
+		"invalid package name 'vim curl'",
+	}, validationError.Problems)
+
+	err = Params{Hostname: "web-1"}.Validate()
+	assert.EqualError(t, err, "invalid install parameters: a root ssh key or a root password hash is required to log in")
+}
+
+func TestParams_FQDN(t *testing.T) {
+	assert.Equal(t, "web-1.example.com", Params{Hostname: "web-1", Domain: "example.com"}.FQDN())
+	assert.Equal(t, "web-1.localdomain", Params{Hostname: "web-1"}.withDefaults().FQDN())
+}
@@ -0,0 +1,108 @@
+package installtemplate
+
+// builtinTemplates are the templates of DefaultLibrary
+var builtinTemplates = []Template{
+	{Name: "debian-preseed", Format: FormatPreseed, OperatingSystems: []string{"debian-*"}, Text: debianPreseed},
+	{Name: "rhel-kickstart", Format: FormatKickstart, OperatingSystems: []string{"almalinux-*", "rockylinux-*", "centos-stream-*", "fedora-*"}, Text: rhelKickstart},
+}
+
+// debianPreseed installs a minimal Debian system with an ssh server
+const debianPreseed = `# debian-installer preseed rendered by gotransip installtemplate
+d-i debian-installer/locale string {{.Locale}}
+d-i keyboard-configuration/xkb-keymap select {{.Keymap}}
+
+d-i netcfg/choose_interface select auto
+d-i netcfg/get_hostname string {{.Hostname}}
+d-i netcfg/get_domain string {{.Domain}}
+d-i netcfg/hostname string {{.Hostname}}
+
+d-i mirror/country string manual
+d-i mirror/http/hostname string deb.debian.org
+d-i mirror/http/directory string /debian
+d-i mirror/http/proxy string
+
+d-i passwd/make-user boolean false
+{{- if .RootPasswordHash}}
+d-i passwd/root-password-crypted password {{.RootPasswordHash}}
+{{- else}}
+d-i passwd/root-password-crypted password !
+{{- end}}
+
+d-i clock-setup/utc boolean true
+d-i time/zone string {{.Timezone}}
+d-i clock-setup/ntp boolean true
+
+d-i partman-auto/disk string /dev/{{.Disk.Device}}
+{{- if .Disk.LVM}}
+d-i partman-auto/method string lvm
+d-i partman-auto-lvm/guided_size string max
+d-i partman-lvm/device_remove_lvm boolean true
+d-i partman-lvm/confirm boolean true
+d-i partman-lvm/confirm_nooverwrite boolean true
+{{- else}}
+d-i partman-auto/method string regular
+{{- end}}
+d-i partman-auto/choose_recipe select {{if .Disk.SeparateHome}}home{{else}}atomic{{end}}
+d-i partman-partitioning/confirm_write_new_label boolean true
+d-i partman/choose_partition select finish
+d-i partman/confirm boolean true
+d-i partman/confirm_nooverwrite boolean true
+
+tasksel tasksel/first multiselect standard, ssh-server
+d-i pkgsel/include string openssh-server{{range .Packages}} {{.}}{{end}}
+d-i pkgsel/upgrade select full-upgrade
+popularity-contest popularity-contest/participate boolean false
+
+d-i grub-installer/only_debian boolean true
+d-i grub-installer/bootdev string /dev/{{.Disk.Device}}
+d-i finish-install/reboot_in_progress note
+
+d-i preseed/late_command string \
+    mkdir -p /target/root/.ssh; \
+{{- range .RootSSHKeys}}
+    echo '{{.}}' >> /target/root/.ssh/authorized_keys; \
+{{- end}}
+{{- if not .RootPasswordHash}}
+    in-target passwd -l root; \
+{{- end}}
+    chmod 700 /target/root/.ssh; \
+    touch /target/root/.ssh/authorized_keys; \
+    chmod 600 /target/root/.ssh/authorized_keys
+`
+
+// rhelKickstart installs a minimal Red Hat Enterprise Linux compatible system with an ssh server
+const rhelKickstart = `# anaconda kickstart rendered by gotransip installtemplate
+text
+lang {{.Locale}}
+keyboard --vckeymap={{.Keymap}}
+timezone {{.Timezone}} --utc
+network --bootproto=dhcp --hostname={{.FQDN}} --activate
+
+{{- if .RootPasswordHash}}
+rootpw --iscrypted {{.RootPasswordHash}}
+{{- else}}
+rootpw --lock
+{{- end}}
+{{- range .RootSSHKeys}}
+sshkey --username=root "{{.}}"
+{{- end}}
+
+ignoredisk --only-use={{.Disk.Device}}
+zerombr
+clearpart --all --initlabel --drives={{.Disk.Device}}
+bootloader --location=mbr --boot-drive={{.Disk.Device}}
+autopart --type={{if .Disk.LVM}}lvm{{else}}plain{{end}}{{if not .Disk.SeparateHome}} --nohome{{end}}
+
+firewall --enabled --ssh
+selinux --enforcing
+services --enabled=sshd
+reboot
+
+%packages
+@^minimal-environment
+openssh-server
+{{- range .Packages}}
+{{.}}
+{{- end}}
+%end
+`
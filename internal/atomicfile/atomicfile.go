@@ -0,0 +1,63 @@
+// Package atomicfile replaces files at once, so an interrupted write keeps the old content.
+// It is used for the token cache and the state files of incremental and resumable commands.
+package atomicfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to a temporary file next to path, flushes it to disk and renames it to path.
+// The temporary file has to be in the same directory, a rename is only atomic within one filesystem.
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	if err := writeAndClose(file, data, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error replacing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes value as indented json followed by a newline, see WriteFile
+func WriteJSON(path string, value any, perm fs.FileMode) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(path, append(data, '\n'), perm)
+}
+
+// writeAndClose sets the permissions of the file, writes data to it, flushes it to disk and closes it
+func writeAndClose(file *os.File, data []byte, perm fs.FileMode) error {
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return fmt.Errorf("error setting file permissions: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("error syncing file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	return nil
+}
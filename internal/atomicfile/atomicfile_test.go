@@ -0,0 +1,46 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	require.NoError(t, WriteFile(path, []byte("first"), 0600))
+	require.NoError(t, WriteFile(path, []byte("second"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the temporary files are renamed, nothing is left next to the file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	err = WriteFile(filepath.Join(dir, "missing", "state.json"), []byte("first"), 0600)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error creating temporary file")
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	require.NoError(t, WriteJSON(path, map[string]int{"b": 2, "a": 1}, 0600))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": 1,\n  \"b\": 2\n}\n", string(data))
+
+	assert.Error(t, WriteJSON(path, func() {}, 0600))
+}
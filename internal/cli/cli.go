@@ -0,0 +1,49 @@
+// Package cli contains the flags the commands of this module share to configure the api client
+package cli
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/assi010/gotransip/v6"
+)
+
+// ErrNoCredentials is returned when neither an account name with a private key, nor a token is configured
+var ErrNoCredentials = errors.New("no credentials, use -account-name with -private-key, or set TRANSIP_TOKEN")
+
+// ClientFlags are the flags to configure the api client
+type ClientFlags struct {
+	AccountName    string
+	PrivateKeyPath string
+	TestMode       bool
+	Demo           bool
+}
+
+// Register adds the client flags to the flag set
+func (f *ClientFlags) Register(flags *flag.FlagSet) {
+	flags.StringVar(&f.AccountName, "account-name", os.Getenv("TRANSIP_ACCOUNT_NAME"), "account name, defaults to $TRANSIP_ACCOUNT_NAME")
+	flags.StringVar(&f.PrivateKeyPath, "private-key", os.Getenv("TRANSIP_PRIVATE_KEY"), "path of the private key, defaults to $TRANSIP_PRIVATE_KEY")
+	flags.BoolVar(&f.TestMode, "test-mode", false, "use the test mode of the api")
+	flags.BoolVar(&f.Demo, "demo", false, "use the demo account")
+}
+
+// Configuration returns the client configuration of the flags, a token can be passed in $TRANSIP_TOKEN
+// so it does not show up in the process list
+func (f *ClientFlags) Configuration() (gotransip.ClientConfiguration, error) {
+	if f.Demo {
+		return gotransip.DemoClientConfiguration, nil
+	}
+
+	config := gotransip.ClientConfiguration{
+		AccountName:    f.AccountName,
+		PrivateKeyPath: f.PrivateKeyPath,
+		Token:          os.Getenv("TRANSIP_TOKEN"),
+		TestMode:       f.TestMode,
+	}
+	if config.Token == "" && (config.AccountName == "" || config.PrivateKeyPath == "") {
+		return gotransip.ClientConfiguration{}, ErrNoCredentials
+	}
+
+	return config, nil
+}
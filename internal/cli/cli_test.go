@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientFlags_Configuration(t *testing.T) {
+	t.Setenv("TRANSIP_ACCOUNT_NAME", "")
+	t.Setenv("TRANSIP_PRIVATE_KEY", "")
+	t.Setenv("TRANSIP_TOKEN", "")
+
+	parse := func(args ...string) *ClientFlags {
+		var clientFlags ClientFlags
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		clientFlags.Register(flags)
+		require.NoError(t, flags.Parse(args))
+		return &clientFlags
+	}
+
+	config, err := parse("-account-name", "example", "-private-key", "transip.key", "-test-mode").Configuration()
+	require.NoError(t, err)
+	assert.Equal(t, gotransip.ClientConfiguration{AccountName: "example", PrivateKeyPath: "transip.key", TestMode: true}, config)
+
+	config, err = parse("-demo").Configuration()
+	require.NoError(t, err)
+	assert.Equal(t, gotransip.DemoClientConfiguration, config)
+
+	_, err = parse("-account-name", "example").Configuration()
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	t.Setenv("TRANSIP_TOKEN", "token")
+	config, err = parse().Configuration()
+	require.NoError(t, err)
+	assert.Equal(t, "token", config.Token)
+}
@@ -0,0 +1,299 @@
+// Command operations generates the list of repository operations in repository/operations_gen.go.
+// It parses every repository package and records the http method and endpoint of each repository method.
+//
+// Run it from the root of the module with: go generate ./repository
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// outputFile is the location of the generated file, relative to the module root
+const outputFile = "repository/operations_gen.go"
+
+// clientMethods maps the methods of repository.Client to the http method they use
+var clientMethods = map[string]string{
+	"Get":               "GET",
+	"Post":              "POST",
+	"PostWithResponse":  "POST",
+	"Put":               "PUT",
+	"PutWithResponse":   "PUT",
+	"Patch":             "PATCH",
+	"PatchWithResponse": "PATCH",
+	"Delete":            "DELETE",
+}
+
+// operation is one repository method that calls the api
+type operation struct {
+	pkg, repository, method string
+	httpMethod, endpoint    string
+	// delegates are set when the method does not call the client itself,
+	// but other methods on the same repository
+	delegates []string
+}
+
+func main() {
+	packageDirs, err := filepath.Glob("*/repository.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var operations []operation
+	for _, file := range packageDirs {
+		dir := filepath.Dir(file)
+		if dir == "repository" {
+			continue
+		}
+		dirOperations, err := parsePackage(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		operations = append(operations, dirOperations...)
+	}
+
+	operations = resolveDelegates(operations)
+	sort.Slice(operations, func(i, j int) bool {
+		a, b := operations[i], operations[j]
+		if a.pkg != b.pkg {
+			return a.pkg < b.pkg
+		}
+		if a.repository != b.repository {
+			return a.repository < b.repository
+		}
+		return a.method < b.method
+	})
+
+	source, err := render(operations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outputFile, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parsePackage returns the operations of all repositories defined in the given package directory
+func parsePackage(dir string) ([]operation, error) {
+	fileSet := token.NewFileSet()
+	packages, err := parser.ParseDir(fileSet, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []operation
+	for pkgName, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok || funcDecl.Recv == nil || !funcDecl.Name.IsExported() {
+					continue
+				}
+				repositoryName, receiverName := receiver(funcDecl)
+				if !strings.HasSuffix(repositoryName, "Repository") || isWaitHelper(funcDecl.Name.Name) {
+					continue
+				}
+
+				op := operation{pkg: pkgName, repository: repositoryName, method: funcDecl.Name.Name}
+				inspectBody(funcDecl.Body, receiverName, &op)
+				if op.httpMethod != "" || len(op.delegates) > 0 {
+					operations = append(operations, op)
+				}
+			}
+		}
+	}
+
+	return operations, nil
+}
+
+// isWaitHelper returns true for methods like WaitUntilStatus, which only poll other repository methods
+// and are not an api operation of their own
+func isWaitHelper(method string) bool {
+	return strings.HasPrefix(method, "WaitUntil") || strings.HasPrefix(method, "WaitFor")
+}
+
+// receiver returns the type name and variable name of the receiver of a method
+func receiver(funcDecl *ast.FuncDecl) (string, string) {
+	field := funcDecl.Recv.List[0]
+	receiverType := field.Type
+	if star, ok := receiverType.(*ast.StarExpr); ok {
+		receiverType = star.X
+	}
+	ident, ok := receiverType.(*ast.Ident)
+	if !ok || len(field.Names) == 0 {
+		return "", ""
+	}
+
+	return ident.Name, field.Names[0].Name
+}
+
+// inspectBody finds the client call and endpoint of a repository method
+func inspectBody(body *ast.BlockStmt, receiverName string, op *operation) {
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CompositeLit:
+			if op.endpoint == "" && isRestRequest(node.Type) {
+				op.endpoint = endpoint(node)
+			}
+		case *ast.CallExpr:
+			selector, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			// r.Client.Get(...)
+			if inner, ok := selector.X.(*ast.SelectorExpr); ok && inner.Sel.Name == "Client" && isIdent(inner.X, receiverName) {
+				if httpMethod, ok := clientMethods[selector.Sel.Name]; ok && op.httpMethod == "" {
+					op.httpMethod = httpMethod
+				}
+			}
+			// r.GetByID(...)
+			if isIdent(selector.X, receiverName) {
+				op.delegates = append(op.delegates, selector.Sel.Name)
+			}
+		}
+		return true
+	})
+}
+
+// isRestRequest returns true when the expression is the rest.Request type
+func isRestRequest(expr ast.Expr) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	return ok && selector.Sel.Name == "Request" && isIdent(selector.X, "rest")
+}
+
+// isIdent returns true when the expression is an identifier with the given name
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// endpoint returns the endpoint of a rest.Request literal,
+// format verbs are replaced by the name of their argument: fmt.Sprintf("/vps/%s", vpsName) becomes "/vps/{vpsName}"
+func endpoint(literal *ast.CompositeLit) string {
+	for _, element := range literal.Elts {
+		keyValue, ok := element.(*ast.KeyValueExpr)
+		if !ok || !isIdent(keyValue.Key, "Endpoint") {
+			continue
+		}
+
+		switch value := keyValue.Value.(type) {
+		case *ast.BasicLit:
+			endpoint, _ := strconv.Unquote(value.Value)
+			return endpoint
+		case *ast.CallExpr:
+			if len(value.Args) == 0 {
+				return ""
+			}
+			formatLiteral, ok := value.Args[0].(*ast.BasicLit)
+			if !ok {
+				return ""
+			}
+			format, _ := strconv.Unquote(formatLiteral.Value)
+			for _, arg := range value.Args[1:] {
+				idx := strings.Index(format, "%")
+				if idx < 0 || idx+1 >= len(format) {
+					break
+				}
+				format = format[:idx] + "{" + argumentName(arg) + "}" + format[idx+2:]
+			}
+			return format
+		}
+	}
+
+	return ""
+}
+
+// argumentName returns a readable name for a format argument
+func argumentName(arg ast.Expr) string {
+	switch arg := arg.(type) {
+	case *ast.Ident:
+		return arg.Name
+	case *ast.SelectorExpr:
+		return arg.Sel.Name
+	default:
+		return "param"
+	}
+}
+
+// resolveDelegates fills in the http method and endpoint of methods that call other methods on their repository,
+// methods that (indirectly) never call the api are left out.
+// A method calling several methods, like a read followed by an update, gets the http method of the first mutating one.
+func resolveDelegates(operations []operation) []operation {
+	index := make(map[string]operation)
+	for _, op := range operations {
+		index[op.pkg+"."+op.repository+"."+op.method] = op
+	}
+
+	var resolved []operation
+	for _, op := range operations {
+		target, ok := resolve(index, op, 0)
+		if !ok {
+			continue
+		}
+
+		op.httpMethod = target.httpMethod
+		if op.endpoint == "" {
+			op.endpoint = target.endpoint
+		}
+		resolved = append(resolved, op)
+	}
+
+	return resolved
+}
+
+// resolve follows the delegates of an operation, like GetAllUsage24Hours -> GetAllUsage -> GetUsage,
+// and returns the operation that calls the api. The depth guards against methods calling each other.
+func resolve(index map[string]operation, op operation, depth int) (operation, bool) {
+	if op.httpMethod != "" {
+		return op, true
+	}
+	if depth > len(index) {
+		return operation{}, false
+	}
+
+	var found operation
+	var ok bool
+	for _, delegate := range op.delegates {
+		next, exists := index[op.pkg+"."+op.repository+"."+delegate]
+		if !exists {
+			continue
+		}
+		target, resolved := resolve(index, next, depth+1)
+		if !resolved {
+			continue
+		}
+		if !ok || (found.httpMethod == "GET" && target.httpMethod != "GET") {
+			found, ok = target, true
+		}
+	}
+
+	return found, ok
+}
+
+// render returns the formatted source of the generated file
+func render(operations []operation) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("// Code generated by internal/gen/operations; DO NOT EDIT.\n\n")
+	buffer.WriteString("package repository\n\n")
+	buffer.WriteString("// operations contains every repository method that calls the api\n")
+	buffer.WriteString("var operations = []Operation{\n")
+	for _, op := range operations {
+		fmt.Fprintf(&buffer, "\t{Package: %q, Repository: %q, Method: %q, HTTPMethod: %q, Endpoint: %q, Mutating: %t},\n",
+			op.pkg, op.repository, op.method, op.httpMethod, op.endpoint, op.httpMethod != "GET")
+	}
+	buffer.WriteString("}\n")
+
+	return format.Source(buffer.Bytes())
+}
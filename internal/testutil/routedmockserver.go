@@ -0,0 +1,109 @@
+package testutil
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// RoutedMockServer is used to test code that calls several endpoints, like a planner listing vpses and their
+// snapshots. It serves fixed responses for GET requests by path and records every other request as a change.
+type RoutedMockServer struct {
+	T *testing.T
+	// Responses maps the path of a GET request to its response body
+	Responses map[string]string
+	// Fallback handles GET requests for paths without a response, it returns false to respond with a 404 error
+	Fallback func(rw http.ResponseWriter, req *http.Request) bool
+	// Failures contains the changes, as "METHOD path", that respond with a 404 error instead
+	Failures map[string]bool
+	// StorePuts serves the body of a PUT request for later GET requests of the same path
+	StorePuts bool
+	// Error is the error message of 404 responses, defaults to "not found"
+	Error string
+	// Changes records the requests that are not a GET, as "METHOD path body"
+	Changes []string
+
+	mutex  sync.Mutex
+	stored map[string]string
+}
+
+// GetHTTPServer returns the server part of the RoutedMockServer
+func (s *RoutedMockServer) GetHTTPServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			s.serveGet(rw, req)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(s.T, err)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.Failures[req.Method+" "+req.URL.Path] {
+			s.writeNotFound(rw)
+			return
+		}
+		s.Changes = append(s.Changes, strings.TrimSpace(req.Method+" "+req.URL.Path+" "+string(body)))
+		if req.Method == http.MethodPut && s.StorePuts {
+			if s.stored == nil {
+				s.stored = make(map[string]string)
+			}
+			s.stored[req.URL.Path] = string(body)
+		}
+
+		// the api responds to a POST with 201 Created and to other changes with 204 No Content
+		if req.Method == http.MethodPost {
+			rw.WriteHeader(http.StatusCreated)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+// GetClient returns a client to the RoutedMockServer and a function that closes the server
+func (s *RoutedMockServer) GetClient() (repository.Client, func()) {
+	httpServer := s.GetHTTPServer()
+	config := gotransip.DemoClientConfiguration
+	config.URL = httpServer.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(s.T, err)
+
+	return client, httpServer.Close
+}
+
+// serveGet responds with the stored PUT body or the response of the path, or else calls the Fallback
+func (s *RoutedMockServer) serveGet(rw http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	response, ok := s.stored[req.URL.Path]
+	if !ok {
+		response, ok = s.Responses[req.URL.Path]
+	}
+	s.mutex.Unlock()
+
+	switch {
+	case ok:
+		_, err := rw.Write([]byte(response))
+		require.NoError(s.T, err, "error when writing mock response")
+	case s.Fallback == nil || !s.Fallback(rw, req):
+		s.writeNotFound(rw)
+	}
+}
+
+// writeNotFound responds with a 404 error, like the api does for an unknown resource
+func (s *RoutedMockServer) writeNotFound(rw http.ResponseWriter) {
+	message := s.Error
+	if message == "" {
+		message = "not found"
+	}
+	rw.WriteHeader(http.StatusNotFound)
+	_, _ = fmt.Fprintf(rw, `{"error":%q}`, message)
+}
@@ -0,0 +1,19 @@
+// Package tokencache contains a token cache for tests. It is separate from testutil, because testutil imports
+// the gotransip package, which can not be imported by the tests of gotransip and the authenticator package.
+package tokencache
+
+import "github.com/assi010/gotransip/v6/jwt"
+
+// Memory is a TokenCache that keeps tokens in memory
+type Memory map[string]jwt.Token
+
+// Set stores the token by key
+func (m Memory) Set(key string, token jwt.Token) error {
+	m[key] = token
+	return nil
+}
+
+// Get returns the token stored by key, or an empty token when there is none
+func (m Memory) Get(key string) (jwt.Token, error) {
+	return m[key], nil
+}
@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// invalidGroupCharacters matches the characters Ansible does not accept in a group name
+var invalidGroupCharacters = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// AnsibleGroup is a group in an Ansible dynamic inventory
+type AnsibleGroup struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+// GroupName returns an Ansible group name of the prefix and value, characters Ansible does not accept become underscores
+func GroupName(prefix string, value string) string {
+	return invalidGroupCharacters.ReplaceAllString(strings.ToLower(prefix+"_"+value), "_")
+}
+
+// Groups returns the groups of the host: a group per tag, the availability zone, product name, status,
+// private network and kubernetes cluster, and a group for the kind of host
+func (h Host) Groups() []string {
+	groups := []string{GroupName("kind", string(h.Kind))}
+	for _, tag := range h.Tags {
+		groups = append(groups, GroupName("tag", tag))
+	}
+	for _, group := range []struct{ prefix, value string }{
+		{"zone", h.AvailabilityZone},
+		{"product", h.ProductName},
+		{"status", h.Status},
+		{"cluster", h.Cluster},
+	} {
+		if group.value != "" {
+			groups = append(groups, GroupName(group.prefix, group.value))
+		}
+	}
+	for _, privateNetwork := range h.PrivateNetworks {
+		groups = append(groups, GroupName("private_network", privateNetwork))
+	}
+
+	return groups
+}
+
+// HostVars returns the Ansible variables of the host, ansible_host is the main address
+func (h Host) HostVars() map[string]interface{} {
+	addresses := make([]string, len(h.Addresses))
+	for idx, address := range h.Addresses {
+		addresses[idx] = address.String()
+	}
+
+	vars := map[string]interface{}{
+		"transip_name":              h.Name,
+		"transip_kind":              h.Kind,
+		"transip_description":       h.Description,
+		"transip_product_name":      h.ProductName,
+		"transip_availability_zone": h.AvailabilityZone,
+		"transip_status":            h.Status,
+		"transip_tags":              nonNil(h.Tags),
+		"transip_ip_addresses":      addresses,
+		"transip_private_networks":  nonNil(h.PrivateNetworks),
+	}
+	if h.Address != nil {
+		vars["ansible_host"] = h.Address.String()
+	}
+	if h.IPv6 != nil {
+		vars["transip_ipv6_address"] = h.IPv6.String()
+	}
+	if h.Kind == KindVps {
+		vars["transip_operating_system"] = h.OperatingSystem
+	}
+	if h.Kind == KindKubernetesNode {
+		vars["transip_cluster"] = h.Cluster
+		vars["transip_node_pool_uuid"] = h.NodePool
+	}
+
+	return vars
+}
+
+// nonNil returns an empty slice instead of nil, so it is written as an empty json list
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Ansible returns the inventory in the format of the --list output of an Ansible inventory script,
+// the groups with the _meta key containing the hostvars of every host
+func (i Inventory) Ansible() map[string]interface{} {
+	groups := make(map[string]*AnsibleGroup)
+	hostVars := make(map[string]interface{}, len(i.Hosts))
+	for _, host := range i.Hosts {
+		hostVars[host.Name] = host.HostVars()
+		for _, name := range host.Groups() {
+			group, ok := groups[name]
+			if !ok {
+				group = &AnsibleGroup{}
+				groups[name] = group
+			}
+			group.Hosts = append(group.Hosts, host.Name)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]interface{}, len(groups)+2)
+	for name, group := range groups {
+		result[name] = group
+	}
+	result["all"] = AnsibleGroup{Children: append(names, "ungrouped")}
+	result["_meta"] = map[string]interface{}{"hostvars": hostVars}
+
+	return result
+}
+
+// WriteAnsible writes the --list output of an Ansible inventory script
+func (i Inventory) WriteAnsible(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(i.Ansible())
+}
+
+// WriteAnsibleHost writes the --host output of an Ansible inventory script, the hostvars of the host
+// or an empty object when the host is not in the inventory
+func (i Inventory) WriteAnsibleHost(writer io.Writer, name string) error {
+	vars := map[string]interface{}{}
+	if host, ok := i.Host(name); ok {
+		vars = host.HostVars()
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(vars)
+}
@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupName(t *testing.T) {
+	assert.Equal(t, "tag_production", GroupName("tag", "production"))
+	assert.Equal(t, "tag_web_frontend_v2", GroupName("tag", "Web-Frontend.v2"))
+	assert.Equal(t, "product_vps_bladevps_x4", GroupName("product", "vps-bladevps-x4"))
+}
+
+func TestHost_Groups(t *testing.T) {
+	inventory := exampleInventory()
+	assert.Equal(t, []string{
+		"kind_vps", "tag_production", "tag_web", "zone_ams0", "product_vps_bladevps_x4", "status_running",
+		"private_network_admin", "private_network_backend",
+	}, inventory.Hosts[2].Groups())
+	assert.Equal(t, []string{
+		"kind_kubernetes_node", "zone_ams0", "product_vps_bladevps_x4", "status_active", "cluster_k888k",
+	}, inventory.Hosts[1].Groups())
+}
+
+func TestInventory_WriteAnsible(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, exampleInventory().WriteAnsible(&buffer))
+
+	var output map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &output))
+	group := func(name string) AnsibleGroup {
+		var group AnsibleGroup
+		require.NoError(t, json.Unmarshal(output[name], &group))
+		return group
+	}
+
+	assert.Equal(t, []string{"db-1", "web-1"}, group("kind_vps").Hosts)
+	assert.Equal(t, []string{"k888k-node-1", "web-1"}, group("zone_ams0").Hosts)
+	assert.Equal(t, []string{"db-1", "web-1"}, group("private_network_backend").Hosts)
+	assert.Equal(t, []string{"web-1"}, group("tag_production").Hosts)
+	assert.Equal(t, []string{"k888k-node-1"}, group("cluster_k888k").Hosts)
+	assert.Equal(t, []string{
+		"cluster_k888k", "kind_kubernetes_node", "kind_vps", "private_network_admin", "private_network_backend",
+		"product_vps_bladevps_x4", "product_vps_bladevps_x8", "status_active", "status_running", "status_stopped",
+		"tag_production", "tag_web", "zone_ams0", "zone_rtm0", "ungrouped",
+	}, group("all").Children)
+
+	var meta struct {
+		HostVars map[string]map[string]interface{} `json:"hostvars"`
+	}
+	require.NoError(t, json.Unmarshal(output["_meta"], &meta))
+	assert.Len(t, meta.HostVars, 3)
+	assert.Equal(t, map[string]interface{}{
+		"ansible_host":              "37.97.254.1",
+		"transip_name":              "web-1",
+		"transip_kind":              "vps",
+		"transip_description":       "webserver",
+		"transip_product_name":      "vps-bladevps-x4",
+		"transip_availability_zone": "ams0",
+		"transip_status":            "running",
+		"transip_operating_system":  "debian-12",
+		"transip_tags":              []interface{}{"production", "web"},
+		"transip_ip_addresses":      []interface{}{"37.97.254.1", "2a01:7c8:3:1337::1", "37.97.254.100"},
+		"transip_ipv6_address":      "2a01:7c8:3:1337::1",
+		"transip_private_networks":  []interface{}{"admin", "backend"},
+	}, meta.HostVars["web-1"])
+	assert.Equal(t, "k888k", meta.HostVars["k888k-node-1"]["transip_cluster"])
+	assert.Equal(t, "pool-1", meta.HostVars["k888k-node-1"]["transip_node_pool_uuid"])
+	assert.Equal(t, []interface{}{}, meta.HostVars["k888k-node-1"]["transip_tags"])
+}
+
+func TestInventory_WriteAnsibleHost(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, exampleInventory().WriteAnsibleHost(&buffer, "db-1"))
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &vars))
+	assert.Equal(t, "37.97.254.2", vars["ansible_host"])
+	assert.NotContains(t, vars, "transip_ipv6_address")
+
+	buffer.Reset()
+	require.NoError(t, exampleInventory().WriteAnsibleHost(&buffer, "unknown"))
+	assert.Equal(t, "{}\n", buffer.String())
+}
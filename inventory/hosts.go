@@ -0,0 +1,71 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The lines around the block in /etc/hosts, UpdateHosts replaces everything between them
+const (
+	HostsBlockBegin = "# BEGIN gotransip inventory"
+	HostsBlockEnd   = "# END gotransip inventory"
+)
+
+// HostsOptions configures the /etc/hosts block
+type HostsOptions struct {
+	// Domain is appended to the name of every host as the first name of the line, like example.com
+	Domain string
+	// IPv6 adds a line with the ipv6 address of every host that has one
+	IPv6 bool
+}
+
+// names returns the names of the host on a line in /etc/hosts
+func (o HostsOptions) names(host Host) string {
+	if o.Domain == "" {
+		return host.Name
+	}
+
+	return fmt.Sprintf("%s.%s %s", host.Name, strings.TrimPrefix(o.Domain, "."), host.Name)
+}
+
+// HostsBlock returns the lines for /etc/hosts between HostsBlockBegin and HostsBlockEnd
+func (i Inventory) HostsBlock(options HostsOptions) string {
+	var builder strings.Builder
+	builder.WriteString(HostsBlockBegin + "\n")
+	for _, host := range i.Hosts {
+		if host.Address != nil {
+			fmt.Fprintf(&builder, "%s\t%s\n", host.Address, options.names(host))
+		}
+		if options.IPv6 && host.IPv6 != nil {
+			fmt.Fprintf(&builder, "%s\t%s\n", host.IPv6, options.names(host))
+		}
+	}
+	builder.WriteString(HostsBlockEnd + "\n")
+
+	return builder.String()
+}
+
+// WriteHosts writes the /etc/hosts block
+func (i Inventory) WriteHosts(writer io.Writer, options HostsOptions) error {
+	_, err := io.WriteString(writer, i.HostsBlock(options))
+	return err
+}
+
+// UpdateHosts returns the contents of an /etc/hosts file with the block replaced by the block of the inventory,
+// the block is appended when the file does not contain one
+func (i Inventory) UpdateHosts(contents string, options HostsOptions) string {
+	block := i.HostsBlock(options)
+
+	begin := strings.Index(contents, HostsBlockBegin)
+	end := strings.Index(contents, HostsBlockEnd)
+	if begin == -1 || end < begin {
+		if contents != "" && !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+		return contents + block
+	}
+
+	rest := strings.TrimPrefix(contents[end+len(HostsBlockEnd):], "\n")
+	return contents[:begin] + block + rest
+}
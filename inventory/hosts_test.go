@@ -0,0 +1,40 @@
+package inventory
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleBlock = `# BEGIN gotransip inventory
+37.97.254.2	db-1.example.com db-1
+37.97.254.3	k888k-node-1.example.com k888k-node-1
+37.97.254.1	web-1.example.com web-1
+2a01:7c8:3:1337::1	web-1.example.com web-1
+# END gotransip inventory
+`
+
+func TestInventory_WriteHosts(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, exampleInventory().WriteHosts(&buffer, HostsOptions{Domain: "example.com", IPv6: true}))
+	assert.Equal(t, exampleBlock, buffer.String())
+
+	assert.Equal(t, "# BEGIN gotransip inventory\n37.97.254.2\tdb-1\n# END gotransip inventory\n",
+		Inventory{Hosts: exampleInventory().Hosts[:1]}.HostsBlock(HostsOptions{IPv6: true}))
+}
+
+func TestInventory_UpdateHosts(t *testing.T) {
+	inventory := exampleInventory()
+	options := HostsOptions{Domain: "example.com", IPv6: true}
+
+	assert.Equal(t, "127.0.0.1\tlocalhost\n"+exampleBlock, inventory.UpdateHosts("127.0.0.1\tlocalhost", options))
+	assert.Equal(t, exampleBlock, inventory.UpdateHosts("", options))
+
+	existing := "127.0.0.1\tlocalhost\n# BEGIN gotransip inventory\n37.97.254.9\told-1\n# END gotransip inventory\n::1\tlocalhost\n"
+	assert.Equal(t, "127.0.0.1\tlocalhost\n"+exampleBlock+"::1\tlocalhost\n", inventory.UpdateHosts(existing, options))
+
+	existing = "127.0.0.1\tlocalhost\n# BEGIN gotransip inventory\n37.97.254.9\told-1\n# END gotransip inventory"
+	assert.Equal(t, "127.0.0.1\tlocalhost\n"+exampleBlock, inventory.UpdateHosts(existing, options))
+}
@@ -0,0 +1,227 @@
+// Package inventory generates inventories of the vpses and kubernetes nodes of an account.
+//
+// Collect combines the vpses, their ip addresses and private networks, and optionally the kubernetes nodes,
+// in an Inventory. It can be written as an Ansible dynamic inventory, an ssh_config fragment or an /etc/hosts block:
+//
+//	inv, err := inventory.Collect(client, inventory.Options{Kubernetes: true})
+//	if err != nil {
+//		panic(err)
+//	}
+//	if err := inv.WriteSSHConfig(os.Stdout, inventory.SSHConfigOptions{User: "root"}); err != nil {
+//		panic(err)
+//	}
+//
+// The transip-inventory command speaks the Ansible inventory script protocol:
+//
+//	ansible-inventory -i transip-inventory --graph
+package inventory
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/assi010/gotransip/v6/kubernetes"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// Kind is the kind of product a host is
+type Kind string
+
+const (
+	// KindVps is a vps
+	KindVps Kind = "vps"
+	// KindKubernetesNode is a node of a kubernetes cluster
+	KindKubernetesNode Kind = "kubernetes_node"
+)
+
+// Host is a machine in the inventory
+type Host struct {
+	// Name is the vps name, or the cluster name followed by the uuid for a kubernetes node
+	Name string
+	// Kind of product
+	Kind Kind
+	// Description that is set by the customer
+	Description string
+	// ProductName of a vps, or the node spec of a kubernetes node
+	ProductName string
+	// AvailabilityZone the host is in
+	AvailabilityZone string
+	// Status of the vps or kubernetes node
+	Status string
+	// OperatingSystem of a vps
+	OperatingSystem string
+	// Tags of a vps
+	Tags []string
+	// Address is the main ip address, the one used to connect to the host
+	Address net.IP
+	// IPv6 is the first ipv6 address, nil when the host has none
+	IPv6 net.IP
+	// Addresses are all ip addresses of the host
+	Addresses []net.IP
+	// PrivateNetworks are the names of the private networks a vps is in
+	PrivateNetworks []string
+	// Cluster is the kubernetes cluster of a node
+	Cluster string
+	// NodePool is the uuid of the node pool of a kubernetes node
+	NodePool string
+}
+
+// Inventory contains the hosts ordered by name
+type Inventory struct {
+	Hosts []Host
+}
+
+// Host returns the host with the given name
+func (i Inventory) Host(name string) (Host, bool) {
+	for _, host := range i.Hosts {
+		if host.Name == name {
+			return host, true
+		}
+	}
+
+	return Host{}, false
+}
+
+// Options configures what Collect includes
+type Options struct {
+	// Kubernetes includes the nodes of all kubernetes clusters
+	Kubernetes bool
+	// SkipIPAddresses does not request the ip addresses of every vps, Addresses and IPv6 then only contain
+	// the main ipv4 address. This saves a request per vps.
+	SkipIPAddresses bool
+}
+
+// Collect returns the inventory of the account
+func Collect(client repository.Client, options Options) (Inventory, error) {
+	vpsRepo := vps.Repository{Client: client}
+	vpss, err := vpsRepo.GetAll()
+	if err != nil {
+		return Inventory{}, fmt.Errorf("error listing vpses: %w", err)
+	}
+
+	privateNetworkRepo := vps.PrivateNetworkRepository{Client: client}
+	privateNetworks, err := privateNetworkRepo.GetAll()
+	if err != nil {
+		return Inventory{}, fmt.Errorf("error listing private networks: %w", err)
+	}
+	networksOfVps := make(map[string][]string)
+	for _, privateNetwork := range privateNetworks {
+		for _, vpsName := range privateNetwork.VpsNames {
+			networksOfVps[vpsName] = append(networksOfVps[vpsName], privateNetwork.Name)
+		}
+	}
+
+	var inventory Inventory
+	for _, vpsItem := range vpss {
+		host := Host{
+			Name:             vpsItem.Name,
+			Kind:             KindVps,
+			Description:      vpsItem.Description,
+			ProductName:      vpsItem.ProductName,
+			AvailabilityZone: vpsItem.AvailabilityZone,
+			Status:           string(vpsItem.Status),
+			OperatingSystem:  vpsItem.OperatingSystem,
+			Tags:             vpsItem.Tags,
+			Address:          net.ParseIP(vpsItem.IPAddress),
+			PrivateNetworks:  networksOfVps[vpsItem.Name],
+		}
+		sort.Strings(host.PrivateNetworks)
+
+		host.addAddress(host.Address)
+		if !options.SkipIPAddresses {
+			ipAddresses, err := vpsRepo.GetIPAddresses(vpsItem.Name)
+			if err != nil {
+				return Inventory{}, fmt.Errorf("error listing ip addresses of vps %s: %w", vpsItem.Name, err)
+			}
+			for _, ipAddress := range ipAddresses {
+				host.addAddress(ipAddress.Address)
+			}
+		}
+		inventory.Hosts = append(inventory.Hosts, host)
+	}
+
+	if options.Kubernetes {
+		nodes, err := collectNodes(client)
+		if err != nil {
+			return Inventory{}, err
+		}
+		inventory.Hosts = append(inventory.Hosts, nodes...)
+	}
+
+	sort.Slice(inventory.Hosts, func(i, j int) bool { return inventory.Hosts[i].Name < inventory.Hosts[j].Name })
+
+	return inventory, nil
+}
+
+// collectNodes returns a host for every node of every kubernetes cluster
+func collectNodes(client repository.Client) ([]Host, error) {
+	kubernetesRepo := kubernetes.Repository{Client: client}
+	clusters, err := kubernetesRepo.GetClusters()
+	if err != nil {
+		return nil, fmt.Errorf("error listing kubernetes clusters: %w", err)
+	}
+
+	var hosts []Host
+	for _, cluster := range clusters {
+		nodePools, err := kubernetesRepo.GetNodePools(cluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error listing node pools of kubernetes cluster %s: %w", cluster.Name, err)
+		}
+		poolsByUUID := make(map[string]kubernetes.NodePool, len(nodePools))
+		for _, nodePool := range nodePools {
+			poolsByUUID[nodePool.UUID] = nodePool
+		}
+
+		nodes, err := kubernetesRepo.GetNodes(cluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error listing nodes of kubernetes cluster %s: %w", cluster.Name, err)
+		}
+		for _, node := range nodes {
+			nodePool := poolsByUUID[node.NodePoolUUID]
+			host := Host{
+				Name:             fmt.Sprintf("%s-%s", cluster.Name, node.UUID),
+				Kind:             KindKubernetesNode,
+				Description:      nodePool.Description,
+				ProductName:      nodePool.NodeSpec,
+				AvailabilityZone: nodePool.AvailabilityZone,
+				Status:           string(node.Status),
+				Cluster:          cluster.Name,
+				NodePool:         node.NodePoolUUID,
+			}
+			// external addresses first, so the main address is one that can be reached
+			for _, addressType := range []kubernetes.NodeAddressType{kubernetes.NodeAddressTypeExternal, kubernetes.NodeAddressTypeInternal} {
+				for _, address := range node.IPAddresses {
+					if address.Type == addressType {
+						host.addAddress(address.Address)
+					}
+				}
+			}
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts, nil
+}
+
+// addAddress adds an ip address, the first ipv4 address becomes the main address when it is not set
+func (h *Host) addAddress(address net.IP) {
+	if address == nil {
+		return
+	}
+	for _, existing := range h.Addresses {
+		if existing.Equal(address) {
+			return
+		}
+	}
+
+	h.Addresses = append(h.Addresses, address)
+	if address.To4() != nil {
+		if h.Address == nil {
+			h.Address = address
+		}
+	} else if h.IPv6 == nil {
+		h.IPv6 = address
+	}
+}
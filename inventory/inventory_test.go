@@ -0,0 +1,119 @@
+package inventory
+
+import (
+	"net"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiResponses are the responses of the api server of getClient by path
+var apiResponses = map[string]string{
+	"/vps": `{"vpss":[
+		{"name":"web-1","description":"webserver","productName":"vps-bladevps-x4","operatingSystem":"debian-12","status":"running",
+			"ipAddress":"37.97.254.1","availabilityZone":"ams0","tags":["production","web"]},
+		{"name":"db-1","productName":"vps-bladevps-x8","status":"stopped","ipAddress":"37.97.254.2","availabilityZone":"rtm0"}]}`,
+	"/vps/web-1/ip-addresses": `{"ipAddresses":[
+		{"address":"2a01:7c8:3:1337::1","subnetMask":"/48"},
+		{"address":"37.97.254.1","subnetMask":"255.255.255.0"},
+		{"address":"37.97.254.100","subnetMask":"255.255.255.0"}]}`,
+	"/vps/db-1/ip-addresses": `{"ipAddresses":[{"address":"37.97.254.2","subnetMask":"255.255.255.0"}]}`,
+	"/private-networks":      `{"privateNetworks":[{"name":"backend","vpsNames":["web-1","db-1"]},{"name":"admin","vpsNames":["web-1"]}]}`,
+	"/kubernetes/clusters":   `{"clusters":[{"name":"k888k"}]}`,
+	"/kubernetes/clusters/k888k/node-pools": `{"nodePools":[
+		{"uuid":"pool-1","clusterName":"k888k","description":"workers","nodeSpec":"vps-bladevps-x4","availabilityZone":"ams0"}]}`,
+	"/kubernetes/clusters/k888k/nodes": `{"nodes":[{"uuid":"node-1","nodePoolUUID":"pool-1","clusterName":"k888k","status":"active","ipAddresses":[
+		{"address":"10.128.0.1","subnetMask":"255.255.0.0","type":"internal"},
+		{"address":"37.97.254.3","subnetMask":"255.255.255.0","type":"external"}]}]}`,
+}
+
+func getClient(t *testing.T) (repository.Client, func()) {
+	server := testutil.RoutedMockServer{T: t, Responses: apiResponses}
+
+	return server.GetClient()
+}
+
+// exampleInventory returns the inventory of the api responses
+func exampleInventory() Inventory {
+	return Inventory{Hosts: []Host{
+		{
+			Name: "db-1", Kind: KindVps, ProductName: "vps-bladevps-x8", AvailabilityZone: "rtm0", Status: "stopped",
+			Address:         net.ParseIP("37.97.254.2"),
+			Addresses:       []net.IP{net.ParseIP("37.97.254.2")},
+			PrivateNetworks: []string{"backend"},
+		},
+		{
+			Name: "k888k-node-1", Kind: KindKubernetesNode, Description: "workers", ProductName: "vps-bladevps-x4",
+			AvailabilityZone: "ams0", Status: "active", Cluster: "k888k", NodePool: "pool-1",
+			Address:   net.ParseIP("37.97.254.3"),
+			Addresses: []net.IP{net.ParseIP("37.97.254.3"), net.ParseIP("10.128.0.1")},
+		},
+		{
+			Name: "web-1", Kind: KindVps, Description: "webserver", ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12",
+			AvailabilityZone: "ams0", Status: "running", Tags: []string{"production", "web"},
+			Address:         net.ParseIP("37.97.254.1"),
+			IPv6:            net.ParseIP("2a01:7c8:3:1337::1"),
+			Addresses:       []net.IP{net.ParseIP("37.97.254.1"), net.ParseIP("2a01:7c8:3:1337::1"), net.ParseIP("37.97.254.100")},
+			PrivateNetworks: []string{"admin", "backend"},
+		},
+	}}
+}
+
+// assertInventory compares the hosts, ip addresses are compared by their string
+func assertInventory(t *testing.T, expected Inventory, actual Inventory) {
+	normalize := func(inventory Inventory) Inventory {
+		for idx, host := range inventory.Hosts {
+			host.Address = net.ParseIP(host.Address.String())
+			host.IPv6 = net.ParseIP(host.IPv6.String())
+			for addressIdx, address := range host.Addresses {
+				host.Addresses[addressIdx] = net.ParseIP(address.String())
+			}
+			inventory.Hosts[idx] = host
+		}
+		return inventory
+	}
+	assert.Equal(t, normalize(expected), normalize(actual))
+}
+
+func TestCollect(t *testing.T) {
+	client, tearDown := getClient(t)
+	defer tearDown()
+
+	inventory, err := Collect(client, Options{Kubernetes: true})
+	require.NoError(t, err)
+	assertInventory(t, exampleInventory(), inventory)
+
+	host, ok := inventory.Host("web-1")
+	assert.True(t, ok)
+	assert.Equal(t, "webserver", host.Description)
+	_, ok = inventory.Host("unknown")
+	assert.False(t, ok)
+}
+
+func TestCollect_Options(t *testing.T) {
+	client, tearDown := getClient(t)
+	defer tearDown()
+
+	inventory, err := Collect(client, Options{SkipIPAddresses: true})
+	require.NoError(t, err)
+	require.Len(t, inventory.Hosts, 2)
+	web := inventory.Hosts[1]
+	assert.Equal(t, "web-1", web.Name)
+	assert.Equal(t, "37.97.254.1", web.Address.String())
+	assert.Nil(t, web.IPv6)
+	assert.Len(t, web.Addresses, 1)
+}
+
+func TestCollect_Error(t *testing.T) {
+	defer func(response string) { apiResponses["/vps"] = response }(apiResponses["/vps"])
+	apiResponses["/vps"] = `{"vpss":[{"name":"unknown-1"}]}`
+	client, tearDown := getClient(t)
+	defer tearDown()
+
+	_, err := Collect(client, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error listing ip addresses of vps unknown-1: ")
+}
@@ -0,0 +1,48 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SSHConfigOptions configures the ssh_config fragment
+type SSHConfigOptions struct {
+	// Prefix is put before the name of every host, like "transip-"
+	Prefix string
+	// User is the user to log in as, it is left out when empty
+	User string
+	// IdentityFile is the private key to use, it is left out when empty
+	IdentityFile string
+	// Port is the ssh port, it is left out when zero
+	Port int
+}
+
+// WriteSSHConfig writes a Host section for every host with an address, which can be included in ~/.ssh/config
+func (i Inventory) WriteSSHConfig(writer io.Writer, options SSHConfigOptions) error {
+	var builder strings.Builder
+	builder.WriteString("# generated by gotransip inventory\n")
+	for _, host := range i.Hosts {
+		if host.Address == nil {
+			continue
+		}
+
+		fmt.Fprintf(&builder, "\nHost %s%s\n", options.Prefix, host.Name)
+		if host.Description != "" {
+			fmt.Fprintf(&builder, "    # %s\n", strings.ReplaceAll(host.Description, "\n", " "))
+		}
+		fmt.Fprintf(&builder, "    HostName %s\n", host.Address)
+		if options.User != "" {
+			fmt.Fprintf(&builder, "    User %s\n", options.User)
+		}
+		if options.Port != 0 {
+			fmt.Fprintf(&builder, "    Port %d\n", options.Port)
+		}
+		if options.IdentityFile != "" {
+			fmt.Fprintf(&builder, "    IdentityFile %s\n", options.IdentityFile)
+		}
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
@@ -0,0 +1,43 @@
+package inventory
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory_WriteSSHConfig(t *testing.T) {
+	inventory := exampleInventory()
+	inventory.Hosts = append(inventory.Hosts, Host{Name: "new-1", Kind: KindVps})
+
+	var buffer bytes.Buffer
+	require.NoError(t, inventory.WriteSSHConfig(&buffer, SSHConfigOptions{Prefix: "transip-", User: "root", IdentityFile: "~/.ssh/transip", Port: 2222}))
+	assert.Equal(t, `# generated by gotransip inventory
+
+Host transip-db-1
+    HostName 37.97.254.2
+    User root
+    Port 2222
+    IdentityFile ~/.ssh/transip
+
+Host transip-k888k-node-1
+    # workers
+    HostName 37.97.254.3
+    User root
+    Port 2222
+    IdentityFile ~/.ssh/transip
+
+Host transip-web-1
+    # webserver
+    HostName 37.97.254.1
+    User root
+    Port 2222
+    IdentityFile ~/.ssh/transip
+`, buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, Inventory{Hosts: inventory.Hosts[:1]}.WriteSSHConfig(&buffer, SSHConfigOptions{}))
+	assert.Equal(t, "# generated by gotransip inventory\n\nHost db-1\n    HostName 37.97.254.2\n", buffer.String())
+}
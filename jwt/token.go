@@ -25,7 +25,13 @@ const expirationSkew = 30
 
 // Expired returns true when the token expiry date is reached
 func (t *Token) Expired() bool {
-	return time.Now().Unix()+expirationSkew > t.ExpiryDate
+	return t.ExpiredAt(time.Now())
+}
+
+// ExpiredAt returns true when the token expiry date is reached at the given time,
+// this allows checking the expiry date against a clock that is corrected for clock skew
+func (t *Token) ExpiredAt(now time.Time) bool {
+	return now.Unix()+expirationSkew > t.ExpiryDate
 }
 
 // GetAuthenticationHeaderValue returns the authentication header value value
@@ -39,12 +45,54 @@ func (t *Token) String() string {
 	return t.RawToken
 }
 
-// tokenPayload is used to unpack the payload from the jwt
-type tokenPayload struct {
-	// This ExpirationTime is a 64 bit epoch that will be put into the token struct
-	// that will later be used to validate if the token is expired or not
-	// once expired we need to request a new token
+// Claims contains the claims from the payload of a TransIP api token
+type Claims struct {
+	// Issuer of the token, normally 'api.transip.nl'
+	Issuer string `json:"iss"`
+	// Audience of the token, normally 'api.transip.nl'
+	Audience string `json:"aud"`
+	// ID is the unique identifier of the token, which is also shown in the TransIP control panel
+	ID string `json:"jti"`
+	// IssuedAt is a 64 bit epoch of the moment the token was issued
+	IssuedAt int64 `json:"iat"`
+	// NotBefore is a 64 bit epoch of the moment from which the token can be used
+	NotBefore int64 `json:"nbf"`
+	// ExpirationTime is a 64 bit epoch of the moment the token expires
 	ExpirationTime int64 `json:"exp"`
+	// CustomerID is the id of the customer the token belongs to
+	CustomerID json.Number `json:"cid"`
+	// ReadOnly is true when the token can only be used to retrieve information
+	ReadOnly bool `json:"ro"`
+	// GlobalKey is true when the token can be used from every ip address,
+	// when false it can only be used from the ip addresses whitelisted in the TransIP control panel
+	GlobalKey bool `json:"gk"`
+	// KeyVerified contains the 'kv' claim of the token
+	KeyVerified bool `json:"kv"`
+}
+
+// IssueTime returns the moment the token was issued
+func (c *Claims) IssueTime() time.Time {
+	return time.Unix(c.IssuedAt, 0)
+}
+
+// ValidFrom returns the moment from which the token can be used
+func (c *Claims) ValidFrom() time.Time {
+	return time.Unix(c.NotBefore, 0)
+}
+
+// ExpiryTime returns the moment the token expires
+func (c *Claims) ExpiryTime() time.Time {
+	return time.Unix(c.ExpirationTime, 0)
+}
+
+// Whitelisted returns true when the token can only be used from whitelisted ip addresses
+func (c *Claims) Whitelisted() bool {
+	return !c.GlobalKey
+}
+
+// Claims decodes and returns all claims from the token payload
+func (t *Token) Claims() (Claims, error) {
+	return decodeClaims(t.RawToken)
 }
 
 // New expects a raw token as string.
@@ -52,28 +100,38 @@ type tokenPayload struct {
 // Once decoded it will retrieve the expiry date and
 // return a Token struct with the RawToken and ExpiryDate set.
 func New(token string) (Token, error) {
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return Token{}, err
+	}
+
+	return Token{
+		RawToken:   token,
+		ExpiryDate: claims.ExpirationTime,
+	}, nil
+}
+
+// decodeClaims decodes the payload of a raw token
+func decodeClaims(token string) (Claims, error) {
 	if len(token) == 0 {
-		return Token{}, errors.New("no token given, a token should be set")
+		return Claims{}, errors.New("no token given, a token should be set")
 	}
 
 	tokenParts := strings.Split(token, ".")
 	if len(tokenParts) != 3 {
-		return Token{}, fmt.Errorf("invalid token '%s' given, token should exist at least of 3 parts", token)
+		return Claims{}, fmt.Errorf("invalid token '%s' given, token should exist at least of 3 parts", token)
 	}
 
 	jsonBody, err := base64.RawURLEncoding.DecodeString(tokenParts[1])
 	if err != nil {
-		return Token{}, errors.New("could not decode token, invalid base64")
+		return Claims{}, errors.New("could not decode token, invalid base64")
 	}
 
-	var tokenRequest tokenPayload
-	err = json.Unmarshal(jsonBody, &tokenRequest)
+	var claims Claims
+	err = json.Unmarshal(jsonBody, &claims)
 	if err != nil {
-		return Token{}, errors.New("could not read token body, invalid json")
+		return Claims{}, errors.New("could not read token body, invalid json")
 	}
 
-	return Token{
-		RawToken:   token,
-		ExpiryDate: tokenRequest.ExpirationTime,
-	}, nil
+	return claims, nil
 }
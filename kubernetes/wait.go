@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/assi010/gotransip/v6/wait"
+)
+
+// WaitUntilNodePoolReady waits until the node pool has exactly its desired node count of active nodes,
+// for example after adding a node pool or changing its desired node count.
+// The returned NodePool has its Nodes set to the nodes of the node pool.
+func (r *Repository) WaitUntilNodePoolReady(ctx context.Context, clusterName string, nodePoolUUID string, opts wait.Options) (NodePool, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("node pool %s of cluster %s", nodePoolUUID, clusterName),
+		func() (NodePool, error) { return r.getNodePoolWithNodes(clusterName, nodePoolUUID) },
+		wait.Condition[NodePool]{
+			Description: "desired node count",
+			Done: func(nodePool NodePool) bool {
+				return len(nodePool.Nodes) == nodePool.DesiredNodeCount && countNodes(nodePool.Nodes, NodeStatusActive) == len(nodePool.Nodes)
+			},
+			State: describeNodePool,
+		},
+	)
+}
+
+// getNodePoolWithNodes returns the node pool with its Nodes set to the nodes of the node pool
+func (r *Repository) getNodePoolWithNodes(clusterName string, nodePoolUUID string) (NodePool, error) {
+	nodePool, err := r.GetNodePool(clusterName, nodePoolUUID)
+	if err != nil {
+		return nodePool, err
+	}
+	nodePool.Nodes, err = r.GetNodesByNodePoolUUID(clusterName, nodePoolUUID)
+
+	return nodePool, err
+}
+
+// countNodes returns the number of nodes with the given status
+func countNodes(nodes []Node, status NodeStatus) int {
+	var count int
+	for _, node := range nodes {
+		if node.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// describeNodePool describes the state of a node pool in wait errors
+func describeNodePool(nodePool NodePool) string {
+	return fmt.Sprintf("%d of %d desired nodes active, %d creating, %d deleting",
+		countNodes(nodePool.Nodes, NodeStatusActive),
+		nodePool.DesiredNodeCount,
+		countNodes(nodePool.Nodes, NodeStatusCreating),
+		countNodes(nodePool.Nodes, NodeStatusDeleting),
+	)
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/rest"
+	"github.com/assi010/gotransip/v6/wait"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedClient responds to GET requests with the next response for the endpoint, repeating the last one
+type scriptedClient struct {
+	t         *testing.T
+	responses map[string][]string
+}
+
+func (c *scriptedClient) Get(request rest.Request, dest interface{}) error {
+	responses := c.responses[request.Endpoint]
+	require.NotEmpty(c.t, responses, "unexpected request to %s", request.Endpoint)
+	if len(responses) > 1 {
+		c.responses[request.Endpoint] = responses[1:]
+	}
+
+	return json.Unmarshal([]byte(responses[0]), dest)
+}
+
+func (c *scriptedClient) Put(rest.Request) error    { return nil }
+func (c *scriptedClient) Post(rest.Request) error   { return nil }
+func (c *scriptedClient) Delete(rest.Request) error { return nil }
+func (c *scriptedClient) Patch(rest.Request) error  { return nil }
+func (c *scriptedClient) PutWithResponse(rest.Request) (rest.Response, error) {
+	return rest.Response{}, nil
+}
+func (c *scriptedClient) PostWithResponse(rest.Request) (rest.Response, error) {
+	return rest.Response{}, nil
+}
+func (c *scriptedClient) PatchWithResponse(rest.Request) (rest.Response, error) {
+	return rest.Response{}, nil
+}
+
+func TestRepository_WaitUntilNodePoolReady(t *testing.T) {
+	const nodePoolEndpoint = "/kubernetes/clusters/k888k/node-pools/402c2f84-c37d-9388-634d-00002b7c6a82"
+	const nodesEndpoint = "/kubernetes/clusters/k888k/nodes"
+
+	client := &scriptedClient{t: t, responses: map[string][]string{
+		nodePoolEndpoint: {`{"nodePool":{"uuid":"402c2f84-c37d-9388-634d-00002b7c6a82","clusterName":"k888k","desiredNodeCount":2}}`},
+		nodesEndpoint: {
+			`{"nodes":[{"uuid":"node-1","status":"active"},{"uuid":"node-2","status":"creating"}]}`,
+			`{"nodes":[{"uuid":"node-1","status":"active"},{"uuid":"node-2","status":"active"},{"uuid":"node-3","status":"deleting"}]}`,
+			`{"nodes":[{"uuid":"node-1","status":"active"},{"uuid":"node-2","status":"active"}]}`,
+		},
+	}}
+	repo := Repository{Client: client}
+	opts := wait.Options{InitialInterval: time.Millisecond, Timeout: time.Second}
+
+	nodePool, err := repo.WaitUntilNodePoolReady(context.Background(), "k888k", "402c2f84-c37d-9388-634d-00002b7c6a82", opts)
+	require.NoError(t, err)
+	assert.Len(t, nodePool.Nodes, 2)
+
+	// the desired node count is never reached
+	client.responses[nodesEndpoint] = []string{`{"nodes":[{"uuid":"node-1","status":"active"},{"uuid":"node-2","status":"creating"}]}`}
+	opts.Timeout = 10 * time.Millisecond
+	_, err = repo.WaitUntilNodePoolReady(context.Background(), "k888k", "402c2f84-c37d-9388-634d-00002b7c6a82", opts)
+	assert.ErrorIs(t, err, wait.ErrTimeout)
+	assert.EqualError(t, err, "timed out waiting for resource: node pool 402c2f84-c37d-9388-634d-00002b7c6a82 of cluster k888k "+
+		"did not reach desired node count, last observed 1 of 2 desired nodes active, 1 creating, 0 deleting")
+}
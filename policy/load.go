@@ -0,0 +1,60 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Parse decodes a yaml policy and validates it, for example:
+//
+//	name: team-a
+//	default: deny
+//	rules:
+//	  - description: manage dns of team-a.example
+//	    effect: allow
+//	    endpoints: ["/domains/team-a.example", "/domains/team-a.example/**"]
+//	  - description: manage vpses of team-a
+//	    effect: allow
+//	    endpoints: ["/vps/**"]
+//	    tags: ["team-a"]
+//	  - description: never cancel anything
+//	    effect: deny
+//	    methods: ["DELETE"]
+func Parse(data []byte) (*Policy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error decoding policy: %w", err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Load reads a yaml policy from the reader, see Parse for the format
+func Load(reader io.Reader) (*Policy, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// LoadFile reads a yaml policy from the file on the given path, see Parse for the format
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file: %w", err)
+	}
+
+	return Parse(data)
+}
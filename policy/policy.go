@@ -0,0 +1,370 @@
+// Package policy implements a client side policy layer, which allows or denies api requests
+// by http method, endpoint pattern and the name or tags of the resource a request is about.
+//
+// This can be used to hand out clients that may only manage a part of an account,
+// for example only the DNS of one domain or only the VPSs tagged with a team name.
+// A Policy is set on the ClientConfiguration and evaluated before a request is sent:
+//
+//	teamPolicy, err := policy.LoadFile("/etc/team-a/policy.yaml")
+//	if err != nil {
+//		panic(err)
+//	}
+//	client, err := gotransip.NewClient(gotransip.ClientConfiguration{
+//		AccountName:    "accountName",
+//		PrivateKeyPath: "/path/to/api/private.key",
+//		Policy:         teamPolicy,
+//	})
+//
+// Policies only look at the request, they do not filter responses.
+// A policy that allows listing all VPSs will return the VPSs of every team.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/rest"
+)
+
+// Effect is the outcome of a matching Rule
+type Effect string
+
+const (
+	// EffectAllow allows requests matching the rule
+	EffectAllow Effect = "allow"
+	// EffectDeny denies requests matching the rule, a matching deny rule always wins from matching allow rules
+	EffectDeny Effect = "deny"
+)
+
+const (
+	// MethodRead can be used in Rule.Methods to match all requests that do not change state
+	MethodRead = "read"
+	// MethodWrite can be used in Rule.Methods to match all requests that change state
+	MethodWrite = "write"
+	// wildcard matches any method, endpoint or resource
+	wildcard = "*"
+	// recursiveWildcard matches any number of endpoint path segments
+	recursiveWildcard = "**"
+)
+
+var (
+	// ErrDenied is wrapped by every error returned when a Policy denies a request
+	ErrDenied = errors.New("request denied by policy")
+	// ErrInvalidPolicy will be thrown when a Policy contains an invalid effect, method or pattern
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+// namespaces are endpoint prefixes that contain collections, the resource name of
+// '/kubernetes/clusters/example' is 'example' instead of 'clusters'
+var namespaces = map[string]bool{"kubernetes": true, "openstack": true}
+
+// taggedCollections are the collections of which the resources have tags,
+// these can be retrieved with a GET request on the resource endpoint
+var taggedCollections = map[string]bool{"vps": true, "domains": true}
+
+// Rule allows or denies the requests it matches. A request matches a rule when it matches all of the
+// non empty conditions, and at least one of the values within a condition.
+type Rule struct {
+	// Description is shown in the error when this rule denies a request
+	Description string `yaml:"description"`
+	// Effect is either EffectAllow or EffectDeny
+	Effect Effect `yaml:"effect"`
+	// Methods are http methods like 'GET' or 'DELETE', MethodRead or MethodWrite. Matches all methods when empty
+	Methods []string `yaml:"methods"`
+	// Endpoints are endpoint patterns like '/domains/*/dns' or '/vps/**'.
+	// Every path segment is matched with path.Match, '**' matches any number of segments.
+	// Matches all endpoints when empty
+	Endpoints []string `yaml:"endpoints"`
+	// Resources are name patterns like '*.team-a.example', matched with path.Match against the resource name.
+	// The resource name is the path segment after the collection, 'team-a.example' in '/domains/team-a.example/dns'.
+	// Requests without a resource name, like listing or ordering, never match a rule with Resources
+	Resources []string `yaml:"resources"`
+	// Tags match resources that have at least one of these tags. Only VPSs and domains have tags,
+	// requests on other resources never match a rule with Tags
+	Tags []string `yaml:"tags"`
+}
+
+// Policy is a list of rules that is evaluated for every request the client sends.
+// A request is denied when a deny rule matches it, allowed when an allow rule matches it
+// and otherwise handled according to the Default effect.
+type Policy struct {
+	// Name is shown in the error when this policy denies a request
+	Name string `yaml:"name"`
+	// Default is the effect for requests that no rule matches, defaults to EffectDeny
+	Default Effect `yaml:"default"`
+	// Rules contains the allow and deny rules of this policy
+	Rules []Rule `yaml:"rules"`
+}
+
+// DeniedError is returned when a Policy denies a request
+type DeniedError struct {
+	// Policy is the name of the policy that denied the request
+	Policy string
+	// Method is the http method of the denied request
+	Method string
+	// Endpoint is the endpoint of the denied request
+	Endpoint string
+	// Rule is the deny rule that matched the request, nil when no rule allowed the request
+	Rule *Rule
+	// Reason is set when the request is denied before the rules are evaluated, like for a non-canonical endpoint
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	reason := "no rule allows it"
+	if e.Reason != "" {
+		reason = e.Reason
+	}
+	if e.Rule != nil {
+		reason = "matched deny rule"
+		if len(e.Rule.Description) > 0 {
+			reason = fmt.Sprintf("matched deny rule '%s'", e.Rule.Description)
+		}
+	}
+
+	return fmt.Sprintf("policy '%s' denied %s %s: %s", e.Policy, e.Method, e.Endpoint, reason)
+}
+
+// Is makes errors.Is(err, ErrDenied) work for a DeniedError
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrDenied
+}
+
+// Validate checks if all effects, methods and patterns in the policy are valid
+func (p *Policy) Validate() error {
+	if p.Default != "" && p.Default != EffectAllow && p.Default != EffectDeny {
+		return fmt.Errorf("%w: unknown default effect '%s'", ErrInvalidPolicy, p.Default)
+	}
+
+	for idx, rule := range p.Rules {
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return fmt.Errorf("%w: rule %d has unknown effect '%s'", ErrInvalidPolicy, idx, rule.Effect)
+		}
+		for _, method := range rule.Methods {
+			if !validMethod(method) {
+				return fmt.Errorf("%w: rule %d has unknown method '%s'", ErrInvalidPolicy, idx, method)
+			}
+		}
+		for _, pattern := range append(append([]string{}, rule.Endpoints...), rule.Resources...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%w: rule %d has invalid pattern '%s'", ErrInvalidPolicy, idx, pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Evaluate returns a DeniedError when the policy does not allow the request.
+// The lookup function is used to retrieve the tags of a resource when a rule contains Tags.
+// It is provided by the client, requests done with it are not evaluated by the policy.
+//
+// Endpoints are sent to the api unchanged, so an endpoint that is not canonical, like one with ".." segments that
+// would walk out of an allowed path, is always denied, whatever the rules and the default effect are.
+func (p *Policy) Evaluate(method rest.Method, request rest.Request, lookup func(request rest.Request, dest any) error) error {
+	if !canonicalEndpoint(request.Endpoint) {
+		return &DeniedError{Policy: p.Name, Method: method.Method, Endpoint: request.Endpoint, Reason: "endpoint is not canonical"}
+	}
+	target := newTarget(method, request, lookup)
+
+	allowed := p.Default == EffectAllow
+	for idx := range p.Rules {
+		rule := &p.Rules[idx]
+		matches, err := rule.matches(target)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			return &DeniedError{Policy: p.Name, Method: method.Method, Endpoint: request.Endpoint, Rule: rule}
+		}
+		allowed = true
+	}
+
+	if !allowed {
+		return &DeniedError{Policy: p.Name, Method: method.Method, Endpoint: request.Endpoint}
+	}
+
+	return nil
+}
+
+// target is the request a policy is evaluated for
+type target struct {
+	method     rest.Method
+	endpoint   string
+	collection string
+	resource   string
+	lookup     func(request rest.Request, dest any) error
+	// tags are retrieved once, on the first rule that needs them
+	tags       []string
+	tagsLoaded bool
+}
+
+// canonicalEndpoint returns true for an absolute endpoint without empty, "." or ".." segments,
+// a trailing slash or percent-encoded slashes, dots and backslashes, so the rules see the path the api resolves
+func canonicalEndpoint(endpoint string) bool {
+	if !strings.HasPrefix(endpoint, "/") || path.Clean(endpoint) != endpoint || strings.Contains(endpoint, "\\") {
+		return false
+	}
+	lower := strings.ToLower(endpoint)
+	for _, encoded := range []string{"%2f", "%2e", "%5c"} {
+		if strings.Contains(lower, encoded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newTarget determines the collection and resource name of a request
+func newTarget(method rest.Method, request rest.Request, lookup func(request rest.Request, dest any) error) *target {
+	segments := strings.Split(strings.Trim(request.Endpoint, "/"), "/")
+	if len(segments) > 1 && namespaces[segments[0]] {
+		segments = segments[1:]
+	}
+
+	t := target{method: method, endpoint: request.Endpoint, collection: segments[0], lookup: lookup}
+	if len(segments) > 1 {
+		t.resource = segments[1]
+	}
+
+	return &t
+}
+
+// getTags returns the tags of the target resource
+func (t *target) getTags() ([]string, error) {
+	if t.tagsLoaded {
+		return t.tags, nil
+	}
+	t.tagsLoaded = true
+
+	if t.resource == "" || !taggedCollections[t.collection] || t.lookup == nil {
+		return nil, nil
+	}
+
+	// the response contains a single object, like {"vps": {"tags": []}}
+	var response map[string]json.RawMessage
+	restRequest := rest.Request{Endpoint: fmt.Sprintf("/%s/%s", t.collection, t.resource)}
+	if err := t.lookup(restRequest, &response); err != nil {
+		return nil, fmt.Errorf("error retrieving tags for policy evaluation: %w", err)
+	}
+	for _, object := range response {
+		var tagged struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(object, &tagged); err == nil {
+			t.tags = tagged.Tags
+		}
+	}
+
+	return t.tags, nil
+}
+
+// matches returns true when the target matches all conditions of the rule
+func (r *Rule) matches(t *target) (bool, error) {
+	if len(r.Methods) > 0 && !anyMatch(r.Methods, func(method string) bool { return methodMatches(method, t.method) }) {
+		return false, nil
+	}
+	if len(r.Endpoints) > 0 && !anyMatch(r.Endpoints, func(pattern string) bool { return endpointMatches(pattern, t.endpoint) }) {
+		return false, nil
+	}
+	if len(r.Resources) > 0 && (t.resource == "" || !anyMatch(r.Resources, func(pattern string) bool { return globMatches(pattern, t.resource) })) {
+		return false, nil
+	}
+	if len(r.Tags) > 0 {
+		tags, err := t.getTags()
+		if err != nil {
+			return false, err
+		}
+		if !anyMatch(r.Tags, func(tag string) bool { return contains(tags, tag) }) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// validMethod returns true when the method can be used in Rule.Methods
+func validMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case wildcard, "GET", "POST", "PUT", "PATCH", "DELETE", strings.ToUpper(MethodRead), strings.ToUpper(MethodWrite):
+		return true
+	}
+
+	return false
+}
+
+// methodMatches returns true when the method of a rule matches the request method
+func methodMatches(ruleMethod string, method rest.Method) bool {
+	switch strings.ToLower(ruleMethod) {
+	case wildcard:
+		return true
+	case MethodRead:
+		return !method.Mutating()
+	case MethodWrite:
+		return method.Mutating()
+	}
+
+	return strings.EqualFold(ruleMethod, method.Method)
+}
+
+// endpointMatches matches an endpoint against a pattern segment by segment, '**' matches any number of segments
+func endpointMatches(pattern string, endpoint string) bool {
+	return segmentsMatch(
+		strings.Split(strings.Trim(pattern, "/"), "/"),
+		strings.Split(strings.Trim(endpoint, "/"), "/"),
+	)
+}
+
+// segmentsMatch is the recursive part of endpointMatches
+func segmentsMatch(patterns []string, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+	if patterns[0] == recursiveWildcard {
+		for idx := 0; idx <= len(segments); idx++ {
+			if segmentsMatch(patterns[1:], segments[idx:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 || !globMatches(patterns[0], segments[0]) {
+		return false
+	}
+
+	return segmentsMatch(patterns[1:], segments[1:])
+}
+
+// globMatches matches a name against a path.Match pattern, invalid patterns never match
+func globMatches(pattern string, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// anyMatch returns true when the match function returns true for one of the values
+func anyMatch(values []string, match func(value string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains is used to see if a certain value is part of an array
+func contains(haystack []string, needle string) bool {
+	for _, value := range haystack {
+		if value == needle {
+			return true
+		}
+	}
+
+	return false
+}
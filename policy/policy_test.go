@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/assi010/gotransip/v6/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const teamPolicy = `
+name: team-a
+rules:
+  - description: manage dns of team-a.example
+    effect: allow
+    endpoints: ["/domains/*/dns", "/domains/*/dns/**"]
+    resources: ["team-a.example", "*.team-a.example"]
+  - description: manage vpses of team-a
+    effect: allow
+    endpoints: ["/vps/**"]
+    tags: ["team-a"]
+  - description: list vpses
+    effect: allow
+    methods: [read]
+    endpoints: ["/vps"]
+  - description: never cancel a vps
+    effect: deny
+    methods: [DELETE]
+    endpoints: ["/vps/*"]
+`
+
+// lookupVpsTags returns a lookup function that responds with the tags of a vps
+func lookupVpsTags(t *testing.T, tags map[string][]string, lookups *int) func(rest.Request, any) error {
+	return func(request rest.Request, dest any) error {
+		*lookups++
+		name := strings.TrimPrefix(request.Endpoint, "/vps/")
+		body, err := json.Marshal(map[string]any{"vps": map[string]any{"name": name, "tags": tags[name]}})
+		require.NoError(t, err)
+		return json.Unmarshal(body, dest)
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := Parse([]byte(teamPolicy))
+	require.NoError(t, err)
+
+	var lookups int
+	lookup := lookupVpsTags(t, map[string][]string{"team-a-vps": {"team-a"}, "team-b-vps": {"team-b"}}, &lookups)
+
+	tests := []struct {
+		method   rest.Method
+		endpoint string
+		allowed  bool
+	}{
+		{rest.GetMethod, "/domains/team-a.example/dns", true},
+		{rest.PostMethod, "/domains/team-a.example/dns", true},
+		{rest.PatchMethod, "/domains/www.team-a.example/dns", true},
+		{rest.PostMethod, "/domains/team-b.example/dns", false},
+		{rest.DeleteMethod, "/domains/team-a.example", false},
+		{rest.GetMethod, "/vps", true},
+		{rest.PostMethod, "/vps", false},
+		{rest.PatchMethod, "/vps/team-a-vps", true},
+		{rest.PostMethod, "/vps/team-a-vps/snapshots", true},
+		{rest.PatchMethod, "/vps/team-b-vps", false},
+		{rest.DeleteMethod, "/vps/team-a-vps", false},
+		{rest.GetMethod, "/haips", false},
+	}
+
+	for _, tt := range tests {
+		err := policy.Evaluate(tt.method, rest.Request{Endpoint: tt.endpoint}, lookup)
+		if tt.allowed {
+			assert.NoError(t, err, "%s %s", tt.method.Method, tt.endpoint)
+		} else {
+			assert.ErrorIs(t, err, ErrDenied, "%s %s", tt.method.Method, tt.endpoint)
+		}
+	}
+
+	assert.Equal(t, 4, lookups, "tags should only be retrieved for vps resources matching a tag rule")
+}
+
+func TestPolicy_DeniedError(t *testing.T) {
+	policy, err := Parse([]byte(teamPolicy))
+	require.NoError(t, err)
+
+	var lookups int
+	lookup := lookupVpsTags(t, map[string][]string{"team-a-vps": {"team-a"}}, &lookups)
+
+	err = policy.Evaluate(rest.DeleteMethod, rest.Request{Endpoint: "/vps/team-a-vps"}, lookup)
+	var deniedError *DeniedError
+	require.True(t, errors.As(err, &deniedError))
+	assert.Equal(t, "never cancel a vps", deniedError.Rule.Description)
+	assert.EqualError(t, err, "policy 'team-a' denied DELETE /vps/team-a-vps: matched deny rule 'never cancel a vps'")
+
+	err = policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/invoices"}, lookup)
+	assert.EqualError(t, err, "policy 'team-a' denied GET /invoices: no rule allows it")
+}
+
+func TestPolicy_NonCanonicalEndpoints(t *testing.T) {
+	policy, err := Parse([]byte(teamPolicy))
+	require.NoError(t, err)
+	policy.Rules = append(policy.Rules, Rule{Effect: EffectAllow, Endpoints: []string{"/domains/team-a.example/**"}})
+
+	var lookups int
+	lookup := lookupVpsTags(t, map[string][]string{}, &lookups)
+	require.NoError(t, policy.Evaluate(rest.DeleteMethod, rest.Request{Endpoint: "/domains/team-a.example/dns"}, lookup))
+
+	for _, endpoint := range []string{
+		"/domains/team-a.example/../other.example",
+		"/domains/team-a.example/./dns",
+		"/domains/team-a.example//dns",
+		"/domains/team-a.example/dns/",
+		"/domains/team-a.example/..%2Fother.example",
+		"/domains/team-a.example/%2e%2e/other.example",
+		"domains/team-a.example/dns",
+	} {
+		err := policy.Evaluate(rest.DeleteMethod, rest.Request{Endpoint: endpoint}, lookup)
+		assert.ErrorIs(t, err, ErrDenied, endpoint)
+	}
+
+	// a non-canonical endpoint is denied even when the default allows everything
+	policy.Default = EffectAllow
+	err = policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/vps/../invoices"}, lookup)
+	assert.EqualError(t, err, "policy 'team-a' denied GET /vps/../invoices: endpoint is not canonical")
+	assert.Zero(t, lookups)
+}
+
+func TestPolicy_DefaultAllow(t *testing.T) {
+	policy := Policy{
+		Name:    "no-writes-to-invoices",
+		Default: EffectAllow,
+		Rules: []Rule{
+			{Effect: EffectDeny, Methods: []string{MethodWrite}, Endpoints: []string{"/invoices/**"}},
+		},
+	}
+	require.NoError(t, policy.Validate())
+
+	assert.NoError(t, policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/invoices/F0001"}, nil))
+	assert.NoError(t, policy.Evaluate(rest.PostMethod, rest.Request{Endpoint: "/vps"}, nil))
+	assert.ErrorIs(t, policy.Evaluate(rest.PatchMethod, rest.Request{Endpoint: "/invoices/F0001"}, nil), ErrDenied)
+}
+
+func TestPolicy_NamespacedResources(t *testing.T) {
+	policy := Policy{
+		Rules: []Rule{
+			{Effect: EffectAllow, Endpoints: []string{"/kubernetes/clusters/**"}, Resources: []string{"team-a-*"}},
+		},
+	}
+
+	assert.NoError(t, policy.Evaluate(rest.PatchMethod, rest.Request{Endpoint: "/kubernetes/clusters/team-a-k8s"}, nil))
+	assert.NoError(t, policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/kubernetes/clusters/team-a-k8s/node-pools"}, nil))
+	assert.ErrorIs(t, policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/kubernetes/clusters/team-b-k8s"}, nil), ErrDenied)
+	assert.ErrorIs(t, policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/kubernetes/clusters"}, nil), ErrDenied)
+}
+
+func TestPolicy_LookupError(t *testing.T) {
+	policy := Policy{Rules: []Rule{{Effect: EffectAllow, Tags: []string{"team-a"}}}}
+	lookup := func(rest.Request, any) error { return errors.New("lookup failed") }
+
+	err := policy.Evaluate(rest.PatchMethod, rest.Request{Endpoint: "/vps/example-vps"}, lookup)
+	assert.EqualError(t, err, "error retrieving tags for policy evaluation: lookup failed")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("rules:\n  - effect: maybe\n"))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = Parse([]byte("rules:\n  - effect: allow\n    methods: [FETCH]\n"))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = Parse([]byte("rules:\n  - effect: allow\n    endpoints: [\"/vps/[\"]\n"))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = Parse([]byte("rules:\n  - effect: allow\n    endpoint: /vps\n"))
+	assert.Error(t, err, "unknown fields should not be ignored")
+
+	policy, err := Parse(nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, policy.Evaluate(rest.GetMethod, rest.Request{Endpoint: "/vps"}, nil), ErrDenied)
+}
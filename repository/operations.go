@@ -0,0 +1,65 @@
+package repository
+
+//go:generate sh -c "cd .. && go run ./internal/gen/operations"
+
+// Operation describes a repository method and the api call it executes.
+// This can be used by tools to list which operations are allowed for a read-only client.
+type Operation struct {
+	// Package is the name of the package the repository is defined in, like 'vps'
+	Package string
+	// Repository is the name of the repository type, like 'BigStorageRepository'
+	Repository string
+	// Method is the name of the repository method, like 'GetAll'
+	Method string
+	// HTTPMethod is the http method of the api call, like 'GET'
+	HTTPMethod string
+	// Endpoint is the api endpoint, parameters are replaced by placeholders like '/vps/{vpsName}'
+	Endpoint string
+	// Mutating is true when the operation changes state, mutating operations are not allowed in read-only mode
+	Mutating bool
+}
+
+// Name returns the qualified name of the operation, like 'vps.Repository.GetAll'
+func (o Operation) Name() string {
+	return o.Package + "." + o.Repository + "." + o.Method
+}
+
+// Operations returns all repository methods that call the api
+func Operations() []Operation {
+	return append([]Operation(nil), operations...)
+}
+
+// ReadOnlyOperations returns all repository methods that are allowed in read-only mode
+func ReadOnlyOperations() []Operation {
+	var readOnly []Operation
+	for _, operation := range operations {
+		if !operation.Mutating {
+			readOnly = append(readOnly, operation)
+		}
+	}
+
+	return readOnly
+}
+
+// MutatingOperations returns all repository methods that change state
+func MutatingOperations() []Operation {
+	var mutating []Operation
+	for _, operation := range operations {
+		if operation.Mutating {
+			mutating = append(mutating, operation)
+		}
+	}
+
+	return mutating
+}
+
+// FindOperation looks up an operation by its qualified name, like 'vps.Repository.GetAll'
+func FindOperation(name string) (Operation, bool) {
+	for _, operation := range operations {
+		if operation.Name() == name {
+			return operation, true
+		}
+	}
+
+	return Operation{}, false
+}
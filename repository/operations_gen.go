@@ -0,0 +1,311 @@
+// Code generated by internal/gen/operations; DO NOT EDIT.
+
+package repository
+
+// operations contains every repository method that calls the api
+var operations = []Operation{
+	{Package: "action", Repository: "Repository", Method: "GetActions", HTTPMethod: "GET", Endpoint: "/actions", Mutating: false},
+	{Package: "action", Repository: "Repository", Method: "GetByID", HTTPMethod: "GET", Endpoint: "/actions/{actionID}", Mutating: false},
+	{Package: "action", Repository: "Repository", Method: "GetChildActionsByParentID", HTTPMethod: "GET", Endpoint: "/actions/children/{actionID}", Mutating: false},
+	{Package: "action", Repository: "Repository", Method: "ParseActionFromResponse", HTTPMethod: "GET", Endpoint: "/actions/{actionID}", Mutating: false},
+	{Package: "availabilityzone", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/availability-zones", Mutating: false},
+	{Package: "colocation", Repository: "Repository", Method: "AddIPAddress", HTTPMethod: "POST", Endpoint: "/colocations/{coloName}/ip-addresses", Mutating: true},
+	{Package: "colocation", Repository: "Repository", Method: "CreateRemoteHandsRequest", HTTPMethod: "POST", Endpoint: "/colocations/{ColoName}/remote-hands", Mutating: true},
+	{Package: "colocation", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/colocations", Mutating: false},
+	{Package: "colocation", Repository: "Repository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/colocations/{coloName}", Mutating: false},
+	{Package: "colocation", Repository: "Repository", Method: "GetIPAddressByAddress", HTTPMethod: "GET", Endpoint: "/colocations/{coloName}/ip-addresses/{param}", Mutating: false},
+	{Package: "colocation", Repository: "Repository", Method: "GetIPAddresses", HTTPMethod: "GET", Endpoint: "/colocations/{coloName}/ip-addresses", Mutating: false},
+	{Package: "colocation", Repository: "Repository", Method: "RemoveIPAddress", HTTPMethod: "DELETE", Endpoint: "/colocations/{coloName}/ip-addresses/{param}", Mutating: true},
+	{Package: "colocation", Repository: "Repository", Method: "UpdateReverseDNS", HTTPMethod: "PUT", Endpoint: "/colocations/{coloName}/ip-addresses/{param}", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "AddDNSEntry", HTTPMethod: "POST", Endpoint: "/domains/{domainName}/dns", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/domains/{domainName}", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "CancelDomainAction", HTTPMethod: "DELETE", Endpoint: "/domains/{domainName}/actions", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/domains", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetAllByTags", HTTPMethod: "GET", Endpoint: "/domains", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetAvailability", HTTPMethod: "GET", Endpoint: "/domain-availability/{domainName}", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetAvailabilityForMultipleDomains", HTTPMethod: "GET", Endpoint: "/domain-availability", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetBranding", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/branding", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetByDomainName", HTTPMethod: "GET", Endpoint: "/domains/{domainName}", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetContacts", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/contacts", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetDNSEntries", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/dns", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetDNSSecEntries", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/dnssec", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetDomainAction", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/actions", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetNameservers", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/nameservers", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetSSLCertificateByID", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/ssl/{certificateID}", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetSSLCertificates", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/ssl", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/domains", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetTLDByTLD", HTTPMethod: "GET", Endpoint: "/tlds/{tld}", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetTLDs", HTTPMethod: "GET", Endpoint: "/tlds", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "GetWHOIS", HTTPMethod: "GET", Endpoint: "/domains/{domainName}/whois", Mutating: false},
+	{Package: "domain", Repository: "Repository", Method: "OrderWhitelabel", HTTPMethod: "POST", Endpoint: "/whitelabel", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "Register", HTTPMethod: "POST", Endpoint: "/domains", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "RemoveDNSEntry", HTTPMethod: "DELETE", Endpoint: "/domains/{domainName}/dns", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "ReplaceDNSEntries", HTTPMethod: "PUT", Endpoint: "/domains/{domainName}/dns", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "ReplaceDNSSecEntries", HTTPMethod: "PUT", Endpoint: "/domains/{domainName}/dnssec", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "RetryDomainAction", HTTPMethod: "PATCH", Endpoint: "/domains/{domainName}/actions", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "Transfer", HTTPMethod: "POST", Endpoint: "/domains", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/domains/{Name}", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "UpdateBranding", HTTPMethod: "PUT", Endpoint: "/domains/{domainName}/branding", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "UpdateContacts", HTTPMethod: "PUT", Endpoint: "/domains/{domainName}/contacts", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "UpdateDNSEntry", HTTPMethod: "PATCH", Endpoint: "/domains/{domainName}/dns", Mutating: true},
+	{Package: "domain", Repository: "Repository", Method: "UpdateNameservers", HTTPMethod: "PUT", Endpoint: "/domains/{domainName}/nameservers", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "CreateMailbox", HTTPMethod: "POST", Endpoint: "/email/{domainName}/mailboxes", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "CreateMailforward", HTTPMethod: "POST", Endpoint: "/email/{domainName}/mail-forwards", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "CreateMaillist", HTTPMethod: "POST", Endpoint: "/email/{domainName}/mail-lists", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "DeleteMailbox", HTTPMethod: "DELETE", Endpoint: "/email/{domainName}/mailboxes/{emailAddress}", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "DeleteMailforward", HTTPMethod: "DELETE", Endpoint: "/email/{domainName}/mail-forwards/{forwardID}", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "DeleteMaillist", HTTPMethod: "DELETE", Endpoint: "/email/{domainName}/mail-lists/{maillistID}", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "GetAddonsByDomainName", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mail-addons", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMailboxByEmailAddress", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mailboxes/{emailAddress}", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMailboxesByDomainName", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mailboxes", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMailforwardByDomainNameAndID", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mail-forwards/{mailforwardID}", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMailforwardsByDomainName", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mail-forwards", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMaillistByDomainNameAndID", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mail-lists/{maillistID}", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMaillistsByDomainName", HTTPMethod: "GET", Endpoint: "/email/{domainName}/mail-lists", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "GetMailpackages", HTTPMethod: "GET", Endpoint: "/email", Mutating: false},
+	{Package: "email", Repository: "Repository", Method: "LinkMailaddon", HTTPMethod: "PATCH", Endpoint: "/email/{domainName}/mail-addons", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "UnlinkMailaddon", HTTPMethod: "PATCH", Endpoint: "/email/{domainName}/mail-addons", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "UpdateMailbox", HTTPMethod: "PUT", Endpoint: "/email/{domainName}/mailboxes/{emailAddress}", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "UpdateMailforward", HTTPMethod: "PUT", Endpoint: "/email/{domainName}/mail-forwards/{forwardID}", Mutating: true},
+	{Package: "email", Repository: "Repository", Method: "UpdateMaillist", HTTPMethod: "PUT", Endpoint: "/email/{domainName}/mail-lists/{maillistID}", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "AddCertificate", HTTPMethod: "POST", Endpoint: "/haips/{haipName}/certificates", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "AddLetsEncryptCertificate", HTTPMethod: "POST", Endpoint: "/haips/{haipName}/certificates", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "AddPortConfiguration", HTTPMethod: "POST", Endpoint: "/haips/{haipName}/port-configurations", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/haips/{haipName}", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "DetachCertificate", HTTPMethod: "DELETE", Endpoint: "/haips/{haipName}/certificates/{certificateID}", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "DetachIPAddresses", HTTPMethod: "DELETE", Endpoint: "/haips/{haipName}/ip-addresses", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/haips", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetAllCertificates", HTTPMethod: "GET", Endpoint: "/haips/{haipName}/certificates", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetAttachedIPAddresses", HTTPMethod: "GET", Endpoint: "/haips/{haipName}/ip-addresses", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/haips/{haipName}", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetPortConfiguration", HTTPMethod: "GET", Endpoint: "/haips/{haipName}/port-configurations/{portConfigurationID}", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetPortConfigurations", HTTPMethod: "GET", Endpoint: "/haips/{haipName}/port-configurations", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/haips", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "GetStatusReport", HTTPMethod: "GET", Endpoint: "/haips/{haipName}/status-reports", Mutating: false},
+	{Package: "haip", Repository: "Repository", Method: "Order", HTTPMethod: "POST", Endpoint: "/haips", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "OrderWithOperation", HTTPMethod: "POST", Endpoint: "/haips", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "OrderWithResponse", HTTPMethod: "POST", Endpoint: "/haips", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "RemovePortConfiguration", HTTPMethod: "DELETE", Endpoint: "/haips/{haipName}/port-configurations/{portConfigurationID}", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "SetAttachedIPAddresses", HTTPMethod: "PUT", Endpoint: "/haips/{haipName}/ip-addresses", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/haips/{Name}", Mutating: true},
+	{Package: "haip", Repository: "Repository", Method: "UpdatePortConfiguration", HTTPMethod: "PUT", Endpoint: "/haips/{haipName}/port-configurations/{ID}", Mutating: true},
+	{Package: "invoice", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/invoices", Mutating: false},
+	{Package: "invoice", Repository: "Repository", Method: "GetByInvoiceNumber", HTTPMethod: "GET", Endpoint: "/invoices/{invoiceNumber}", Mutating: false},
+	{Package: "invoice", Repository: "Repository", Method: "GetInvoiceItems", HTTPMethod: "GET", Endpoint: "/invoices/{invoiceNumber}/invoice-items", Mutating: false},
+	{Package: "invoice", Repository: "Repository", Method: "GetInvoicePdf", HTTPMethod: "GET", Endpoint: "/invoices/{invoiceNumber}/pdf", Mutating: false},
+	{Package: "invoice", Repository: "Repository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/invoices", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "AddBlockStorageVolume", HTTPMethod: "POST", Endpoint: "/kubernetes/clusters/{ClusterName}/block-storages", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "AddNodePool", HTTPMethod: "POST", Endpoint: "/kubernetes/clusters/{ClusterName}/node-pools", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "CreateCluster", HTTPMethod: "POST", Endpoint: "/kubernetes/clusters", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "CreateLoadBalancer", HTTPMethod: "POST", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetBlockStorageStatistics", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/block-storages/{name}/stats", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetBlockStorageVolume", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/block-storages/{name}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetBlockStorageVolumes", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/block-storages", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetClusterByName", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetClusters", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetCompatibleRelease", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/releases/{version}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetCompatibleReleases", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/releases", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetEventByName", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/events/{eventName}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetEvents", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/events", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetEventsByNamespace", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/events", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetKubeConfig", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/kubeconfig", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetLabels", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}/labels", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetLoadBalancer", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers/{name}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetLoadBalancerStatusReports", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers/{name}/status-reports", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetLoadBalancerStatusReportsForNode", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers/{name}/status-reports/{nodeUUID}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetLoadBalancers", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNode", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/nodes/{nodeUUID}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNodePool", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNodePools", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNodeStatistics", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/nodes/{nodeUUID}/stats", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNodes", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/nodes", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetNodesByNodePoolUUID", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/nodes", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetRelease", HTTPMethod: "GET", Endpoint: "/kubernetes/releases/{version}", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetReleases", HTTPMethod: "GET", Endpoint: "/kubernetes/releases", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "GetTaints", HTTPMethod: "GET", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}/taints", Mutating: false},
+	{Package: "kubernetes", Repository: "Repository", Method: "RebootNode", HTTPMethod: "PATCH", Endpoint: "/kubernetes/clusters/{clusterName}/nodes/{nodeUUID}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "RemoveBlockStorageVolume", HTTPMethod: "DELETE", Endpoint: "/kubernetes/clusters/{clusterName}/block-storages/{name}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "RemoveCluster", HTTPMethod: "DELETE", Endpoint: "/kubernetes/clusters/{clusterName}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "RemoveLoadBalancer", HTTPMethod: "DELETE", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers/{name}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "RemoveNodePool", HTTPMethod: "DELETE", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "ResetCluster", HTTPMethod: "PATCH", Endpoint: "/kubernetes/clusters/{clusterName}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "SetLabels", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}/labels", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "SetTaints", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{clusterName}/node-pools/{nodePoolUUID}/taints", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "UpdateBlockStorageVolume", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{ClusterName}/block-storages/{Name}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "UpdateCluster", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{Name}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "UpdateLoadBalancer", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{clusterName}/load-balancers/{name}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "UpdateNodePool", HTTPMethod: "PUT", Endpoint: "/kubernetes/clusters/{ClusterName}/node-pools/{UUID}", Mutating: true},
+	{Package: "kubernetes", Repository: "Repository", Method: "UpgradeCluster", HTTPMethod: "PATCH", Endpoint: "/kubernetes/clusters/{clusterName}", Mutating: true},
+	{Package: "mailservice", Repository: "Repository", Method: "AddDNSEntriesDomains", HTTPMethod: "POST", Endpoint: "/mail-service", Mutating: true},
+	{Package: "mailservice", Repository: "Repository", Method: "GetInformation", HTTPMethod: "GET", Endpoint: "/mail-service", Mutating: false},
+	{Package: "mailservice", Repository: "Repository", Method: "RegeneratePassword", HTTPMethod: "PATCH", Endpoint: "/mail-service", Mutating: true},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/openstack/projects/{projectID}", Mutating: true},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "Create", HTTPMethod: "POST", Endpoint: "/openstack/projects", Mutating: true},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/openstack/projects", Mutating: false},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "GetByID", HTTPMethod: "GET", Endpoint: "/openstack/projects/{projectID}", Mutating: false},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "Handover", HTTPMethod: "PATCH", Endpoint: "/openstack/projects/{projectID}", Mutating: true},
+	{Package: "openstack", Repository: "ProjectRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/openstack/projects/{ID}", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "AddToProject", HTTPMethod: "POST", Endpoint: "/openstack/projects/{projectID}/users", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "ChangePassword", HTTPMethod: "PATCH", Endpoint: "/openstack/users/{userID}", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "Create", HTTPMethod: "POST", Endpoint: "/openstack/users", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "Delete", HTTPMethod: "DELETE", Endpoint: "/openstack/users/{userID}", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/openstack/users", Mutating: false},
+	{Package: "openstack", Repository: "UserRepository", Method: "GetByID", HTTPMethod: "GET", Endpoint: "/openstack/users/{userID}", Mutating: false},
+	{Package: "openstack", Repository: "UserRepository", Method: "GetByProjectID", HTTPMethod: "GET", Endpoint: "/openstack/projects/{projectID}/users", Mutating: false},
+	{Package: "openstack", Repository: "UserRepository", Method: "RemoveFromProject", HTTPMethod: "DELETE", Endpoint: "/openstack/projects/{projectID}/users/{userID}", Mutating: true},
+	{Package: "openstack", Repository: "UserRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/openstack/users/{ID}", Mutating: true},
+	{Package: "product", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/products", Mutating: false},
+	{Package: "product", Repository: "Repository", Method: "GetSpecificationsForProduct", HTTPMethod: "GET", Endpoint: "/products/{Name}/elements", Mutating: false},
+	{Package: "sshkey", Repository: "Repository", Method: "Add", HTTPMethod: "POST", Endpoint: "/ssh-keys", Mutating: true},
+	{Package: "sshkey", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/ssh-keys", Mutating: false},
+	{Package: "sshkey", Repository: "Repository", Method: "GetByID", HTTPMethod: "GET", Endpoint: "/ssh-keys/{sshKeyID}", Mutating: false},
+	{Package: "sshkey", Repository: "Repository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/ssh-keys", Mutating: false},
+	{Package: "sshkey", Repository: "Repository", Method: "Remove", HTTPMethod: "DELETE", Endpoint: "/ssh-keys/{sshKeyID}", Mutating: true},
+	{Package: "sshkey", Repository: "Repository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/ssh-keys/{ID}", Mutating: true},
+	{Package: "sslcertificate", Repository: "Repository", Method: "Download", HTTPMethod: "GET", Endpoint: "/ssl-certificates/{id}/download", Mutating: false},
+	{Package: "sslcertificate", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/ssl-certificates", Mutating: false},
+	{Package: "sslcertificate", Repository: "Repository", Method: "GetByID", HTTPMethod: "GET", Endpoint: "/ssl-certificates/{id}", Mutating: false},
+	{Package: "sslcertificate", Repository: "Repository", Method: "GetDetails", HTTPMethod: "GET", Endpoint: "/ssl-certificates/{id}/details", Mutating: false},
+	{Package: "sslcertificate", Repository: "Repository", Method: "Order", HTTPMethod: "POST", Endpoint: "/ssl-certificates", Mutating: true},
+	{Package: "test", Repository: "Repository", Method: "Test", HTTPMethod: "GET", Endpoint: "/api-test", Mutating: false},
+	{Package: "traffic", Repository: "Repository", Method: "GetTrafficInformationForVps", HTTPMethod: "GET", Endpoint: "/traffic/{vpsName}", Mutating: false},
+	{Package: "traffic", Repository: "Repository", Method: "GetTrafficPool", HTTPMethod: "GET", Endpoint: "/traffic", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "AttachToVps", HTTPMethod: "PUT", Endpoint: "/big-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/big-storages/{bigStorageName}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "DetachFromVps", HTTPMethod: "PUT", Endpoint: "/big-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/big-storages", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetBackups", HTTPMethod: "GET", Endpoint: "/big-storages/{bigStorageName}/backups", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/big-storages/{bigStorageName}", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/big-storages", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetUsage", HTTPMethod: "GET", Endpoint: "/big-storages/{bigStorageName}/usage", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "GetUsageLast24Hours", HTTPMethod: "GET", Endpoint: "/big-storages/{bigStorageName}/usage", Mutating: false},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "Order", HTTPMethod: "POST", Endpoint: "/big-storages", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "OrderWithOperation", HTTPMethod: "POST", Endpoint: "/big-storages", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "OrderWithResponse", HTTPMethod: "POST", Endpoint: "/big-storages", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackup", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackupToOtherBigStorage", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackupToOtherBigStorageWithOperation", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackupToOtherBigStorageWithResponse", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackupWithOperation", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "RevertBackupWithResponse", HTTPMethod: "PATCH", Endpoint: "/big-storages/{bigStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/big-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "UpdateWithOperation", HTTPMethod: "PUT", Endpoint: "/big-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "UpdateWithResponse", HTTPMethod: "PUT", Endpoint: "/big-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BigStorageRepository", Method: "Upgrade", HTTPMethod: "POST", Endpoint: "/big-storages", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "AttachToVps", HTTPMethod: "PUT", Endpoint: "/block-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/block-storages/{blockStorageName}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "DetachFromVps", HTTPMethod: "PUT", Endpoint: "/block-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/block-storages", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetBackups", HTTPMethod: "GET", Endpoint: "/block-storages/{blockStorageName}/backups", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/block-storages/{blockStorageName}", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/block-storages", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetUsage", HTTPMethod: "GET", Endpoint: "/block-storages/{blockStorageName}/usage", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "GetUsageLast24Hours", HTTPMethod: "GET", Endpoint: "/block-storages/{blockStorageName}/usage", Mutating: false},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "Order", HTTPMethod: "POST", Endpoint: "/block-storages", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "OrderWithOperation", HTTPMethod: "POST", Endpoint: "/block-storages", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "OrderWithResponse", HTTPMethod: "POST", Endpoint: "/block-storages", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackup", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackupToOtherBlockStorage", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackupToOtherBlockStorageWithOperation", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackupToOtherBlockStorageWithResponse", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackupWithOperation", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "RevertBackupWithResponse", HTTPMethod: "PATCH", Endpoint: "/block-storages/{blockStorageName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/block-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "UpdateWithOperation", HTTPMethod: "PUT", Endpoint: "/block-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "UpdateWithResponse", HTTPMethod: "PUT", Endpoint: "/block-storages/{Name}", Mutating: true},
+	{Package: "vps", Repository: "BlockStorageRepository", Method: "Upgrade", HTTPMethod: "POST", Endpoint: "/block-storages", Mutating: true},
+	{Package: "vps", Repository: "FirewallRepository", Method: "ApplyFirewall", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/firewall", Mutating: true},
+	{Package: "vps", Repository: "FirewallRepository", Method: "GetFirewall", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/firewall", Mutating: false},
+	{Package: "vps", Repository: "FirewallRepository", Method: "ModifyFirewall", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/firewall", Mutating: true},
+	{Package: "vps", Repository: "FirewallRepository", Method: "UpdateFirewall", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/firewall", Mutating: true},
+	{Package: "vps", Repository: "LicenseRepository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}/licenses/{licenseID}", Mutating: true},
+	{Package: "vps", Repository: "LicenseRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/licenses", Mutating: false},
+	{Package: "vps", Repository: "LicenseRepository", Method: "Order", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/licenses", Mutating: true},
+	{Package: "vps", Repository: "LicenseRepository", Method: "Replace", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/licenses/{LicenseID}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "AttachVps", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "AttachVpsWithOperation", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "AttachVpsWithResponse", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "DetachVps", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "DetachVpsWithOperation", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "DetachVpsWithResponse", HTTPMethod: "PATCH", Endpoint: "/private-networks/{privateNetworkName}", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/private-networks", Mutating: false},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/private-networks/{privateNetworkName}", Mutating: false},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/private-networks", Mutating: false},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "Order", HTTPMethod: "POST", Endpoint: "/private-networks", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "OrderWithOperation", HTTPMethod: "POST", Endpoint: "/private-networks", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "OrderWithResponse", HTTPMethod: "POST", Endpoint: "/private-networks", Mutating: true},
+	{Package: "vps", Repository: "PrivateNetworkRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/private-networks/{Name}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "AddIPv6Address", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/ip-addresses", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Cancel", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CancelAddon", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}/addons/{addon}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Clone", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CloneToAvailabilityZone", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CloneToAvailabilityZoneWithOperation", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CloneToAvailabilityZoneWithResponse", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CloneWithOperation", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CloneWithResponse", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "ConvertBackupToSnapshot", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "ConvertBackupToSnapshotWithOperation", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "ConvertBackupToSnapshotWithResponse", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CreateSnapshot", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/snapshots", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CreateSnapshotWithOperation", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/snapshots", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "CreateSnapshotWithResponse", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/snapshots", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "GetAddons", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/addons", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/vps", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetAllByTags", HTTPMethod: "GET", Endpoint: "/vps", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetAllUsage", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/usage", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetAllUsage24Hours", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/usage", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetBackups", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/backups", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetIPAddressByAddress", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/ip-addresses/{param}", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetIPAddresses", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/ip-addresses", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetOperatingSystems", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/operating-systems", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetSelection", HTTPMethod: "GET", Endpoint: "/vps", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetSnapshotByName", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetSnapshots", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/snapshots", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetUpgrades", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/upgrades", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetUsage", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/usage", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "GetVNCData", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/vnc-data", Mutating: false},
+	{Package: "vps", Repository: "Repository", Method: "Handover", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "InstallOperatingSystem", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/operating-systems", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "InstallOperatingSystemWithOptions", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/operating-systems", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Order", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderAddons", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/addons", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderMultiple", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderMultipleWithOperation", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderMultipleWithResponse", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderWithOperation", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "OrderWithResponse", HTTPMethod: "POST", Endpoint: "/vps", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RegenerateVNCToken", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/vnc-data", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RemoveIPv6Address", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}/ip-addresses/{param}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RemoveSnapshot", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Reset", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertBackup", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertBackupWithOperation", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertBackupWithResponse", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/backups/{backupID}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshot", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshotToOtherVps", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshotToOtherVpsWithOperation", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshotToOtherVpsWithResponse", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshotWithOperation", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "RevertSnapshotWithResponse", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/snapshots/{snapshotName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Start", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Stop", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/vps/{Name}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "UpdateReverseDNS", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/ip-addresses/{param}", Mutating: true},
+	{Package: "vps", Repository: "Repository", Method: "Upgrade", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/upgrades", Mutating: true},
+	{Package: "vps", Repository: "RescueImageRepository", Method: "BootRescueImage", HTTPMethod: "PATCH", Endpoint: "/vps/{vpsName}/rescue-images", Mutating: true},
+	{Package: "vps", Repository: "RescueImageRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/rescue-images", Mutating: false},
+	{Package: "vps", Repository: "SettingRepository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/settings", Mutating: false},
+	{Package: "vps", Repository: "SettingRepository", Method: "GetByName", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/settings/{settingName}", Mutating: false},
+	{Package: "vps", Repository: "SettingRepository", Method: "Update", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/settings/{Name}", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "CreateContact", HTTPMethod: "POST", Endpoint: "/monitoring-contacts", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "CreateTCPMonitor", HTTPMethod: "POST", Endpoint: "/vps/{vpsName}/tcp-monitors", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "GetContacts", HTTPMethod: "GET", Endpoint: "/monitoring-contacts", Mutating: false},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "GetTCPMonitors", HTTPMethod: "GET", Endpoint: "/vps/{vpsName}/tcp-monitors", Mutating: false},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "RemoveContact", HTTPMethod: "DELETE", Endpoint: "/monitoring-contacts/{contactID}", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "RemoveTCPMonitor", HTTPMethod: "DELETE", Endpoint: "/vps/{vpsName}/tcp-monitors/{param}", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "UpdateContact", HTTPMethod: "PUT", Endpoint: "/monitoring-contacts/{ID}", Mutating: true},
+	{Package: "vps", Repository: "TCPMonitorRepository", Method: "UpdateTCPMonitor", HTTPMethod: "PUT", Endpoint: "/vps/{vpsName}/tcp-monitors/{param}", Mutating: true},
+}
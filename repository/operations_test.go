@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOperation(t *testing.T) {
+	operation, found := FindOperation("vps.Repository.GetAll")
+	require.True(t, found)
+	assert.Equal(t, Operation{Package: "vps", Repository: "Repository", Method: "GetAll", HTTPMethod: "GET", Endpoint: "/vps"}, operation)
+
+	operation, found = FindOperation("vps.Repository.CreateSnapshot")
+	require.True(t, found)
+	assert.Equal(t, "POST", operation.HTTPMethod)
+	assert.Equal(t, "/vps/{vpsName}/snapshots", operation.Endpoint)
+	assert.True(t, operation.Mutating)
+
+	// methods calling other repository methods inherit their api call
+	operation, found = FindOperation("vps.Repository.GetAllUsage24Hours")
+	require.True(t, found)
+	assert.Equal(t, "GET", operation.HTTPMethod)
+	assert.False(t, operation.Mutating)
+
+	// methods that read before they update are mutating
+	operation, found = FindOperation("vps.FirewallRepository.ModifyFirewall")
+	require.True(t, found)
+	assert.Equal(t, "PUT", operation.HTTPMethod)
+	assert.True(t, operation.Mutating)
+
+	_, found = FindOperation("vps.Repository.DoesNotExist")
+	assert.False(t, found)
+}
+
+func TestReadOnlyAndMutatingOperations(t *testing.T) {
+	readOnly := ReadOnlyOperations()
+	mutating := MutatingOperations()
+	assert.Len(t, Operations(), len(readOnly)+len(mutating))
+
+	for _, operation := range readOnly {
+		assert.Equal(t, "GET", operation.HTTPMethod, operation.Name())
+	}
+	for _, operation := range mutating {
+		assert.NotEqual(t, "GET", operation.HTTPMethod, operation.Name())
+	}
+}
@@ -30,6 +30,12 @@ func (r *Method) StatusCodeOK(statusCode int) bool {
 	return contains(r.ExpectedStatusCodes, statusCode)
 }
 
+// Mutating returns true when requests with this method change state on the api server,
+// these requests are not allowed for a client in read-only mode
+func (r *Method) Mutating() bool {
+	return r.Method != GetMethod.Method
+}
+
 // contains is used to see if a certain value is part of an array
 func contains(haystack []int, needle int) bool {
 	for _, a := range haystack {
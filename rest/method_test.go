@@ -23,3 +23,11 @@ func TestContains(t *testing.T) {
 	assert.True(t, contains([]int{1, 2, 3, 4, 5}, 5))
 	assert.False(t, contains([]int{1, 2, 3, 4, 5}, 10))
 }
+
+func TestMethodMutating(t *testing.T) {
+	assert.False(t, GetMethod.Mutating())
+	assert.True(t, PostMethod.Mutating())
+	assert.True(t, PutMethod.Mutating())
+	assert.True(t, PatchMethod.Mutating())
+	assert.True(t, DeleteMethod.Mutating())
+}
@@ -0,0 +1,374 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// Action is what a plan does with a snapshot
+type Action string
+
+const (
+	// ActionKeep means the snapshot is kept
+	ActionKeep Action = "keep"
+	// ActionDelete means the snapshot is removed
+	ActionDelete Action = "delete"
+)
+
+// SnapshotDecision is what a plan does with a snapshot and why
+type SnapshotDecision struct {
+	// Snapshot the decision is about
+	Snapshot vps.Snapshot `json:"snapshot"`
+	// Action is what happens to the snapshot
+	Action Action `json:"action"`
+	// Reasons explain the action, like the periods a kept snapshot is the newest of
+	Reasons []string `json:"reasons"`
+}
+
+// VpsPlan is the plan for the snapshots of one vps
+type VpsPlan struct {
+	// VpsName is the name of the vps
+	VpsName string `json:"vpsName"`
+	// Policy is the name of the policy that selected the vps
+	Policy string `json:"policy"`
+	// Skipped is the reason nothing is done for the vps, like the vps being locked
+	Skipped string `json:"skipped,omitempty"`
+	// Error is set when the snapshots of the vps could not be listed
+	Error string `json:"error,omitempty"`
+	// Snapshots contains a decision for every existing snapshot, newest first
+	Snapshots []SnapshotDecision `json:"snapshots,omitempty"`
+	// Create is true when a new snapshot is created, after the deletions
+	Create bool `json:"create"`
+	// CreateDescription is the description of the new snapshot
+	CreateDescription string `json:"createDescription,omitempty"`
+	// ShouldStartVps is passed to CreateSnapshot, it is true when the vps is running so it keeps running
+	ShouldStartVps bool `json:"shouldStartVps,omitempty"`
+	// Notes explain why the plan deviates from the policy, like a new snapshot not fitting in MaxSnapshots
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Deletions returns the snapshots the plan deletes, newest first
+func (p VpsPlan) Deletions() []vps.Snapshot {
+	var deletions []vps.Snapshot
+	for _, decision := range p.Snapshots {
+		if decision.Action == ActionDelete {
+			deletions = append(deletions, decision.Snapshot)
+		}
+	}
+
+	return deletions
+}
+
+// Plan contains a VpsPlan for every vps selected by a policy, ordered by vps name
+type Plan struct {
+	Vpses []VpsPlan `json:"vpses"`
+}
+
+// String returns the plan as human readable text, this is the output of a dry run
+func (p Plan) String() string {
+	var builder strings.Builder
+	for _, vpsPlan := range p.Vpses {
+		header := fmt.Sprintf("%s (policy %s)", vpsPlan.VpsName, vpsPlan.Policy)
+		switch {
+		case vpsPlan.Error != "":
+			fmt.Fprintf(&builder, "%s: error: %s\n", header, vpsPlan.Error)
+			continue
+		case vpsPlan.Skipped != "":
+			fmt.Fprintf(&builder, "%s: skipped, %s\n", header, vpsPlan.Skipped)
+			continue
+		}
+
+		fmt.Fprintf(&builder, "%s:\n", header)
+		for _, decision := range vpsPlan.Snapshots {
+			snapshot := decision.Snapshot
+			fmt.Fprintf(&builder, "  %-7s %s  %s  %s  %s\n", decision.Action, snapshot.Name, snapshot.DateTimeCreate, snapshot.Status, strings.Join(decision.Reasons, ", "))
+		}
+		if vpsPlan.Create {
+			fmt.Fprintf(&builder, "  %-7s %q\n", "create", vpsPlan.CreateDescription)
+		}
+		for _, note := range vpsPlan.Notes {
+			fmt.Fprintf(&builder, "  %-7s %s\n", "note", note)
+		}
+	}
+
+	return builder.String()
+}
+
+// candidate is a snapshot that can be deleted, unless a period keeps it
+type candidate struct {
+	decision *SnapshotDecision
+	created  time.Time
+}
+
+// PlanSnapshots returns the plan for the snapshots of a vps according to the policy.
+// The creation times of the snapshots are interpreted in the location of now, the api returns them in Europe/Amsterdam.
+// The snapshot that is created is taken into account, so a plan does not need a second run to rotate.
+func PlanSnapshots(policy Policy, vpsItem vps.Vps, snapshots []vps.Snapshot, now time.Time) VpsPlan {
+	plan := VpsPlan{VpsName: vpsItem.Name, Policy: policy.Name}
+	if vpsItem.IsLocked {
+		plan.Skipped = "the vps is locked"
+		return plan
+	}
+
+	plan.Snapshots = make([]SnapshotDecision, len(snapshots))
+	createdAt := make(map[string]time.Time, len(snapshots))
+	for idx, snapshot := range snapshots {
+		plan.Snapshots[idx] = SnapshotDecision{Snapshot: snapshot, Action: ActionKeep}
+		if created, err := time.ParseInLocation(snapshotTimeLayout, snapshot.DateTimeCreate, now.Location()); err == nil {
+			createdAt[snapshot.Name] = created
+		}
+	}
+	// newest first, snapshots with an unknown creation time last
+	sort.SliceStable(plan.Snapshots, func(i, j int) bool {
+		a, aKnown := createdAt[plan.Snapshots[i].Snapshot.Name]
+		b, bKnown := createdAt[plan.Snapshots[j].Snapshot.Name]
+		if aKnown != bKnown {
+			return aKnown
+		}
+		return a.After(b)
+	})
+
+	periods := policy.periods()
+	var current *period
+	for idx := range periods {
+		if periods[idx].count > 0 {
+			current = &periods[idx]
+			break
+		}
+	}
+
+	var candidates []candidate
+	hasCurrent := false
+	for idx := range plan.Snapshots {
+		decision := &plan.Snapshots[idx]
+		snapshot := decision.Snapshot
+		created, known := createdAt[snapshot.Name]
+		switch {
+		case policy.DescriptionPrefix != "" && !strings.HasPrefix(snapshot.Description, policy.DescriptionPrefix):
+			decision.Reasons = []string{fmt.Sprintf("description does not start with '%s'", policy.DescriptionPrefix)}
+			continue
+		case !known:
+			decision.Reasons = []string{"unknown creation time"}
+			continue
+		}
+
+		if current != nil && current.key(created) == current.key(now) {
+			hasCurrent = true
+		}
+		if snapshot.Status != vps.SnapshotStatusActive {
+			decision.Reasons = []string{fmt.Sprintf("status is %s", snapshot.Status)}
+			continue
+		}
+		candidates = append(candidates, candidate{decision: decision, created: created})
+	}
+
+	if policy.Create && !hasCurrent {
+		plan.Create = true
+		prefix := policy.DescriptionPrefix
+		if prefix == "" {
+			prefix = defaultDescriptionPrefix
+		}
+		plan.CreateDescription = fmt.Sprintf("%s %s", prefix, now.Format("2006-01-02 15:04"))
+		plan.ShouldStartVps = vpsItem.Status == vps.VpsStatusRunning
+	}
+
+	for _, bucket := range periods {
+		if bucket.count == 0 {
+			continue
+		}
+		kept := make(map[string]bool, bucket.count)
+		if plan.Create {
+			// the new snapshot is the newest of the current period
+			kept[bucket.key(now)] = true
+		}
+		for _, item := range candidates {
+			if len(kept) >= bucket.count {
+				break
+			}
+			key := bucket.key(item.created)
+			if kept[key] {
+				continue
+			}
+			kept[key] = true
+			item.decision.Reasons = append(item.decision.Reasons, fmt.Sprintf("%s %s", bucket.name, key))
+		}
+	}
+
+	count := len(snapshots)
+	if vpsItem.CurrentSnapshots > count {
+		count = vpsItem.CurrentSnapshots
+	}
+	for _, item := range candidates {
+		if len(item.decision.Reasons) == 0 {
+			item.decision.Action = ActionDelete
+			item.decision.Reasons = []string{"not kept by any period"}
+			count--
+		}
+	}
+
+	if plan.Create && vpsItem.MaxSnapshots > 0 {
+		// make room for the new snapshot by deleting the oldest snapshots the policy keeps
+		for idx := len(candidates) - 1; idx >= 0 && count >= vpsItem.MaxSnapshots; idx-- {
+			decision := candidates[idx].decision
+			if decision.Action == ActionDelete {
+				continue
+			}
+			decision.Action = ActionDelete
+			decision.Reasons = []string{fmt.Sprintf("makes room for the new snapshot, the vps has a maximum of %d snapshots", vpsItem.MaxSnapshots)}
+			count--
+		}
+		if count >= vpsItem.MaxSnapshots {
+			plan.Create = false
+			plan.CreateDescription = ""
+			plan.ShouldStartVps = false
+			plan.Notes = append(plan.Notes, fmt.Sprintf("no room for a new snapshot, the vps has a maximum of %d snapshots and the others can not be deleted", vpsItem.MaxSnapshots))
+		}
+	}
+
+	return plan
+}
+
+// Engine plans and applies retention policies for all vpses
+type Engine struct {
+	// Policies are matched in order, a vps is handled by the first policy that selects it
+	Policies []Policy
+	// Location of the creation times of snapshots, Europe/Amsterdam when nil
+	Location *time.Location
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// Plan lists all vpses and their snapshots and returns what the policies would do, without changing anything.
+// Vpses that no policy selects are left out, a vps of which the snapshots can not be listed gets a VpsPlan with an Error.
+func (e *Engine) Plan(client repository.Client) (Plan, error) {
+	for _, policy := range e.Policies {
+		if err := policy.Validate(); err != nil {
+			return Plan{}, err
+		}
+	}
+	location := e.Location
+	if location == nil {
+		var err error
+		if location, err = time.LoadLocation("Europe/Amsterdam"); err != nil {
+			return Plan{}, err
+		}
+	}
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+
+	vpsRepo := vps.Repository{Client: client}
+	vpss, err := vpsRepo.GetAll()
+	if err != nil {
+		return Plan{}, fmt.Errorf("error listing vpses: %w", err)
+	}
+	sort.Slice(vpss, func(i, j int) bool { return vpss[i].Name < vpss[j].Name })
+
+	plan := Plan{Vpses: []VpsPlan{}}
+	for _, vpsItem := range vpss {
+		policy, ok := e.policyFor(vpsItem)
+		if !ok {
+			continue
+		}
+		if vpsItem.IsLocked {
+			plan.Vpses = append(plan.Vpses, PlanSnapshots(policy, vpsItem, nil, now().In(location)))
+			continue
+		}
+
+		snapshots, err := vpsRepo.GetSnapshots(vpsItem.Name)
+		if err != nil {
+			plan.Vpses = append(plan.Vpses, VpsPlan{VpsName: vpsItem.Name, Policy: policy.Name, Error: err.Error()})
+			continue
+		}
+		plan.Vpses = append(plan.Vpses, PlanSnapshots(policy, vpsItem, snapshots, now().In(location)))
+	}
+
+	return plan, nil
+}
+
+// policyFor returns the first policy that selects the vps
+func (e *Engine) policyFor(vpsItem vps.Vps) (Policy, bool) {
+	for _, policy := range e.Policies {
+		if policy.selects(vpsItem.Tags) {
+			return policy, true
+		}
+	}
+
+	return Policy{}, false
+}
+
+// Apply deletes and creates the snapshots of the plan. Before deleting, the vps and its snapshots are read again:
+// a vps that got locked is skipped with an error wrapping ErrVpsLocked, and a snapshot that is no longer active
+// is not deleted. A vps is not given a new snapshot when a deletion failed.
+// All vpses are handled, the errors are joined.
+func (e *Engine) Apply(client repository.Client, plan Plan) error {
+	vpsRepo := vps.Repository{Client: client}
+
+	var errs []error
+	for _, vpsPlan := range plan.Vpses {
+		if vpsPlan.Skipped != "" || vpsPlan.Error != "" || (len(vpsPlan.Deletions()) == 0 && !vpsPlan.Create) {
+			continue
+		}
+		vpsItem, err := vpsRepo.GetByName(vpsPlan.VpsName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("vps %s: error getting vps: %w", vpsPlan.VpsName, err))
+			continue
+		}
+		if vpsItem.IsLocked {
+			errs = append(errs, fmt.Errorf("vps %s: %w, its snapshots are left for the next run", vpsPlan.VpsName, ErrVpsLocked))
+			continue
+		}
+		if err := applyDeletions(vpsRepo, vpsPlan); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if vpsPlan.Create {
+			if err := vpsRepo.CreateSnapshot(vpsPlan.VpsName, vpsPlan.CreateDescription, vpsPlan.ShouldStartVps); err != nil {
+				errs = append(errs, fmt.Errorf("vps %s: error creating snapshot: %w", vpsPlan.VpsName, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyDeletions removes the snapshots the plan deletes that are still active
+func applyDeletions(vpsRepo vps.Repository, vpsPlan VpsPlan) error {
+	deletions := vpsPlan.Deletions()
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	snapshots, err := vpsRepo.GetSnapshots(vpsPlan.VpsName)
+	if err != nil {
+		return fmt.Errorf("vps %s: error listing snapshots: %w", vpsPlan.VpsName, err)
+	}
+	statuses := make(map[string]vps.SnapshotStatus, len(snapshots))
+	for _, snapshot := range snapshots {
+		statuses[snapshot.Name] = snapshot.Status
+	}
+
+	var errs []error
+	for _, snapshot := range deletions {
+		status, exists := statuses[snapshot.Name]
+		switch {
+		case !exists:
+			// already removed
+		case status != vps.SnapshotStatusActive:
+			errs = append(errs, fmt.Errorf("vps %s: snapshot %s not deleted, its status changed to %s", vpsPlan.VpsName, snapshot.Name, status))
+		default:
+			if err := vpsRepo.RemoveSnapshot(vpsPlan.VpsName, snapshot.Name); err != nil {
+				errs = append(errs, fmt.Errorf("vps %s: error removing snapshot %s: %w", vpsPlan.VpsName, snapshot.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,225 @@
+package retention
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNow returns the time plans are made at in these tests
+func testNow(t *testing.T) time.Time {
+	location, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+
+	return time.Date(2026, time.October, 18, 12, 30, 0, 0, location)
+}
+
+// decisions returns the decisions of a plan as "name action reasons" lines
+func decisions(plan VpsPlan) []string {
+	var lines []string
+	for _, decision := range plan.Snapshots {
+		lines = append(lines, string(decision.Action)+" "+decision.Snapshot.Name+": "+strings.Join(decision.Reasons, ", "))
+	}
+
+	return lines
+}
+
+func TestPlanSnapshots(t *testing.T) {
+	policy := Policy{Name: "gfs", Daily: 3, Weekly: 2, Create: true}
+	snapshots := []vps.Snapshot{
+		{Name: "c", DateTimeCreate: "2026-10-17 01:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "a", DateTimeCreate: "2026-10-18 02:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "x", DateTimeCreate: "", Status: vps.SnapshotStatusActive},
+		{Name: "b", DateTimeCreate: "2026-10-17 02:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "d", DateTimeCreate: "2026-10-16 02:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "e", DateTimeCreate: "2026-10-15 02:00:00", Status: "syncing"},
+		{Name: "f", DateTimeCreate: "2026-10-11 02:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "g", DateTimeCreate: "2026-10-04 02:00:00", Status: vps.SnapshotStatusActive},
+	}
+
+	plan := PlanSnapshots(policy, vps.Vps{Name: "example-vps", MaxSnapshots: 10, CurrentSnapshots: 8}, snapshots, testNow(t))
+	assert.Equal(t, "example-vps", plan.VpsName)
+	assert.Equal(t, "gfs", plan.Policy)
+	// there already is a snapshot of today
+	assert.False(t, plan.Create)
+	assert.Empty(t, plan.Notes)
+	assert.Equal(t, []string{
+		"keep a: daily 2026-10-18, weekly 2026-W42",
+		"keep b: daily 2026-10-17",
+		"delete c: not kept by any period",
+		"keep d: daily 2026-10-16",
+		"keep e: status is syncing",
+		"keep f: weekly 2026-W41",
+		"delete g: not kept by any period",
+		"keep x: unknown creation time",
+	}, decisions(plan))
+	assert.Equal(t, []vps.Snapshot{snapshots[0], snapshots[7]}, plan.Deletions())
+}
+
+func TestPlanSnapshots_Create(t *testing.T) {
+	policy := Policy{Name: "gfs", Hourly: 2, Daily: 2, Create: true, DescriptionPrefix: "gfs"}
+	snapshots := []vps.Snapshot{
+		{Name: "manual", Description: "before upgrade", DateTimeCreate: "2026-10-18 11:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "hour", Description: "gfs 2026-10-18 11:00", DateTimeCreate: "2026-10-18 11:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "day", Description: "gfs 2026-10-17 23:00", DateTimeCreate: "2026-10-17 23:00:00", Status: vps.SnapshotStatusActive},
+		{Name: "old", Description: "gfs 2026-10-16 23:00", DateTimeCreate: "2026-10-16 23:00:00", Status: vps.SnapshotStatusActive},
+	}
+	vpsItem := vps.Vps{Name: "example-vps", Status: vps.VpsStatusRunning, MaxSnapshots: 10, CurrentSnapshots: 4}
+
+	plan := PlanSnapshots(policy, vpsItem, snapshots, testNow(t))
+	assert.True(t, plan.Create)
+	assert.Equal(t, "gfs 2026-10-18 12:30", plan.CreateDescription)
+	assert.True(t, plan.ShouldStartVps)
+	// the new snapshot is the newest of the current hour and day
+	assert.Equal(t, []string{
+		"keep manual: description does not start with 'gfs'",
+		"keep hour: hourly 2026-10-18 11h",
+		"keep day: daily 2026-10-17",
+		"delete old: not kept by any period",
+	}, decisions(plan))
+
+	// without room for the new snapshot the oldest kept snapshot is deleted
+	vpsItem.MaxSnapshots = 3
+	plan = PlanSnapshots(policy, vpsItem, snapshots, testNow(t))
+	assert.True(t, plan.Create)
+	assert.Equal(t, []string{
+		"keep manual: description does not start with 'gfs'",
+		"keep hour: hourly 2026-10-18 11h",
+		"delete day: makes room for the new snapshot, the vps has a maximum of 3 snapshots",
+		"delete old: not kept by any period",
+	}, decisions(plan))
+}
+
+func TestPlanSnapshots_NoRoom(t *testing.T) {
+	policy := Policy{Name: "daily", Daily: 7, Create: true}
+	snapshots := []vps.Snapshot{
+		{Name: "creating", DateTimeCreate: "2026-10-17 02:00:00", Status: vps.SnapshotStatusCreating},
+	}
+
+	plan := PlanSnapshots(policy, vps.Vps{Name: "example-vps", Status: vps.VpsStatusStopped, MaxSnapshots: 1, CurrentSnapshots: 1}, snapshots, testNow(t))
+	assert.False(t, plan.Create)
+	assert.Empty(t, plan.CreateDescription)
+	assert.Equal(t, []string{"no room for a new snapshot, the vps has a maximum of 1 snapshots and the others can not be deleted"}, plan.Notes)
+	assert.Equal(t, []string{"keep creating: status is creating"}, decisions(plan))
+}
+
+func TestPlanSnapshots_Locked(t *testing.T) {
+	policy := Policy{Name: "daily", Daily: 1, Create: true}
+	snapshots := []vps.Snapshot{
+		{Name: "old", DateTimeCreate: "2026-10-01 02:00:00", Status: vps.SnapshotStatusActive},
+	}
+
+	plan := PlanSnapshots(policy, vps.Vps{Name: "example-vps", IsLocked: true}, snapshots, testNow(t))
+	assert.Equal(t, "the vps is locked", plan.Skipped)
+	assert.False(t, plan.Create)
+	assert.Empty(t, plan.Snapshots)
+}
+
+// vpsList is the response of /vps for the snapshot server
+const vpsList = `{"vpss":[
+	{"name":"web-1","status":"running","tags":["production"],"maxSnapshots":10,"currentSnapshots":2},
+	{"name":"db-1","status":"running","tags":["production"],"isLocked":true},
+	{"name":"dev-1","status":"stopped","tags":["development"]},
+	{"name":"web-2","status":"stopped","tags":["production"]}]}`
+
+// snapshotServer serves a fleet of vpses with the snapshots by path and records the changes
+func snapshotServer(t *testing.T, snapshots map[string]string) *testutil.RoutedMockServer {
+	responses := map[string]string{"/vps": vpsList}
+	for path, response := range snapshots {
+		responses[path] = response
+	}
+
+	return &testutil.RoutedMockServer{T: t, Responses: responses, Error: "Vps not found"}
+}
+
+func TestEngine_PlanAndApply(t *testing.T) {
+	server := snapshotServer(t, map[string]string{
+		"/vps/web-1": `{"vps":{"name":"web-1"}}`,
+		"/vps/web-1/snapshots": `{"snapshots":[
+			{"name":"1","description":"retention 2026-10-17 00:00","status":"active","dateTimeCreate":"2026-10-17 00:00:00"},
+			{"name":"2","description":"retention 2026-10-16 00:00","status":"active","dateTimeCreate":"2026-10-16 00:00:00"}]}`,
+	})
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	engine := Engine{
+		Policies: []Policy{{Name: "production", Tags: []string{"production"}, Daily: 2, Create: true}},
+		Now:      func() time.Time { return testNow(t) },
+	}
+	plan, err := engine.Plan(client)
+	require.NoError(t, err)
+
+	assert.Equal(t, `db-1 (policy production): skipped, the vps is locked
+web-1 (policy production):
+  keep    1  2026-10-17 00:00:00  active  daily 2026-10-17
+  delete  2  2026-10-16 00:00:00  active  not kept by any period
+  create  "retention 2026-10-18 12:30"
+web-2 (policy production): error: Vps not found
+`, plan.String())
+
+	// the status of snapshot 2 is checked again before deleting it
+	require.NoError(t, engine.Apply(client, plan))
+	assert.Equal(t, []string{
+		"DELETE /vps/web-1/snapshots/2",
+		`POST /vps/web-1/snapshots {"description":"retention 2026-10-18 12:30","shouldStartVps":true}`,
+	}, server.Changes)
+}
+
+func TestEngine_ApplyStatusChanged(t *testing.T) {
+	server := snapshotServer(t, map[string]string{
+		"/vps/web-1":           `{"vps":{"name":"web-1"}}`,
+		"/vps/web-1/snapshots": `{"snapshots":[{"name":"2","status":"reverting","dateTimeCreate":"2026-10-16 00:00:00"}]}`,
+	})
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan := Plan{Vpses: []VpsPlan{{
+		VpsName:           "web-1",
+		Policy:            "production",
+		Snapshots:         []SnapshotDecision{{Snapshot: vps.Snapshot{Name: "2", Status: vps.SnapshotStatusActive}, Action: ActionDelete}},
+		Create:            true,
+		CreateDescription: "retention 2026-10-18 12:30",
+	}}}
+
+	engine := Engine{}
+	err := engine.Apply(client, plan)
+	require.Error(t, err)
+	assert.EqualError(t, err, "vps web-1: snapshot 2 not deleted, its status changed to reverting")
+	// no snapshot is created when a deletion failed
+	assert.Empty(t, server.Changes)
+}
+
+func TestEngine_ApplyLocked(t *testing.T) {
+	server := snapshotServer(t, map[string]string{
+		"/vps/web-1":           `{"vps":{"name":"web-1","isLocked":true}}`,
+		"/vps/web-1/snapshots": `{"snapshots":[{"name":"2","status":"active","dateTimeCreate":"2026-10-16 00:00:00"}]}`,
+	})
+	client, closeServer := server.GetClient()
+	defer closeServer()
+
+	plan := Plan{Vpses: []VpsPlan{{
+		VpsName:           "web-1",
+		Policy:            "production",
+		Snapshots:         []SnapshotDecision{{Snapshot: vps.Snapshot{Name: "2", Status: vps.SnapshotStatusActive}, Action: ActionDelete}},
+		Create:            true,
+		CreateDescription: "retention 2026-10-18 12:30",
+	}}}
+
+	// the vps got locked after the plan was made, nothing is deleted or created
+	engine := Engine{}
+	err := engine.Apply(client, plan)
+	assert.ErrorIs(t, err, ErrVpsLocked)
+	assert.EqualError(t, err, "vps web-1: vps is locked, its snapshots are left for the next run")
+	assert.Empty(t, server.Changes)
+}
+
+func TestEngine_PlanInvalidPolicy(t *testing.T) {
+	engine := Engine{Policies: []Policy{{Name: "empty"}}}
+	_, err := engine.Plan(nil)
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+}
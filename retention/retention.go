@@ -0,0 +1,158 @@
+// Package retention rotates vps snapshots with grandfather-father-son policies.
+//
+// A Policy selects vpses by tag and keeps the newest snapshot of the last N hours, days, weeks, months and years.
+// The Engine plans which snapshots to delete and whether to create a new one, so the plan can be reviewed first:
+//
+//	policies, err := retention.LoadPolicies(file)
+//	if err != nil {
+//		panic(err)
+//	}
+//	engine := retention.Engine{Policies: policies}
+//	plan, err := engine.Plan(client)
+//	if err != nil {
+//		panic(err)
+//	}
+//	fmt.Print(plan)
+//	if !dryRun {
+//		err = engine.Apply(client, plan)
+//	}
+//
+// Snapshots are only deleted when their status is active, and vpses that are locked are skipped,
+// both are checked again by Apply.
+// The MaxSnapshots of a vps is respected: when there is no room for a new snapshot, the oldest snapshot the policy
+// keeps is deleted first.
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDescriptionPrefix is used in the description of new snapshots when Policy.DescriptionPrefix is not set
+const defaultDescriptionPrefix = "retention"
+
+// snapshotTimeLayout is the layout of the creation time of a snapshot returned by the api
+const snapshotTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// ErrInvalidPolicy is returned when a policy has no name, a negative count or keeps no snapshots at all
+	ErrInvalidPolicy = errors.New("invalid retention policy")
+	// ErrVpsLocked is returned by Engine.Apply for a vps that was locked after the plan was made
+	ErrVpsLocked = errors.New("vps is locked")
+)
+
+// Policy is a grandfather-father-son retention policy for the snapshots of the vpses it selects
+type Policy struct {
+	// Name identifies the policy in a Plan
+	Name string `yaml:"name"`
+	// Tags select the vpses that have at least one of these tags, empty to select every vps
+	Tags []string `yaml:"tags"`
+	// Hourly is the number of hours of which the newest snapshot is kept
+	Hourly int `yaml:"hourly"`
+	// Daily is the number of days of which the newest snapshot is kept
+	Daily int `yaml:"daily"`
+	// Weekly is the number of ISO weeks of which the newest snapshot is kept
+	Weekly int `yaml:"weekly"`
+	// Monthly is the number of months of which the newest snapshot is kept
+	Monthly int `yaml:"monthly"`
+	// Yearly is the number of years of which the newest snapshot is kept
+	Yearly int `yaml:"yearly"`
+	// Create makes the engine create a snapshot when there is none yet in the current hour,
+	// or day, week, month or year when the policy has no shorter period
+	Create bool `yaml:"create"`
+	// DescriptionPrefix limits the policy to snapshots of which the description starts with it,
+	// other snapshots are never deleted. New snapshots get a description of this prefix followed by the time.
+	DescriptionPrefix string `yaml:"descriptionPrefix"`
+}
+
+// Validate returns an ErrInvalidPolicy error when the policy has no name, a negative count
+// or would not keep any snapshot
+func (p Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidPolicy)
+	}
+
+	total := 0
+	for _, bucket := range p.periods() {
+		if bucket.count < 0 {
+			return fmt.Errorf("%w: policy '%s' has a negative %s count", ErrInvalidPolicy, p.Name, bucket.name)
+		}
+		total += bucket.count
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: policy '%s' does not keep any snapshot", ErrInvalidPolicy, p.Name)
+	}
+
+	return nil
+}
+
+// selects returns true when the vps has at least one of the tags of the policy, or the policy has no tags
+func (p Policy) selects(tags []string) bool {
+	if len(p.Tags) == 0 {
+		return true
+	}
+	for _, tag := range p.Tags {
+		for _, vpsTag := range tags {
+			if tag == vpsTag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// period is a bucket of a grandfather-father-son policy
+type period struct {
+	name  string
+	count int
+	// key returns the same value for all times in the same period
+	key func(t time.Time) string
+}
+
+// periods returns the periods of the policy, from short to long
+func (p Policy) periods() []period {
+	return []period{
+		{name: "hourly", count: p.Hourly, key: func(t time.Time) string { return t.Format("2006-01-02 15h") }},
+		{name: "daily", count: p.Daily, key: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{name: "weekly", count: p.Weekly, key: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{name: "monthly", count: p.Monthly, key: func(t time.Time) string { return t.Format("2006-01") }},
+		{name: "yearly", count: p.Yearly, key: func(t time.Time) string { return t.Format("2006") }},
+	}
+}
+
+// LoadPolicies reads a yaml list of policies from the reader and validates them, for example:
+//
+//   - name: production
+//     tags: ["production"]
+//     hourly: 6
+//     daily: 7
+//     weekly: 4
+//     monthly: 6
+//     create: true
+//     descriptionPrefix: gfs
+//   - name: default
+//     daily: 3
+//     create: true
+func LoadPolicies(reader io.Reader) ([]Policy, error) {
+	var policies []Policy
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policies); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error decoding retention policies: %w", err)
+	}
+	for _, policy := range policies {
+		if err := policy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return policies, nil
+}
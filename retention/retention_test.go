@@ -0,0 +1,60 @@
+package retention
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	assert.NoError(t, Policy{Name: "daily", Daily: 7}.Validate())
+
+	err := Policy{Daily: 7}.Validate()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.EqualError(t, err, "invalid retention policy: a name is required")
+
+	err = Policy{Name: "broken", Daily: 7, Weekly: -1}.Validate()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.EqualError(t, err, "invalid retention policy: policy 'broken' has a negative weekly count")
+
+	err = Policy{Name: "empty", Create: true}.Validate()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.EqualError(t, err, "invalid retention policy: policy 'empty' does not keep any snapshot")
+}
+
+func TestPolicy_Selects(t *testing.T) {
+	policy := Policy{Name: "production", Tags: []string{"production", "critical"}}
+	assert.True(t, policy.selects([]string{"web", "critical"}))
+	assert.False(t, policy.selects([]string{"staging"}))
+	assert.False(t, policy.selects(nil))
+	assert.True(t, Policy{Name: "default"}.selects(nil))
+}
+
+func TestLoadPolicies(t *testing.T) {
+	policies, err := LoadPolicies(strings.NewReader(`
+- name: production
+  tags: ["production"]
+  hourly: 6
+  daily: 7
+  weekly: 4
+  monthly: 6
+  create: true
+  descriptionPrefix: gfs
+- name: default
+  daily: 3
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Policy{
+		{Name: "production", Tags: []string{"production"}, Hourly: 6, Daily: 7, Weekly: 4, Monthly: 6, Create: true, DescriptionPrefix: "gfs"},
+		{Name: "default", Daily: 3},
+	}, policies)
+
+	_, err = LoadPolicies(strings.NewReader("- name: default\n  create: true\n"))
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = LoadPolicies(strings.NewReader("- name: default\n  days: 3\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error decoding retention policies")
+}
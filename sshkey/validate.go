@@ -0,0 +1,56 @@
+package sshkey
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidKey is returned when a public key is not in the authorized_keys format
+	ErrInvalidKey = errors.New("invalid ssh public key")
+)
+
+// keyTypes are the public key algorithms accepted by ValidateKey
+var keyTypes = map[string]bool{
+	"ssh-rsa":                            true,
+	"ssh-dss":                            true,
+	"ssh-ed25519":                        true,
+	"ecdsa-sha2-nistp256":                true,
+	"ecdsa-sha2-nistp384":                true,
+	"ecdsa-sha2-nistp521":                true,
+	"sk-ssh-ed25519@openssh.com":         true,
+	"sk-ecdsa-sha2-nistp256@openssh.com": true,
+}
+
+// ValidateKey checks that the key is a public key in the authorized_keys format, like "ssh-ed25519 AAAA... comment",
+// without options. It checks the algorithm and that the encoded key starts with the same algorithm,
+// which catches truncated and mixed up keys before they are sent to the api or written to an install text.
+func ValidateKey(key string) error {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return fmt.Errorf("%w: expected an algorithm followed by the base64 encoded key", ErrInvalidKey)
+	}
+	if !keyTypes[fields[0]] {
+		return fmt.Errorf("%w: unknown algorithm '%s'", ErrInvalidKey, fields[0])
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return fmt.Errorf("%w: key is not base64 encoded", ErrInvalidKey)
+	}
+	if len(blob) < 4 {
+		return fmt.Errorf("%w: key is truncated", ErrInvalidKey)
+	}
+	length := binary.BigEndian.Uint32(blob)
+	if uint64(len(blob)) < 4+uint64(length) {
+		return fmt.Errorf("%w: key is truncated", ErrInvalidKey)
+	}
+	if algorithm := string(blob[4 : 4+length]); algorithm != fields[0] {
+		return fmt.Errorf("%w: key of algorithm '%s' is labeled '%s'", ErrInvalidKey, algorithm, fields[0])
+	}
+
+	return nil
+}
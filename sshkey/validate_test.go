@@ -0,0 +1,28 @@
+package sshkey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f user@example"))
+	assert.NoError(t, ValidateKey("ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAQAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8="))
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"", "invalid ssh public key: expected an algorithm followed by the base64 encoded key"},
+		{"ssh-foo AAAA", "invalid ssh public key: unknown algorithm 'ssh-foo'"},
+		{"ssh-ed25519 not-base64!", "invalid ssh public key: key is not base64 encoded"},
+		{"ssh-ed25519 AAAAC3NzaC1lZDI1", "invalid ssh public key: key is truncated"},
+		{"ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f", "invalid ssh public key: key of algorithm 'ssh-ed25519' is labeled 'ssh-rsa'"},
+	}
+	for _, tt := range tests {
+		err := ValidateKey(tt.key)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+		assert.EqualError(t, err, tt.expected)
+	}
+}
@@ -0,0 +1,244 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// The values used for the Analyzer fields that are not set
+const (
+	// DefaultIdleThreshold is the cpu percentage below which a vps is idle
+	DefaultIdleThreshold = 2.0
+	// DefaultIdleDuration is how long the cpu usage has to stay below the idle threshold
+	DefaultIdleDuration = 14 * 24 * time.Hour
+	// DefaultZScoreThreshold is the z-score from which a sample is a spike
+	DefaultZScoreThreshold = 3.0
+	// DefaultMinSamples is the number of samples a metric needs before spikes are detected
+	DefaultMinSamples = 10
+)
+
+// DefaultAnomalyMetrics are the metrics that are checked for spikes when Analyzer.AnomalyMetrics is empty
+var DefaultAnomalyMetrics = []Metric{MetricDiskIopsRead, MetricDiskIopsWrite, MetricNetworkMbitIn, MetricNetworkMbitOut}
+
+// Analyzer computes usage reports, the zero value uses the defaults
+type Analyzer struct {
+	// Window is the longest period requested at once, DefaultWindow when zero
+	Window time.Duration
+	// IdleThreshold is the cpu percentage below which a vps is idle, DefaultIdleThreshold when zero
+	IdleThreshold float64
+	// IdleDuration is how long the cpu usage has to stay below IdleThreshold, DefaultIdleDuration when zero.
+	// The analyzed period has to be at least this long to detect an idle vps.
+	IdleDuration time.Duration
+	// ZScoreThreshold is the z-score from which a sample is a spike, DefaultZScoreThreshold when zero
+	ZScoreThreshold float64
+	// MinSamples is the number of samples a metric needs before spikes are detected, DefaultMinSamples when zero
+	MinSamples int
+	// AnomalyMetrics are checked for spikes, DefaultAnomalyMetrics when empty
+	AnomalyMetrics []Metric
+}
+
+// VpsReport is the usage analysis of one vps
+type VpsReport struct {
+	// VpsName is the name of the vps
+	VpsName string `json:"vpsName"`
+	// Error is set when the usage of the vps could not be fetched
+	Error string `json:"error,omitempty"`
+	// Stats per metric, metrics without samples are left out
+	Stats map[Metric]Stats `json:"stats,omitempty"`
+	// Idle is true when the cpu usage stayed below the idle threshold for the idle duration
+	Idle bool `json:"idle"`
+	// IdleSince is the start of the period the cpu usage is below the idle threshold, zero when it is not
+	IdleSince time.Time `json:"idleSince,omitempty"`
+	// Anomalies are the spikes in the anomaly metrics, ordered by time
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+}
+
+// FleetStats summarizes a metric over the samples of all vpses
+type FleetStats struct {
+	Stats
+	// PeakVps is the vps of the Peak sample
+	PeakVps string `json:"peakVps"`
+}
+
+// FleetReport is the usage analysis of all vpses
+type FleetReport struct {
+	// Period that is analyzed
+	Period vps.UsagePeriod `json:"period"`
+	// Vpses contains a report for every vps, ordered by name
+	Vpses []VpsReport `json:"vpses"`
+	// Fleet contains the stats per metric over the samples of all vpses
+	Fleet map[Metric]FleetStats `json:"fleet,omitempty"`
+}
+
+// IdleVpses returns the names of the idle vpses
+func (r FleetReport) IdleVpses() []string {
+	var names []string
+	for _, report := range r.Vpses {
+		if report.Idle {
+			names = append(names, report.VpsName)
+		}
+	}
+
+	return names
+}
+
+// String returns the stats of every metric for the fleet and every vps, followed by the idle vpses and anomalies
+func (r FleetReport) String() string {
+	var builder strings.Builder
+	writeStats := func(name string, metric Metric, stats Stats) {
+		fmt.Fprintf(&builder, "%-20s %-17s mean=%.2f p95=%.2f p99=%.2f peak=%.2f at %s\n",
+			name, metric, stats.Mean, stats.P95, stats.P99, stats.Peak.Value, stats.Peak.Time.UTC().Format(time.RFC3339))
+	}
+
+	for _, metric := range Metrics {
+		if stats, ok := r.Fleet[metric]; ok {
+			writeStats("fleet", metric, stats.Stats)
+		}
+	}
+	for _, report := range r.Vpses {
+		if report.Error != "" {
+			fmt.Fprintf(&builder, "%-20s error: %s\n", report.VpsName, report.Error)
+			continue
+		}
+		for _, metric := range Metrics {
+			if stats, ok := report.Stats[metric]; ok {
+				writeStats(report.VpsName, metric, stats)
+			}
+		}
+	}
+	for _, report := range r.Vpses {
+		if report.Idle {
+			fmt.Fprintf(&builder, "[idle] %s: idle since %s\n", report.VpsName, report.IdleSince.UTC().Format(time.RFC3339))
+		}
+		for _, anomaly := range report.Anomalies {
+			fmt.Fprintf(&builder, "[spike] %s: %s %.2f at %s, z-score %.1f\n",
+				report.VpsName, anomaly.Metric, anomaly.Sample.Value, anomaly.Sample.Time.UTC().Format(time.RFC3339), anomaly.ZScore)
+		}
+	}
+
+	return builder.String()
+}
+
+// Analyze returns the report for the usage data of a vps
+func (a *Analyzer) Analyze(vpsName string, usage vps.Usage) VpsReport {
+	return a.analyzeSeries(vpsName, Series(usage))
+}
+
+// AnalyzeVps fetches the usage data of the vps in the period and returns its report
+func (a *Analyzer) AnalyzeVps(client repository.Client, vpsName string, period vps.UsagePeriod) (VpsReport, error) {
+	usage, err := Fetch(client, vpsName, period, a.Window)
+	if err != nil {
+		return VpsReport{}, err
+	}
+
+	return a.Analyze(vpsName, usage), nil
+}
+
+// AnalyzeFleet fetches the usage data of all vpses in the period and returns the fleet report.
+// A vps of which the usage can not be fetched has an Error in its report,
+// an error is only returned when the period is invalid or the vpses can not be listed.
+func (a *Analyzer) AnalyzeFleet(client repository.Client, period vps.UsagePeriod) (FleetReport, error) {
+	if _, err := Windows(period, time.Second); err != nil {
+		return FleetReport{}, err
+	}
+
+	vpsRepo := vps.Repository{Client: client}
+	vpss, err := vpsRepo.GetAll()
+	if err != nil {
+		return FleetReport{}, fmt.Errorf("error listing vpses: %w", err)
+	}
+	sort.Slice(vpss, func(i, j int) bool { return vpss[i].Name < vpss[j].Name })
+
+	report := FleetReport{Period: period, Vpses: []VpsReport{}, Fleet: make(map[Metric]FleetStats)}
+	fleetSamples := make(map[Metric][]Sample)
+	peaks := make(map[Metric]FleetStats)
+	for _, vpsItem := range vpss {
+		usage, err := Fetch(client, vpsItem.Name, period, a.Window)
+		if err != nil {
+			report.Vpses = append(report.Vpses, VpsReport{VpsName: vpsItem.Name, Error: err.Error()})
+			continue
+		}
+
+		series := Series(usage)
+		vpsReport := a.analyzeSeries(vpsItem.Name, series)
+		report.Vpses = append(report.Vpses, vpsReport)
+		for metric, stats := range vpsReport.Stats {
+			fleetSamples[metric] = append(fleetSamples[metric], series[metric]...)
+			if peak, ok := peaks[metric]; !ok || stats.Peak.Value > peak.Peak.Value {
+				peaks[metric] = FleetStats{Stats: Stats{Peak: stats.Peak}, PeakVps: vpsItem.Name}
+			}
+		}
+	}
+
+	for metric, samples := range fleetSamples {
+		stats := Summarize(samples)
+		// Summarize can pick the sample of another vps with the same value, use the peak of the first vps instead
+		stats.Peak = peaks[metric].Peak
+		report.Fleet[metric] = FleetStats{Stats: stats, PeakVps: peaks[metric].PeakVps}
+	}
+
+	return report, nil
+}
+
+// analyzeSeries returns the report for the samples of a vps
+func (a *Analyzer) analyzeSeries(vpsName string, series map[Metric][]Sample) VpsReport {
+	report := VpsReport{VpsName: vpsName, Stats: make(map[Metric]Stats, len(series))}
+	for metric, samples := range series {
+		if len(samples) > 0 {
+			report.Stats[metric] = Summarize(samples)
+		}
+	}
+
+	if cpu := series[MetricCPU]; len(cpu) > 0 {
+		if since, ok := IdleSince(cpu, a.idleThreshold()); ok {
+			report.IdleSince = since
+			report.Idle = cpu[len(cpu)-1].Time.Sub(since) >= a.idleDuration()
+		}
+	}
+
+	anomalyMetrics := a.AnomalyMetrics
+	if len(anomalyMetrics) == 0 {
+		anomalyMetrics = DefaultAnomalyMetrics
+	}
+	for _, metric := range anomalyMetrics {
+		report.Anomalies = append(report.Anomalies, Spikes(metric, series[metric], a.zScoreThreshold(), a.minSamples())...)
+	}
+	sort.SliceStable(report.Anomalies, func(i, j int) bool {
+		return report.Anomalies[i].Sample.Time.Before(report.Anomalies[j].Sample.Time)
+	})
+
+	return report
+}
+
+func (a *Analyzer) idleThreshold() float64 {
+	if a.IdleThreshold == 0 {
+		return DefaultIdleThreshold
+	}
+	return a.IdleThreshold
+}
+
+func (a *Analyzer) idleDuration() time.Duration {
+	if a.IdleDuration == 0 {
+		return DefaultIdleDuration
+	}
+	return a.IdleDuration
+}
+
+func (a *Analyzer) zScoreThreshold() float64 {
+	if a.ZScoreThreshold == 0 {
+		return DefaultZScoreThreshold
+	}
+	return a.ZScoreThreshold
+}
+
+func (a *Analyzer) minSamples() int {
+	if a.MinSamples == 0 {
+		return DefaultMinSamples
+	}
+	return a.MinSamples
+}
@@ -0,0 +1,120 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const day = int64(24 * 3600)
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	var usage vps.Usage
+	for idx := int64(0); idx < 24; idx++ {
+		at := idx * 3600
+		cpu := float32(1)
+		if idx == 2 {
+			cpu = 40
+		}
+		read := float32(5)
+		if idx == 20 {
+			read = 500
+		}
+		usage.CPU = append(usage.CPU, vps.UsageDataCPU{Date: at, Percentage: cpu})
+		usage.Disk = append(usage.Disk, vps.UsageDataDisk{Date: at, IopsRead: read, IopsWrite: 1})
+	}
+
+	analyzer := Analyzer{IdleDuration: 12 * time.Hour}
+	report := analyzer.Analyze("web-1", usage)
+	assert.Equal(t, "web-1", report.VpsName)
+	assert.Equal(t, []Metric{MetricCPU, MetricDiskIopsRead, MetricDiskIopsWrite}, metrics(report.Stats))
+	assert.Equal(t, 40.0, report.Stats[MetricCPU].Peak.Value)
+	assert.True(t, report.Idle)
+	assert.Equal(t, time.Unix(3*3600, 0), report.IdleSince)
+	require.Len(t, report.Anomalies, 1)
+	assert.Equal(t, MetricDiskIopsRead, report.Anomalies[0].Metric)
+	assert.Equal(t, time.Unix(20*3600, 0), report.Anomalies[0].Sample.Time)
+
+	// with the default idle duration of 14 days a day of samples is not enough
+	analyzer = Analyzer{}
+	report = analyzer.Analyze("web-1", usage)
+	assert.False(t, report.Idle)
+	assert.Equal(t, time.Unix(3*3600, 0), report.IdleSince)
+
+	// the cpu spike is only reported when the cpu is an anomaly metric
+	analyzer = Analyzer{AnomalyMetrics: []Metric{MetricCPU}}
+	report = analyzer.Analyze("web-1", usage)
+	require.Len(t, report.Anomalies, 1)
+	assert.Equal(t, MetricCPU, report.Anomalies[0].Metric)
+}
+
+func TestAnalyzer_AnalyzeFleet(t *testing.T) {
+	server := usageServer{
+		lists: map[string]string{"/vps": `{"vpss":[{"name":"web-1"},{"name":"idle-1"},{"name":"broken-1"},{"name":"spiky-1"}]}`},
+		usage: map[string]func(int64) vps.Usage{
+			"/vps/web-1":  constantUsage(30, 20, 100),
+			"/vps/idle-1": constantUsage(0.5, 1, 0.1),
+			"/vps/spiky-1": func(at int64) vps.Usage {
+				usage := constantUsage(10, 10, 50)(at)
+				if at == 9*day+12*3600 {
+					usage.Network[0].MbitOut = 900
+				}
+				return usage
+			},
+		},
+	}
+	client, tearDown := server.getClient(t)
+	defer tearDown()
+
+	analyzer := Analyzer{Window: 7 * 24 * time.Hour}
+	report, err := analyzer.AnalyzeFleet(client, vps.UsagePeriod{TimeStart: 0, TimeEnd: 15 * day})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/vps/idle-1 0-604800", "/vps/idle-1 604800-1209600", "/vps/idle-1 1209600-1296000",
+		"/vps/spiky-1 0-604800", "/vps/spiky-1 604800-1209600", "/vps/spiky-1 1209600-1296000",
+		"/vps/web-1 0-604800", "/vps/web-1 604800-1209600", "/vps/web-1 1209600-1296000",
+	}, server.requests)
+
+	require.Len(t, report.Vpses, 4)
+	assert.Equal(t, "broken-1", report.Vpses[0].VpsName)
+	assert.Contains(t, report.Vpses[0].Error, "Vps not found")
+	assert.Equal(t, []string{"idle-1"}, report.IdleVpses())
+	assert.Equal(t, time.Unix(0, 0), report.Vpses[1].IdleSince)
+	require.Len(t, report.Vpses[2].Anomalies, 1)
+	assert.Equal(t, "spiky-1", report.Vpses[2].VpsName)
+	assert.Equal(t, 900.0, report.Vpses[2].Anomalies[0].Sample.Value)
+	assert.Empty(t, report.Vpses[3].Anomalies)
+
+	samplesPerVps := int(15*day/3600) + 1
+	assert.Equal(t, samplesPerVps, report.Vpses[3].Stats[MetricCPU].Count)
+	fleetCPU := report.Fleet[MetricCPU]
+	assert.Equal(t, 3*samplesPerVps, fleetCPU.Count)
+	assert.InDelta(t, 13.5, fleetCPU.Mean, 0.001)
+	assert.Equal(t, "web-1", fleetCPU.PeakVps)
+	assert.Equal(t, time.Unix(0, 0), fleetCPU.Peak.Time)
+	assert.Equal(t, "spiky-1", report.Fleet[MetricNetworkMbitOut].PeakVps)
+	assert.Equal(t, 900.0, report.Fleet[MetricNetworkMbitOut].Peak.Value)
+
+	output := report.String()
+	assert.Contains(t, output, "fleet                cpu_percentage    mean=13.50 p95=30.00 p99=30.00 peak=30.00 at 1970-01-01T00:00:00Z\n")
+	assert.Contains(t, output, "broken-1             error: ")
+	assert.Contains(t, output, "[idle] idle-1: idle since 1970-01-01T00:00:00Z\n")
+	assert.Contains(t, output, "[spike] spiky-1: network_mbit_out 900.00 at 1970-01-10T12:00:00Z, z-score ")
+
+	_, err = analyzer.AnalyzeFleet(client, vps.UsagePeriod{TimeStart: 10, TimeEnd: 0})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
+
+// metrics returns the metrics with stats in the order of Metrics
+func metrics(stats map[Metric]Stats) []Metric {
+	var result []Metric
+	for _, metric := range Metrics {
+		if _, ok := stats[metric]; ok {
+			result = append(result, metric)
+		}
+	}
+	return result
+}
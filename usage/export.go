@@ -0,0 +1,246 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// Format is an output format of Write
+type Format string
+
+const (
+	// FormatCSV writes a header followed by a line per point
+	FormatCSV Format = "csv"
+	// FormatNDJSON writes a json object per line for every point
+	FormatNDJSON Format = "ndjson"
+	// FormatOpenMetrics writes the OpenMetrics text format with timestamps,
+	// which can be backfilled with promtool tsdb create-blocks-from openmetrics
+	FormatOpenMetrics Format = "openmetrics"
+)
+
+// ErrUnknownFormat is returned by Write for an unknown format
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// metricHelp describes the metrics in the OpenMetrics output
+var metricHelp = map[Metric]string{
+	MetricCPU:            "CPU usage in percent.",
+	MetricDiskIopsRead:   "Disk read operations per second.",
+	MetricDiskIopsWrite:  "Disk write operations per second.",
+	MetricNetworkMbitIn:  "Inbound network traffic in Mbit per second.",
+	MetricNetworkMbitOut: "Outbound network traffic in Mbit per second.",
+}
+
+// Point is a sample of a metric of a resource
+type Point struct {
+	// Resource the sample is of
+	Resource Resource
+	// Metric of the sample
+	Metric Metric
+	// Sample with the time and value
+	Sample Sample
+}
+
+// Points returns the samples in the usage data of the resource, ordered by metric and time
+func Points(resource Resource, usage vps.Usage) []Point {
+	series := Series(usage)
+
+	var points []Point
+	for _, metric := range Metrics {
+		for _, sample := range series[metric] {
+			points = append(points, Point{Resource: resource, Metric: metric, Sample: sample})
+		}
+	}
+
+	return points
+}
+
+// Write writes the points in the format. The OpenMetrics format groups the points by metric,
+// the other formats write the points in the given order.
+func Write(writer io.Writer, format Format, points []Point) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(writer, points)
+	case FormatNDJSON:
+		return writeNDJSON(writer, points)
+	case FormatOpenMetrics:
+		return writeOpenMetrics(writer, points)
+	default:
+		return fmt.Errorf("%w: '%s'", ErrUnknownFormat, format)
+	}
+}
+
+// formatValue formats a value without the noise of the float32 the api returns
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 32)
+}
+
+func writeCSV(writer io.Writer, points []Point) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"kind", "cluster", "name", "metric", "timestamp", "value"}); err != nil {
+		return err
+	}
+	for _, point := range points {
+		record := []string{
+			string(point.Resource.Kind),
+			point.Resource.Cluster,
+			point.Resource.Name,
+			string(point.Metric),
+			strconv.FormatInt(point.Sample.Time.Unix(), 10),
+			formatValue(point.Sample.Value),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
+// ndjsonPoint is the json object of a point in the ndjson format
+type ndjsonPoint struct {
+	Kind      ResourceKind `json:"kind"`
+	Cluster   string       `json:"cluster,omitempty"`
+	Name      string       `json:"name"`
+	Metric    Metric       `json:"metric"`
+	Timestamp int64        `json:"timestamp"`
+	Value     json.Number  `json:"value"`
+}
+
+func writeNDJSON(writer io.Writer, points []Point) error {
+	encoder := json.NewEncoder(writer)
+	for _, point := range points {
+		err := encoder.Encode(ndjsonPoint{
+			Kind:      point.Resource.Kind,
+			Cluster:   point.Resource.Cluster,
+			Name:      point.Resource.Name,
+			Metric:    point.Metric,
+			Timestamp: point.Sample.Time.Unix(),
+			Value:     json.Number(formatValue(point.Sample.Value)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// metricName returns the OpenMetrics name of a metric
+func metricName(metric Metric) string {
+	return "transip_" + string(metric)
+}
+
+// labelValue escapes a value for an OpenMetrics label
+func labelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func writeOpenMetrics(writer io.Writer, points []Point) error {
+	// every metric family has to be written at once, with the samples of every series in time order
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	metricOrder := make(map[Metric]int, len(Metrics))
+	for idx, metric := range Metrics {
+		metricOrder[metric] = idx
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Metric != b.Metric {
+			return metricOrder[a.Metric] < metricOrder[b.Metric]
+		}
+		if a.Resource.ID() != b.Resource.ID() {
+			return a.Resource.ID() < b.Resource.ID()
+		}
+		return a.Sample.Time.Before(b.Sample.Time)
+	})
+
+	var builder strings.Builder
+	var family Metric
+	for idx, point := range sorted {
+		name := metricName(point.Metric)
+		if idx == 0 || point.Metric != family {
+			family = point.Metric
+			fmt.Fprintf(&builder, "# TYPE %s gauge\n", name)
+			if help, ok := metricHelp[point.Metric]; ok {
+				fmt.Fprintf(&builder, "# HELP %s %s\n", name, help)
+			}
+		}
+
+		labels := fmt.Sprintf(`kind="%s",name="%s"`, labelValue(string(point.Resource.Kind)), labelValue(point.Resource.Name))
+		if point.Resource.Cluster != "" {
+			labels = fmt.Sprintf(`cluster="%s",%s`, labelValue(point.Resource.Cluster), labels)
+		}
+		fmt.Fprintf(&builder, "%s{%s} %s %d\n", name, labels, formatValue(point.Sample.Value), point.Sample.Time.Unix())
+	}
+	builder.WriteString("# EOF\n")
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// Exporter collects the usage samples of resources
+type Exporter struct {
+	// Window is the longest period requested at once, DefaultWindow when zero
+	Window time.Duration
+	// State contains the last exported timestamp per resource. When it is set, Collect only returns samples
+	// after the last exported timestamp and records the newest timestamp it returns.
+	State *State
+}
+
+// Collect fetches the samples of the resources in the period. The samples of a resource that can not be fetched are
+// left out and its state is not changed, the returned error joins the errors of all resources that failed.
+func (e *Exporter) Collect(client repository.Client, resources []Resource, period vps.UsagePeriod) ([]Point, error) {
+	if _, err := Windows(period, time.Second); err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	var errs []error
+	for _, resource := range resources {
+		resourcePeriod := period
+		last, exported := int64(0), false
+		if e.State != nil {
+			last, exported = e.State.Last(resource)
+		}
+		if exported && last >= resourcePeriod.TimeStart {
+			resourcePeriod.TimeStart = last + 1
+		}
+		if resourcePeriod.TimeStart > resourcePeriod.TimeEnd {
+			continue
+		}
+
+		usage, err := FetchResource(client, resource, resourcePeriod, e.Window)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		newest := last
+		for _, point := range Points(resource, usage) {
+			timestamp := point.Sample.Time.Unix()
+			if exported && timestamp <= last {
+				continue
+			}
+			points = append(points, point)
+			if timestamp > newest {
+				newest = timestamp
+			}
+		}
+		if e.State != nil && newest > last {
+			e.State.SetLast(resource, newest)
+		}
+	}
+
+	return points, errors.Join(errs...)
+}
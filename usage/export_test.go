@@ -0,0 +1,138 @@
+package usage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// examplePoints returns the points of a vps and a kubernetes node, the node has a label value that has to be escaped.
+// The network date is a float32 in the api, so the timestamps are multiples of 128 seconds that it can represent.
+func examplePoints() []Point {
+	webUsage := vps.Usage{
+		CPU:     []vps.UsageDataCPU{{Date: 1574787600, Percentage: 3.11}, {Date: 1574784000, Percentage: 2.5}},
+		Network: []vps.UsageDataNetwork{{Date: 1574784000, MbitIn: 249.93, MbitOut: 100.2}},
+	}
+	nodeUsage := vps.Usage{CPU: []vps.UsageDataCPU{{Date: 1574784000, Percentage: 50}}}
+
+	return append(
+		Points(Resource{Kind: ResourceVps, Name: "web-1"}, webUsage),
+		Points(Resource{Kind: ResourceKubernetesNode, Cluster: `k8"s`, Name: "node-1"}, nodeUsage)...,
+	)
+}
+
+func TestPoints(t *testing.T) {
+	points := examplePoints()
+	require.Len(t, points, 5)
+	assert.Equal(t, Point{
+		Resource: Resource{Kind: ResourceVps, Name: "web-1"},
+		Metric:   MetricCPU,
+		Sample:   Sample{Time: time.Unix(1574784000, 0), Value: float64(float32(2.5))},
+	}, points[0])
+	assert.Equal(t, MetricCPU, points[1].Metric)
+	assert.Equal(t, MetricNetworkMbitIn, points[2].Metric)
+	assert.Equal(t, MetricNetworkMbitOut, points[3].Metric)
+	assert.Equal(t, ResourceKubernetesNode, points[4].Resource.Kind)
+}
+
+func TestWrite_CSV(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, FormatCSV, examplePoints()))
+	assert.Equal(t, `kind,cluster,name,metric,timestamp,value
+vps,,web-1,cpu_percentage,1574784000,2.5
+vps,,web-1,cpu_percentage,1574787600,3.11
+vps,,web-1,network_mbit_in,1574784000,249.93
+vps,,web-1,network_mbit_out,1574784000,100.2
+kubernetes_node,"k8""s",node-1,cpu_percentage,1574784000,50
+`, buffer.String())
+}
+
+func TestWrite_NDJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, FormatNDJSON, examplePoints()[1:2]))
+	assert.Equal(t, `{"kind":"vps","name":"web-1","metric":"cpu_percentage","timestamp":1574787600,"value":3.11}`+"\n", buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, Write(&buffer, FormatNDJSON, examplePoints()[4:]))
+	assert.Equal(t, `{"kind":"kubernetes_node","cluster":"k8\"s","name":"node-1","metric":"cpu_percentage","timestamp":1574784000,"value":50}`+"\n", buffer.String())
+}
+
+func TestWrite_OpenMetrics(t *testing.T) {
+	points := examplePoints()
+	// the order of the points does not matter
+	points[0], points[4] = points[4], points[0]
+
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, FormatOpenMetrics, points))
+	assert.Equal(t, `# TYPE transip_cpu_percentage gauge
+# HELP transip_cpu_percentage CPU usage in percent.
+transip_cpu_percentage{cluster="k8\"s",kind="kubernetes_node",name="node-1"} 50 1574784000
+transip_cpu_percentage{kind="vps",name="web-1"} 2.5 1574784000
+transip_cpu_percentage{kind="vps",name="web-1"} 3.11 1574787600
+# TYPE transip_network_mbit_in gauge
+# HELP transip_network_mbit_in Inbound network traffic in Mbit per second.
+transip_network_mbit_in{kind="vps",name="web-1"} 249.93 1574784000
+# TYPE transip_network_mbit_out gauge
+# HELP transip_network_mbit_out Outbound network traffic in Mbit per second.
+transip_network_mbit_out{kind="vps",name="web-1"} 100.2 1574784000
+# EOF
+`, buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, Write(&buffer, FormatOpenMetrics, nil))
+	assert.Equal(t, "# EOF\n", buffer.String())
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "xml", examplePoints())
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.EqualError(t, err, "unknown export format: 'xml'")
+}
+
+func TestExporter_Collect(t *testing.T) {
+	server := resourceServer()
+	client, tearDown := server.getClient(t)
+	defer tearDown()
+
+	blockStorage := Resource{Kind: ResourceBlockStorage, Name: "block-1"}
+	webServer := Resource{Kind: ResourceVps, Name: "web-1"}
+	unknown := Resource{Kind: ResourceBigStorage, Name: "unknown"}
+	exporter := Exporter{State: &State{}}
+
+	points, err := exporter.Collect(client, []Resource{blockStorage, webServer, unknown}, vps.UsagePeriod{TimeStart: 0, TimeEnd: 2 * 3600})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error getting usage of bigstorage/unknown from 0 to 7200: ")
+	// 3 samples of 2 disk metrics and 3 samples of 5 vps metrics
+	assert.Len(t, points, 3*2+3*5)
+	assert.Equal(t, map[string]int64{"blockstorage/block-1": 7200, "vps/web-1": 7200}, exporter.State.LastExported)
+
+	// the next run only fetches and returns the samples after the last exported timestamp
+	server.requests = nil
+	points, err = exporter.Collect(client, []Resource{blockStorage, webServer}, vps.UsagePeriod{TimeStart: 0, TimeEnd: 4 * 3600})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/block-storages/block-1 7201-14400", "/vps/web-1 7201-14400"}, server.requests)
+	assert.Len(t, points, 2*2+2*5)
+	for _, point := range points {
+		assert.True(t, point.Sample.Time.Unix() > 7200)
+	}
+	assert.Equal(t, map[string]int64{"blockstorage/block-1": 14400, "vps/web-1": 14400}, exporter.State.LastExported)
+
+	// nothing is fetched when the period was already exported
+	server.requests = nil
+	points, err = exporter.Collect(client, []Resource{blockStorage}, vps.UsagePeriod{TimeStart: 0, TimeEnd: 4 * 3600})
+	require.NoError(t, err)
+	assert.Empty(t, points)
+	assert.Empty(t, server.requests)
+
+	// without state all samples in the period are returned
+	points, err = (&Exporter{}).Collect(client, []Resource{blockStorage}, vps.UsagePeriod{TimeStart: 0, TimeEnd: 3600})
+	require.NoError(t, err)
+	assert.Len(t, points, 2*2)
+
+	_, err = exporter.Collect(client, []Resource{blockStorage}, vps.UsagePeriod{TimeStart: 10, TimeEnd: 0})
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
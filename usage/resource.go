@@ -0,0 +1,127 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/assi010/gotransip/v6/kubernetes"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// ResourceKind is the kind of product usage samples are exported for
+type ResourceKind string
+
+const (
+	// ResourceVps is a vps, with cpu, disk and network samples
+	ResourceVps ResourceKind = "vps"
+	// ResourceBlockStorage is a block storage, with disk samples
+	ResourceBlockStorage ResourceKind = "blockstorage"
+	// ResourceBigStorage is a big storage, with disk samples
+	ResourceBigStorage ResourceKind = "bigstorage"
+	// ResourceKubernetesNode is a node of a kubernetes cluster, with cpu, disk and network samples
+	ResourceKubernetesNode ResourceKind = "kubernetes_node"
+)
+
+// Resource is a product usage samples are exported for
+type Resource struct {
+	// Kind of product
+	Kind ResourceKind `json:"kind"`
+	// Cluster is the name of the kubernetes cluster of a node, it is empty for other kinds
+	Cluster string `json:"cluster,omitempty"`
+	// Name of the product, the uuid of a kubernetes node
+	Name string `json:"name"`
+}
+
+// ID returns a unique identifier of the resource, like vps/example-vps or kubernetes_node/k888k/<uuid>
+func (r Resource) ID() string {
+	if r.Cluster != "" {
+		return fmt.Sprintf("%s/%s/%s", r.Kind, r.Cluster, r.Name)
+	}
+
+	return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+}
+
+// Discover lists the vpses, block storages, big storages and kubernetes nodes, ordered by id
+func Discover(client repository.Client) ([]Resource, error) {
+	var resources []Resource
+
+	vpsRepo := vps.Repository{Client: client}
+	vpss, err := vpsRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing vpses: %w", err)
+	}
+	for _, vpsItem := range vpss {
+		resources = append(resources, Resource{Kind: ResourceVps, Name: vpsItem.Name})
+	}
+
+	blockStorageRepo := vps.BlockStorageRepository{Client: client}
+	blockStorages, err := blockStorageRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing block storages: %w", err)
+	}
+	for _, blockStorage := range blockStorages {
+		resources = append(resources, Resource{Kind: ResourceBlockStorage, Name: blockStorage.Name})
+	}
+
+	bigStorageRepo := vps.BigStorageRepository{Client: client}
+	bigStorages, err := bigStorageRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing big storages: %w", err)
+	}
+	for _, bigStorage := range bigStorages {
+		resources = append(resources, Resource{Kind: ResourceBigStorage, Name: bigStorage.Name})
+	}
+
+	kubernetesRepo := kubernetes.Repository{Client: client}
+	clusters, err := kubernetesRepo.GetClusters()
+	if err != nil {
+		return nil, fmt.Errorf("error listing kubernetes clusters: %w", err)
+	}
+	for _, cluster := range clusters {
+		nodes, err := kubernetesRepo.GetNodes(cluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error listing nodes of kubernetes cluster %s: %w", cluster.Name, err)
+		}
+		for _, node := range nodes {
+			resources = append(resources, Resource{Kind: ResourceKubernetesNode, Cluster: cluster.Name, Name: node.UUID})
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID() < resources[j].ID() })
+
+	return resources, nil
+}
+
+// FetchResource returns the usage data of the resource in the period, requested in windows like Fetch.
+// The usage data of a block or big storage only contains disk samples.
+func FetchResource(client repository.Client, resource Resource, period vps.UsagePeriod, window time.Duration) (vps.Usage, error) {
+	var get func(window vps.UsagePeriod) (vps.Usage, error)
+	switch resource.Kind {
+	case ResourceVps:
+		return Fetch(client, resource.Name, period, window)
+	case ResourceBlockStorage:
+		blockStorageRepo := vps.BlockStorageRepository{Client: client}
+		get = func(window vps.UsagePeriod) (vps.Usage, error) {
+			disk, err := blockStorageRepo.GetUsage(resource.Name, window)
+			return vps.Usage{Disk: disk}, err
+		}
+	case ResourceBigStorage:
+		bigStorageRepo := vps.BigStorageRepository{Client: client}
+		get = func(window vps.UsagePeriod) (vps.Usage, error) {
+			disk, err := bigStorageRepo.GetUsage(resource.Name, window)
+			return vps.Usage{Disk: disk}, err
+		}
+	case ResourceKubernetesNode:
+		kubernetesRepo := kubernetes.Repository{Client: client}
+		usageTypes := []vps.UsageType{vps.UsageTypeCPU, vps.UsageTypeDisk, vps.UsageTypeNetwork}
+		get = func(window vps.UsagePeriod) (vps.Usage, error) {
+			return kubernetesRepo.GetNodeStatistics(resource.Cluster, resource.Name, usageTypes, window)
+		}
+	default:
+		return vps.Usage{}, fmt.Errorf("unknown resource kind '%s'", resource.Kind)
+	}
+
+	return fetchWindows(resource.ID(), period, window, get)
+}
@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resourceServer serves a vps, block storage, big storage and kubernetes node with usage samples
+func resourceServer() *usageServer {
+	return &usageServer{
+		lists: map[string]string{
+			"/vps":                             `{"vpss":[{"name":"web-1"}]}`,
+			"/block-storages":                  `{"blockStorages":[{"name":"block-1"}]}`,
+			"/big-storages":                    `{"bigStorages":[{"name":"big-1"}]}`,
+			"/kubernetes/clusters":             `{"clusters":[{"name":"k888k"}]}`,
+			"/kubernetes/clusters/k888k/nodes": `{"nodes":[{"uuid":"node-1","clusterName":"k888k"}]}`,
+		},
+		usage: map[string]func(int64) vps.Usage{
+			"/vps/web-1":                              constantUsage(10, 4, 100),
+			"/block-storages/block-1":                 constantUsage(0, 8, 0),
+			"/big-storages/big-1":                     constantUsage(0, 2, 0),
+			"/kubernetes/clusters/k888k/nodes/node-1": constantUsage(50, 6, 300),
+		},
+	}
+}
+
+func TestResource_ID(t *testing.T) {
+	assert.Equal(t, "vps/web-1", Resource{Kind: ResourceVps, Name: "web-1"}.ID())
+	assert.Equal(t, "kubernetes_node/k888k/node-1", Resource{Kind: ResourceKubernetesNode, Cluster: "k888k", Name: "node-1"}.ID())
+}
+
+func TestDiscover(t *testing.T) {
+	client, tearDown := resourceServer().getClient(t)
+	defer tearDown()
+
+	resources, err := Discover(client)
+	require.NoError(t, err)
+	assert.Equal(t, []Resource{
+		{Kind: ResourceBigStorage, Name: "big-1"},
+		{Kind: ResourceBlockStorage, Name: "block-1"},
+		{Kind: ResourceKubernetesNode, Cluster: "k888k", Name: "node-1"},
+		{Kind: ResourceVps, Name: "web-1"},
+	}, resources)
+}
+
+func TestFetchResource(t *testing.T) {
+	server := resourceServer()
+	client, tearDown := server.getClient(t)
+	defer tearDown()
+	period := vps.UsagePeriod{TimeStart: 0, TimeEnd: 3600}
+
+	usage, err := FetchResource(client, Resource{Kind: ResourceBlockStorage, Name: "block-1"}, period, 0)
+	require.NoError(t, err)
+	assert.Equal(t, vps.Usage{Disk: []vps.UsageDataDisk{{Date: 0, IopsRead: 8, IopsWrite: 4}, {Date: 3600, IopsRead: 8, IopsWrite: 4}}}, usage)
+
+	usage, err = FetchResource(client, Resource{Kind: ResourceBigStorage, Name: "big-1"}, period, 0)
+	require.NoError(t, err)
+	assert.Len(t, usage.Disk, 2)
+	assert.Empty(t, usage.CPU)
+
+	usage, err = FetchResource(client, Resource{Kind: ResourceKubernetesNode, Cluster: "k888k", Name: "node-1"}, period, 0)
+	require.NoError(t, err)
+	assert.Len(t, usage.CPU, 2)
+	assert.EqualValues(t, 50, usage.CPU[0].Percentage)
+
+	usage, err = FetchResource(client, Resource{Kind: ResourceVps, Name: "web-1"}, period, 0)
+	require.NoError(t, err)
+	assert.Len(t, usage.Network, 2)
+
+	assert.Equal(t, []string{
+		"/block-storages/block-1 0-3600",
+		"/big-storages/big-1 0-3600",
+		"/kubernetes/clusters/k888k/nodes/node-1 0-3600",
+		"/vps/web-1 0-3600",
+	}, server.requests)
+
+	_, err = FetchResource(client, Resource{Kind: ResourceBlockStorage, Name: "unknown"}, period, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error getting usage of blockstorage/unknown from 0 to 3600: ")
+
+	_, err = FetchResource(client, Resource{Kind: "domain", Name: "example.com"}, period, 0)
+	assert.EqualError(t, err, "unknown resource kind 'domain'")
+}
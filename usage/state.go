@@ -0,0 +1,61 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/assi010/gotransip/v6/internal/atomicfile"
+)
+
+// State remembers the last exported timestamp per resource, so an incremental export only fetches new samples
+type State struct {
+	// LastExported maps the Resource.ID to the unix timestamp of the newest exported sample
+	LastExported map[string]int64 `json:"lastExported"`
+}
+
+// Last returns the last exported timestamp of the resource, and false when nothing was exported for it
+func (s *State) Last(resource Resource) (int64, bool) {
+	last, ok := s.LastExported[resource.ID()]
+	return last, ok
+}
+
+// SetLast records the last exported timestamp of the resource
+func (s *State) SetLast(resource Resource, timestamp int64) {
+	if s.LastExported == nil {
+		s.LastExported = make(map[string]int64)
+	}
+	s.LastExported[resource.ID()] = timestamp
+}
+
+// LoadState reads the state from a json file, it returns an empty state when the file does not exist
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{LastExported: map[string]int64{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading export state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing export state %s: %w", path, err)
+	}
+	if state.LastExported == nil {
+		state.LastExported = map[string]int64{}
+	}
+
+	return &state, nil
+}
+
+// Save writes the state to a json file, see atomicfile.WriteFile
+func (s *State) Save(path string) error {
+	if err := atomicfile.WriteJSON(path, s, 0600); err != nil {
+		return fmt.Errorf("error saving export state: %w", err)
+	}
+
+	return nil
+}
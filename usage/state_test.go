@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage-state.json")
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	_, exported := state.Last(Resource{Kind: ResourceVps, Name: "web-1"})
+	assert.False(t, exported)
+
+	state.SetLast(Resource{Kind: ResourceVps, Name: "web-1"}, 1574783109)
+	state.SetLast(Resource{Kind: ResourceKubernetesNode, Cluster: "k888k", Name: "node-1"}, 1574786709)
+	require.NoError(t, state.Save(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "lastExported": {
+    "kubernetes_node/k888k/node-1": 1574786709,
+    "vps/web-1": 1574783109
+  }
+}
+`, string(data))
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	last, exported := loaded.Last(Resource{Kind: ResourceVps, Name: "web-1"})
+	assert.True(t, exported)
+	assert.EqualValues(t, 1574783109, last)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file is renamed")
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage-state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := LoadState(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error parsing export state "+path)
+
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	state.SetLast(Resource{Kind: ResourceVps, Name: "web-1"}, 1)
+	assert.Len(t, state.LastExported, 1)
+}
+
+func TestState_SetLastOnZeroValue(t *testing.T) {
+	var state State
+	state.SetLast(Resource{Kind: ResourceVps, Name: "web-1"}, 1)
+	last, exported := state.Last(Resource{Kind: ResourceVps, Name: "web-1"})
+	assert.True(t, exported)
+	assert.EqualValues(t, 1, last)
+}
@@ -0,0 +1,122 @@
+package usage
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats summarizes the samples of a metric
+type Stats struct {
+	// Count is the number of samples
+	Count int `json:"count"`
+	// Mean is the average value
+	Mean float64 `json:"mean"`
+	// StdDev is the population standard deviation
+	StdDev float64 `json:"stdDev"`
+	// Min is the lowest value
+	Min float64 `json:"min"`
+	// P95 is the 95th percentile
+	P95 float64 `json:"p95"`
+	// P99 is the 99th percentile
+	P99 float64 `json:"p99"`
+	// Peak is the sample with the highest value, the first one when several samples have the highest value
+	Peak Sample `json:"peak"`
+}
+
+// Summarize returns the stats of the samples, all values are zero when there are no samples
+func Summarize(samples []Sample) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+
+	stats := Stats{Count: len(samples), Min: samples[0].Value, Peak: samples[0]}
+	values := make([]float64, len(samples))
+	var sum float64
+	for idx, sample := range samples {
+		values[idx] = sample.Value
+		sum += sample.Value
+		if sample.Value < stats.Min {
+			stats.Min = sample.Value
+		}
+		if sample.Value > stats.Peak.Value {
+			stats.Peak = sample
+		}
+	}
+	stats.Mean = sum / float64(len(samples))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - stats.Mean) * (value - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(squares / float64(len(samples)))
+
+	sort.Float64s(values)
+	stats.P95 = Percentile(values, 95)
+	stats.P99 = Percentile(values, 99)
+
+	return stats
+}
+
+// Percentile returns the p-th percentile, between 0 and 100, of the sorted values.
+// It interpolates linearly between the two closest values and returns zero when there are no values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// Anomaly is a sample that is far above the other samples of the metric
+type Anomaly struct {
+	// Metric of the sample
+	Metric Metric `json:"metric"`
+	// Sample that is a spike
+	Sample Sample `json:"sample"`
+	// ZScore is the number of standard deviations the sample is above the mean
+	ZScore float64 `json:"zScore"`
+}
+
+// Spikes returns the samples with a z-score of at least threshold, in the order of the samples.
+// There are no spikes when there are less than minSamples samples or all samples have the same value.
+func Spikes(metric Metric, samples []Sample, threshold float64, minSamples int) []Anomaly {
+	stats := Summarize(samples)
+	if stats.Count < minSamples || stats.StdDev == 0 {
+		return nil
+	}
+
+	var anomalies []Anomaly
+	for _, sample := range samples {
+		zScore := (sample.Value - stats.Mean) / stats.StdDev
+		if zScore >= threshold {
+			anomalies = append(anomalies, Anomaly{Metric: metric, Sample: sample, ZScore: zScore})
+		}
+	}
+
+	return anomalies
+}
+
+// IdleSince returns the time of the first sample after the last sample with a value of at least threshold.
+// It returns false when there are no samples or the last sample is not below the threshold.
+func IdleSince(samples []Sample, threshold float64) (time.Time, bool) {
+	idx := len(samples)
+	for idx > 0 && samples[idx-1].Value < threshold {
+		idx--
+	}
+	if idx == len(samples) {
+		return time.Time{}, false
+	}
+
+	return samples[idx].Time, true
+}
@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// samples returns hourly samples with the values, starting at the unix epoch
+func samples(values ...float64) []Sample {
+	result := make([]Sample, len(values))
+	for idx, value := range values {
+		result[idx] = Sample{Time: time.Unix(int64(idx)*3600, 0), Value: value}
+	}
+	return result
+}
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize(samples(2, 4, 4, 4, 5, 5, 7, 9))
+	assert.Equal(t, 8, stats.Count)
+	assert.Equal(t, 5.0, stats.Mean)
+	assert.Equal(t, 2.0, stats.StdDev)
+	assert.Equal(t, 2.0, stats.Min)
+	assert.InDelta(t, 8.3, stats.P95, 0.0001)
+	assert.InDelta(t, 8.86, stats.P99, 0.0001)
+	assert.Equal(t, Sample{Time: time.Unix(7*3600, 0), Value: 9}, stats.Peak)
+
+	// the first sample with the highest value is the peak
+	stats = Summarize(samples(1, 3, 3))
+	assert.Equal(t, time.Unix(3600, 0), stats.Peak.Time)
+
+	assert.Equal(t, Stats{}, Summarize(nil))
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{10, 20, 30, 40, 50}
+	assert.Equal(t, 10.0, Percentile(sorted, 0))
+	assert.Equal(t, 30.0, Percentile(sorted, 50))
+	assert.Equal(t, 35.0, Percentile(sorted, 62.5))
+	assert.Equal(t, 50.0, Percentile(sorted, 100))
+	assert.Equal(t, 50.0, Percentile(sorted, 120))
+	assert.Equal(t, 10.0, Percentile(sorted, -5))
+	assert.Equal(t, 7.0, Percentile([]float64{7}, 95))
+	assert.Equal(t, 0.0, Percentile(nil, 95))
+}
+
+func TestSpikes(t *testing.T) {
+	values := samples(10, 11, 9, 10, 10, 11, 9, 10, 10, 11, 9, 200, 10)
+	anomalies := Spikes(MetricNetworkMbitIn, values, 3, 10)
+	if assert.Len(t, anomalies, 1) {
+		assert.Equal(t, MetricNetworkMbitIn, anomalies[0].Metric)
+		assert.Equal(t, values[11], anomalies[0].Sample)
+		assert.InDelta(t, 3.46, anomalies[0].ZScore, 0.01)
+	}
+
+	assert.Empty(t, Spikes(MetricNetworkMbitIn, values, 4, 10), "the spike is below the threshold")
+	assert.Empty(t, Spikes(MetricNetworkMbitIn, values, 3, 20), "there are not enough samples")
+	assert.Empty(t, Spikes(MetricNetworkMbitIn, samples(5, 5, 5, 5), 3, 1), "constant values have no spikes")
+}
+
+func TestIdleSince(t *testing.T) {
+	since, idle := IdleSince(samples(50, 1, 30, 1.5, 0.5, 1), 2)
+	assert.True(t, idle)
+	assert.Equal(t, time.Unix(3*3600, 0), since)
+
+	since, idle = IdleSince(samples(1, 1, 1), 2)
+	assert.True(t, idle)
+	assert.Equal(t, time.Unix(0, 0), since)
+
+	_, idle = IdleSince(samples(1, 1, 2), 2)
+	assert.False(t, idle)
+	_, idle = IdleSince(nil, 2)
+	assert.False(t, idle)
+}
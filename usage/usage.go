@@ -0,0 +1,180 @@
+// Package usage analyses the cpu, disk and network usage of vpses.
+//
+// GetUsage only returns raw samples, this package fetches them for periods of any length by splitting the period
+// in windows the api accepts, and summarizes every metric with its mean, percentiles and peak.
+// It also flags idle vpses and spikes in the network traffic and disk iops:
+//
+//	analyzer := usage.Analyzer{}
+//	period := vps.UsagePeriod{TimeStart: time.Now().Add(-30 * 24 * time.Hour).Unix(), TimeEnd: time.Now().Unix()}
+//	report, err := analyzer.AnalyzeFleet(client, period)
+//	if err != nil {
+//		panic(err)
+//	}
+//	fmt.Print(report)
+//
+// The samples of vpses, block storages, big storages and kubernetes nodes can be exported as CSV,
+// newline-delimited JSON or OpenMetrics text. An Exporter with a State only exports the samples
+// after the last exported timestamp of every resource, so a nightly job only fetches new samples:
+//
+//	state, err := usage.LoadState("usage-state.json")
+//	if err != nil {
+//		panic(err)
+//	}
+//	resources, err := usage.Discover(client)
+//	if err != nil {
+//		panic(err)
+//	}
+//	exporter := usage.Exporter{State: state}
+//	points, err := exporter.Collect(client, resources, vps.UsagePeriod{TimeStart: time.Now().Add(-30 * 24 * time.Hour).Unix(), TimeEnd: time.Now().Unix()})
+//	if err != nil {
+//		log.Print(err)
+//	}
+//	if err := usage.Write(os.Stdout, usage.FormatOpenMetrics, points); err != nil {
+//		panic(err)
+//	}
+//	if err := state.Save("usage-state.json"); err != nil {
+//		panic(err)
+//	}
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// DefaultWindow is the longest period requested with one GetUsage call when Fetch is called without a window
+const DefaultWindow = 7 * 24 * time.Hour
+
+// ErrInvalidPeriod is returned when a usage period ends before it starts
+var ErrInvalidPeriod = errors.New("invalid usage period")
+
+// Metric is one of the values in the usage data of a vps
+type Metric string
+
+const (
+	// MetricCPU is the cpu usage percentage
+	MetricCPU Metric = "cpu_percentage"
+	// MetricDiskIopsRead is the number of disk read operations per second
+	MetricDiskIopsRead Metric = "disk_iops_read"
+	// MetricDiskIopsWrite is the number of disk write operations per second
+	MetricDiskIopsWrite Metric = "disk_iops_write"
+	// MetricNetworkMbitIn is the inbound network traffic in Mbps
+	MetricNetworkMbitIn Metric = "network_mbit_in"
+	// MetricNetworkMbitOut is the outbound network traffic in Mbps
+	MetricNetworkMbitOut Metric = "network_mbit_out"
+)
+
+// Metrics are all metrics in the order they are reported
+var Metrics = []Metric{MetricCPU, MetricDiskIopsRead, MetricDiskIopsWrite, MetricNetworkMbitIn, MetricNetworkMbitOut}
+
+// Sample is the value of a metric at a point in time
+type Sample struct {
+	// Time of the sample
+	Time time.Time `json:"time"`
+	// Value of the sample
+	Value float64 `json:"value"`
+}
+
+// Series returns the samples of every metric in the usage data, ordered by time
+func Series(usage vps.Usage) map[Metric][]Sample {
+	series := make(map[Metric][]Sample, len(Metrics))
+	for _, entry := range usage.CPU {
+		at := time.Unix(entry.Date, 0)
+		series[MetricCPU] = append(series[MetricCPU], Sample{Time: at, Value: float64(entry.Percentage)})
+	}
+	for _, entry := range usage.Disk {
+		at := time.Unix(entry.Date, 0)
+		series[MetricDiskIopsRead] = append(series[MetricDiskIopsRead], Sample{Time: at, Value: float64(entry.IopsRead)})
+		series[MetricDiskIopsWrite] = append(series[MetricDiskIopsWrite], Sample{Time: at, Value: float64(entry.IopsWrite)})
+	}
+	for _, entry := range usage.Network {
+		at := time.Unix(int64(entry.Date), 0)
+		series[MetricNetworkMbitIn] = append(series[MetricNetworkMbitIn], Sample{Time: at, Value: float64(entry.MbitIn)})
+		series[MetricNetworkMbitOut] = append(series[MetricNetworkMbitOut], Sample{Time: at, Value: float64(entry.MbitOut)})
+	}
+
+	for _, samples := range series {
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	}
+
+	return series
+}
+
+// Windows splits the period in consecutive periods of at most window long, the last one ends at period.TimeEnd
+func Windows(period vps.UsagePeriod, window time.Duration) ([]vps.UsagePeriod, error) {
+	if period.TimeEnd < period.TimeStart {
+		return nil, fmt.Errorf("%w: end %d is before start %d", ErrInvalidPeriod, period.TimeEnd, period.TimeStart)
+	}
+	if window < time.Second {
+		return nil, fmt.Errorf("%w: window %s is shorter than a second", ErrInvalidPeriod, window)
+	}
+
+	step := int64(window / time.Second)
+	var windows []vps.UsagePeriod
+	for start := period.TimeStart; ; start += step {
+		end := start + step
+		if end >= period.TimeEnd {
+			windows = append(windows, vps.UsagePeriod{TimeStart: start, TimeEnd: period.TimeEnd})
+			return windows, nil
+		}
+		windows = append(windows, vps.UsagePeriod{TimeStart: start, TimeEnd: end})
+	}
+}
+
+// Fetch returns all usage data of the vps in the period. The period is requested in windows of at most window long,
+// or DefaultWindow when window is zero, and samples on the boundary of two windows are only returned once.
+func Fetch(client repository.Client, vpsName string, period vps.UsagePeriod, window time.Duration) (vps.Usage, error) {
+	vpsRepo := vps.Repository{Client: client}
+
+	return fetchWindows("vps "+vpsName, period, window, func(window vps.UsagePeriod) (vps.Usage, error) {
+		return vpsRepo.GetAllUsage(vpsName, window)
+	})
+}
+
+// fetchWindows calls get for every window of the period and merges the usage data, without duplicate samples.
+// The description of the resource is used in the error when get fails.
+func fetchWindows(description string, period vps.UsagePeriod, window time.Duration, get func(window vps.UsagePeriod) (vps.Usage, error)) (vps.Usage, error) {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	windows, err := Windows(period, window)
+	if err != nil {
+		return vps.Usage{}, err
+	}
+
+	var result vps.Usage
+	seenCPU := make(map[int64]bool)
+	seenDisk := make(map[int64]bool)
+	seenNetwork := make(map[float32]bool)
+	for _, window := range windows {
+		usage, err := get(window)
+		if err != nil {
+			return vps.Usage{}, fmt.Errorf("error getting usage of %s from %d to %d: %w", description, window.TimeStart, window.TimeEnd, err)
+		}
+		for _, entry := range usage.CPU {
+			if !seenCPU[entry.Date] {
+				seenCPU[entry.Date] = true
+				result.CPU = append(result.CPU, entry)
+			}
+		}
+		for _, entry := range usage.Disk {
+			if !seenDisk[entry.Date] {
+				seenDisk[entry.Date] = true
+				result.Disk = append(result.Disk, entry)
+			}
+		}
+		for _, entry := range usage.Network {
+			if !seenNetwork[entry.Date] {
+				seenNetwork[entry.Date] = true
+				result.Network = append(result.Network, entry)
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package usage
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageServer serves usage samples on every hour, including the start and end of the requested period,
+// generated by a function per resource path like /vps/web-1, and the responses of the list endpoints
+type usageServer struct {
+	lists    map[string]string
+	usage    map[string]func(at int64) vps.Usage
+	requests []string
+}
+
+func (s *usageServer) getClient(t *testing.T) (repository.Client, func()) {
+	server := testutil.RoutedMockServer{T: t, Responses: s.lists, Error: "Vps not found"}
+	server.Fallback = func(rw http.ResponseWriter, req *http.Request) bool {
+		resource := strings.TrimSuffix(strings.TrimSuffix(req.URL.Path, "/usage"), "/stats")
+		generate, ok := s.usage[resource]
+		if !ok {
+			return false
+		}
+
+		query := req.URL.Query()
+		if strings.HasPrefix(resource, "/vps/") || strings.HasPrefix(resource, "/kubernetes/") {
+			assert.Equal(t, "cpu,disk,network", query.Get("types"))
+		}
+		start, err := strconv.ParseInt(query.Get("dateTimeStart"), 10, 64)
+		require.NoError(t, err)
+		end, err := strconv.ParseInt(query.Get("dateTimeEnd"), 10, 64)
+		require.NoError(t, err)
+		s.requests = append(s.requests, resource+" "+query.Get("dateTimeStart")+"-"+query.Get("dateTimeEnd"))
+
+		var usage vps.Usage
+		for at := (start + 3599) / 3600 * 3600; at <= end; at += 3600 {
+			sample := generate(at)
+			usage.CPU = append(usage.CPU, sample.CPU...)
+			usage.Disk = append(usage.Disk, sample.Disk...)
+			usage.Network = append(usage.Network, sample.Network...)
+		}
+		if strings.HasPrefix(resource, "/vps/") || strings.HasPrefix(resource, "/kubernetes/") {
+			require.NoError(t, json.NewEncoder(rw).Encode(map[string]vps.Usage{"usage": usage}))
+		} else {
+			require.NoError(t, json.NewEncoder(rw).Encode(map[string][]vps.UsageDataDisk{"usage": usage.Disk}))
+		}
+
+		return true
+	}
+
+	return server.GetClient()
+}
+
+// constantUsage returns a generator of samples with the same values at every time
+func constantUsage(cpu float32, iops float32, mbit float32) func(at int64) vps.Usage {
+	return func(at int64) vps.Usage {
+		return vps.Usage{
+			CPU:     []vps.UsageDataCPU{{Date: at, Percentage: cpu}},
+			Disk:    []vps.UsageDataDisk{{Date: at, IopsRead: iops, IopsWrite: iops / 2}},
+			Network: []vps.UsageDataNetwork{{Date: float32(at), MbitIn: mbit, MbitOut: mbit / 2}},
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	series := Series(vps.Usage{
+		CPU:     []vps.UsageDataCPU{{Date: 7200, Percentage: 12.5}, {Date: 3600, Percentage: 2.5}},
+		Disk:    []vps.UsageDataDisk{{Date: 3600, IopsRead: 4, IopsWrite: 2}},
+		Network: []vps.UsageDataNetwork{{Date: 3600, MbitIn: 100, MbitOut: 50}},
+	})
+
+	assert.Equal(t, map[Metric][]Sample{
+		MetricCPU:            {{Time: time.Unix(3600, 0), Value: 2.5}, {Time: time.Unix(7200, 0), Value: 12.5}},
+		MetricDiskIopsRead:   {{Time: time.Unix(3600, 0), Value: 4}},
+		MetricDiskIopsWrite:  {{Time: time.Unix(3600, 0), Value: 2}},
+		MetricNetworkMbitIn:  {{Time: time.Unix(3600, 0), Value: 100}},
+		MetricNetworkMbitOut: {{Time: time.Unix(3600, 0), Value: 50}},
+	}, series)
+	assert.Empty(t, Series(vps.Usage{}))
+}
+
+func TestWindows(t *testing.T) {
+	windows, err := Windows(vps.UsagePeriod{TimeStart: 0, TimeEnd: 250}, 100*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []vps.UsagePeriod{{TimeStart: 0, TimeEnd: 100}, {TimeStart: 100, TimeEnd: 200}, {TimeStart: 200, TimeEnd: 250}}, windows)
+
+	windows, err = Windows(vps.UsagePeriod{TimeStart: 0, TimeEnd: 200}, 100*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []vps.UsagePeriod{{TimeStart: 0, TimeEnd: 100}, {TimeStart: 100, TimeEnd: 200}}, windows)
+
+	windows, err = Windows(vps.UsagePeriod{TimeStart: 50, TimeEnd: 50}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []vps.UsagePeriod{{TimeStart: 50, TimeEnd: 50}}, windows)
+
+	_, err = Windows(vps.UsagePeriod{TimeStart: 100, TimeEnd: 50}, time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+	assert.EqualError(t, err, "invalid usage period: end 50 is before start 100")
+
+	_, err = Windows(vps.UsagePeriod{TimeStart: 0, TimeEnd: 50}, time.Millisecond)
+	assert.EqualError(t, err, "invalid usage period: window 1ms is shorter than a second")
+}
+
+func TestFetch(t *testing.T) {
+	server := usageServer{usage: map[string]func(int64) vps.Usage{"/vps/web-1": constantUsage(10, 4, 100)}}
+	client, tearDown := server.getClient(t)
+	defer tearDown()
+
+	usage, err := Fetch(client, "web-1", vps.UsagePeriod{TimeStart: 0, TimeEnd: 10 * 3600}, 4*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/vps/web-1 0-14400", "/vps/web-1 14400-28800", "/vps/web-1 28800-36000"}, server.requests)
+
+	// the samples on the boundaries of the windows are returned twice by the api
+	require.Len(t, usage.CPU, 11)
+	require.Len(t, usage.Disk, 11)
+	require.Len(t, usage.Network, 11)
+	for idx, entry := range usage.CPU {
+		assert.EqualValues(t, idx*3600, entry.Date)
+	}
+
+	_, err = Fetch(client, "unknown", vps.UsagePeriod{TimeStart: 0, TimeEnd: 3600}, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error getting usage of vps unknown from 0 to 3600: ")
+	assert.Contains(t, err.Error(), "Vps not found")
+}
@@ -0,0 +1,189 @@
+// Package vncbridge exposes the VNC console of a vps as a plain RFB port on localhost.
+//
+// GetVNCData returns the location of the websocket proxy of the TransIP web console. A Bridge listens on a local
+// tcp port and tunnels every VNC client that connects to it through the websocket, so any VNC client can be used
+// when ssh is broken. The client asks for the password in VncData.Password:
+//
+//	bridge := vncbridge.Bridge{
+//		Client:          client,
+//		VpsName:         "example-vps",
+//		RegenerateToken: true,
+//		OnReady: func(address net.Addr, data vps.VncData) {
+//			fmt.Printf("connect your vnc client to %s, the password is %s\n", address, data.Password)
+//		},
+//	}
+//	if err := bridge.ListenAndServe(ctx, "127.0.0.1:5900"); err != nil {
+//		panic(err)
+//	}
+package vncbridge
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+)
+
+// DefaultListenAddress is the address the vncbridge command listens on by default, the default VNC port on localhost
+const DefaultListenAddress = "127.0.0.1:5900"
+
+// ErrNoVncData is returned when the api returns no websocket location for the vps
+var ErrNoVncData = errors.New("no vnc data")
+
+// Bridge tunnels local VNC clients to the console of a vps, one session at a time
+type Bridge struct {
+	// Client is used to get the vnc data and regenerate the token
+	Client repository.Client
+	// VpsName is the name of the vps of which the console is exposed
+	VpsName string
+	// RegenerateToken calls RegenerateVNCToken after every session, and when serving stops while waiting for a client,
+	// so the token and password shown by OnReady can not be used again
+	RegenerateToken bool
+	// Once stops serving after the first session
+	Once bool
+	// TLSConfig is used to connect to the websocket proxy, the system roots are used when nil
+	TLSConfig *tls.Config
+	// Header contains extra headers for the websocket handshake, like an Origin
+	Header http.Header
+	// OnReady is called with the listen address and the vnc data before waiting for a client,
+	// it can show the password the VNC client has to use
+	OnReady func(address net.Addr, data vps.VncData)
+	// OnSessionEnd is called when a session ends, with the error that ended it or nil when a side disconnected
+	OnSessionEnd func(err error)
+}
+
+// ListenAndServe listens on the tcp address and serves sessions until the context is done
+func (b *Bridge) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	return b.Serve(ctx, listener)
+}
+
+// Serve accepts VNC clients on the listener, one at a time, and tunnels each of them to the console.
+// The vnc data is requested again before every session. It returns nil when the context is done
+// or after the first session when Once is set.
+func (b *Bridge) Serve(ctx context.Context, listener net.Listener) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			listener.Close()
+		case <-stop:
+		}
+	}()
+
+	vpsRepo := vps.Repository{Client: b.Client}
+	for {
+		data, err := vpsRepo.GetVNCData(b.VpsName)
+		if err != nil {
+			return fmt.Errorf("error getting vnc data of vps %s: %w", b.VpsName, err)
+		}
+		if b.OnReady != nil {
+			b.OnReady(listener.Addr(), data)
+		}
+
+		conn, err := listener.Accept()
+		if err != nil {
+			// OnReady could have shown the password already, so it is invalidated even though no client connected
+			regenerateErr := b.regenerateToken(vpsRepo)
+			if ctx.Err() != nil {
+				return regenerateErr
+			}
+			return errors.Join(err, regenerateErr)
+		}
+
+		sessionErr := b.session(ctx, conn, data)
+		if b.OnSessionEnd != nil {
+			b.OnSessionEnd(sessionErr)
+		}
+
+		if err := b.regenerateToken(vpsRepo); err != nil {
+			return err
+		}
+		if b.Once || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// regenerateToken regenerates the vnc token when RegenerateToken is set
+func (b *Bridge) regenerateToken(vpsRepo vps.Repository) error {
+	if !b.RegenerateToken {
+		return nil
+	}
+	if err := vpsRepo.RegenerateVNCToken(b.VpsName); err != nil {
+		return fmt.Errorf("error regenerating vnc token of vps %s: %w", b.VpsName, err)
+	}
+
+	return nil
+}
+
+// session tunnels the connection of a VNC client through the websocket until one side disconnects
+func (b *Bridge) session(ctx context.Context, conn net.Conn, data vps.VncData) error {
+	defer conn.Close()
+
+	websocketURL, err := WebsocketURL(data)
+	if err != nil {
+		return err
+	}
+	websocket, err := dialWebsocket(ctx, websocketURL, b.Header, b.TLSConfig)
+	if err != nil {
+		return fmt.Errorf("error connecting to the vnc proxy: %w", err)
+	}
+
+	// closing the websocket sends a normal closure to the proxy
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-sessionCtx.Done()
+		conn.Close()
+		websocket.Close()
+	}()
+
+	// the client side is copied in a goroutine, when it disconnects first the error of the proxy side is expected
+	clientDone := make(chan struct{})
+	go func() {
+		defer close(clientDone)
+		_, _ = io.Copy(websocket, conn)
+		cancel()
+	}()
+	_, upstreamErr := io.Copy(conn, websocket)
+	clientDisconnected := sessionCtx.Err() != nil
+	cancel()
+	<-clientDone
+
+	if upstreamErr != nil && !clientDisconnected {
+		return fmt.Errorf("error reading from the vnc proxy: %w", upstreamErr)
+	}
+
+	return nil
+}
+
+// WebsocketURL returns the websocket url of the vnc data, the URL with a ws or wss scheme
+// or else a wss url of the Host and Path
+func WebsocketURL(data vps.VncData) (string, error) {
+	switch {
+	case strings.HasPrefix(data.URL, "https://"):
+		return "wss://" + strings.TrimPrefix(data.URL, "https://"), nil
+	case strings.HasPrefix(data.URL, "http://"):
+		return "ws://" + strings.TrimPrefix(data.URL, "http://"), nil
+	case strings.HasPrefix(data.URL, "wss://"), strings.HasPrefix(data.URL, "ws://"):
+		return data.URL, nil
+	case data.URL == "" && data.Host != "":
+		return "wss://" + data.Host + "/" + strings.TrimPrefix(data.Path, "/"), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported websocket url '%s'", ErrNoVncData, data.URL)
+	}
+}
@@ -0,0 +1,207 @@
+package vncbridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/repository"
+	"github.com/assi010/gotransip/v6/vps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// apiServer serves the vnc data of example-vps pointing to the websocket url and records the token regenerations
+type apiServer struct {
+	websocketURL string
+	lock         sync.Mutex
+	regenerated  int
+}
+
+func (s *apiServer) getClient(t *testing.T) (repository.Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path != "/vps/example-vps/vnc-data":
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error":"Vps not found"}`))
+		case req.Method == http.MethodGet:
+			_, _ = fmt.Fprintf(rw, `{"vncData":{"host":"vncproxy.transip.nl","path":"websockify?token=testtokentje",`+
+				`"url":"%s","token":"testtokentje","password":"esisteinpassw0rd"}}`, s.websocketURL)
+		case req.Method == http.MethodPatch:
+			s.lock.Lock()
+			s.regenerated++
+			s.lock.Unlock()
+			rw.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	config := gotransip.DemoClientConfiguration
+	config.URL = server.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(t, err)
+
+	return client, server.Close
+}
+
+// serveBridge starts serving the bridge on a local port and returns the address once it is ready,
+// the error of Serve is sent on the returned channel
+func serveBridge(t *testing.T, ctx context.Context, bridge *Bridge) (chan string, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ready := make(chan string, 10)
+	bridge.OnReady = func(address net.Addr, data vps.VncData) {
+		assert.Equal(t, "esisteinpassw0rd", data.Password)
+		ready <- address.String()
+	}
+	result := make(chan error, 1)
+	go func() {
+		defer listener.Close()
+		result <- bridge.Serve(ctx, listener)
+	}()
+
+	return ready, result
+}
+
+func TestBridge_Session(t *testing.T) {
+	proxy := websocketServer(t, func(server *websocket.Conn) {
+		// the start of the RFB handshake, the rest of the session is echoed
+		require.NoError(t, websocket.Message.Send(server, []byte("RFB 003.008\n")))
+		payload, err := receive(server)
+		require.NoError(t, err)
+		assert.Equal(t, "RFB 003.008\n", string(payload))
+
+		_, err = receive(server)
+		assert.Equal(t, io.EOF, err, "the bridge closes the websocket when the vnc client disconnects")
+	})
+	defer proxy.Close()
+
+	api := apiServer{websocketURL: proxy.URL + "/websockify?token=testtokentje"}
+	client, tearDown := api.getClient(t)
+	defer tearDown()
+
+	sessionErrors := make(chan error, 1)
+	bridge := Bridge{
+		Client:          client,
+		VpsName:         "example-vps",
+		RegenerateToken: true,
+		Once:            true,
+		TLSConfig:       clientTLSConfig(proxy),
+		OnSessionEnd:    func(err error) { sessionErrors <- err },
+	}
+	ready, result := serveBridge(t, context.Background(), &bridge)
+
+	conn, err := net.Dial("tcp", <-ready)
+	require.NoError(t, err)
+	version := make([]byte, 12)
+	_, err = io.ReadFull(conn, version)
+	require.NoError(t, err)
+	assert.Equal(t, "RFB 003.008\n", string(version))
+	_, err = conn.Write(version)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.NoError(t, <-result)
+	assert.NoError(t, <-sessionErrors)
+	assert.Equal(t, 1, api.regenerated)
+}
+
+func TestBridge_ProxyDisconnects(t *testing.T) {
+	sessions := 0
+	proxy := websocketServer(t, func(server *websocket.Conn) {
+		sessions++
+		require.NoError(t, websocket.Message.Send(server, []byte(fmt.Sprintf("session %d", sessions))))
+		require.NoError(t, server.Close())
+	})
+	defer proxy.Close()
+
+	api := apiServer{websocketURL: proxy.URL + "/websockify?token=testtokentje"}
+	client, tearDown := api.getClient(t)
+	defer tearDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bridge := Bridge{Client: client, VpsName: "example-vps", TLSConfig: clientTLSConfig(proxy)}
+	ready, result := serveBridge(t, ctx, &bridge)
+
+	// every vnc client gets a new session until the context is cancelled
+	for session := 1; session <= 2; session++ {
+		conn, err := net.Dial("tcp", <-ready)
+		require.NoError(t, err)
+		received, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("session %d", session), string(received))
+		conn.Close()
+	}
+
+	<-ready
+	cancel()
+	require.NoError(t, <-result)
+	assert.Equal(t, 0, api.regenerated)
+}
+
+func TestBridge_CancelledBeforeSession(t *testing.T) {
+	api := apiServer{websocketURL: "wss://vncproxy.transip.nl/websockify?token=testtokentje"}
+	client, tearDown := api.getClient(t)
+	defer tearDown()
+
+	// the password was shown by OnReady, so it is regenerated even though no client connected
+	ctx, cancel := context.WithCancel(context.Background())
+	bridge := Bridge{Client: client, VpsName: "example-vps", RegenerateToken: true}
+	ready, result := serveBridge(t, ctx, &bridge)
+	<-ready
+	cancel()
+	require.NoError(t, <-result)
+	assert.Equal(t, 1, api.regenerated)
+}
+
+func TestBridge_Errors(t *testing.T) {
+	api := apiServer{websocketURL: "wss://127.0.0.1:1/websockify?token=testtokentje"}
+	client, tearDown := api.getClient(t)
+	defer tearDown()
+
+	sessionErrors := make(chan error, 1)
+	bridge := Bridge{Client: client, VpsName: "example-vps", Once: true, OnSessionEnd: func(err error) { sessionErrors <- err }}
+	ready, result := serveBridge(t, context.Background(), &bridge)
+	conn, err := net.Dial("tcp", <-ready)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, <-result)
+	err = <-sessionErrors
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error connecting to the vnc proxy: ")
+
+	bridge = Bridge{Client: client, VpsName: "unknown-vps"}
+	_, result = serveBridge(t, context.Background(), &bridge)
+	err = <-result
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error getting vnc data of vps unknown-vps: ")
+}
+
+func TestWebsocketURL(t *testing.T) {
+	tests := []struct {
+		data     vps.VncData
+		expected string
+	}{
+		{vps.VncData{URL: "https://vncproxy.transip.nl/websockify?token=testtokentje"}, "wss://vncproxy.transip.nl/websockify?token=testtokentje"},
+		{vps.VncData{URL: "http://localhost:6080/websockify"}, "ws://localhost:6080/websockify"},
+		{vps.VncData{URL: "wss://vncproxy.transip.nl/websockify"}, "wss://vncproxy.transip.nl/websockify"},
+		{vps.VncData{Host: "vncproxy.transip.nl", Path: "websockify?token=testtokentje"}, "wss://vncproxy.transip.nl/websockify?token=testtokentje"},
+	}
+	for _, tt := range tests {
+		websocketURL, err := WebsocketURL(tt.data)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, websocketURL)
+	}
+
+	_, err := WebsocketURL(vps.VncData{})
+	assert.ErrorIs(t, err, ErrNoVncData)
+	_, err = WebsocketURL(vps.VncData{URL: "ftp://vncproxy.transip.nl"})
+	assert.EqualError(t, err, "no vnc data: unsupported websocket url 'ftp://vncproxy.transip.nl'")
+}
@@ -0,0 +1,94 @@
+package vncbridge
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrHandshake is returned when the websocket server does not accept the connection
+var ErrHandshake = errors.New("websocket handshake failed")
+
+// dialWebsocket connects to the ws or wss url and performs the opening handshake with the binary subprotocol.
+// The returned connection sends binary messages and reads the payload of the messages as a stream of bytes,
+// which is how websockify tunnels a tcp connection.
+func dialWebsocket(ctx context.Context, rawURL string, header http.Header, tlsConfig *tls.Config) (*websocket.Conn, error) {
+	location, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket url '%s': %w", rawURL, err)
+	}
+
+	port := location.Port()
+	origin := "https://" + location.Host
+	switch location.Scheme {
+	case "ws":
+		if port == "" {
+			port = "80"
+		}
+		origin = "http://" + location.Host
+	case "wss":
+		if port == "" {
+			port = "443"
+		}
+	default:
+		return nil, fmt.Errorf("invalid websocket url '%s': unsupported scheme '%s'", rawURL, location.Scheme)
+	}
+	if header.Get("Origin") != "" {
+		origin = header.Get("Origin")
+	}
+
+	config, err := websocket.NewConfig(rawURL, origin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket url '%s': %w", rawURL, err)
+	}
+	config.Protocol = []string{"binary"}
+	for name, values := range header {
+		config.Header[name] = values
+	}
+
+	// the connection is set up here instead of by websocket.DialConfig, which does not take a context
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(location.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	if location.Scheme == "wss" {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = location.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		var protocolError *websocket.ProtocolError
+		if errors.As(err, &protocolError) {
+			return nil, fmt.Errorf("%w: %s", ErrHandshake, err)
+		}
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	ws.PayloadType = websocket.BinaryFrame
+
+	return ws, nil
+}
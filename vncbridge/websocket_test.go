@@ -0,0 +1,136 @@
+package vncbridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// websocketServer is a stand-in for the vnc proxy, it accepts websocket connections and passes them to handle
+func websocketServer(t *testing.T, handle func(server *websocket.Conn)) *httptest.Server {
+	return httptest.NewTLSServer(websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			assert.Equal(t, []string{"binary"}, config.Protocol)
+			assert.Equal(t, "token=testtokentje", req.URL.RawQuery)
+			assert.Equal(t, "https://"+req.Host, req.Header.Get("Origin"))
+			return nil
+		},
+		Handler: handle,
+	})
+}
+
+// clientTLSConfig trusts the certificate of the test server
+func clientTLSConfig(server *httptest.Server) *tls.Config {
+	return server.Client().Transport.(*http.Transport).TLSClientConfig
+}
+
+// websocketURL returns the wss url of the test server
+func websocketURL(server *httptest.Server) string {
+	return strings.Replace(server.URL, "https://", "wss://", 1) + "/websockify?token=testtokentje"
+}
+
+// binaryMessage receives the payload of a binary message and rejects text messages
+var binaryMessage = websocket.Codec{Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+	if payloadType != websocket.BinaryFrame {
+		return fmt.Errorf("unexpected payload type %d", payloadType)
+	}
+	*v.(*[]byte) = data
+
+	return nil
+}}
+
+// receive reads the next message as the server, it returns io.EOF when the client closed the websocket
+func receive(server *websocket.Conn) ([]byte, error) {
+	var payload []byte
+	err := binaryMessage.Receive(server, &payload)
+
+	return payload, err
+}
+
+func TestWebsocket_Echo(t *testing.T) {
+	server := websocketServer(t, func(server *websocket.Conn) {
+		for {
+			payload, err := receive(server)
+			if err != nil {
+				assert.Equal(t, io.EOF, err)
+				return
+			}
+			require.NoError(t, websocket.Message.Send(server, payload))
+		}
+	})
+	defer server.Close()
+
+	client, err := dialWebsocket(context.Background(), websocketURL(server), nil, clientTLSConfig(server))
+	require.NoError(t, err)
+
+	for _, size := range []int{5, 300, 70000} {
+		message := bytes.Repeat([]byte{byte(size)}, size)
+		_, err = client.Write(message)
+		require.NoError(t, err)
+
+		received := make([]byte, size)
+		_, err = io.ReadFull(client, received)
+		require.NoError(t, err)
+		assert.Equal(t, message, received)
+	}
+
+	require.NoError(t, client.Close())
+}
+
+func TestWebsocket_Origin(t *testing.T) {
+	server := httptest.NewServer(websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			assert.Equal(t, "https://console.example.com", req.Header.Get("Origin"))
+			assert.Equal(t, "gotransip", req.Header.Get("X-Client"))
+			return nil
+		},
+		Handler: func(server *websocket.Conn) {
+			require.NoError(t, websocket.Message.Send(server, []byte("RFB 003.008\n")))
+		},
+	})
+	defer server.Close()
+
+	header := http.Header{"Origin": {"https://console.example.com"}, "X-Client": {"gotransip"}}
+	client, err := dialWebsocket(context.Background(), strings.Replace(server.URL, "http://", "ws://", 1), header, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	received, err := io.ReadAll(client)
+	require.NoError(t, err)
+	assert.Equal(t, "RFB 003.008\n", string(received))
+}
+
+func TestDialWebsocket_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/forbidden" {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		rw.Header().Set("Upgrade", "websocket")
+		rw.Header().Set("Connection", "Upgrade")
+		rw.Header().Set("Sec-WebSocket-Accept", "invalid")
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	defer server.Close()
+	baseURL := strings.Replace(server.URL, "http://", "ws://", 1)
+
+	_, err := dialWebsocket(context.Background(), baseURL+"/forbidden", nil, nil)
+	assert.ErrorIs(t, err, ErrHandshake)
+	assert.EqualError(t, err, "websocket handshake failed: bad status")
+
+	_, err = dialWebsocket(context.Background(), baseURL+"/websockify", nil, nil)
+	assert.ErrorIs(t, err, ErrHandshake)
+
+	_, err = dialWebsocket(context.Background(), "ftp://vncproxy.transip.nl/", nil, nil)
+	assert.EqualError(t, err, "invalid websocket url 'ftp://vncproxy.transip.nl/': unsupported scheme 'ftp'")
+}
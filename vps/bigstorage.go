@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 )
@@ -134,6 +135,24 @@ func (r *BigStorageRepository) OrderWithResponse(order BigStorageOrder) (rest.Re
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// OrderWithOperation allows you to order a new bigstorage and returns an operation,
+// which finds the new big storage by the description of the order and can cancel it
+// Deprecated: Use block storage resource instead
+func (r *BigStorageRepository) OrderWithOperation(order BigStorageOrder) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing big storages before the order: %w", err)
+	}
+	response, err := r.OrderWithResponse(order)
+	hooks := action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before, r.GetAll,
+			func(bigStorage BigStorage) (string, string) { return bigStorage.Name, bigStorage.Description }, order.Description),
+		Cancel: r.Cancel,
+	}
+
+	return action.NewOperation(r.Client, response, hooks), err
+}
+
 // Upgrade allows you to upgrade a BigStorage's size or/and to enable off-site backups
 // Deprecated: Use block storage resource instead
 func (r *BigStorageRepository) Upgrade(bigStorageName string, size int, offsiteBackups bool) error {
@@ -167,6 +186,14 @@ func (r *BigStorageRepository) UpdateWithResponse(bigStorage BigStorage) (rest.R
 	return r.Client.PutWithResponse(restRequest)
 }
 
+// UpdateWithOperation allows you to update a bigstorage and returns an operation
+// Deprecated: Use block storage resource instead
+func (r *BigStorageRepository) UpdateWithOperation(bigStorage BigStorage) (*action.Operation, error) {
+	response, err := r.UpdateWithResponse(bigStorage)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // DetachFromVps allows you to detach a bigstorage from the vps it is attached to
 // Deprecated: Use block storage resource instead
 func (r *BigStorageRepository) DetachFromVps(bigStorage BigStorage) error {
@@ -227,6 +254,14 @@ func (r *BigStorageRepository) RevertBackupWithResponse(bigStorageName string, b
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertBackupWithOperation allows you to revert a bigstorage by bigstorage name and backupID and returns an operation
+// Deprecated: Use block storage resource instead
+func (r *BigStorageRepository) RevertBackupWithOperation(bigStorageName string, backupID int64) (*action.Operation, error) {
+	response, err := r.RevertBackupWithResponse(bigStorageName, backupID)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // RevertBackupToOtherBigStorage allows you to revert a backup to a different big storage
 // Deprecated: Use block storage resource instead
 func (r *BigStorageRepository) RevertBackupToOtherBigStorage(bigStorageName string, backupID int64, destinationBigStorageName string) error {
@@ -249,6 +284,14 @@ func (r *BigStorageRepository) RevertBackupToOtherBigStorageWithResponse(
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertBackupToOtherBigStorageWithOperation allows you to revert a backup to a different big storage and returns an operation
+// Deprecated: Use block storage resource instead
+func (r *BigStorageRepository) RevertBackupToOtherBigStorageWithOperation(bigStorageName string, backupID int64, destinationBigStorageName string) (*action.Operation, error) {
+	response, err := r.RevertBackupToOtherBigStorageWithResponse(bigStorageName, backupID, destinationBigStorageName)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // GetUsage allows you to query your bigstorage usage within a certain period
 // Deprecated: Use block storage resource instead
 func (r *BigStorageRepository) GetUsage(bigStorageName string, period UsagePeriod) ([]UsageDataDisk, error) {
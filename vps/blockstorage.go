@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 )
@@ -147,6 +148,23 @@ func (r *BlockStorageRepository) OrderWithResponse(order BlockStorageOrder) (res
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// OrderWithOperation allows you to order a new blockstorage and returns an operation,
+// which finds the new block storage by the description of the order and can cancel it
+func (r *BlockStorageRepository) OrderWithOperation(order BlockStorageOrder) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing block storages before the order: %w", err)
+	}
+	response, err := r.OrderWithResponse(order)
+	hooks := action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before, r.GetAll,
+			func(blockStorage BlockStorage) (string, string) { return blockStorage.Name, blockStorage.Description }, order.Description),
+		Cancel: r.Cancel,
+	}
+
+	return action.NewOperation(r.Client, response, hooks), err
+}
+
 // Upgrade allows you to upgrade a BlockStorage's size or/and to enable off-site backups
 func (r *BlockStorageRepository) Upgrade(blockStorageName string, size int, offsiteBackups bool) error {
 	requestBody := blockStorageUpgradeRequest{BlockStorageName: blockStorageName, Size: size, OffsiteBackups: offsiteBackups}
@@ -176,6 +194,13 @@ func (r *BlockStorageRepository) UpdateWithResponse(blockStorage BlockStorage) (
 	return r.Client.PutWithResponse(restRequest)
 }
 
+// UpdateWithOperation allows you to update a blockstorage and returns an operation
+func (r *BlockStorageRepository) UpdateWithOperation(blockStorage BlockStorage) (*action.Operation, error) {
+	response, err := r.UpdateWithResponse(blockStorage)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // DetachFromVps allows you to detach a blockstorage from the vps it is attached to
 func (r *BlockStorageRepository) DetachFromVps(blockStorage BlockStorage) error {
 	blockStorage.VpsName = ""
@@ -229,6 +254,13 @@ func (r *BlockStorageRepository) RevertBackupWithResponse(blockStorageName strin
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertBackupWithOperation allows you to revert a blockstorage by blockstorage name and backupID and returns an operation
+func (r *BlockStorageRepository) RevertBackupWithOperation(blockStorageName string, backupID int64) (*action.Operation, error) {
+	response, err := r.RevertBackupWithResponse(blockStorageName, backupID)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // RevertBackupToOtherBlockStorage allows you to revert a backup to a different block storage
 func (r *BlockStorageRepository) RevertBackupToOtherBlockStorage(blockStorageName string, backupID int64, destinationBlockStorageName string) error {
 	requestBody := blockStorageRestoreBackupsWrapper{Action: "revert", DestinationBlockStorageName: destinationBlockStorageName}
@@ -249,6 +281,13 @@ func (r *BlockStorageRepository) RevertBackupToOtherBlockStorageWithResponse(
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertBackupToOtherBlockStorageWithOperation allows you to revert a backup to a different block storage and returns an operation
+func (r *BlockStorageRepository) RevertBackupToOtherBlockStorageWithOperation(blockStorageName string, backupID int64, destinationBlockStorageName string) (*action.Operation, error) {
+	response, err := r.RevertBackupToOtherBlockStorageWithResponse(blockStorageName, backupID, destinationBlockStorageName)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // GetUsage allows you to query your blockstorage usage within a certain period
 func (r *BlockStorageRepository) GetUsage(blockStorageName string, period UsagePeriod) ([]UsageDataDisk, error) {
 	var response usageDataDiskWrapper
@@ -0,0 +1,451 @@
+package vps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/assi010/gotransip/v6/ipaddress"
+)
+
+// Protocols a FirewallRule can use
+const (
+	// FirewallProtocolTCP allows tcp traffic
+	FirewallProtocolTCP = "tcp"
+	// FirewallProtocolUDP allows udp traffic
+	FirewallProtocolUDP = "udp"
+	// FirewallProtocolTCPUDP allows both tcp and udp traffic
+	FirewallProtocolTCPUDP = "tcp_udp"
+)
+
+// firewallModifyAttempts is the number of times ModifyFirewall reads and modifies the firewall
+// before giving up on concurrent modifications
+const firewallModifyAttempts = 3
+
+var (
+	// ErrInvalidFirewall is wrapped by every FirewallValidationError
+	ErrInvalidFirewall = errors.New("invalid firewall")
+	// ErrFirewallRuleExists is returned by AddRule when a rule for the same protocol and ports already exists
+	ErrFirewallRuleExists = errors.New("firewall rule already exists")
+	// ErrFirewallRuleNotFound is returned by RemoveRule when there is no rule for the protocol and ports
+	ErrFirewallRuleNotFound = errors.New("firewall rule not found")
+	// ErrFirewallConflict is wrapped by every FirewallConflictError
+	ErrFirewallConflict = errors.New("firewall was modified concurrently")
+)
+
+// FirewallValidationError is returned when a firewall contains invalid rules, it lists all problems
+type FirewallValidationError struct {
+	// Problems describes every invalid rule, or pair of overlapping rules
+	Problems []string
+}
+
+func (e *FirewallValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidFirewall, strings.Join(e.Problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidFirewall) work for a FirewallValidationError
+func (e *FirewallValidationError) Is(target error) bool {
+	return target == ErrInvalidFirewall
+}
+
+// FirewallConflictError is returned by ModifyFirewall when the firewall kept changing between reading
+// and updating it, or when the firewall read back after the update differs from the firewall that was sent
+type FirewallConflictError struct {
+	// VpsName is the name of the vps of the firewall
+	VpsName string
+	// Diff contains the concurrent changes observed during the last attempt,
+	// or the changes from the sent firewall to the firewall that was read back
+	Diff FirewallDiff
+}
+
+func (e *FirewallConflictError) Error() string {
+	return fmt.Sprintf("%s: vps %s changed while applying the update:\n%s", ErrFirewallConflict, e.VpsName, e.Diff)
+}
+
+// Is makes errors.Is(err, ErrFirewallConflict) work for a FirewallConflictError
+func (e *FirewallConflictError) Is(target error) bool {
+	return target == ErrFirewallConflict
+}
+
+// FirewallRuleChange is a rule of which the description or whitelist changed
+type FirewallRuleChange struct {
+	// Old is the current rule
+	Old FirewallRule
+	// New is the desired rule
+	New FirewallRule
+}
+
+// FirewallDiff contains the differences between a current and a desired firewall.
+// Rules are matched on their protocol and port range.
+type FirewallDiff struct {
+	// EnabledChanged is true when the firewall is enabled or disabled
+	EnabledChanged bool
+	// IsEnabled is the desired state of the firewall
+	IsEnabled bool
+	// Added contains the rules that are only in the desired firewall
+	Added []FirewallRule
+	// Removed contains the rules that are only in the current firewall
+	Removed []FirewallRule
+	// Changed contains the rules with a different description or whitelist
+	Changed []FirewallRuleChange
+}
+
+// IsEmpty returns true when the current and desired firewall are the same
+func (d FirewallDiff) IsEmpty() bool {
+	return !d.EnabledChanged && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String returns the diff with one line per change, prefixed with + for added, - for removed and ~ for changed rules
+func (d FirewallDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes"
+	}
+
+	var lines []string
+	if d.EnabledChanged {
+		if d.IsEnabled {
+			lines = append(lines, "firewall: disabled -> enabled")
+		} else {
+			lines = append(lines, "firewall: enabled -> disabled")
+		}
+	}
+	for _, rule := range d.Removed {
+		lines = append(lines, "- "+rule.String())
+	}
+	for _, rule := range d.Added {
+		lines = append(lines, "+ "+rule.String())
+	}
+	for _, change := range d.Changed {
+		lines = append(lines, "~ "+describeRuleChange(change))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// touches returns true when the rule is added or changed by the diff
+func (d FirewallDiff) touches(rule FirewallRule) bool {
+	for _, added := range d.Added {
+		if sameRule(added, rule) {
+			return true
+		}
+	}
+	for _, change := range d.Changed {
+		if sameRule(change.New, rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DiffFirewall computes the changes needed to turn the current firewall into the desired firewall
+func DiffFirewall(current Firewall, desired Firewall) FirewallDiff {
+	diff := FirewallDiff{EnabledChanged: current.IsEnabled != desired.IsEnabled, IsEnabled: desired.IsEnabled}
+
+	for _, rule := range desired.RuleSet {
+		idx := current.ruleIndex(rule)
+		switch {
+		case idx < 0:
+			diff.Added = append(diff.Added, rule)
+		case !rulesEqual(current.RuleSet[idx], rule):
+			diff.Changed = append(diff.Changed, FirewallRuleChange{Old: current.RuleSet[idx], New: rule})
+		}
+	}
+	for _, rule := range current.RuleSet {
+		if desired.ruleIndex(rule) < 0 {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+
+	return diff
+}
+
+// String describes the rule, like "tcp 80 (HTTP) from any" or "udp 1000-2000 from 10.0.0.0/8"
+func (r FirewallRule) String() string {
+	ports := fmt.Sprintf("%d", r.StartPort)
+	if r.EndPort != r.StartPort {
+		ports = fmt.Sprintf("%d-%d", r.StartPort, r.EndPort)
+	}
+	description := ""
+	if r.Description != "" {
+		description = fmt.Sprintf(" (%s)", r.Description)
+	}
+
+	return fmt.Sprintf("%s %s%s from %s", r.Protocol, ports, description, describeWhitelist(r.Whitelist))
+}
+
+// AddRule adds a rule to the firewall,
+// it returns ErrFirewallRuleExists when there already is a rule for the same protocol and ports
+func (f *Firewall) AddRule(rule FirewallRule) error {
+	if idx := f.ruleIndex(rule); idx >= 0 {
+		return fmt.Errorf("%w: %s", ErrFirewallRuleExists, f.RuleSet[idx])
+	}
+	f.RuleSet = append(f.RuleSet, rule)
+
+	return nil
+}
+
+// RemoveRule removes the rule with the same protocol and ports as the given rule,
+// it returns ErrFirewallRuleNotFound when there is no such rule
+func (f *Firewall) RemoveRule(rule FirewallRule) error {
+	idx := f.ruleIndex(rule)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrFirewallRuleNotFound, rule)
+	}
+	f.RuleSet = append(f.RuleSet[:idx:idx], f.RuleSet[idx+1:]...)
+
+	return nil
+}
+
+// UpsertRule replaces the rule with the same protocol and ports as the given rule, or adds it when there is none
+func (f *Firewall) UpsertRule(rule FirewallRule) {
+	if idx := f.ruleIndex(rule); idx >= 0 {
+		f.RuleSet[idx] = rule
+		return
+	}
+	f.RuleSet = append(f.RuleSet, rule)
+}
+
+// Validate checks the port ranges, protocols and whitelists of all rules,
+// and that no two rules allow the same port for the same protocol.
+// It returns a FirewallValidationError listing all problems.
+func (f Firewall) Validate() error {
+	return f.validateRules(func(FirewallRule) bool { return true })
+}
+
+// validateRules validates the rules for which check returns true,
+// and reports the overlaps in which at least one of those rules is involved
+func (f Firewall) validateRules(check func(rule FirewallRule) bool) error {
+	var problems []string
+	valid := make([]bool, len(f.RuleSet))
+	for idx, rule := range f.RuleSet {
+		ruleProblems := validateRule(rule)
+		valid[idx] = len(ruleProblems) == 0
+		if check(rule) {
+			problems = append(problems, ruleProblems...)
+		}
+	}
+	// only valid rules are checked for overlaps, an invalid port range would report bogus overlaps
+	for i := 0; i < len(f.RuleSet); i++ {
+		for j := i + 1; j < len(f.RuleSet); j++ {
+			a, b := f.RuleSet[i], f.RuleSet[j]
+			if valid[i] && valid[j] && (check(a) || check(b)) && rulesOverlap(a, b) {
+				problems = append(problems, fmt.Sprintf("rule '%s' overlaps with rule '%s'", a, b))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &FirewallValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// ModifyFirewall applies modify to the current firewall of a vps and updates the firewall when it changed.
+// The added and changed rules are validated before the firewall is sent, existing rules are left as they are,
+// so a firewall that already has overlapping rules can still be modified.
+//
+// Right before the update the firewall is read again, when another tool changed it in the meantime
+// modify is applied again to the new state. After three attempts a FirewallConflictError is returned.
+// The api has no conditional update, so a change in the short time between that read and the update is still
+// overwritten. The firewall is therefore also read back after the update,
+// a FirewallConflictError is returned when it does not match the firewall that was sent.
+// The returned diff contains the applied changes.
+func (r *FirewallRepository) ModifyFirewall(vpsName string, modify func(firewall *Firewall) error) (FirewallDiff, error) {
+	var conflict FirewallDiff
+	for attempt := 0; attempt < firewallModifyAttempts; attempt++ {
+		current, err := r.GetFirewall(vpsName)
+		if err != nil {
+			return FirewallDiff{}, err
+		}
+
+		desired := current.clone()
+		if err := modify(&desired); err != nil {
+			return FirewallDiff{}, err
+		}
+		diff := DiffFirewall(current, desired)
+		if diff.IsEmpty() {
+			return diff, nil
+		}
+		if err := desired.validateRules(diff.touches); err != nil {
+			return FirewallDiff{}, err
+		}
+
+		latest, err := r.GetFirewall(vpsName)
+		if err != nil {
+			return FirewallDiff{}, err
+		}
+		if conflict = DiffFirewall(current, latest); !conflict.IsEmpty() {
+			continue
+		}
+
+		if err := r.UpdateFirewall(vpsName, desired); err != nil {
+			return FirewallDiff{}, err
+		}
+
+		updated, err := r.GetFirewall(vpsName)
+		if err != nil {
+			return diff, fmt.Errorf("firewall was updated, but reading it back failed: %w", err)
+		}
+		if conflict := DiffFirewall(desired, updated); !conflict.IsEmpty() {
+			return diff, &FirewallConflictError{VpsName: vpsName, Diff: conflict}
+		}
+
+		return diff, nil
+	}
+
+	return FirewallDiff{}, &FirewallConflictError{VpsName: vpsName, Diff: conflict}
+}
+
+// ApplyFirewall makes the firewall of a vps match the desired firewall,
+// see ModifyFirewall for the validation and the check after the update
+func (r *FirewallRepository) ApplyFirewall(vpsName string, desired Firewall) (FirewallDiff, error) {
+	return r.ModifyFirewall(vpsName, func(firewall *Firewall) error {
+		*firewall = desired.clone()
+		return nil
+	})
+}
+
+// ruleIndex returns the index of the rule with the same protocol and ports, or -1 when there is none
+func (f Firewall) ruleIndex(rule FirewallRule) int {
+	for idx, existing := range f.RuleSet {
+		if sameRule(existing, rule) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// sameRule returns true when two rules are for the same protocol and ports
+func sameRule(a FirewallRule, b FirewallRule) bool {
+	return a.Protocol == b.Protocol && a.StartPort == b.StartPort && a.EndPort == b.EndPort
+}
+
+// clone returns a copy of the firewall that does not share its rule set and whitelists
+func (f Firewall) clone() Firewall {
+	clone := Firewall{IsEnabled: f.IsEnabled, RuleSet: make([]FirewallRule, len(f.RuleSet))}
+	for idx, rule := range f.RuleSet {
+		if rule.Whitelist != nil {
+			rule.Whitelist = append(make([]ipaddress.IPRange, 0, len(rule.Whitelist)), rule.Whitelist...)
+		}
+		clone.RuleSet[idx] = rule
+	}
+
+	return clone
+}
+
+// validateRule returns the problems of a single rule
+func validateRule(rule FirewallRule) []string {
+	var problems []string
+	switch rule.Protocol {
+	case FirewallProtocolTCP, FirewallProtocolUDP, FirewallProtocolTCPUDP:
+	default:
+		problems = append(problems, fmt.Sprintf("rule '%s' has invalid protocol '%s', expected tcp, udp or tcp_udp", rule, rule.Protocol))
+	}
+	if rule.StartPort < 1 || rule.StartPort > 65535 || rule.EndPort < 1 || rule.EndPort > 65535 {
+		problems = append(problems, fmt.Sprintf("rule '%s' has a port outside of 1-65535", rule))
+	} else if rule.StartPort > rule.EndPort {
+		problems = append(problems, fmt.Sprintf("rule '%s' has a start port after its end port", rule))
+	}
+	for _, ipRange := range rule.Whitelist {
+		if problem := validateIPRange(ipRange); problem != "" {
+			problems = append(problems, fmt.Sprintf("rule '%s' has invalid whitelist entry: %s", rule, problem))
+		}
+	}
+
+	return problems
+}
+
+// validateIPRange returns a description of the problem with an ip range, or an empty string when it is valid
+func validateIPRange(ipRange ipaddress.IPRange) string {
+	ones, bits := ipRange.Mask.Size()
+	if bits == 0 {
+		return fmt.Sprintf("%s has no valid netmask", ipRange.IP)
+	}
+	ip := ipRange.IP.To4()
+	if ip == nil {
+		ip = ipRange.IP.To16()
+	}
+	if ip == nil || len(ip)*8 != bits {
+		return fmt.Sprintf("%s/%d is not a valid ip address with netmask", ipRange.IP, ones)
+	}
+	if network := ip.Mask(ipRange.Mask); !ip.Equal(network) {
+		return fmt.Sprintf("%s has host bits set, use %s", ipRange.String(), (&net.IPNet{IP: network, Mask: ipRange.Mask}).String())
+	}
+
+	return ""
+}
+
+// rulesOverlap returns true when two rules allow at least one port for the same protocol
+func rulesOverlap(a FirewallRule, b FirewallRule) bool {
+	protocolsOverlap := a.Protocol == b.Protocol || a.Protocol == FirewallProtocolTCPUDP || b.Protocol == FirewallProtocolTCPUDP
+
+	return protocolsOverlap && a.StartPort <= b.EndPort && b.StartPort <= a.EndPort
+}
+
+// rulesEqual returns true when two rules have the same description and whitelist, regardless of its order
+func rulesEqual(a FirewallRule, b FirewallRule) bool {
+	return a.Description == b.Description && reflect.DeepEqual(whitelistStrings(a.Whitelist), whitelistStrings(b.Whitelist))
+}
+
+// whitelistStrings returns the sorted string representations of a whitelist
+func whitelistStrings(whitelist []ipaddress.IPRange) []string {
+	ranges := make([]string, 0, len(whitelist))
+	for _, ipRange := range whitelist {
+		ranges = append(ranges, ipRange.String())
+	}
+	sort.Strings(ranges)
+
+	return ranges
+}
+
+// describeWhitelist returns the whitelist as a comma separated list, or "any" for an empty whitelist
+func describeWhitelist(whitelist []ipaddress.IPRange) string {
+	if len(whitelist) == 0 {
+		return "any"
+	}
+
+	return strings.Join(whitelistStrings(whitelist), ", ")
+}
+
+// describeRuleChange describes the changed description and whitelist entries of a rule
+func describeRuleChange(change FirewallRuleChange) string {
+	var changes []string
+	if change.Old.Description != change.New.Description {
+		changes = append(changes, fmt.Sprintf("description '%s' -> '%s'", change.Old.Description, change.New.Description))
+	}
+
+	oldRanges := whitelistStrings(change.Old.Whitelist)
+	newRanges := whitelistStrings(change.New.Whitelist)
+	if len(oldRanges) == 0 || len(newRanges) == 0 {
+		// an empty whitelist allows any address, list both sides instead of every added or removed range
+		if len(oldRanges) != len(newRanges) {
+			changes = append(changes, fmt.Sprintf("whitelist %s -> %s", describeWhitelist(change.Old.Whitelist), describeWhitelist(change.New.Whitelist)))
+		}
+	} else {
+		for _, ipRange := range newRanges {
+			if !containsString(oldRanges, ipRange) {
+				changes = append(changes, "+"+ipRange)
+			}
+		}
+		for _, ipRange := range oldRanges {
+			if !containsString(newRanges, ipRange) {
+				changes = append(changes, "-"+ipRange)
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s: %s", change.New, strings.Join(changes, ", "))
+}
+
+// containsString returns true when the sorted slice contains the value
+func containsString(sorted []string, value string) bool {
+	idx := sort.SearchStrings(sorted, value)
+
+	return idx < len(sorted) && sorted[idx] == value
+}
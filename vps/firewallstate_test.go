@@ -0,0 +1,248 @@
+package vps
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseRanges returns the IPRanges for the given cidrs
+func parseRanges(t *testing.T, cidrs ...string) []ipaddress.IPRange {
+	ranges := make([]ipaddress.IPRange, len(cidrs))
+	for idx, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ranges[idx] = ipaddress.IPRange{IPNet: *ipNet}
+	}
+
+	return ranges
+}
+
+// firewallServer responds to every GET with the next firewall in the script,
+// after the script it returns the last PUT body or repeats the last firewall, and it records the body of every PUT
+type firewallServer struct {
+	firewalls []string
+	gets      int
+	puts      []string
+}
+
+func (s *firewallServer) getRepository(t *testing.T) (FirewallRepository, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/vps/example-vps/firewall", req.URL.Path)
+		switch req.Method {
+		case http.MethodGet:
+			response := s.firewalls[len(s.firewalls)-1]
+			switch {
+			case s.gets < len(s.firewalls):
+				response = s.firewalls[s.gets]
+			case len(s.puts) > 0:
+				response = s.puts[len(s.puts)-1]
+			}
+			s.gets++
+			_, err := rw.Write([]byte(response))
+			require.NoError(t, err)
+		case http.MethodPut:
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			s.puts = append(s.puts, string(body))
+			rw.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	config := gotransip.DemoClientConfiguration
+	config.URL = server.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(t, err)
+
+	return FirewallRepository{Client: client}, server.Close
+}
+
+func TestFirewall_AddRemoveUpsertRule(t *testing.T) {
+	firewall := Firewall{IsEnabled: true}
+	ssh := FirewallRule{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP}
+
+	require.NoError(t, firewall.AddRule(ssh))
+	err := firewall.AddRule(FirewallRule{StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP})
+	assert.ErrorIs(t, err, ErrFirewallRuleExists)
+	assert.EqualError(t, err, "firewall rule already exists: tcp 22 (SSH) from any")
+
+	ssh.Whitelist = parseRanges(t, "10.0.0.0/8")
+	firewall.UpsertRule(ssh)
+	firewall.UpsertRule(FirewallRule{Description: "DNS", StartPort: 53, EndPort: 53, Protocol: FirewallProtocolUDP})
+	require.Len(t, firewall.RuleSet, 2)
+	assert.Equal(t, "tcp 22 (SSH) from 10.0.0.0/8", firewall.RuleSet[0].String())
+
+	require.NoError(t, firewall.RemoveRule(ssh))
+	assert.ErrorIs(t, firewall.RemoveRule(ssh), ErrFirewallRuleNotFound)
+	require.Len(t, firewall.RuleSet, 1)
+	assert.Equal(t, "udp 53 (DNS) from any", firewall.RuleSet[0].String())
+}
+
+func TestFirewall_Validate(t *testing.T) {
+	hostBits := parseRanges(t, "192.168.1.0/24")
+	hostBits[0].IP = net.ParseIP("192.168.1.5").To4()
+
+	firewall := Firewall{RuleSet: []FirewallRule{
+		{Description: "web", StartPort: 80, EndPort: 443, Protocol: FirewallProtocolTCP},
+		{Description: "https", StartPort: 443, EndPort: 443, Protocol: FirewallProtocolTCPUDP},
+		{StartPort: 53, EndPort: 53, Protocol: FirewallProtocolUDP, Whitelist: hostBits},
+		{StartPort: 0, EndPort: 70000, Protocol: "icmp"},
+		{StartPort: 9000, EndPort: 8000, Protocol: FirewallProtocolTCP},
+	}}
+
+	err := firewall.Validate()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidFirewall)
+
+	var validationErr *FirewallValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"rule 'udp 53 from 192.168.1.5/24' has invalid whitelist entry: 192.168.1.5/24 has host bits set, use 192.168.1.0/24",
+		"rule 'icmp 0-70000 from any' has invalid protocol 'icmp', expected tcp, udp or tcp_udp",
+		"rule 'icmp 0-70000 from any' has a port outside of 1-65535",
+		"rule 'tcp 9000-8000 from any' has a start port after its end port",
+		"rule 'tcp 80-443 (web) from any' overlaps with rule 'tcp_udp 443 (https) from any'",
+	}, validationErr.Problems)
+
+	valid := Firewall{RuleSet: []FirewallRule{
+		{StartPort: 53, EndPort: 53, Protocol: FirewallProtocolTCP},
+		{StartPort: 53, EndPort: 53, Protocol: FirewallProtocolUDP, Whitelist: parseRanges(t, "2a01:7c8::/32")},
+	}}
+	assert.NoError(t, valid.Validate())
+}
+
+func TestDiffFirewall(t *testing.T) {
+	current := Firewall{IsEnabled: false, RuleSet: []FirewallRule{
+		{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP, Whitelist: parseRanges(t, "10.0.0.0/8", "192.168.0.0/16")},
+		{StartPort: 8080, EndPort: 8080, Protocol: FirewallProtocolTCP},
+		{Description: "HTTP", StartPort: 80, EndPort: 80, Protocol: FirewallProtocolTCP},
+	}}
+	desired := Firewall{IsEnabled: true, RuleSet: []FirewallRule{
+		{Description: "HTTP", StartPort: 80, EndPort: 80, Protocol: FirewallProtocolTCP},
+		{Description: "ssh", StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP, Whitelist: parseRanges(t, "192.168.0.0/16", "172.16.0.0/12")},
+		{Description: "HTTPS", StartPort: 443, EndPort: 443, Protocol: FirewallProtocolTCP},
+	}}
+
+	diff := DiffFirewall(current, desired)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, `firewall: disabled -> enabled
+- tcp 8080 from any
++ tcp 443 (HTTPS) from any
+~ tcp 22 (ssh) from 172.16.0.0/12, 192.168.0.0/16: description 'SSH' -> 'ssh', +172.16.0.0/12, -10.0.0.0/8`, diff.String())
+
+	assert.True(t, DiffFirewall(current, current).IsEmpty())
+	assert.Equal(t, "no changes", DiffFirewall(desired, desired).String())
+}
+
+func TestFirewallRepository_ModifyFirewall(t *testing.T) {
+	server := firewallServer{firewalls: []string{
+		`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"HTTP","startPort":80,"endPort":80,"protocol":"tcp","whitelist":[]}]}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	diff, err := repo.ModifyFirewall("example-vps", func(firewall *Firewall) error {
+		return firewall.AddRule(FirewallRule{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP, Whitelist: parseRanges(t, "10.0.0.0/8")})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "+ tcp 22 (SSH) from 10.0.0.0/8", diff.String())
+	assert.Equal(t, []string{
+		`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"HTTP","startPort":80,"endPort":80,"protocol":"tcp","whitelist":[]},{"description":"SSH","startPort":22,"endPort":22,"protocol":"tcp","whitelist":["10.0.0.0/8"]}]}}`,
+	}, server.puts)
+
+	// applying the current state again results in no update
+	diff, err = repo.ApplyFirewall("example-vps", Firewall{IsEnabled: true, RuleSet: []FirewallRule{
+		{Description: "SSH", StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP, Whitelist: parseRanges(t, "10.0.0.0/8")},
+		{Description: "HTTP", StartPort: 80, EndPort: 80, Protocol: FirewallProtocolTCP},
+	}})
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+	assert.Len(t, server.puts, 1)
+
+	_, err = repo.ModifyFirewall("example-vps", func(firewall *Firewall) error {
+		firewall.UpsertRule(FirewallRule{StartPort: 80, EndPort: 90, Protocol: FirewallProtocolTCPUDP})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidFirewall)
+	assert.Len(t, server.puts, 1)
+}
+
+func TestFirewallRepository_ModifyFirewallExistingOverlap(t *testing.T) {
+	// the existing rules overlap, which does not stop changes to other rules
+	server := firewallServer{firewalls: []string{
+		`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"web","startPort":80,"endPort":443,"protocol":"tcp","whitelist":[]},{"description":"HTTPS","startPort":443,"endPort":443,"protocol":"tcp","whitelist":[]},{"description":"DNS","startPort":53,"endPort":53,"protocol":"udp","whitelist":[]}]}}`,
+	}}
+	repo, tearDown := server.getRepository(t)
+	defer tearDown()
+
+	diff, err := repo.ModifyFirewall("example-vps", func(firewall *Firewall) error {
+		return firewall.RemoveRule(FirewallRule{StartPort: 53, EndPort: 53, Protocol: FirewallProtocolUDP})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "- udp 53 (DNS) from any", diff.String())
+
+	_, err = repo.ModifyFirewall("example-vps", func(firewall *Firewall) error {
+		return firewall.AddRule(FirewallRule{StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP})
+	})
+	require.NoError(t, err)
+	assert.Len(t, server.puts, 2)
+
+	// an added rule that overlaps is still rejected, only its own overlaps are reported
+	_, err = repo.ModifyFirewall("example-vps", func(firewall *Firewall) error {
+		return firewall.AddRule(FirewallRule{StartPort: 100, EndPort: 200, Protocol: FirewallProtocolTCPUDP})
+	})
+	assert.EqualError(t, err, "invalid firewall: rule 'tcp 80-443 (web) from any' overlaps with rule 'tcp_udp 100-200 from any'")
+	var validationError *FirewallValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Len(t, validationError.Problems, 1)
+	assert.Len(t, server.puts, 2)
+}
+
+func TestFirewallRepository_ModifyFirewallConflict(t *testing.T) {
+	const before = `{"vpsFirewall":{"isEnabled":true,"ruleSet":[]}}`
+	const after = `{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"DNS","startPort":53,"endPort":53,"protocol":"udp","whitelist":[]}]}}`
+	addSSH := func(firewall *Firewall) error {
+		firewall.UpsertRule(FirewallRule{StartPort: 22, EndPort: 22, Protocol: FirewallProtocolTCP})
+		return nil
+	}
+
+	// another tool changes the firewall before the update, the modification is applied again to its change
+	server := firewallServer{firewalls: []string{before, after}}
+	repo, tearDown := server.getRepository(t)
+	diff, err := repo.ModifyFirewall("example-vps", addSSH)
+	tearDown()
+	require.NoError(t, err)
+	assert.Equal(t, "+ tcp 22 from any", diff.String())
+	assert.Equal(t, 5, server.gets)
+	assert.Equal(t, []string{
+		`{"vpsFirewall":{"isEnabled":true,"ruleSet":[{"description":"DNS","startPort":53,"endPort":53,"protocol":"udp","whitelist":[]},{"startPort":22,"endPort":22,"protocol":"tcp","whitelist":null}]}}`,
+	}, server.puts)
+
+	// the firewall keeps changing, it is never updated
+	server = firewallServer{firewalls: []string{before, after, before, after, before, after}}
+	repo, tearDown = server.getRepository(t)
+	_, err = repo.ModifyFirewall("example-vps", addSSH)
+	tearDown()
+	assert.ErrorIs(t, err, ErrFirewallConflict)
+	assert.EqualError(t, err, "firewall was modified concurrently: vps example-vps changed while applying the update:\n+ udp 53 (DNS) from any")
+	assert.Equal(t, 6, server.gets)
+	assert.Empty(t, server.puts)
+
+	// another tool changes the firewall right after the update, which is read back
+	server = firewallServer{firewalls: []string{before, before, after}}
+	repo, tearDown = server.getRepository(t)
+	diff, err = repo.ModifyFirewall("example-vps", addSSH)
+	tearDown()
+	assert.ErrorIs(t, err, ErrFirewallConflict)
+	assert.EqualError(t, err, "firewall was modified concurrently: vps example-vps changed while applying the update:\n- tcp 22 from any\n+ udp 53 (DNS) from any")
+	assert.Equal(t, "+ tcp 22 from any", diff.String())
+	assert.Equal(t, 3, server.gets)
+	assert.Len(t, server.puts, 1)
+}
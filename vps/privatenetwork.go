@@ -5,6 +5,7 @@ import (
 	"net/url"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/repository"
 	"github.com/assi010/gotransip/v6/rest"
 )
@@ -76,6 +77,25 @@ func (r *PrivateNetworkRepository) OrderWithResponse(description string) (rest.R
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// OrderWithOperation allows you to order new private network with a given description and returns an operation,
+// which finds the new private network by its description and can cancel it
+func (r *PrivateNetworkRepository) OrderWithOperation(description string) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing private networks before the order: %w", err)
+	}
+	response, err := r.OrderWithResponse(description)
+	hooks := action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before, r.GetAll,
+			func(privateNetwork PrivateNetwork) (string, string) {
+				return privateNetwork.Name, privateNetwork.Description
+			}, description),
+		Cancel: r.Cancel,
+	}
+
+	return action.NewOperation(r.Client, response, hooks), err
+}
+
 // Update allows you to update the private network.
 // You can change the description by changing the Description field
 // on the PrivateNetwork struct Updating it using this function.
@@ -102,6 +122,13 @@ func (r *PrivateNetworkRepository) AttachVpsWithResponse(vpsName string, private
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// AttachVpsWithOperation allows you to attach a VPS to a PrivateNetwork and returns an operation
+func (r *PrivateNetworkRepository) AttachVpsWithOperation(vpsName string, privateNetworkName string) (*action.Operation, error) {
+	response, err := r.AttachVpsWithResponse(vpsName, privateNetworkName)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // DetachVps allows you to detach a VPS from a PrivateNetwork
 func (r *PrivateNetworkRepository) DetachVps(vpsName string, privateNetworkName string) error {
 	requestBody := privateNetworkActionwrapper{Action: "detachvps", VpsName: vpsName}
@@ -118,6 +145,13 @@ func (r *PrivateNetworkRepository) DetachVpsWithResponse(vpsName string, private
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// DetachVpsWithOperation allows you to detach a VPS from a PrivateNetwork and returns an operation
+func (r *PrivateNetworkRepository) DetachVpsWithOperation(vpsName string, privateNetworkName string) (*action.Operation, error) {
+	response, err := r.DetachVpsWithResponse(vpsName, privateNetworkName)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // Cancel allows you to cancel a private network
 func (r *PrivateNetworkRepository) Cancel(privateNetworkName string, endTime gotransip.CancellationTime) error {
 	requestBody := gotransip.CancellationRequest{EndTime: endTime}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/ipaddress"
 	"github.com/assi010/gotransip/v6/product"
 	"github.com/assi010/gotransip/v6/repository"
@@ -75,6 +76,18 @@ func (r *Repository) OrderWithResponse(vpsOrder Order) (rest.Response, error) {
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// OrderWithOperation allows you to order a new VPS and returns an operation,
+// which finds the new vps by the description of the order and can cancel it
+func (r *Repository) OrderWithOperation(vpsOrder Order) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing vpses before the order: %w", err)
+	}
+	response, err := r.OrderWithResponse(vpsOrder)
+
+	return action.NewOperation(r.Client, response, r.createdVpsHooks(before, vpsOrder.Description)), err
+}
+
 // OrderMultiple allows you to order multiple vpses at the same time
 func (r *Repository) OrderMultiple(orders []Order) error {
 	requestBody := vpssOrderWrapper{Orders: orders}
@@ -91,6 +104,23 @@ func (r *Repository) OrderMultipleWithResponse(orders []Order) (rest.Response, e
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// OrderMultipleWithOperation allows you to order multiple vpses at the same time and returns an operation,
+// which finds the new vpses by the descriptions of the orders and can cancel all of them.
+// The created resource of the operation is the names of the new vpses joined with ", ", in the order of the orders.
+func (r *Repository) OrderMultipleWithOperation(orders []Order) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing vpses before the order: %w", err)
+	}
+	descriptions := make([]string, 0, len(orders))
+	for _, order := range orders {
+		descriptions = append(descriptions, order.Description)
+	}
+	response, err := r.OrderMultipleWithResponse(orders)
+
+	return action.NewOperation(r.Client, response, r.createdVpsHooks(before, descriptions...)), err
+}
+
 // Clone allows you to clone an existing VPS
 // There are a few things to take into account when you want to clone an existing VPS to a new VPS:
 //
@@ -120,6 +150,18 @@ func (r *Repository) CloneWithResponse(vpsName string) (rest.Response, error) {
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// CloneWithOperation allows you to clone an existing VPS and returns an operation,
+// which finds the clone as the only new vps and can cancel it
+func (r *Repository) CloneWithOperation(vpsName string) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing vpses before the clone: %w", err)
+	}
+	response, err := r.CloneWithResponse(vpsName)
+
+	return action.NewOperation(r.Client, response, r.createdVpsHooks(before, "")), err
+}
+
 // CloneToAvailabilityZone allows you to clone a vps to a specific availability zone, identified by name
 func (r *Repository) CloneToAvailabilityZone(vpsName string, availabilityZone string) error {
 	requestBody := cloneRequest{VpsName: vpsName, AvailabilityZone: availabilityZone}
@@ -136,6 +178,36 @@ func (r *Repository) CloneToAvailabilityZoneWithResponse(vpsName string, availab
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// CloneToAvailabilityZoneWithOperation allows you to clone a vps to a specific availability zone, identified by name and returns an operation,
+// which finds the clone as the only new vps and can cancel it
+func (r *Repository) CloneToAvailabilityZoneWithOperation(vpsName string, availabilityZone string) (*action.Operation, error) {
+	before, err := r.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error listing vpses before the clone: %w", err)
+	}
+	response, err := r.CloneToAvailabilityZoneWithResponse(vpsName, availabilityZone)
+
+	return action.NewOperation(r.Client, response, r.createdVpsHooks(before, "")), err
+}
+
+// createdVpsHooks returns the hooks of an operation that creates vpses with the given descriptions,
+// before are the vpses listed before the request
+func (r *Repository) createdVpsHooks(before []Vps, descriptions ...string) action.OperationHooks {
+	return action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before, r.GetAll,
+			func(vps Vps) (string, string) { return vps.Name, vps.Description }, descriptions...),
+		Cancel: func(vpsNames string, endTime gotransip.CancellationTime) error {
+			for _, vpsName := range action.SplitResourceNames(vpsNames) {
+				if err := r.Cancel(vpsName, endTime); err != nil {
+					return fmt.Errorf("error canceling vps %s: %w", vpsName, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
 // Update allows you to lock/unlock a VPS, update a VPS description, and add/remove tags.
 //
 //	For locking the VPS, set isCustomerLocked to true. Set the value to false for unlocking the VPS
@@ -398,6 +470,18 @@ func (r *Repository) CreateSnapshotWithResponse(vpsName string, description stri
 	return r.Client.PostWithResponse(restRequest)
 }
 
+// CreateSnapshotWithOperation allows you to create a snapshot for restoring it at a later time or restoring it to another VPS and returns an operation,
+// which finds the new snapshot by its description. A snapshot can not be cancelled, use RemoveSnapshot instead.
+func (r *Repository) CreateSnapshotWithOperation(vpsName string, description string, shouldStartVps bool) (*action.Operation, error) {
+	before, err := r.GetSnapshots(vpsName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots before the snapshot: %w", err)
+	}
+	response, err := r.CreateSnapshotWithResponse(vpsName, description, shouldStartVps)
+
+	return action.NewOperation(r.Client, response, r.createdSnapshotHooks(vpsName, before, description)), err
+}
+
 // RevertSnapshot allows you to revert a snapshot of a vps,
 // if you want to revert a snapshot to a different vps you can use the RevertSnapshotToOtherVps method
 func (r *Repository) RevertSnapshot(vpsName string, snapshotName string) error {
@@ -413,6 +497,13 @@ func (r *Repository) RevertSnapshotWithResponse(vpsName string, snapshotName str
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertSnapshotWithOperation allows you to revert a snapshot of a vps and returns an operation
+func (r *Repository) RevertSnapshotWithOperation(vpsName string, snapshotName string) (*action.Operation, error) {
+	response, err := r.RevertSnapshotWithResponse(vpsName, snapshotName)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // RevertSnapshotToOtherVps allows you to revert a snapshot to a different vps
 func (r *Repository) RevertSnapshotToOtherVps(vpsName string, snapshotName string, destinationVps string) error {
 	requestBody := revertSnapshotRequest{DestinationVpsName: destinationVps}
@@ -429,6 +520,13 @@ func (r *Repository) RevertSnapshotToOtherVpsWithResponse(vpsName string, snapsh
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertSnapshotToOtherVpsWithOperation allows you to revert a snapshot to a different vps and returns an operation
+func (r *Repository) RevertSnapshotToOtherVpsWithOperation(vpsName string, snapshotName string, destinationVps string) (*action.Operation, error) {
+	response, err := r.RevertSnapshotToOtherVpsWithResponse(vpsName, snapshotName, destinationVps)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // RemoveSnapshot allows you to remove a snapshot from a given VPS
 func (r *Repository) RemoveSnapshot(vpsName string, snapshotName string) error {
 	restRequest := rest.Request{Endpoint: fmt.Sprintf("/vps/%s/snapshots/%s", vpsName, snapshotName)}
@@ -461,6 +559,13 @@ func (r *Repository) RevertBackupWithResponse(vpsName string, backupID int64) (r
 	return r.Client.PatchWithResponse(restRequest)
 }
 
+// RevertBackupWithOperation allows you to revert a backup and returns an operation
+func (r *Repository) RevertBackupWithOperation(vpsName string, backupID int64) (*action.Operation, error) {
+	response, err := r.RevertBackupWithResponse(vpsName, backupID)
+
+	return action.NewOperation(r.Client, response, action.OperationHooks{}), err
+}
+
 // ConvertBackupToSnapshot allows you to convert a backup to a snapshot
 func (r *Repository) ConvertBackupToSnapshot(vpsName string, backupID int64, snapshotDescription string) error {
 	requestBody := convertBackupRequest{SnapshotDescription: snapshotDescription, Action: "convert"}
@@ -476,3 +581,25 @@ func (r *Repository) ConvertBackupToSnapshotWithResponse(vpsName string, backupI
 
 	return r.Client.PatchWithResponse(restRequest)
 }
+
+// ConvertBackupToSnapshotWithOperation allows you to convert a backup to a snapshot and returns an operation,
+// which finds the new snapshot by its description
+func (r *Repository) ConvertBackupToSnapshotWithOperation(vpsName string, backupID int64, snapshotDescription string) (*action.Operation, error) {
+	before, err := r.GetSnapshots(vpsName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots before the conversion: %w", err)
+	}
+	response, err := r.ConvertBackupToSnapshotWithResponse(vpsName, backupID, snapshotDescription)
+
+	return action.NewOperation(r.Client, response, r.createdSnapshotHooks(vpsName, before, snapshotDescription)), err
+}
+
+// createdSnapshotHooks returns the hooks of an operation that creates a snapshot of the vps with the description,
+// before are the snapshots listed before the request
+func (r *Repository) createdSnapshotHooks(vpsName string, before []Snapshot, description string) action.OperationHooks {
+	return action.OperationHooks{
+		CreatedResource: action.CreatedResourceFromList(before,
+			func() ([]Snapshot, error) { return r.GetSnapshots(vpsName) },
+			func(snapshot Snapshot) (string, string) { return snapshot.Name, snapshot.Description }, description),
+	}
+}
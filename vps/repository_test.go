@@ -1,15 +1,20 @@
 package vps
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
 
 	"github.com/assi010/gotransip/v6"
+	"github.com/assi010/gotransip/v6/action"
 	"github.com/assi010/gotransip/v6/internal/testutil"
 	"github.com/assi010/gotransip/v6/ipaddress"
+	"github.com/assi010/gotransip/v6/wait"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -212,6 +217,104 @@ func TestRepository_CloneToAvailabilityZone(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRepository_CloneWithOperation(t *testing.T) {
+	const actionUUID = "6c7fa1c1-f509-4999-a513-bdf4e7a0cebb"
+	var requests []string
+	var cloned bool
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		switch req.Method + " " + req.URL.Path {
+		case "GET /vps":
+			vpses := `{"vpss":[{"name":"example-vps","description":"example VPS"}]}`
+			if cloned {
+				vpses = `{"vpss":[{"name":"example-vps","description":"example VPS"},{"name":"example-vps2","description":"example VPS"}]}`
+			}
+			_, err := rw.Write([]byte(vpses))
+			require.NoError(t, err)
+		case "POST /vps":
+			cloned = true
+			rw.Header().Set("Content-Location", "/v6/actions/"+actionUUID)
+			rw.WriteHeader(http.StatusCreated)
+		case "GET /actions/" + actionUUID:
+			_, err := rw.Write([]byte(`{"action":{"uuid":"` + actionUUID + `","name":"vps clone","status":"finished","metadata":{"progress":100}}}`))
+			require.NoError(t, err)
+		case "GET /actions/children/" + actionUUID:
+			_, err := rw.Write([]byte(`{"actions":[]}`))
+			require.NoError(t, err)
+		case "DELETE /vps/example-vps2":
+			rw.WriteHeader(http.StatusNoContent)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := gotransip.DemoClientConfiguration
+	config.URL = server.URL
+	client, err := gotransip.NewClient(config)
+	require.NoError(t, err)
+	repo := Repository{Client: client}
+
+	operation, err := repo.CloneWithOperation("example-vps")
+	require.NoError(t, err)
+	assert.Equal(t, actionUUID, operation.UUID())
+	assert.Equal(t, http.StatusCreated, operation.StatusCode)
+
+	clone, err := operation.Wait(context.Background(), action.WaitOptions{Options: wait.Options{InitialInterval: time.Millisecond}})
+	require.NoError(t, err)
+	assert.Equal(t, action.StatusFinished, clone.Status)
+
+	// the clone is the vps that was not listed before the request
+	name, err := operation.CreatedResource()
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps2", name)
+
+	require.NoError(t, operation.Cancel(gotransip.CancellationTimeImmediately))
+	assert.Contains(t, requests, "DELETE /vps/example-vps2")
+	assert.NotContains(t, requests, "DELETE /vps/example-vps")
+
+	// the operation keeps the response of a failed request
+	operation, err = repo.RevertSnapshotWithOperation("example-vps", "1572607577")
+	require.Error(t, err)
+	require.NotNil(t, operation)
+	assert.Equal(t, http.StatusNotFound, operation.StatusCode)
+	assert.Equal(t, "", operation.UUID())
+	assert.ErrorIs(t, operation.Cancel(gotransip.CancellationTimeImmediately), action.ErrCancelNotSupported)
+}
+
+func TestRepository_OrderMultipleWithOperation(t *testing.T) {
+	server := testutil.RoutedMockServer{T: t, Responses: map[string]string{
+		"/vps": `{"vpss":[{"name":"example-vps","description":"web-1"}]}`,
+	}}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := Repository{Client: client}
+
+	operation, err := repo.OrderMultipleWithOperation([]Order{
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", Description: "web-2"},
+		{ProductName: "vps-bladevps-x4", OperatingSystem: "debian-12", Description: "web-3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "", operation.UUID())
+
+	// an order does not return an action, the vpses are found once they are listed
+	_, err = operation.CreatedResource()
+	assert.ErrorIs(t, err, action.ErrNoCreatedResource)
+	assert.Contains(t, err.Error(), "no new resource with description 'web-2' is listed")
+
+	server.Responses["/vps"] = `{"vpss":[{"name":"example-vps","description":"web-1"},{"name":"example-vps3","description":"web-3"},{"name":"example-vps2","description":"web-2"}]}`
+	names, err := operation.CreatedResource()
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps2, example-vps3", names)
+
+	require.NoError(t, operation.Cancel(gotransip.CancellationTimeEnd))
+	assert.Equal(t, []string{
+		`POST /vps {"vpss":[{"productName":"vps-bladevps-x4","operatingSystem":"debian-12","description":"web-2"},{"productName":"vps-bladevps-x4","operatingSystem":"debian-12","description":"web-3"}]}`,
+		`DELETE /vps/example-vps2 {"endTime":"end"}`,
+		`DELETE /vps/example-vps3 {"endTime":"end"}`,
+	}, server.Changes)
+}
+
 func TestRepository_Update(t *testing.T) {
 	const expectedRequest = `{"vps":{"name":"example-vps","uuid":"bfa08ad9-6c12-4e03-95dd-a888b97ffe49","description":"example VPS","productName":"vps-bladevps-x1","operatingSystem":"ubuntu-18.04","diskSize":157286400,"memorySize":4194304,"cpus":2,"status":"running","ipAddress":"37.97.254.6","macAddress":"52:54:00:3b:52:65","currentSnapshots":1,"maxSnapshots":10,"isCustomerLocked":false,"availabilityZone":"ams0","tags":["customTag","anotherTag"]}}`
 	server := testutil.MockServer{T: t, ExpectedURL: "/vps/example-vps", ExpectedMethod: "PUT", StatusCode: 204, ExpectedRequest: expectedRequest}
@@ -0,0 +1,113 @@
+package vps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/assi010/gotransip/v6/wait"
+)
+
+// WaitUntilStatus waits until the vps reaches the given status,
+// for example VpsStatusRunning after Start or VpsStatusStopped after Stop
+func (r *Repository) WaitUntilStatus(ctx context.Context, vpsName string, status Status, opts wait.Options) (Vps, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("vps %s", vpsName),
+		func() (Vps, error) { return r.GetByName(vpsName) },
+		wait.Condition[Vps]{
+			Description: fmt.Sprintf("status %s", status),
+			Done:        func(vps Vps) bool { return vps.Status == status },
+			State:       describeVps,
+		},
+	)
+}
+
+// WaitUntilUnlocked waits until the vps is no longer locked, for example after an upgrade or a snapshot revert
+func (r *Repository) WaitUntilUnlocked(ctx context.Context, vpsName string, opts wait.Options) (Vps, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("vps %s", vpsName),
+		func() (Vps, error) { return r.GetByName(vpsName) },
+		wait.Condition[Vps]{
+			Description: "unlocked",
+			Done:        func(vps Vps) bool { return !vps.IsLocked },
+			State:       describeVps,
+		},
+	)
+}
+
+// WaitUntilSnapshotActive waits until the snapshot of a vps is active and ready to use
+func (r *Repository) WaitUntilSnapshotActive(ctx context.Context, vpsName string, snapshotName string, opts wait.Options) (Snapshot, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("snapshot %s of vps %s", snapshotName, vpsName),
+		func() (Snapshot, error) { return r.GetSnapshotByName(vpsName, snapshotName) },
+		wait.Condition[Snapshot]{
+			Description: fmt.Sprintf("status %s", SnapshotStatusActive),
+			Done:        func(snapshot Snapshot) bool { return snapshot.Status == SnapshotStatusActive },
+			State:       func(snapshot Snapshot) string { return fmt.Sprintf("status %s", snapshot.Status) },
+		},
+	)
+}
+
+// WaitUntilAttached waits until the block storage is active and attached to the given vps.
+// Use an empty vpsName to wait until the block storage is detached.
+func (r *BlockStorageRepository) WaitUntilAttached(ctx context.Context, blockStorageName string, vpsName string, opts wait.Options) (BlockStorage, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("block storage %s", blockStorageName),
+		func() (BlockStorage, error) { return r.GetByName(blockStorageName) },
+		wait.Condition[BlockStorage]{
+			Description: describeAttachment(vpsName),
+			Done: func(blockStorage BlockStorage) bool {
+				return blockStorage.Status == BlockStorageStatusActive && !blockStorage.IsLocked && blockStorage.VpsName == vpsName
+			},
+			State: func(blockStorage BlockStorage) string {
+				return describeStorage(string(blockStorage.Status), blockStorage.VpsName, blockStorage.IsLocked)
+			},
+		},
+	)
+}
+
+// WaitUntilAttached waits until the big storage is active and attached to the given vps.
+// Use an empty vpsName to wait until the big storage is detached.
+// Deprecated: Use block storage resource instead
+func (r *BigStorageRepository) WaitUntilAttached(ctx context.Context, bigStorageName string, vpsName string, opts wait.Options) (BigStorage, error) {
+	return wait.Until(ctx, opts, fmt.Sprintf("big storage %s", bigStorageName),
+		func() (BigStorage, error) { return r.GetByName(bigStorageName) },
+		wait.Condition[BigStorage]{
+			Description: describeAttachment(vpsName),
+			Done: func(bigStorage BigStorage) bool {
+				return bigStorage.Status == BigStorageStatusActive && !bigStorage.IsLocked && bigStorage.VpsName == vpsName
+			},
+			State: func(bigStorage BigStorage) string {
+				return describeStorage(string(bigStorage.Status), bigStorage.VpsName, bigStorage.IsLocked)
+			},
+		},
+	)
+}
+
+// describeVps describes the state of a vps in wait errors
+func describeVps(vps Vps) string {
+	if vps.IsLocked {
+		return fmt.Sprintf("status %s, locked", vps.Status)
+	}
+
+	return fmt.Sprintf("status %s, unlocked", vps.Status)
+}
+
+// describeAttachment describes the desired state of a storage in wait errors
+func describeAttachment(vpsName string) string {
+	if vpsName == "" {
+		return "status active, detached"
+	}
+
+	return fmt.Sprintf("status active, attached to %s", vpsName)
+}
+
+// describeStorage describes the state of a block or big storage in wait errors
+func describeStorage(status string, vpsName string, locked bool) string {
+	description := fmt.Sprintf("status %s", status)
+	if vpsName == "" {
+		description += ", detached"
+	} else {
+		description += fmt.Sprintf(", attached to %s", vpsName)
+	}
+	if locked {
+		description += ", locked"
+	}
+
+	return description
+}
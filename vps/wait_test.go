@@ -0,0 +1,72 @@
+package vps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/assi010/gotransip/v6/internal/testutil"
+	"github.com/assi010/gotransip/v6/wait"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastPolling polls every millisecond and gives up after 20 milliseconds
+var fastPolling = wait.Options{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond, Timeout: 20 * time.Millisecond}
+
+func TestRepository_WaitUntilStatus(t *testing.T) {
+	const apiResponse = `{"vps":{"name":"example-vps","status":"running","isLocked":true}}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/vps/example-vps", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := Repository{Client: *client}
+
+	vps, err := repo.WaitUntilStatus(context.Background(), "example-vps", VpsStatusRunning, fastPolling)
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps", vps.Name)
+
+	_, err = repo.WaitUntilStatus(context.Background(), "example-vps", VpsStatusStopped, fastPolling)
+	assert.ErrorIs(t, err, wait.ErrTimeout)
+	assert.EqualError(t, err, "timed out waiting for resource: vps example-vps did not reach status stopped, last observed status running, locked")
+
+	_, err = repo.WaitUntilUnlocked(context.Background(), "example-vps", fastPolling)
+	assert.EqualError(t, err, "timed out waiting for resource: vps example-vps did not reach unlocked, last observed status running, locked")
+}
+
+func TestRepository_WaitUntilSnapshotActive(t *testing.T) {
+	const apiResponse = `{"snapshot":{"name":"1572607577","status":"creating"}}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/vps/example-vps/snapshots/1572607577", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := Repository{Client: *client}
+
+	snapshot, err := repo.WaitUntilSnapshotActive(context.Background(), "example-vps", "1572607577", fastPolling)
+	assert.EqualError(t, err, "timed out waiting for resource: snapshot 1572607577 of vps example-vps did not reach status active, last observed status creating")
+	assert.Equal(t, SnapshotStatusCreating, snapshot.Status)
+}
+
+func TestBlockStorageRepository_WaitUntilAttached(t *testing.T) {
+	const apiResponse = `{"blockStorage":{"name":"example-faststorage","vpsName":"example-vps","status":"active","isLocked":false}}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/block-storages/example-faststorage", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := BlockStorageRepository{Client: *client}
+
+	blockStorage, err := repo.WaitUntilAttached(context.Background(), "example-faststorage", "example-vps", fastPolling)
+	require.NoError(t, err)
+	assert.Equal(t, "example-vps", blockStorage.VpsName)
+
+	_, err = repo.WaitUntilAttached(context.Background(), "example-faststorage", "", fastPolling)
+	assert.EqualError(t, err, "timed out waiting for resource: block storage example-faststorage did not reach status active, detached, last observed status active, attached to example-vps")
+}
+
+func TestBigStorageRepository_WaitUntilAttached(t *testing.T) {
+	const apiResponse = `{"bigStorage":{"name":"example-bigstorage","vpsName":"","status":"detaching","isLocked":true}}`
+	server := testutil.MockServer{T: t, ExpectedURL: "/big-storages/example-bigstorage", ExpectedMethod: "GET", StatusCode: 200, Response: apiResponse}
+	client, tearDown := server.GetClient()
+	defer tearDown()
+	repo := BigStorageRepository{Client: *client}
+
+	_, err := repo.WaitUntilAttached(context.Background(), "example-bigstorage", "", fastPolling)
+	assert.EqualError(t, err, "timed out waiting for resource: big storage example-bigstorage did not reach status active, detached, last observed status detaching, detached, locked")
+}
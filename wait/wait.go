@@ -0,0 +1,176 @@
+// Package wait polls a resource until it reaches a desired state.
+//
+// Many api calls return before the change they request is complete, like starting a vps or attaching a block storage.
+// The resource packages offer WaitUntil methods built on Until, for example:
+//
+//	if err := vpsRepo.Start("example-vps"); err != nil {
+//		panic(err)
+//	}
+//	_, err := vpsRepo.WaitUntilStatus(ctx, "example-vps", vps.VpsStatusRunning, wait.Options{Timeout: 5 * time.Minute})
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultInitialInterval is used when Options.InitialInterval is not set
+	defaultInitialInterval = 2 * time.Second
+	// defaultMaxInterval is used when Options.MaxInterval is not set
+	defaultMaxInterval = 30 * time.Second
+	// defaultMultiplier is used when Options.Multiplier is not set
+	defaultMultiplier = 1.5
+)
+
+var (
+	// ErrTimeout is wrapped by every TimeoutError
+	ErrTimeout = errors.New("timed out waiting for resource")
+	// ErrUnexpectedState is wrapped by every UnexpectedStateError
+	ErrUnexpectedState = errors.New("resource reached an unexpected state")
+)
+
+// TimeoutError is returned when a resource did not reach the desired state before the deadline
+type TimeoutError struct {
+	// Resource describes the resource that was waited for, like "vps example-vps"
+	Resource string
+	// Condition describes the desired state, like "status running"
+	Condition string
+	// State describes the last observed state of the resource
+	State string
+	// Last is the last observed value of the resource
+	Last any
+	// Err is the error of the context, context.DeadlineExceeded
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s did not reach %s, last observed %s", ErrTimeout, e.Resource, e.Condition, e.State)
+}
+
+// Is makes errors.Is(err, ErrTimeout) work for a TimeoutError
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// Unwrap returns the error of the context
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// UnexpectedStateError is returned when a resource reached a state from which the desired state can not be reached
+type UnexpectedStateError struct {
+	// Resource describes the resource that was waited for
+	Resource string
+	// Condition describes the desired state
+	Condition string
+	// State describes the state the resource reached
+	State string
+	// Last is the value of the resource in that state
+	Last any
+}
+
+func (e *UnexpectedStateError) Error() string {
+	return fmt.Sprintf("%s: %s can not reach %s, it is %s", ErrUnexpectedState, e.Resource, e.Condition, e.State)
+}
+
+// Is makes errors.Is(err, ErrUnexpectedState) work for an UnexpectedStateError
+func (e *UnexpectedStateError) Is(target error) bool {
+	return target == ErrUnexpectedState
+}
+
+// Options configures the polling, the zero value polls every 2 seconds at first,
+// backing off to every 30 seconds, until the context is done
+type Options struct {
+	// InitialInterval is the time between the first polls, defaults to 2 seconds
+	InitialInterval time.Duration
+	// MaxInterval is the maximum time between polls, defaults to 30 seconds
+	MaxInterval time.Duration
+	// Multiplier increases the interval after every poll, defaults to 1.5
+	Multiplier float64
+	// Timeout limits the time to wait, in addition to the deadline of the context.
+	// If zero, only the context is used
+	Timeout time.Duration
+}
+
+// Condition describes the state a resource of type T should reach
+type Condition[T any] struct {
+	// Description of the desired state, used in errors
+	Description string
+	// Done returns true when the resource reached the desired state
+	Done func(resource T) bool
+	// Failed returns true when the resource can no longer reach the desired state, it is optional
+	Failed func(resource T) bool
+	// State describes the observed state of the resource, used in errors
+	State func(resource T) string
+}
+
+// Until polls the resource with get until the condition is met.
+// It returns a TimeoutError when the context deadline or Options.Timeout is exceeded,
+// and an UnexpectedStateError when Condition.Failed returns true.
+// Errors returned by get stop the polling and are returned wrapped.
+func Until[T any](ctx context.Context, opts Options, resource string, get func() (T, error), condition Condition[T]) (T, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = defaultInitialInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxInterval
+	}
+	multiplier := opts.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultMultiplier
+	}
+
+	var last T
+	for {
+		current, err := get()
+		if err != nil {
+			return last, fmt.Errorf("error retrieving %s: %w", resource, err)
+		}
+		last = current
+
+		if condition.Done(current) {
+			return current, nil
+		}
+		if condition.Failed != nil && condition.Failed(current) {
+			return current, &UnexpectedStateError{
+				Resource:  resource,
+				Condition: condition.Description,
+				State:     condition.State(current),
+				Last:      current,
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return last, fmt.Errorf("stopped waiting for %s: %w", resource, ctx.Err())
+			}
+			return last, &TimeoutError{
+				Resource:  resource,
+				Condition: condition.Description,
+				State:     condition.State(last),
+				Last:      last,
+				Err:       ctx.Err(),
+			}
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * multiplier)
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
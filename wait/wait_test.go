@@ -0,0 +1,73 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastPolling polls every millisecond
+var fastPolling = Options{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+// counterCondition waits until a counter reaches 3, and fails when it reaches 100
+var counterCondition = Condition[int]{
+	Description: "count 3",
+	Done:        func(count int) bool { return count == 3 },
+	Failed:      func(count int) bool { return count >= 100 },
+	State:       func(count int) string { return fmt.Sprintf("count %d", count) },
+}
+
+func TestUntil(t *testing.T) {
+	var count int
+	get := func() (int, error) {
+		count++
+		return count, nil
+	}
+
+	result, err := Until(context.Background(), fastPolling, "counter", get, counterCondition)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result)
+}
+
+func TestUntil_Timeout(t *testing.T) {
+	get := func() (int, error) { return 1, nil }
+
+	opts := fastPolling
+	opts.Timeout = 10 * time.Millisecond
+	result, err := Until(context.Background(), opts, "counter", get, counterCondition)
+	assert.Equal(t, 1, result)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "timed out waiting for resource: counter did not reach count 3, last observed count 1")
+
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, 1, timeoutErr.Last)
+
+	// a cancelled context is not a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Until(ctx, fastPolling, "counter", get, counterCondition)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrTimeout)
+}
+
+func TestUntil_UnexpectedState(t *testing.T) {
+	get := func() (int, error) { return 100, nil }
+
+	_, err := Until(context.Background(), fastPolling, "counter", get, counterCondition)
+	assert.ErrorIs(t, err, ErrUnexpectedState)
+	assert.EqualError(t, err, "resource reached an unexpected state: counter can not reach count 3, it is count 100")
+}
+
+func TestUntil_GetError(t *testing.T) {
+	get := func() (int, error) { return 0, errors.New("not found") }
+
+	_, err := Until(context.Background(), fastPolling, "counter", get, counterCondition)
+	assert.EqualError(t, err, "error retrieving counter: not found")
+}